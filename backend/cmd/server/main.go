@@ -3,51 +3,64 @@ package main
 import (
 	"context"
 	"log"
-	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
 
+	"backend/internal/config"
 	"backend/internal/db"
+	"backend/internal/es"
+	"backend/internal/eventbus"
 	"backend/internal/handlers"
+	"backend/internal/middleware"
+	"backend/internal/tracing"
+	"backend/internal/utils"
 )
 
 func main() {
+	startup := &startupTimer{}
+
 	// Load .env file
+	phaseDone := startup.phase("env_load")
 	if err := godotenv.Load(); err != nil {
 		log.Println("[WARNING] No .env file found or error loading it:", err)
 	}
+	phaseDone()
 
 	ctx := context.Background()
 
 	// Debug: Log the DATABASE_URL being used
-	dbDSN := os.Getenv("DATABASE_URL")
+	dbDSN := config.GetString("DATABASE_URL", "")
 	if dbDSN == "" {
 		log.Fatal("DATABASE_URL environment variable is not set")
 	}
 	log.Printf("[DEBUG] Connecting to database with DSN: %s", dbDSN)
 
+	phaseDone = startup.phase("db_connect")
 	pool, err := db.Open(ctx)
 	if err != nil {
 		log.Fatalf("[ERROR] Failed to connect to database: %v", err)
 	}
 	defer pool.Close()
+	phaseDone()
 
 	// Debug: Test database connection with a simple ping
 	log.Println("[DEBUG] Testing database connection...")
+	phaseDone = startup.phase("db_ping")
 	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if err := pool.Ping(ctxTimeout); err != nil {
 		log.Fatalf("[ERROR] Database ping failed: %v", err)
 	}
+	phaseDone()
 	log.Println("[SUCCESS] Database connection established successfully!")
 
 	// Debug: Check if tables exist
 	log.Println("[DEBUG] Checking if required tables exist...")
+	phaseDone = startup.phase("table_check")
 	var tableCount int
 	err = pool.QueryRow(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_name IN ('gen_spec_jobs', 'game_specs')").Scan(&tableCount)
 	if err != nil {
@@ -58,25 +71,127 @@ func main() {
 			log.Println("[WARNING] Some required tables are missing. Run 'make migrate-up' to create them.")
 		}
 	}
+	phaseDone()
+
+	if err := handlers.ValidateLLMModels(); err != nil {
+		log.Fatalf("[ERROR] Invalid LLM model configuration: %v", err)
+	}
+	if err := utils.ValidateDevinConfig(); err != nil {
+		log.Fatalf("[ERROR] Invalid Devin configuration: %v", err)
+	}
+	if err := utils.ValidateReadmeTemplate(); err != nil {
+		log.Fatalf("[ERROR] Invalid README template: %v", err)
+	}
+	if err := utils.ValidateCommitMessageTemplate(); err != nil {
+		log.Fatalf("[ERROR] Invalid GIT_COMMIT_MESSAGE_TEMPLATE: %v", err)
+	}
 
-	app := fiber.New()
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	bus, err := eventbus.New()
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to set up event bus: %v", err)
+	}
+	defer bus.Close()
+	handlers.UseEventBus(bus)
+
+	// Subscriber that fans job.created events out to the code job workers
+	if err := handlers.RunCodeJobWorkers(ctx, pool, bus); err != nil {
+		log.Fatalf("[ERROR] Failed to subscribe code job workers: %v", err)
+	}
+
+	// Jobs left behind by a previous process never finish on their own
+	if err := handlers.RecoverInterruptedJobs(pool); err != nil {
+		log.Printf("[ERROR] Failed to recover interrupted jobs: %v", err)
+	}
+
+	go handlers.RunQuotaReset(ctx, pool)
+	go es.RunProjector(ctx, pool)
+	go utils.RunLocalOutputCleanup(ctx)
+	go handlers.RunArchiver(ctx, pool)
+	go handlers.RunJobCleanup(ctx, pool)
+
+	app := fiber.New(fiber.Config{
+		BodyLimit:    middleware.MaxRequestBytes(),
+		ReadTimeout:  config.MustGetDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		WriteTimeout: config.MustGetDuration("HTTP_WRITE_TIMEOUT", 10*time.Minute),
+		ErrorHandler: middleware.ErrorHandler,
+	})
 	app.Use(logger.New())
-	app.Use(cors.New(cors.Config{AllowOrigins: "*", AllowHeaders: "*"}))
+	app.Use(middleware.Tracing())
+	app.Use(middleware.ProblemDetails())
+	app.Use(middleware.CORS("X-Next-Cursor"))
+	app.Use(middleware.Decompress())
 
-	api := app.Group("/api")
+	// Public routes, registered before the API group so they stay outside its middleware
+	app.Get("/healthz", handlers.Healthz(pool))
+	app.Get("/startup-complete", startup.handler())
+	app.Get("/api/share/:token", handlers.GetSharedSpec(pool))
+	app.Post("/api/workspaces", middleware.RequireAdmin(), handlers.CreateWorkspace(pool))
+	app.Get("/api/specs/:id/feedback", middleware.RequireAdmin(), handlers.GetSpecFeedback(pool))
+	app.Post("/api/admin/code-jobs/retry-failed", middleware.RequireAdmin(), handlers.RetryFailedCodeJobs(pool))
+	app.Post("/api/admin/workspaces/:id/migrate-vectors", middleware.RequireAdmin(), handlers.MigrateWorkspaceVectors(pool))
+
+	api := app.Group("/api", middleware.Workspace(pool), middleware.User())
+	api.Get("/activity", handlers.GetActivity(pool))
+	api.Get("/spec-jobs", handlers.ListSpecJobs(pool))
 	api.Post("/spec-jobs", handlers.PostSpecJob(pool))
+	api.Post("/spec-jobs/stream", handlers.StreamSpecJob(pool))
+	api.Post("/spec-jobs/estimate", handlers.EstimateSpec())
 	api.Get("/spec-jobs/:id", handlers.GetJob(pool))
+	api.Post("/spec-jobs/:id/retry", handlers.RetrySpecJob(pool))
 	api.Get("/specs", handlers.ListSpecs(pool))
+	api.Get("/specs/recommended", handlers.GetRecommendedSpecs(pool))
+	api.Get("/genres/popular", handlers.GetPopularGenres(pool))
+	api.Post("/specs/validate", handlers.ValidateSpec())
+	api.Post("/specs/import-url", handlers.ImportSpecURL(pool))
 	api.Get("/specs/:id", handlers.GetSpec(pool))
 	api.Get("/specs/:id/state-logs", handlers.GetSpecStateLogs(pool))
+	api.Get("/specs/:id/manifest", handlers.GetSpecManifest(pool))
+	api.Get("/specs/:id/json", handlers.GetSpecJSON(pool))
+	api.Get("/specs/:id/markdown", handlers.GetSpecMarkdown(pool))
+	api.Get("/specs/:id/spec.html", handlers.GetSpecHTML(pool))
+	api.Get("/specs/:id/tutorial", handlers.GetSpecTutorial(pool))
+	api.Get("/specs/:id/tutorial.html", handlers.GetSpecTutorialHTML(pool))
+	api.Get("/specs/:id/status", handlers.GetSpecStatus(pool))
+	api.Get("/specs/:id/diff/:other_id", handlers.DiffSpecs(pool))
+	api.Get("/specs/:id/duplicates", handlers.GetSpecDuplicates(pool))
+	api.Get("/specs/:id/embed", handlers.GetSpecEmbedding(pool))
+	api.Get("/specs/:id/files", handlers.GetSpecFiles(pool))
+	api.Get("/specs/:id/files/*", handlers.GetSpecFile(pool))
+	api.Get("/specs/:id/preview/*", handlers.GetSpecPreview(pool))
+	api.Post("/specs/:id/share", handlers.CreateSpecShare(pool))
+	api.Delete("/specs/:id/share", handlers.RevokeSpecShares(pool))
+	api.Post("/specs/:id/feedback", handlers.PostSpecFeedback(pool))
+	api.Post("/specs/:id/regenerate", handlers.RegenerateSpec(pool))
+	api.Get("/specs/:id/changelogs", handlers.GetSpecChangelogs(pool))
+	api.Get("/specs/:id/versions", handlers.GetSpecVersions(pool))
+	api.Get("/specs/:id/versions/:n", handlers.GetSpecVersion(pool))
+	api.Post("/specs/:id/versions/:n/restore", handlers.RestoreSpecVersion(pool))
+	api.Post("/specs/bulk-delete", handlers.BulkDeleteSpecs(pool))
 	api.Delete("/specs/:id", handlers.DeleteSpec(pool))
 	api.Get("/specs/:spec_id/code-job", handlers.GetCodeJobBySpecID(pool))
+	api.Post("/specs/:id/generate-code", handlers.GenerateSpecCode(pool))
+	api.Post("/specs/:id/push", handlers.PushSpec(pool))
+	api.Post("/code-jobs", handlers.PostCodeJob(pool))
+	api.Get("/code-jobs/:id", handlers.GetCodeJob(pool))
+	api.Delete("/code-jobs/:id", handlers.DeleteCodeJob(pool))
 	api.Post("/specs/:id/devin-task", handlers.CreateDevinTask(pool))
+	api.Get("/specs/:id/devin-sessions", handlers.GetDevinSessions(pool))
+	api.Get("/stats/p95-latency", handlers.GetLatencyStats(pool))
+	api.Get("/debug/cache-stats", handlers.GetCacheStats())
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := config.GetString("PORT", "8080")
+	phaseDone = startup.phase("server_listen")
+	app.Hooks().OnListen(func(fiber.ListenData) error {
+		phaseDone()
+		startup.complete()
+		return nil
+	})
 	log.Printf("[INFO] Server starting on port %s", port)
-	log.Fatal(app.Listen(":" + port))
+	log.Fatal(listen(app, ":"+port))
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// processStart approximates when the process started, as package variables are initialized before main
+var processStart = time.Now()
+
+// startupTimer logs how long each startup phase takes and backs the GET /startup-complete probe
+type startupTimer struct {
+	// completedMS is the startup time in milliseconds once every phase passed, and 0 until then
+	completedMS atomic.Int64
+}
+
+// phase starts timing a startup phase; calling the returned function logs its duration
+func (t *startupTimer) phase(name string) func() {
+	start := time.Now()
+	return func() {
+		log.Printf("[STARTUP] %s completed in %dms", name, time.Since(start).Milliseconds())
+	}
+}
+
+// complete marks the server as started
+func (t *startupTimer) complete() {
+	ms := time.Since(processStart).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	t.completedMS.Store(ms)
+	log.Printf("[STARTUP] Server started in %dms", ms)
+}
+
+// handler answers the startup probe: 503 until complete was called, then 200 with the startup time
+func (t *startupTimer) handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ms := t.completedMS.Load()
+		if ms == 0 {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"ready": false})
+		}
+		return c.JSON(fiber.Map{"ready": true, "startup_ms": ms})
+	}
+}
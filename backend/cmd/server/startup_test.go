@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func probeStartup(t *testing.T, app *fiber.App) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", "/startup-complete", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestStartupComplete(t *testing.T) {
+	startup := &startupTimer{}
+	app := fiber.New()
+	app.Get("/startup-complete", startup.handler())
+
+	done := startup.phase("db_connect")
+	done()
+	status, body := probeStartup(t, app)
+	if status != fiber.StatusServiceUnavailable {
+		t.Errorf("status before startup = %d, want %d", status, fiber.StatusServiceUnavailable)
+	}
+	if body["ready"] != false {
+		t.Errorf("body before startup = %v", body)
+	}
+
+	startup.complete()
+	status, body = probeStartup(t, app)
+	if status != fiber.StatusOK {
+		t.Errorf("status after startup = %d, want %d", status, fiber.StatusOK)
+	}
+	if ms, ok := body["startup_ms"].(float64); body["ready"] != true || !ok || ms < 1 {
+		t.Errorf("body after startup = %v", body)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"backend/internal/config"
+	"crypto/tls"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// listen serves the app on addr. With ACME_DOMAIN set, certificates are obtained and renewed
+// from Let's Encrypt (TLS-ALPN-01, so addr must be reachable on 443). Otherwise TLS_CERT_FILE
+// and TLS_KEY_FILE are used when both are set, and plain HTTP when they aren't.
+func listen(app *fiber.App, addr string) error {
+	if domains := splitList(config.GetString("ACME_DOMAIN", "")); len(domains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(config.GetString("ACME_CACHE_DIR", "./acme-cache")),
+			Email:      config.GetString("ACME_EMAIL", ""),
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] Serving HTTPS with Let's Encrypt certificates for %s", strings.Join(domains, ", "))
+		return app.Listener(tls.NewListener(ln, m.TLSConfig()))
+	}
+
+	certFile := config.GetString("TLS_CERT_FILE", "")
+	keyFile := config.GetString("TLS_KEY_FILE", "")
+	if certFile != "" && keyFile != "" {
+		log.Printf("[INFO] Serving HTTPS with certificate %s", certFile)
+		return app.ListenTLS(addr, certFile, keyFile)
+	}
+	if certFile != "" || keyFile != "" {
+		log.Println("[WARNING] TLS_CERT_FILE and TLS_KEY_FILE must both be set, serving plain HTTP")
+	}
+	return app.Listen(addr)
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// topGenres is how many of the viewer's most viewed genres recommendations are drawn from
+const topGenres = 3
+
+// Recommendation is a spec suggested to a workspace
+type Recommendation struct {
+	ID            string   `json:"id"`
+	Title         string   `json:"title"`
+	Genre         *string  `json:"genre"`
+	AverageRating *float64 `json:"average_rating"`
+	FeedbackCount int      `json:"feedback_count"`
+}
+
+// RecordView stores a view of a spec. An empty workspaceID is the shared workspace.
+func RecordView(ctx context.Context, pool *pgxpool.Pool, specID, workspaceID string) error {
+	_, err := pool.Exec(ctx, `INSERT INTO spec_views (spec_id, workspace_id) VALUES ($1, NULLIF($2, '')::uuid)`, specID, workspaceID)
+	return err
+}
+
+// Recommend returns up to limit specs of the workspace that it hasn't viewed, taken from the genres
+// it views most and ordered by average rating. Workspaces without views, or whose genres are
+// exhausted, get the top rated unviewed specs instead. Genres come from the genre column, which
+// archiving leaves in place.
+func Recommend(ctx context.Context, pool *pgxpool.Pool, workspaceID string, limit int) ([]Recommendation, error) {
+	recs, err := queryRecommendations(ctx, pool, `
+		WITH top_genres AS (
+			SELECT lower(s.genre) AS genre
+			FROM spec_views v JOIN game_specs s ON s.id = v.spec_id
+			WHERE v.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid AND s.genre <> ''
+			GROUP BY 1
+			ORDER BY COUNT(*) DESC
+			LIMIT $3
+		)
+		SELECT s.id, s.title, s.genre, s.average_rating, s.feedback_count
+		FROM game_specs s
+		WHERE s.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid
+			AND lower(s.genre) IN (SELECT genre FROM top_genres)
+			AND NOT EXISTS (SELECT 1 FROM spec_views v WHERE v.spec_id = s.id AND v.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid)
+		ORDER BY s.average_rating DESC NULLS LAST, s.feedback_count DESC, s.created_at DESC
+		LIMIT $2
+	`, workspaceID, limit, topGenres)
+	if err != nil || len(recs) > 0 {
+		return recs, err
+	}
+
+	return queryRecommendations(ctx, pool, `
+		SELECT s.id, s.title, s.genre, s.average_rating, s.feedback_count
+		FROM game_specs s
+		WHERE s.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid
+			AND NOT EXISTS (SELECT 1 FROM spec_views v WHERE v.spec_id = s.id AND v.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid)
+		ORDER BY s.average_rating DESC NULLS LAST, s.feedback_count DESC, s.created_at DESC
+		LIMIT $2
+	`, workspaceID, limit)
+}
+
+func queryRecommendations(ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) ([]Recommendation, error) {
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recs := []Recommendation{}
+	for rows.Next() {
+		var r Recommendation
+		if err := rows.Scan(&r.ID, &r.Title, &r.Genre, &r.AverageRating, &r.FeedbackCount); err != nil {
+			return nil, err
+		}
+		recs = append(recs, r)
+	}
+	return recs, rows.Err()
+}
@@ -0,0 +1,134 @@
+package analytics
+
+import (
+	"backend/internal/dbtest"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newSpec inserts a spec of genre rated rating (0 for unrated) into a workspace ("" for the
+// shared one) and returns its id
+func newSpec(t *testing.T, pool *pgxpool.Pool, workspaceID, title, genre string, rating float64) string {
+	t.Helper()
+	id := uuid.New().String()
+	var avg *float64
+	if rating > 0 {
+		avg = &rating
+	}
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO game_specs (id, title, brief, spec_markdown, spec_json, spec_hash, genre, workspace_id, average_rating)
+		VALUES ($1, $2, 'brief', '# spec', jsonb_build_object('genre', $3::text), $1, $3, NULLIF($4, '')::uuid, $5)
+	`, id, title, genre, workspaceID, avg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func newWorkspace(t *testing.T, pool *pgxpool.Pool) string {
+	t.Helper()
+	id := uuid.New().String()
+	if _, err := pool.Exec(context.Background(), `INSERT INTO workspaces (id, name, api_key_hash) VALUES ($1, $1, $1)`, id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func titles(recs []Recommendation) []string {
+	out := []string{}
+	for _, r := range recs {
+		out = append(out, r.Title)
+	}
+	return out
+}
+
+func TestRecommend(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	ws := newWorkspace(t, pool)
+
+	platformer1 := newSpec(t, pool, ws, "platformer 1", "platformer", 0)
+	newSpec(t, pool, ws, "platformer 2", "Platformer", 3)
+	newSpec(t, pool, ws, "platformer 3", "platformer", 5)
+	puzzle := newSpec(t, pool, ws, "puzzle 1", "puzzle", 0)
+	newSpec(t, pool, ws, "puzzle 2", "puzzle", 4)
+	newSpec(t, pool, ws, "shooter 1", "shooter", 4.5)
+	newSpec(t, pool, "", "shared platformer", "platformer", 5)
+
+	t.Run("new workspace gets top rated", func(t *testing.T) {
+		recs, err := Recommend(ctx, pool, ws, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := titles(recs), []string{"platformer 3", "shooter 1", "puzzle 2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("recommendations = %v, want %v", got, want)
+		}
+	})
+
+	for _, id := range []string{platformer1, platformer1, puzzle} {
+		if err := RecordView(ctx, pool, id, ws); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("unviewed specs of viewed genres", func(t *testing.T) {
+		recs, err := Recommend(ctx, pool, ws, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The shooter was never viewed, platformer genres match regardless of case
+		if got, want := titles(recs), []string{"platformer 3", "puzzle 2", "platformer 2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("recommendations = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		recs, err := Recommend(ctx, pool, ws, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) != 1 {
+			t.Errorf("%d recommendations, want 1", len(recs))
+		}
+	})
+
+	t.Run("shared workspace", func(t *testing.T) {
+		recs, err := Recommend(ctx, pool, "", 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := titles(recs), []string{"shared platformer"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("recommendations = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRecordView(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	ws := newWorkspace(t, pool)
+	specID := newSpec(t, pool, ws, "game", "puzzle", 0)
+
+	for _, workspaceID := range []string{ws, ""} {
+		if err := RecordView(ctx, pool, specID, workspaceID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var inWorkspace, shared int
+	err := pool.QueryRow(ctx, `SELECT count(*) FILTER (WHERE workspace_id IS NOT NULL), count(*) FILTER (WHERE workspace_id IS NULL) FROM spec_views WHERE spec_id = $1`, specID).
+		Scan(&inWorkspace, &shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inWorkspace != 1 || shared != 1 {
+		t.Errorf("views: %d in the workspace, %d shared, want 1 each", inWorkspace, shared)
+	}
+
+	if err := RecordView(ctx, pool, uuid.New().String(), ws); err == nil {
+		t.Error("expected an error viewing a missing spec")
+	}
+}
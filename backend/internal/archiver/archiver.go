@@ -0,0 +1,245 @@
+package archiver
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotConfigured is returned when AWS_S3_BUCKET is unset and no store was set with UseStore
+var ErrNotConfigured = errors.New("spec archiving needs AWS_S3_BUCKET")
+
+// ErrSpecChanged is returned by ArchiveSpec when the spec was changed while it was being uploaded.
+// The spec is left as it is and picked up again by a later run.
+var ErrSpecChanged = errors.New("spec changed while it was archived")
+
+const archiveBatch = 100
+
+// Store keeps archived specs by key
+type Store interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Archive is the content of a spec that is moved out of Postgres
+type Archive struct {
+	SpecMarkdown     string                 `json:"spec_markdown"`
+	SpecJSON         map[string]interface{} `json:"spec_json"`
+	TutorialMarkdown *string                `json:"tutorial_markdown,omitempty"`
+}
+
+var (
+	storeMu    sync.Mutex
+	store      Store
+	onArchived func(specID string)
+)
+
+// UseStore replaces the store archives are written to, which otherwise is the S3 bucket in AWS_S3_BUCKET
+func UseStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	store = s
+}
+
+// OnArchive sets a function called with the id of each spec ArchiveSpec archives, so callers
+// can drop cached copies of its content
+func OnArchive(fn func(specID string)) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	onArchived = fn
+}
+
+func currentStore(ctx context.Context) (Store, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if store != nil {
+		return store, nil
+	}
+	bucket := config.GetString("AWS_S3_BUCKET", "")
+	if bucket == "" {
+		return nil, ErrNotConfigured
+	}
+	s, err := NewS3Store(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	store = s
+	return store, nil
+}
+
+// objectKey is where the archive of a spec is stored
+func objectKey(specID string) string {
+	return fmt.Sprintf("game-specs/%s.json.gz", specID)
+}
+
+// IsStub reports whether spec_json is the placeholder left by ArchiveSpec
+func IsStub(specJSON map[string]interface{}) bool {
+	archived, _ := specJSON["archived"].(bool)
+	key, _ := specJSON["s3_key"].(string)
+	return archived && key != ""
+}
+
+// ArchiveSpec uploads the markdown, spec_json and tutorial of a spec as gzip-compressed JSON and
+// replaces them with a stub holding the object key. Title, brief, hash and scores stay in place
+// so listing and duplicate detection keep working. Archiving an archived spec does nothing, and a
+// spec whose hash changed after it was read returns ErrSpecChanged without being archived.
+func ArchiveSpec(ctx context.Context, pool *pgxpool.Pool, specID string) error {
+	s, err := currentStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	var a Archive
+	var specJSON []byte
+	var hash string
+	var archivedAt *time.Time
+	err = pool.QueryRow(ctx, `SELECT spec_markdown, spec_json, tutorial_markdown, spec_hash, archived_at FROM game_specs WHERE id = $1`, specID).
+		Scan(&a.SpecMarkdown, &specJSON, &a.TutorialMarkdown, &hash, &archivedAt)
+	if err != nil {
+		return err
+	}
+	if archivedAt != nil {
+		return nil
+	}
+	if err := json.Unmarshal(specJSON, &a.SpecJSON); err != nil {
+		return fmt.Errorf("failed to parse spec JSON: %w", err)
+	}
+
+	body, err := compress(a)
+	if err != nil {
+		return err
+	}
+	key := objectKey(specID)
+	if err := s.Put(ctx, key, body); err != nil {
+		return err
+	}
+
+	// A regenerate or version restore between the read and this update would otherwise be
+	// replaced by a stub of the old content
+	stub := map[string]interface{}{"archived": true, "s3_key": key}
+	tag, err := pool.Exec(ctx, `
+		UPDATE game_specs
+		SET spec_json = $2, spec_markdown = '', tutorial_markdown = NULL, manifest = NULL, archived_at = now()
+		WHERE id = $1 AND archived_at IS NULL AND spec_hash = $3
+	`, specID, stub, hash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSpecChanged
+	}
+
+	storeMu.Lock()
+	fn := onArchived
+	storeMu.Unlock()
+	if fn != nil {
+		fn(specID)
+	}
+	return nil
+}
+
+// Restore downloads the archive a stub points at
+func Restore(ctx context.Context, stub map[string]interface{}) (Archive, error) {
+	var a Archive
+	s, err := currentStore(ctx)
+	if err != nil {
+		return a, err
+	}
+	key, _ := stub["s3_key"].(string)
+	body, err := s.Get(ctx, key)
+	if err != nil {
+		return a, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return a, fmt.Errorf("failed to read archive %s: %w", key, err)
+	}
+	defer zr.Close()
+	if err := json.NewDecoder(zr).Decode(&a); err != nil {
+		return a, fmt.Errorf("failed to decode archive %s: %w", key, err)
+	}
+	return a, nil
+}
+
+func compress(a Archive) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(a); err != nil {
+		return nil, fmt.Errorf("failed to encode archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ArchiveStale archives up to one batch of specs created more than olderThan ago that nobody
+// viewed within notViewedFor, returning how many were archived
+func ArchiveStale(ctx context.Context, pool *pgxpool.Pool, olderThan, notViewedFor time.Duration) (int, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT s.id FROM game_specs s
+		WHERE s.archived_at IS NULL AND s.created_at < $1
+			AND NOT EXISTS (SELECT 1 FROM spec_views v WHERE v.spec_id = s.id AND v.viewed_at >= $2)
+		ORDER BY s.created_at
+		LIMIT $3
+	`, time.Now().Add(-olderThan), time.Now().Add(-notViewedFor), archiveBatch)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, err
+	}
+
+	archived := 0
+	for _, id := range ids {
+		if err := ArchiveSpec(ctx, pool, id); errors.Is(err, ErrSpecChanged) {
+			log.Printf("[INFO] Skipped archiving spec %s: %v", id, err)
+			continue
+		} else if err != nil {
+			log.Printf("[ERROR] Failed to archive spec %s: %v", id, err)
+			continue
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+// Run archives stale specs every ARCHIVE_INTERVAL (default 24h): specs older than ARCHIVE_AFTER
+// (default 90 days) not viewed within ARCHIVE_UNVIEWED_FOR (default 30 days). It does nothing
+// when AWS_S3_BUCKET is unset.
+func Run(ctx context.Context, pool *pgxpool.Pool) {
+	if config.GetString("AWS_S3_BUCKET", "") == "" {
+		return
+	}
+	interval := config.MustGetDuration("ARCHIVE_INTERVAL", 24*time.Hour)
+	olderThan := config.MustGetDuration("ARCHIVE_AFTER", 90*24*time.Hour)
+	notViewedFor := config.MustGetDuration("ARCHIVE_UNVIEWED_FOR", 30*24*time.Hour)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if archived, err := ArchiveStale(ctx, pool, olderThan, notViewedFor); err != nil {
+			log.Printf("[ERROR] Spec archiving failed: %v", err)
+		} else if archived > 0 {
+			log.Printf("[INFO] Archived %d stale specs to S3", archived)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
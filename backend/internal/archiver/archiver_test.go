@@ -0,0 +1,170 @@
+package archiver
+
+import (
+	"backend/internal/dbtest"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memStore keeps archives in memory
+type memStore map[string][]byte
+
+func (m memStore) Put(_ context.Context, key string, body []byte) error {
+	m[key] = body
+	return nil
+}
+
+func (m memStore) Get(_ context.Context, key string) ([]byte, error) {
+	body, ok := m[key]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return body, nil
+}
+
+func TestIsStub(t *testing.T) {
+	tests := []struct {
+		name     string
+		specJSON map[string]interface{}
+		want     bool
+	}{
+		{"stub", map[string]interface{}{"archived": true, "s3_key": "game-specs/a.json.gz"}, true},
+		{"no key", map[string]interface{}{"archived": true}, false},
+		{"not archived", map[string]interface{}{"archived": false, "s3_key": "k"}, false},
+		{"spec", map[string]interface{}{"genre": "puzzle"}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsStub(tt.specJSON); got != tt.want {
+				t.Errorf("IsStub() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store := memStore{}
+	UseStore(store)
+	t.Cleanup(func() { UseStore(nil) })
+
+	tutorial := "# How to play"
+	archive := Archive{
+		SpecMarkdown:     "# Spec",
+		SpecJSON:         map[string]interface{}{"genre": "puzzle", "duration_sec": float64(60)},
+		TutorialMarkdown: &tutorial,
+	}
+	body, err := compress(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := objectKey("spec-1")
+	store[key] = body
+
+	got, err := Restore(context.Background(), map[string]interface{}{"archived": true, "s3_key": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.SpecMarkdown != archive.SpecMarkdown {
+		t.Errorf("SpecMarkdown = %q, want %q", got.SpecMarkdown, archive.SpecMarkdown)
+	}
+	if got.SpecJSON["genre"] != "puzzle" || got.SpecJSON["duration_sec"] != float64(60) {
+		t.Errorf("SpecJSON = %v, want %v", got.SpecJSON, archive.SpecJSON)
+	}
+	if got.TutorialMarkdown == nil || *got.TutorialMarkdown != tutorial {
+		t.Errorf("TutorialMarkdown = %v, want %q", got.TutorialMarkdown, tutorial)
+	}
+}
+
+func TestRestoreErrors(t *testing.T) {
+	store := memStore{"game-specs/bad.json.gz": []byte("not gzip")}
+	UseStore(store)
+	t.Cleanup(func() { UseStore(nil) })
+
+	for _, key := range []string{"game-specs/missing.json.gz", "game-specs/bad.json.gz"} {
+		if _, err := Restore(context.Background(), map[string]interface{}{"archived": true, "s3_key": key}); err == nil {
+			t.Errorf("Restore(%s) succeeded, want an error", key)
+		}
+	}
+}
+
+// changingStore is a memStore that runs change before storing, standing in for a write to the
+// spec that lands while it is being uploaded
+type changingStore struct {
+	memStore
+	change func()
+}
+
+func (s changingStore) Put(ctx context.Context, key string, body []byte) error {
+	s.change()
+	return s.memStore.Put(ctx, key, body)
+}
+
+func TestArchiveSpec(t *testing.T) {
+	pool := dbtest.New(t)
+	newSpec := func(t *testing.T) string {
+		t.Helper()
+		id := uuid.New().String()
+		_, err := pool.Exec(context.Background(), `
+			INSERT INTO game_specs (id, title, brief, spec_markdown, spec_json, spec_hash, genre)
+			VALUES ($1, 'Game', 'brief', '# Spec', '{"genre":"puzzle"}', $1, 'puzzle')
+		`, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	var archived []string
+	OnArchive(func(specID string) { archived = append(archived, specID) })
+	t.Cleanup(func() { OnArchive(nil); UseStore(nil) })
+
+	t.Run("archived", func(t *testing.T) {
+		archived = nil
+		UseStore(memStore{})
+		id := newSpec(t)
+		if err := ArchiveSpec(context.Background(), pool, id); err != nil {
+			t.Fatal(err)
+		}
+		var markdown string
+		if err := pool.QueryRow(context.Background(), `SELECT spec_markdown FROM game_specs WHERE id = $1 AND archived_at IS NOT NULL`, id).Scan(&markdown); err != nil || markdown != "" {
+			t.Fatalf("spec not archived: markdown %q, %v", markdown, err)
+		}
+		if len(archived) != 1 || archived[0] != id {
+			t.Errorf("OnArchive got %v, want [%s]", archived, id)
+		}
+
+		// Archiving it again does nothing
+		if err := ArchiveSpec(context.Background(), pool, id); err != nil || len(archived) != 1 {
+			t.Errorf("second archive: err = %v, OnArchive got %v", err, archived)
+		}
+	})
+
+	t.Run("changed during upload", func(t *testing.T) {
+		archived = nil
+		id := newSpec(t)
+		UseStore(changingStore{memStore: memStore{}, change: func() {
+			_, err := pool.Exec(context.Background(), `UPDATE game_specs SET spec_markdown = '# Regenerated', spec_hash = 'regenerated-'||id WHERE id = $1`, id)
+			if err != nil {
+				t.Error(err)
+			}
+		}})
+		if err := ArchiveSpec(context.Background(), pool, id); !errors.Is(err, ErrSpecChanged) {
+			t.Fatalf("err = %v, want ErrSpecChanged", err)
+		}
+		var markdown string
+		var archivedAt *time.Time
+		if err := pool.QueryRow(context.Background(), `SELECT spec_markdown, archived_at FROM game_specs WHERE id = $1`, id).Scan(&markdown, &archivedAt); err != nil {
+			t.Fatal(err)
+		}
+		if markdown != "# Regenerated" || archivedAt != nil {
+			t.Errorf("markdown %q, archived_at %v: the regenerated spec was replaced", markdown, archivedAt)
+		}
+		if len(archived) != 0 {
+			t.Errorf("OnArchive got %v for a skipped spec", archived)
+		}
+	})
+}
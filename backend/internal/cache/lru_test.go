@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	c.Set("c", 3) // b is now the least recently used
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(key); !ok || got != want {
+			t.Errorf("Get(%q) = %d, %t, want %d", key, got, ok, want)
+		}
+	}
+	if s := c.Stats(); s.Size != 2 || s.Capacity != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestLRUSetReplaces(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+	c.Set("a", 1)
+	c.Set("a", 2)
+	if got, _ := c.Get("a"); got != 2 {
+		t.Errorf("Get(a) = %d, want 2", got)
+	}
+	if s := c.Stats(); s.Size != 1 {
+		t.Errorf("size = %d, want 1", s.Size)
+	}
+}
+
+func TestLRUExpires(t *testing.T) {
+	c := NewLRU[string, int](2, 10*time.Millisecond)
+	c.Set("a", 1)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry was served")
+	}
+	if s := c.Stats(); s.Size != 0 || s.Misses != 1 {
+		t.Errorf("stats = %+v, want the expired entry dropped and counted as a miss", s)
+	}
+}
+
+func TestLRUDelete(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+	c.Set("a", 1)
+	c.Delete("a")
+	c.Delete("missing")
+	if _, ok := c.Get("a"); ok {
+		t.Error("deleted entry was served")
+	}
+}
+
+func TestLRUStats(t *testing.T) {
+	c := NewLRU[string, int](0, time.Minute)
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	want := Stats{Hits: 2, Misses: 1, Size: 1, Capacity: 1}
+	if s := c.Stats(); s != want {
+		t.Errorf("stats = %+v, want %+v", s, want)
+	}
+}
+
+func TestLRUConcurrent(t *testing.T) {
+	c := NewLRU[int, int](16, time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				c.Set(i%32, g)
+				c.Get((i + g) % 32)
+				if i%7 == 0 {
+					c.Delete(i % 32)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if s := c.Stats(); s.Size > 16 || s.Hits+s.Misses != 8*500 {
+		t.Errorf("stats = %+v", s)
+	}
+}
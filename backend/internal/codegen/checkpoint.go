@@ -0,0 +1,76 @@
+package codegen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Stages of the code generation pipeline, in the order they run
+const (
+	StageFolder = "folder"
+	StageAssets = "assets"
+	StagePush   = "push"
+)
+
+var stageOrder = []string{StageFolder, StageAssets, StagePush}
+
+// CodeGenCheckpoint is the last stage a code job completed along with what later stages need
+type CodeGenCheckpoint struct {
+	Stage    string    `json:"stage"`
+	GamePath string    `json:"game_path,omitempty"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+// Done reports whether stage completed before the checkpoint was saved. A nil checkpoint has
+// completed nothing.
+func (c *CodeGenCheckpoint) Done(stage string) bool {
+	if c == nil {
+		return false
+	}
+	return stageIndex(stage) <= stageIndex(c.Stage)
+}
+
+func stageIndex(stage string) int {
+	for i, s := range stageOrder {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadCheckpoint returns the checkpoint of a code job, or nil when it has none
+func LoadCheckpoint(ctx context.Context, pool *pgxpool.Pool, jobID string) (*CodeGenCheckpoint, error) {
+	var raw []byte
+	err := pool.QueryRow(ctx, `SELECT checkpoint FROM code_jobs WHERE id = $1`, jobID).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && raw == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c CodeGenCheckpoint
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if stageIndex(c.Stage) < 0 {
+		return nil, fmt.Errorf("unknown checkpoint stage %q", c.Stage)
+	}
+	return &c, nil
+}
+
+// SaveCheckpoint records that a code job completed c.Stage
+func SaveCheckpoint(ctx context.Context, pool *pgxpool.Pool, jobID string, c CodeGenCheckpoint) error {
+	if stageIndex(c.Stage) < 0 {
+		return fmt.Errorf("unknown checkpoint stage %q", c.Stage)
+	}
+	c.SavedAt = time.Now().UTC()
+	_, err := pool.Exec(ctx, `UPDATE code_jobs SET checkpoint = $2 WHERE id = $1`, jobID, c)
+	return err
+}
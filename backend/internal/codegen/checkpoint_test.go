@@ -0,0 +1,70 @@
+package codegen
+
+import (
+	"backend/internal/dbtest"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckpointDone(t *testing.T) {
+	var none *CodeGenCheckpoint
+	for _, stage := range stageOrder {
+		if none.Done(stage) {
+			t.Errorf("nil checkpoint has done %s", stage)
+		}
+	}
+
+	assets := &CodeGenCheckpoint{Stage: StageAssets}
+	for stage, want := range map[string]bool{StageFolder: true, StageAssets: true, StagePush: false} {
+		if got := assets.Done(stage); got != want {
+			t.Errorf("assets checkpoint Done(%s) = %v, want %v", stage, got, want)
+		}
+	}
+}
+
+func TestSaveLoadCheckpoint(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	specID, jobID := uuid.New().String(), uuid.New().String()
+	_, err := pool.Exec(ctx, `
+		INSERT INTO game_specs (id, title, brief, spec_markdown, spec_json, spec_hash) VALUES ($1, 'Game', 'brief', '# Game', '{}', $1);
+	`, specID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO code_jobs (id, game_spec_id, status, created_at, updated_at) VALUES ($1, $2, 'processing', now(), now())`, jobID, specID); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err := LoadCheckpoint(ctx, pool, jobID); err != nil || c != nil {
+		t.Fatalf("LoadCheckpoint of a fresh job = %+v, %v, want none", c, err)
+	}
+	if c, err := LoadCheckpoint(ctx, pool, uuid.New().String()); err != nil || c != nil {
+		t.Fatalf("LoadCheckpoint of a missing job = %+v, %v, want none", c, err)
+	}
+
+	for _, stage := range stageOrder {
+		if err := SaveCheckpoint(ctx, pool, jobID, CodeGenCheckpoint{Stage: stage, GamePath: "/repo/game"}); err != nil {
+			t.Fatal(err)
+		}
+		c, err := LoadCheckpoint(ctx, pool, jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Stage != stage || c.GamePath != "/repo/game" || c.SavedAt.IsZero() {
+			t.Errorf("loaded %+v after saving %s", c, stage)
+		}
+	}
+
+	if err := SaveCheckpoint(ctx, pool, jobID, CodeGenCheckpoint{Stage: "deploy"}); err == nil {
+		t.Error("saved an unknown stage")
+	}
+	if _, err := pool.Exec(ctx, `UPDATE code_jobs SET checkpoint = '{"stage":"deploy"}' WHERE id = $1`, jobID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoint(ctx, pool, jobID); err == nil {
+		t.Error("loaded a checkpoint of an unknown stage")
+	}
+}
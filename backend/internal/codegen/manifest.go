@@ -0,0 +1,137 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Manifest describes the browser capabilities a generated game needs
+type Manifest struct {
+	RequiresCanvas   bool     `json:"requires_canvas"`
+	RequiresAudio    bool     `json:"requires_audio"`
+	RequiresTouch    bool     `json:"requires_touch"`
+	RequiresKeyboard bool     `json:"requires_keyboard"`
+	RequiresGamepad  bool     `json:"requires_gamepad"`
+	RequiresNetwork  bool     `json:"requires_network"`
+	MinBrowser       string   `json:"min_browser"`
+	EstimatedKB      int      `json:"estimated_kb"`
+	Dependencies     []string `json:"dependencies"`
+}
+
+var (
+	audioKeywords    = []string{"audio", "sound", "music", "sfx"}
+	touchKeywords    = []string{"touch", "swipe", "tap", "pinch"}
+	keyboardKeywords = []string{"keyboard", "arrow", "wasd", "key", "spacebar"}
+	gamepadKeywords  = []string{"gamepad", "controller", "joystick"}
+	networkKeywords  = []string{"multiplayer", "online", "pvp", "network", "realtime"}
+	webglKeywords    = []string{"3d", "webgl", "shader"}
+	storageKeywords  = []string{"save", "persist", "high score", "highscore", "leaderboard", "progress"}
+	textOnlyKeywords = []string{"text-based", "text adventure", "interactive fiction"}
+)
+
+// GenerateManifest derives a capability manifest from the mechanics and controls of a spec
+func GenerateManifest(specJSON map[string]interface{}) Manifest {
+	mechanics := flattenText(specJSON["mechanics"])
+	controls := flattenText(specJSON["controls"])
+	all := strings.Join([]string{mechanics, controls, flattenText(specJSON["genre"]), flattenText(specJSON["constraints"])}, " ")
+
+	m := Manifest{
+		RequiresCanvas:   !containsAny(all, textOnlyKeywords),
+		RequiresAudio:    containsAny(all, audioKeywords),
+		RequiresTouch:    containsAny(controls, touchKeywords),
+		RequiresKeyboard: containsAny(controls, keyboardKeywords),
+		RequiresGamepad:  containsAny(controls, gamepadKeywords),
+		RequiresNetwork:  containsAny(all, networkKeywords),
+		MinBrowser:       "chrome90",
+	}
+
+	deps := []string{}
+	if m.RequiresCanvas {
+		deps = append(deps, "canvas")
+	}
+	if m.RequiresAudio {
+		deps = append(deps, "webaudio")
+	}
+	if m.RequiresTouch {
+		deps = append(deps, "touch-events")
+	}
+	if m.RequiresGamepad {
+		deps = append(deps, "gamepad-api")
+	}
+	if m.RequiresNetwork {
+		deps = append(deps, "websocket")
+	}
+	if containsAny(all, webglKeywords) {
+		deps = append(deps, "webgl")
+	}
+	if containsAny(all, storageKeywords) {
+		deps = append(deps, "localstorage")
+	}
+	sort.Strings(deps)
+	m.Dependencies = deps
+
+	// Rough size estimate: a small engine baseline plus weight per feature
+	kb := 40 + 8*countItems(specJSON["mechanics"]) + 4*countItems(specJSON["controls"])
+	if m.RequiresAudio {
+		kb += 30
+	}
+	if m.RequiresNetwork {
+		kb += 20
+	}
+	if containsAny(all, webglKeywords) {
+		kb += 40
+	}
+	m.EstimatedKB = kb
+
+	return m
+}
+
+// flattenText turns a spec_json value (string, list or object) into lowercase searchable text
+func flattenText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.ToLower(val)
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, flattenText(item))
+		}
+		return strings.Join(parts, " ")
+	case map[string]interface{}:
+		parts := make([]string, 0, len(val)*2)
+		for k, item := range val {
+			parts = append(parts, strings.ToLower(k), flattenText(item))
+		}
+		return strings.Join(parts, " ")
+	default:
+		return strings.ToLower(fmt.Sprintf("%v", val))
+	}
+}
+
+func countItems(v interface{}) int {
+	switch val := v.(type) {
+	case []interface{}:
+		return len(val)
+	case map[string]interface{}:
+		return len(val)
+	case string:
+		if val == "" {
+			return 0
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		specJSON map[string]interface{}
+		want     Manifest
+	}{
+		{
+			name:     "empty spec",
+			specJSON: map[string]interface{}{},
+			want: Manifest{
+				RequiresCanvas: true,
+				MinBrowser:     "chrome90",
+				EstimatedKB:    40,
+				Dependencies:   []string{"canvas"},
+			},
+		},
+		{
+			name: "keyboard platformer with music and high scores",
+			specJSON: map[string]interface{}{
+				"genre":     "platformer",
+				"mechanics": []interface{}{"jump", "collect coins", "background music", "high score table"},
+				"controls":  map[string]interface{}{"move": "arrow keys", "jump": "spacebar"},
+			},
+			want: Manifest{
+				RequiresCanvas:   true,
+				RequiresAudio:    true,
+				RequiresKeyboard: true,
+				MinBrowser:       "chrome90",
+				EstimatedKB:      40 + 8*4 + 4*2 + 30,
+				Dependencies:     []string{"canvas", "localstorage", "webaudio"},
+			},
+		},
+		{
+			name: "online 3d shooter with touch and gamepad",
+			specJSON: map[string]interface{}{
+				"genre":     "3D shooter",
+				"mechanics": "online multiplayer arena",
+				"controls":  []interface{}{"tap to shoot", "gamepad sticks"},
+			},
+			want: Manifest{
+				RequiresCanvas:  true,
+				RequiresTouch:   true,
+				RequiresGamepad: true,
+				RequiresNetwork: true,
+				MinBrowser:      "chrome90",
+				EstimatedKB:     40 + 8*1 + 4*2 + 20 + 40,
+				Dependencies:    []string{"canvas", "gamepad-api", "touch-events", "webgl", "websocket"},
+			},
+		},
+		{
+			name: "text adventure needs no canvas",
+			specJSON: map[string]interface{}{
+				"genre":    "text adventure",
+				"controls": "type commands",
+			},
+			want: Manifest{
+				MinBrowser:   "chrome90",
+				EstimatedKB:  40 + 4,
+				Dependencies: []string{},
+			},
+		},
+		{
+			name: "touch words outside controls don't require touch",
+			specJSON: map[string]interface{}{
+				"mechanics": []interface{}{"swipe-themed story"},
+			},
+			want: Manifest{
+				RequiresCanvas: true,
+				MinBrowser:     "chrome90",
+				EstimatedKB:    40 + 8,
+				Dependencies:   []string{"canvas"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateManifest(tt.specJSON); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateManifest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetString returns the value of the environment variable key, or defaultVal when it is unset or empty
+func GetString(key, defaultVal string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return defaultVal
+}
+
+// MustGetInt returns key parsed as an int. Invalid values log a warning and fall back to defaultVal.
+func MustGetInt(key string, defaultVal int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("[WARNING] Invalid integer for %s=%q, using default %d", key, v, defaultVal)
+		return defaultVal
+	}
+	return n
+}
+
+// MustGetFloat returns key parsed as a float64. Invalid values log a warning and fall back to defaultVal.
+func MustGetFloat(key string, defaultVal float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return defaultVal
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("[WARNING] Invalid number for %s=%q, using default %g", key, v, defaultVal)
+		return defaultVal
+	}
+	return f
+}
+
+// MustGetBool returns key parsed as a bool ("true", "1", "false", "0", ...). Invalid values log a warning and fall back to defaultVal.
+func MustGetBool(key string, defaultVal bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return defaultVal
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("[WARNING] Invalid boolean for %s=%q, using default %t", key, v, defaultVal)
+		return defaultVal
+	}
+	return b
+}
+
+// MustGetDuration returns key parsed as a time.Duration ("30s", "5m"). Invalid values log a warning and fall back to defaultVal.
+func MustGetDuration(key string, defaultVal time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("[WARNING] Invalid duration for %s=%q, using default %s", key, v, defaultVal)
+		return defaultVal
+	}
+	return d
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "CONFIG_TEST_VALUE"
+
+// captureLog returns what fn logs
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(orig)
+	fn()
+	return buf.String()
+}
+
+func TestGetString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", "fallback"},
+		{"   ", "fallback"},
+		{"value", "value"},
+		{"  padded  ", "padded"},
+	}
+	for _, tt := range tests {
+		t.Setenv(testKey, tt.value)
+		if got := GetString(testKey, "fallback"); got != tt.want {
+			t.Errorf("GetString(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMustGetInt(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     int
+		wantWarn bool
+	}{
+		{value: "", want: 7},
+		{value: "42", want: 42},
+		{value: " -3 ", want: -3},
+		{value: "4MB", want: 7, wantWarn: true},
+		{value: "1.5", want: 7, wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(testKey, tt.value)
+		var got int
+		out := captureLog(t, func() { got = MustGetInt(testKey, 7) })
+		if got != tt.want {
+			t.Errorf("MustGetInt(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+		if warned := strings.Contains(out, "[WARNING]"); warned != tt.wantWarn {
+			t.Errorf("MustGetInt(%q) logged %q", tt.value, out)
+		}
+	}
+}
+
+func TestMustGetFloat(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     float64
+		wantWarn bool
+	}{
+		{value: "", want: 0.5},
+		{value: "0.85", want: 0.85},
+		{value: "2", want: 2},
+		{value: "high", want: 0.5, wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(testKey, tt.value)
+		var got float64
+		out := captureLog(t, func() { got = MustGetFloat(testKey, 0.5) })
+		if got != tt.want {
+			t.Errorf("MustGetFloat(%q) = %g, want %g", tt.value, got, tt.want)
+		}
+		if warned := strings.Contains(out, "[WARNING]"); warned != tt.wantWarn {
+			t.Errorf("MustGetFloat(%q) logged %q", tt.value, out)
+		}
+	}
+}
+
+func TestMustGetBool(t *testing.T) {
+	tests := []struct {
+		value    string
+		def      bool
+		want     bool
+		wantWarn bool
+	}{
+		{value: "", def: true, want: true},
+		{value: "", def: false, want: false},
+		{value: "true", want: true},
+		{value: "1", want: true},
+		{value: "TRUE", want: true},
+		{value: "false", def: true, want: false},
+		{value: "0", def: true, want: false},
+		{value: "yes", def: true, want: true, wantWarn: true},
+		{value: "on", want: false, wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(testKey, tt.value)
+		var got bool
+		out := captureLog(t, func() { got = MustGetBool(testKey, tt.def) })
+		if got != tt.want {
+			t.Errorf("MustGetBool(%q, %t) = %t, want %t", tt.value, tt.def, got, tt.want)
+		}
+		if warned := strings.Contains(out, "[WARNING]"); warned != tt.wantWarn {
+			t.Errorf("MustGetBool(%q) logged %q", tt.value, out)
+		}
+	}
+}
+
+func TestMustGetDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     time.Duration
+		wantWarn bool
+	}{
+		{value: "", want: time.Minute},
+		{value: "30s", want: 30 * time.Second},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "0s", want: 0},
+		{value: "30", want: time.Minute, wantWarn: true},
+		{value: "soon", want: time.Minute, wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(testKey, tt.value)
+		var got time.Duration
+		out := captureLog(t, func() { got = MustGetDuration(testKey, time.Minute) })
+		if got != tt.want {
+			t.Errorf("MustGetDuration(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+		if warned := strings.Contains(out, "[WARNING]"); warned != tt.wantWarn {
+			t.Errorf("MustGetDuration(%q) logged %q", tt.value, out)
+		}
+	}
+}
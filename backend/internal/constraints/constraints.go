@@ -0,0 +1,110 @@
+package constraints
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConstraintError describes a constraint whose value doesn't match the schema
+type ConstraintError struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// rule is the schema entry of one constraint
+type rule struct {
+	Type string   `yaml:"type"`
+	Min  *float64 `yaml:"min"`
+	Max  *float64 `yaml:"max"`
+	Enum []string `yaml:"enum"`
+}
+
+//go:embed schema.yaml
+var schemaYAML []byte
+
+// schema maps constraint keys to their rules. New constraints are added to schema.yaml.
+var schema = mustParseSchema(schemaYAML)
+
+func mustParseSchema(b []byte) map[string]rule {
+	rules := map[string]rule{}
+	if err := yaml.Unmarshal(b, &rules); err != nil {
+		panic(fmt.Sprintf("constraints: invalid schema.yaml: %v", err))
+	}
+	for key, r := range rules {
+		switch r.Type {
+		case "integer", "number", "string", "boolean":
+		default:
+			panic(fmt.Sprintf("constraints: %s has unknown type %q", key, r.Type))
+		}
+	}
+	return rules
+}
+
+// Validate checks the constraints of a job request against the schema, sorted by key.
+// Keys the schema doesn't know are left alone.
+func Validate(c map[string]interface{}) []ConstraintError {
+	errs := []ConstraintError{}
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		r, ok := schema[key]
+		if !ok {
+			continue
+		}
+		if msg := r.check(c[key]); msg != "" {
+			errs = append(errs, ConstraintError{Key: key, Message: msg})
+		}
+	}
+	return errs
+}
+
+// check returns why v breaks the rule, or "" when it doesn't
+func (r rule) check(v interface{}) string {
+	switch r.Type {
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return "must be a boolean"
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if len(r.Enum) > 0 && !contains(r.Enum, s) {
+			return "must be one of " + strings.Join(r.Enum, ", ")
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if r.Type == "integer" && (!ok || n != math.Trunc(n)) {
+			return "must be an integer"
+		}
+		if !ok {
+			return "must be a number"
+		}
+		if r.Min != nil && n < *r.Min {
+			return fmt.Sprintf("must be at least %v", *r.Min)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fmt.Sprintf("must be at most %v", *r.Max)
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package constraints
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []ConstraintError
+	}{
+		{"empty", `{}`, []ConstraintError{}},
+		{
+			"all valid",
+			`{"max_file_size_kb": 1024, "target_platform": "mobile", "no_external_libs": true, "max_mechanics": 1}`,
+			[]ConstraintError{},
+		},
+		{"unknown keys pass", `{"art_style": 3, "palette": ["red"]}`, []ConstraintError{}},
+		{"size too large", `{"max_file_size_kb": 1025}`, []ConstraintError{{"max_file_size_kb", "must be at most 1024"}}},
+		{"size not positive", `{"max_file_size_kb": 0}`, []ConstraintError{{"max_file_size_kb", "must be at least 1"}}},
+		{"size fractional", `{"max_file_size_kb": 12.5}`, []ConstraintError{{"max_file_size_kb", "must be an integer"}}},
+		{"size as string", `{"max_file_size_kb": "512"}`, []ConstraintError{{"max_file_size_kb", "must be an integer"}}},
+		{"platform not allowed", `{"target_platform": "console"}`, []ConstraintError{{"target_platform", "must be one of web, mobile, desktop"}}},
+		{"platform not a string", `{"target_platform": 1}`, []ConstraintError{{"target_platform", "must be a string"}}},
+		{"libs not a boolean", `{"no_external_libs": "yes"}`, []ConstraintError{{"no_external_libs", "must be a boolean"}}},
+		{"mechanics bounds", `{"max_mechanics": 21}`, []ConstraintError{{"max_mechanics", "must be at most 20"}}},
+		{
+			"errors sorted by key",
+			`{"target_platform": "tv", "max_mechanics": 0, "no_external_libs": null}`,
+			[]ConstraintError{
+				{"max_mechanics", "must be at least 1"},
+				{"no_external_libs", "must be a boolean"},
+				{"target_platform", "must be one of web, mobile, desktop"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Constraints arrive decoded from a JSON request body
+			var c map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.json), &c); err != nil {
+				t.Fatal(err)
+			}
+			if got := Validate(c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%s) = %v, want %v", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaExtension(t *testing.T) {
+	rules := mustParseSchema([]byte("difficulty:\n  type: string\n  enum: [easy, hard]\n"))
+	if msg := rules["difficulty"].check("medium"); msg != "must be one of easy, hard" {
+		t.Errorf("check = %q", msg)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("an unknown type didn't panic")
+		}
+	}()
+	mustParseSchema([]byte("difficulty:\n  type: enum\n"))
+}
@@ -0,0 +1,85 @@
+package content
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+)
+
+// AgeRating is the audience a generated game is suitable for
+type AgeRating string
+
+// Age ratings, from least to most restricted
+const (
+	RatingEveryone AgeRating = "E"
+	RatingTeen     AgeRating = "T"
+	RatingMature   AgeRating = "M"
+)
+
+// ParseAgeRating returns the rating named by s ("E", "T" or "M", case-insensitive)
+func ParseAgeRating(s string) (AgeRating, bool) {
+	switch r := AgeRating(strings.ToUpper(strings.TrimSpace(s))); r {
+	case RatingEveryone, RatingTeen, RatingMature:
+		return r, true
+	}
+	return "", false
+}
+
+//go:embed wordlist.txt
+var wordlist string
+
+// ratedTerms maps each term of the word list to the rating it implies
+var ratedTerms = parseWordlist(wordlist)
+
+func parseWordlist(list string) map[string]AgeRating {
+	terms := map[string]AgeRating{}
+	rating := RatingEveryone
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			if r, ok := ParseAgeRating(line[1 : len(line)-1]); ok {
+				rating = r
+			}
+		default:
+			terms[normalize(line)] = rating
+		}
+	}
+	return terms
+}
+
+// normalize lowercases s and reduces it to single-space separated words, padded with a space
+// on both ends so terms only match whole words
+func normalize(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return " " + strings.Join(words, " ") + " "
+}
+
+// ClassifyAgeRating rates a spec by the violence, horror and sexual content terms in its
+// markdown. The highest rating of any matched term wins; a spec matching none is E.
+func ClassifyAgeRating(specMarkdown string) AgeRating {
+	text := normalize(specMarkdown)
+	rating := RatingEveryone
+	for term, r := range ratedTerms {
+		if rank(r) > rank(rating) && strings.Contains(text, term) {
+			rating = r
+			if rating == RatingMature {
+				break
+			}
+		}
+	}
+	return rating
+}
+
+func rank(r AgeRating) int {
+	switch r {
+	case RatingTeen:
+		return 1
+	case RatingMature:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package content
+
+import "testing"
+
+func TestClassifyAgeRating(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     AgeRating
+	}{
+		{name: "empty", markdown: "", want: RatingEveryone},
+		{name: "puzzle", markdown: "# Yarn Cat\n\nMatch three balls of yarn to clear the board.", want: RatingEveryone},
+		{
+			name:     "violent keywords",
+			markdown: "# Arena\n\n## Mechanics\n- Pick up a sword and FIGHT waves of zombies.\n- Blood splashes on every hit.",
+			want:     RatingTeen,
+		},
+		{name: "horror", markdown: "Explore a haunted house", want: RatingTeen},
+		{
+			name:     "graphic violence",
+			markdown: "# Butcher\n\nCombat ends in dismemberment and gore.",
+			want:     RatingMature,
+		},
+		{name: "phrase", markdown: "A slow-burn psychological   horror story", want: RatingMature},
+		{name: "sexual content", markdown: "Contains nudity.", want: RatingMature},
+		{name: "case and punctuation", markdown: "**GORE!**", want: RatingMature},
+		// Terms only match whole words
+		{name: "word inside another word", markdown: "Swordfish swim past the warden; a sextant guides the ship.", want: RatingEveryone},
+		{name: "plural not in the list", markdown: "Collect all the kisses", want: RatingEveryone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyAgeRating(tt.markdown); got != tt.want {
+				t.Errorf("ClassifyAgeRating(%q) = %s, want %s", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAgeRating(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   AgeRating
+		wantOK bool
+	}{
+		{"E", RatingEveryone, true},
+		{" t ", RatingTeen, true},
+		{"m", RatingMature, true},
+		{"", "", false},
+		{"PG", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseAgeRating(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseAgeRating(%q) = %q, %t, want %q, %t", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseWordlist(t *testing.T) {
+	terms := parseWordlist("# comment\nignored before a section\n[T]\nBlood\n\n[X]\nstill teen\n[m]\nBody  Horror\n")
+	want := map[string]AgeRating{
+		" ignored before a section ": RatingEveryone,
+		" blood ":                    RatingTeen,
+		" still teen ":               RatingTeen,
+		" body horror ":              RatingMature,
+	}
+	if len(terms) != len(want) {
+		t.Fatalf("terms = %v, want %v", terms, want)
+	}
+	for term, r := range want {
+		if terms[term] != r {
+			t.Errorf("terms[%q] = %q, want %q", term, terms[term], r)
+		}
+	}
+}
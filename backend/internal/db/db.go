@@ -1,15 +1,15 @@
 package db
 
 import (
+	"backend/internal/config"
 	"context"
-	"os"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func Open(ctx context.Context) (*pgxpool.Pool, error) {
-	dsn := os.Getenv("DATABASE_URL")
+	dsn := config.GetString("DATABASE_URL", "")
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TryLockSpec takes the transaction-scoped advisory lock of a spec without waiting and reports
+// whether it was acquired. The lock is released when tx commits or rolls back.
+func TryLockSpec(ctx context.Context, tx pgx.Tx, specID string) (bool, error) {
+	var locked bool
+	err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext($1))`, specID).Scan(&locked)
+	return locked, err
+}
+
+// LockSpec waits for the transaction-scoped advisory lock of a spec
+func LockSpec(ctx context.Context, tx pgx.Tx, specID string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, specID)
+	return err
+}
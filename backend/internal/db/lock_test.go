@@ -0,0 +1,117 @@
+package db
+
+import (
+	"backend/internal/dbtest"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTryLockSpecConcurrent(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+
+	const workers = 2
+	var (
+		ready   sync.WaitGroup
+		done    sync.WaitGroup
+		release = make(chan struct{})
+		results = make(chan bool, workers)
+		errs    = make(chan error, workers)
+	)
+	ready.Add(workers)
+	done.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer done.Done()
+			tx, err := pool.Begin(ctx)
+			if err != nil {
+				ready.Done()
+				errs <- err
+				return
+			}
+			defer tx.Rollback(ctx)
+			locked, err := TryLockSpec(ctx, tx, "spec-1")
+			ready.Done()
+			if err != nil {
+				errs <- err
+				return
+			}
+			results <- locked
+			// Hold the lock until both goroutines have tried
+			<-release
+		}()
+	}
+	ready.Wait()
+	close(release)
+	done.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+	acquired := 0
+	for locked := range results {
+		if locked {
+			acquired++
+		}
+	}
+	if acquired != 1 {
+		t.Fatalf("%d goroutines got the lock, want exactly 1", acquired)
+	}
+
+	// The lock is released with the transaction, so it can be taken again
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	locked, err := TryLockSpec(ctx, tx, "spec-1")
+	if err != nil || !locked {
+		t.Fatalf("TryLockSpec after release = %v, %v; want true", locked, err)
+	}
+}
+
+func TestLockSpecWaits(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+
+	holder, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Rollback(ctx)
+	if err := LockSpec(ctx, holder, "spec-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			acquired <- err
+			return
+		}
+		defer tx.Rollback(ctx)
+		acquired <- LockSpec(ctx, tx, "spec-2")
+	}()
+
+	select {
+	case err := <-acquired:
+		t.Fatalf("LockSpec returned (%v) while another transaction held the lock", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := holder.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("LockSpec still waiting after the lock was released")
+	}
+}
@@ -0,0 +1,91 @@
+package devin
+
+import (
+	"backend/internal/config"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed configs/devin_task_template.yaml
+var defaultTemplateYAML []byte
+
+// TaskData is what the Devin task template is rendered with
+type TaskData struct {
+	// Folder is where the game lives in the repository
+	Folder     string
+	RepoURL    string
+	GameTitle  string
+	GameSpecID string
+	// CodeSubdir is GENERATED_CODE_SUBDIR, empty when code goes in the game folder itself
+	CodeSubdir      string
+	TargetFramework string
+}
+
+// DevinTemplate is the prompt of the Devin task created for every code job
+type DevinTemplate struct {
+	PromptTemplate string `yaml:"prompt_template"`
+	SystemContext  string `yaml:"system_context"`
+
+	prompt *template.Template
+	system *template.Template
+}
+
+// LoadDevinTemplate reads a task template from a YAML file, or the embedded default when path is empty
+func LoadDevinTemplate(path string) (*DevinTemplate, error) {
+	b := defaultTemplateYAML
+	if path != "" {
+		var err error
+		if b, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read Devin template: %w", err)
+		}
+	}
+
+	var t DevinTemplate
+	if err := yaml.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("invalid Devin template %s: %w", templateName(path), err)
+	}
+	if strings.TrimSpace(t.PromptTemplate) == "" {
+		return nil, fmt.Errorf("Devin template %s has no prompt_template", templateName(path))
+	}
+	var err error
+	if t.prompt, err = template.New("prompt_template").Option("missingkey=error").Parse(t.PromptTemplate); err != nil {
+		return nil, fmt.Errorf("invalid prompt_template in %s: %w", templateName(path), err)
+	}
+	if t.system, err = template.New("system_context").Option("missingkey=error").Parse(t.SystemContext); err != nil {
+		return nil, fmt.Errorf("invalid system_context in %s: %w", templateName(path), err)
+	}
+	return &t, nil
+}
+
+func templateName(path string) string {
+	if path == "" {
+		return "(embedded)"
+	}
+	return path
+}
+
+// ConfiguredTemplate loads DEVIN_PROMPT_TEMPLATE_FILE, or the embedded default when it is unset.
+// The file is read on every call so the prompt can be tuned without a restart.
+func ConfiguredTemplate() (*DevinTemplate, error) {
+	return LoadDevinTemplate(config.GetString("DEVIN_PROMPT_TEMPLATE_FILE", ""))
+}
+
+// Render returns the task description: the system context, if any, followed by the prompt
+func (t *DevinTemplate) Render(data TaskData) (string, error) {
+	var prompt, system strings.Builder
+	if err := t.prompt.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to render Devin prompt: %w", err)
+	}
+	if err := t.system.Execute(&system, data); err != nil {
+		return "", fmt.Errorf("failed to render Devin system context: %w", err)
+	}
+	if s := strings.TrimSpace(system.String()); s != "" {
+		return s + "\n\n" + prompt.String(), nil
+	}
+	return prompt.String(), nil
+}
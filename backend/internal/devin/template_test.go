@@ -0,0 +1,122 @@
+package devin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderCodeSubdir(t *testing.T) {
+	tmpl, err := LoadDevinTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := TaskData{Folder: "game-1", RepoURL: "https://github.com/acme/games", GameTitle: "Yarn Cat", GameSpecID: "spec-1"}
+
+	flat, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(flat, "subfolder") {
+		t.Errorf("flat layout prompt mentions a subfolder:\n%s", flat)
+	}
+
+	data.CodeSubdir = "src"
+	nested, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(nested, "under the src/ subfolder") {
+		t.Errorf("prompt doesn't point at src/:\n%s", nested)
+	}
+	if !strings.HasPrefix(nested, flat) {
+		t.Error("the subfolder note changed the rest of the prompt")
+	}
+}
+
+func TestRenderPopulatesPlaceholders(t *testing.T) {
+	tmpl, err := LoadDevinTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := TaskData{
+		Folder:          "yarn-cat-1234",
+		RepoURL:         "https://github.com/acme/games",
+		GameTitle:       "Yarn Cat",
+		GameSpecID:      "spec-1234",
+		CodeSubdir:      "src",
+		TargetFramework: "phaser",
+	}
+	got, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"folder yarn-cat-1234",
+		"Repository: https://github.com/acme/games",
+		"Game Title: Yarn Cat",
+		"Game Spec ID: spec-1234",
+		"implement/game-spec-1234",
+		"under the src/ subfolder",
+		"Target Framework: phaser",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt lacks %q", want)
+		}
+	}
+	if strings.Contains(got, "{{") || strings.Contains(got, "<no value>") {
+		t.Errorf("prompt has unrendered placeholders:\n%s", got)
+	}
+}
+
+func writeTemplate(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "devin.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDevinTemplateFile(t *testing.T) {
+	path := writeTemplate(t, "system_context: You build games for {{.RepoURL}}.\nprompt_template: Build {{.GameTitle}} in {{.Folder}}.\n")
+	t.Setenv("DEVIN_PROMPT_TEMPLATE_FILE", path)
+	tmpl, err := ConfiguredTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tmpl.Render(TaskData{Folder: "cat", RepoURL: "acme/games", GameTitle: "Yarn Cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "You build games for acme/games.\n\nBuild Yarn Cat in cat."; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
+
+func TestLoadDevinTemplateErrors(t *testing.T) {
+	for _, tt := range []struct{ name, content string }{
+		{"not yaml", "prompt_template: [unclosed"},
+		{"no prompt", "system_context: hello\n"},
+		{"bad syntax", "prompt_template: Build {{.GameTitle}\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadDevinTemplate(writeTemplate(t, tt.content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+	if _, err := LoadDevinTemplate(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+
+	// Unknown fields fail when rendering rather than producing "<no value>"
+	tmpl, err := LoadDevinTemplate(writeTemplate(t, "prompt_template: Build {{.Genre}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Render(TaskData{}); err == nil {
+		t.Error("rendered a template using an unknown field")
+	}
+}
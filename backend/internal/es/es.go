@@ -0,0 +1,145 @@
+package es
+
+import (
+	"backend/internal/config"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// InitialState is the state of a spec before any event, matching the game_specs.state default
+const InitialState = "creating"
+
+// Querier is satisfied by both *pgxpool.Pool and pgx.Tx
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Event is a state transition of a spec
+type Event struct {
+	StateBefore *string   `json:"state_before"`
+	StateAfter  string    `json:"state_after"`
+	Detail      *string   `json:"detail,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Replay folds events, oldest first, into the state they lead to
+func Replay(events []Event) string {
+	state := InitialState
+	for _, ev := range events {
+		state = ev.StateAfter
+	}
+	return state
+}
+
+// ListEvents returns the events of a spec in the order they were appended
+func ListEvents(ctx context.Context, q Querier, specID string) ([]Event, error) {
+	rows, err := q.Query(ctx, `
+		SELECT state_before, state_after, detail, created_at
+		FROM game_spec_states
+		WHERE game_spec_id = $1
+		ORDER BY created_at ASC, seq ASC
+	`, specID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var ev Event
+		if err := rows.Scan(&ev.StateBefore, &ev.StateAfter, &ev.Detail, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// ProjectCurrentState computes the current state of a spec by replaying its events
+func ProjectCurrentState(ctx context.Context, q Querier, specID string) (string, error) {
+	events, err := ListEvents(ctx, q, specID)
+	if err != nil {
+		return "", err
+	}
+	return Replay(events), nil
+}
+
+// AppendEvent records a transition to state and returns the state it replaced. It locks the
+// spec row, so call it inside a transaction to serialize concurrent transitions of a spec.
+func AppendEvent(ctx context.Context, q Querier, specID, state, detail string) (string, error) {
+	var locked int
+	if err := q.QueryRow(ctx, "SELECT 1 FROM game_specs WHERE id = $1 FOR UPDATE", specID).Scan(&locked); err != nil {
+		return "", fmt.Errorf("failed to lock spec: %w", err)
+	}
+
+	before, err := ProjectCurrentState(ctx, q, specID)
+	if err != nil {
+		return "", fmt.Errorf("failed to project current state: %w", err)
+	}
+
+	// clock_timestamp keeps events appended in one transaction in order
+	_, err = q.Exec(ctx, `
+		INSERT INTO game_spec_states (game_spec_id, state_before, state_after, detail, created_at)
+		VALUES ($1, $2, $3, $4, clock_timestamp())
+	`, specID, before, state, detail)
+	if err != nil {
+		return "", fmt.Errorf("failed to append state event: %w", err)
+	}
+	return before, nil
+}
+
+// Project refreshes game_specs.state of one spec from its events
+func Project(ctx context.Context, q Querier, specID string) error {
+	state, err := ProjectCurrentState(ctx, q, specID)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx, "UPDATE game_specs SET state = $1 WHERE id = $2 AND state IS DISTINCT FROM $1", state, specID)
+	return err
+}
+
+// projectAllSQL brings every stale game_specs.state in line with the latest event of its spec
+const projectAllSQL = `
+	UPDATE game_specs s
+	SET state = latest.state_after
+	FROM (
+		SELECT DISTINCT ON (game_spec_id) game_spec_id, state_after
+		FROM game_spec_states
+		ORDER BY game_spec_id, created_at DESC, seq DESC
+	) latest
+	WHERE s.id = latest.game_spec_id AND s.state IS DISTINCT FROM latest.state_after
+`
+
+// RunProjector keeps game_specs.state eventually consistent with the event log, catching
+// projections missed by a crash between append and projection. It runs every
+// SPEC_PROJECTOR_INTERVAL (default 1m) until ctx is done.
+func RunProjector(ctx context.Context, q Querier) {
+	ticker := time.NewTicker(config.MustGetDuration("SPEC_PROJECTOR_INTERVAL", time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		projectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		tag, err := q.Exec(projectCtx, projectAllSQL)
+		cancel()
+		if err != nil {
+			log.Printf("[ERROR] Failed to project spec states: %v", err)
+			continue
+		}
+		if tag.RowsAffected() > 0 {
+			log.Printf("[STATE] Projector corrected the state of %d specs", tag.RowsAffected())
+		}
+	}
+}
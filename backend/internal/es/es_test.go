@@ -0,0 +1,195 @@
+package es
+
+import (
+	"backend/internal/dbtest"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		want   string
+	}{
+		{name: "no events", want: InitialState},
+		{name: "one event", events: []Event{{StateBefore: strPtr(InitialState), StateAfter: "git_initing"}}, want: "git_initing"},
+		{
+			name: "latest event wins",
+			events: []Event{
+				{StateBefore: strPtr(InitialState), StateAfter: "git_initing"},
+				{StateBefore: strPtr("git_initing"), StateAfter: "git_inited"},
+				{StateBefore: strPtr("git_inited"), StateAfter: "code_generating"},
+			},
+			want: "code_generating",
+		},
+		{
+			name: "returning to an earlier state",
+			events: []Event{
+				{StateBefore: strPtr(InitialState), StateAfter: "code_generating"},
+				{StateBefore: strPtr("code_generating"), StateAfter: "git_inited"},
+			},
+			want: "git_inited",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Replay(tt.events); got != tt.want {
+				t.Errorf("Replay = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newSpec inserts a spec without events and returns its id
+func newSpec(t *testing.T, pool *pgxpool.Pool) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO game_specs (id, title, brief, spec_markdown, spec_json, spec_hash, genre)
+		VALUES ($1, 'Test game', 'A test brief', '# Test game', '{}', $1, 'puzzle')
+	`, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// appendEvent appends an event in its own transaction, like the handlers do
+func appendEvent(t *testing.T, pool *pgxpool.Pool, specID, state string) string {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	before, err := AppendEvent(ctx, tx, specID, state, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return before
+}
+
+func storedState(t *testing.T, pool *pgxpool.Pool, specID string) string {
+	t.Helper()
+	var state string
+	if err := pool.QueryRow(context.Background(), `SELECT state FROM game_specs WHERE id = $1`, specID).Scan(&state); err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestAppendEventReplay(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	specID := newSpec(t, pool)
+
+	if state, err := ProjectCurrentState(ctx, pool, specID); err != nil || state != InitialState {
+		t.Fatalf("state without events = %q, %v, want %q", state, err, InitialState)
+	}
+
+	states := []string{"git_initing", "git_inited", "code_generating", "code_generated"}
+	want := InitialState
+	for _, state := range states {
+		if before := appendEvent(t, pool, specID, state); before != want {
+			t.Errorf("AppendEvent(%s) replaced %q, want %q", state, before, want)
+		}
+		want = state
+	}
+
+	events, err := ListEvents(ctx, pool, specID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(states) {
+		t.Fatalf("%d events, want %d", len(events), len(states))
+	}
+	for i, ev := range events {
+		if ev.StateAfter != states[i] {
+			t.Errorf("event %d: state_after = %q, want %q", i, ev.StateAfter, states[i])
+		}
+		if ev.Detail == nil || *ev.Detail != "test" {
+			t.Errorf("event %d: detail = %v", i, ev.Detail)
+		}
+	}
+	if state, err := ProjectCurrentState(ctx, pool, specID); err != nil || state != "code_generated" {
+		t.Errorf("ProjectCurrentState = %q, %v, want code_generated", state, err)
+	}
+
+	// Events appended in one transaction keep their order
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range []string{"git_inited", "code_generating"} {
+		if _, err := AppendEvent(ctx, tx, specID, state, "same transaction"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := ProjectCurrentState(ctx, pool, specID); state != "code_generating" {
+		t.Errorf("state after one transaction = %q, want code_generating", state)
+	}
+
+	// The column is only a projection until Project refreshes it
+	if got := storedState(t, pool, specID); got != InitialState {
+		t.Errorf("game_specs.state before projection = %q, want %q", got, InitialState)
+	}
+	if err := Project(ctx, pool, specID); err != nil {
+		t.Fatal(err)
+	}
+	if got := storedState(t, pool, specID); got != "code_generating" {
+		t.Errorf("game_specs.state after projection = %q, want code_generating", got)
+	}
+}
+
+func TestAppendEventMissingSpec(t *testing.T) {
+	pool := dbtest.New(t)
+	if _, err := AppendEvent(context.Background(), pool, uuid.New().String(), "git_initing", "missing"); err == nil {
+		t.Error("AppendEvent for a missing spec succeeded")
+	}
+}
+
+func TestRunProjector(t *testing.T) {
+	pool := dbtest.New(t)
+	t.Setenv("SPEC_PROJECTOR_INTERVAL", "10ms")
+
+	stale := newSpec(t, pool)
+	appendEvent(t, pool, stale, "git_initing")
+	appendEvent(t, pool, stale, "git_inited")
+	untouched := newSpec(t, pool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunProjector(ctx, pool)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for storedState(t, pool, stale) != "git_inited" {
+		if time.Now().After(deadline) {
+			t.Fatalf("projector did not correct the stale state, still %q", storedState(t, pool, stale))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := storedState(t, pool, untouched); got != InitialState {
+		t.Errorf("spec without events projected to %q, want %q", got, InitialState)
+	}
+}
@@ -0,0 +1,41 @@
+package eventbus
+
+import (
+	"backend/internal/config"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Topics published by the backend
+const (
+	TopicJobCreated   = "job.created"
+	TopicJobCompleted = "job.completed"
+)
+
+// Handler processes the payload of one event
+type Handler func(payload []byte)
+
+// EventBus carries job events between backend instances
+type EventBus interface {
+	Publish(topic string, payload []byte) error
+	// Subscribe delivers events of topic to handler. Subscribers sharing a group split the
+	// events between them, so each event is handled by one instance of the group.
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+	Close() error
+}
+
+// New creates the event bus selected by EVENT_BUS: memory (default), nats or redis
+func New() (EventBus, error) {
+	kind := strings.ToLower(config.GetString("EVENT_BUS", "memory"))
+	switch kind {
+	case "memory":
+		return NewMemoryEventBus(), nil
+	case "nats":
+		return NewNATSEventBus(config.GetString("NATS_URL", "nats://localhost:4222"))
+	case "redis":
+		return NewRedisStreamEventBus(config.GetString("REDIS_URL", "redis://localhost:6379/0"))
+	default:
+		return nil, fmt.Errorf("unknown EVENT_BUS %q (expected memory, nats or redis)", kind)
+	}
+}
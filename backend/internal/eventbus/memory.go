@@ -0,0 +1,69 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+type memorySubscription struct {
+	ctx     context.Context
+	handler Handler
+}
+
+// MemoryEventBus delivers events within the current process. It is the default
+// for single instance deployments.
+type MemoryEventBus struct {
+	mu     sync.RWMutex
+	groups map[string]map[string][]memorySubscription
+	next   map[string]int
+}
+
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{
+		groups: map[string]map[string][]memorySubscription{},
+		next:   map[string]int{},
+	}
+}
+
+func (b *MemoryEventBus) Publish(topic string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for group, subs := range b.groups[topic] {
+		// Drop subscriptions whose context has ended
+		live := subs[:0]
+		for _, s := range subs {
+			if s.ctx.Err() == nil {
+				live = append(live, s)
+			}
+		}
+		b.groups[topic][group] = live
+		if len(live) == 0 {
+			continue
+		}
+
+		// Round robin within the group, like a queue group
+		key := topic + "/" + group
+		s := live[b.next[key]%len(live)]
+		b.next[key]++
+
+		msg := append([]byte(nil), payload...)
+		go s.handler(msg)
+	}
+	return nil
+}
+
+func (b *MemoryEventBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.groups[topic] == nil {
+		b.groups[topic] = map[string][]memorySubscription{}
+	}
+	b.groups[topic][group] = append(b.groups[topic][group], memorySubscription{ctx: ctx, handler: handler})
+	return nil
+}
+
+func (b *MemoryEventBus) Close() error {
+	return nil
+}
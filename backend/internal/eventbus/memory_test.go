@@ -0,0 +1,137 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector records the payloads delivered to a handler
+type collector struct {
+	mu       sync.Mutex
+	payloads []string
+	got      chan struct{}
+}
+
+func newCollector() *collector {
+	return &collector{got: make(chan struct{}, 100)}
+}
+
+func (c *collector) handle(payload []byte) {
+	c.mu.Lock()
+	c.payloads = append(c.payloads, string(payload))
+	c.mu.Unlock()
+	c.got <- struct{}{}
+}
+
+// wait blocks until n events were delivered
+func (c *collector) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.got:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d of %d events", i, n)
+		}
+	}
+}
+
+func (c *collector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.payloads)
+}
+
+func TestMemoryEventBusDelivers(t *testing.T) {
+	bus := NewMemoryEventBus()
+	ctx := context.Background()
+	created, completed := newCollector(), newCollector()
+	if err := bus.Subscribe(ctx, TopicJobCreated, "workers", created.handle); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe(ctx, TopicJobCompleted, "workers", completed.handle); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"job_id":"1"}`)
+	if err := bus.Publish(TopicJobCreated, payload); err != nil {
+		t.Fatal(err)
+	}
+	// The bus keeps its own copy of the payload
+	payload[0] = 'x'
+	created.wait(t, 1)
+	if created.payloads[0] != `{"job_id":"1"}` {
+		t.Errorf("payload = %q", created.payloads[0])
+	}
+	if completed.count() != 0 {
+		t.Errorf("job.completed subscriber received %d events", completed.count())
+	}
+}
+
+func TestMemoryEventBusGroups(t *testing.T) {
+	bus := NewMemoryEventBus()
+	ctx := context.Background()
+	workerA, workerB, audit := newCollector(), newCollector(), newCollector()
+	bus.Subscribe(ctx, TopicJobCreated, "workers", workerA.handle)
+	bus.Subscribe(ctx, TopicJobCreated, "workers", workerB.handle)
+	bus.Subscribe(ctx, TopicJobCreated, "audit", audit.handle)
+
+	const events = 10
+	for i := 0; i < events; i++ {
+		if err := bus.Publish(TopicJobCreated, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audit.wait(t, events)
+	workerA.wait(t, events/2)
+	workerB.wait(t, events/2)
+
+	// Each group gets every event once, split between its members
+	if a, b := workerA.count(), workerB.count(); a != events/2 || b != events/2 {
+		t.Errorf("workers received %d and %d events, want %d each", a, b, events/2)
+	}
+	if n := audit.count(); n != events {
+		t.Errorf("audit received %d events, want %d", n, events)
+	}
+}
+
+func TestMemoryEventBusDropsEndedSubscriptions(t *testing.T) {
+	bus := NewMemoryEventBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	ended, live := newCollector(), newCollector()
+	bus.Subscribe(ctx, TopicJobCreated, "workers", ended.handle)
+	bus.Subscribe(context.Background(), TopicJobCreated, "workers", live.handle)
+	cancel()
+
+	for i := 0; i < 4; i++ {
+		bus.Publish(TopicJobCreated, []byte("job"))
+	}
+	live.wait(t, 4)
+	if n := ended.count(); n != 0 {
+		t.Errorf("ended subscription received %d events", n)
+	}
+}
+
+func TestMemoryEventBusNoSubscribers(t *testing.T) {
+	if err := NewMemoryEventBus().Publish(TopicJobCreated, []byte("job")); err != nil {
+		t.Errorf("publish without subscribers: %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, kind := range []string{"", "memory", "MEMORY"} {
+		t.Setenv("EVENT_BUS", kind)
+		bus, err := New()
+		if err != nil {
+			t.Fatalf("EVENT_BUS=%q: %v", kind, err)
+		}
+		if _, ok := bus.(*MemoryEventBus); !ok {
+			t.Errorf("EVENT_BUS=%q: got %T", kind, bus)
+		}
+	}
+	t.Setenv("EVENT_BUS", "kafka")
+	if _, err := New(); err == nil {
+		t.Error("EVENT_BUS=kafka: expected an error")
+	}
+}
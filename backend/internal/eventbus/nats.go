@@ -0,0 +1,44 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSEventBus publishes events as NATS subjects and uses queue groups for subscribers
+type NATSEventBus struct {
+	conn *nats.Conn
+}
+
+func NewNATSEventBus(url string) (*NATSEventBus, error) {
+	conn, err := nats.Connect(url, nats.Name("game-generator-backend"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+	return &NATSEventBus{conn: conn}, nil
+}
+
+func (b *NATSEventBus) Publish(topic string, payload []byte) error {
+	return b.conn.Publish(topic, payload)
+}
+
+func (b *NATSEventBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	sub, err := b.conn.QueueSubscribe(topic, group, func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %v", topic, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = sub.Unsubscribe()
+	}()
+	return nil
+}
+
+func (b *NATSEventBus) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,90 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisStreamMaxLen = 10000
+
+// RedisStreamEventBus publishes events to Redis Streams and reads them through consumer groups
+type RedisStreamEventBus struct {
+	client   *redis.Client
+	consumer string
+}
+
+func NewRedisStreamEventBus(url string) (*RedisStreamEventBus, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+
+	host, _ := os.Hostname()
+	return &RedisStreamEventBus{client: client, consumer: fmt.Sprintf("%s-%d", host, os.Getpid())}, nil
+}
+
+func (b *RedisStreamEventBus) Publish(topic string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		MaxLen: redisStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"payload": payload},
+	}).Err()
+}
+
+func (b *RedisStreamEventBus) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	err := b.client.XGroupCreateMkStream(ctx, topic, group, "$").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s on %s: %v", group, topic, err)
+	}
+
+	go func() {
+		for ctx.Err() == nil {
+			streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    group,
+				Consumer: b.consumer,
+				Streams:  []string{topic, ">"},
+				Count:    10,
+				Block:    5 * time.Second,
+			}).Result()
+			if err != nil {
+				if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+					continue
+				}
+				log.Printf("[ERROR] Failed to read %s from Redis: %v", topic, err)
+				time.Sleep(time.Second)
+				continue
+			}
+
+			for _, stream := range streams {
+				for _, msg := range stream.Messages {
+					if payload, ok := msg.Values["payload"].(string); ok {
+						handler([]byte(payload))
+					}
+					b.client.XAck(ctx, topic, group, msg.ID)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *RedisStreamEventBus) Close() error {
+	return b.client.Close()
+}
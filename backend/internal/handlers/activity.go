@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ActivityItem struct {
+	ID          string    `json:"id"`
+	SpecID      string    `json:"spec_id"`
+	SpecTitle   string    `json:"spec_title"`
+	StateBefore *string   `json:"state_before"`
+	StateAfter  string    `json:"state_after"`
+	Detail      *string   `json:"detail,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ActivityResp struct {
+	Activity   []ActivityItem `json:"activity"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
+// GetActivity returns the most recent state transitions of all the workspace's specs, newest
+// first. state_after takes a comma-separated list (e.g. ready,failed) and spec_id limits the feed
+// to one spec. Pages are keyset-paginated on (created_at, id) like ListSpecJobs.
+func GetActivity(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := pageSize(c.QueryInt("limit", defaultPageSize))
+
+		var cursorTime *time.Time
+		var cursorID *string
+		if raw := c.Query("after"); raw != "" {
+			cur, err := decodeSpecCursor(raw)
+			if err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+			}
+			cursorTime, cursorID = &cur.CreatedAt, &cur.ID
+		}
+
+		var states []string
+		for _, state := range strings.Split(c.Query("state_after"), ",") {
+			if state = strings.TrimSpace(state); state != "" {
+				states = append(states, state)
+			}
+		}
+		var specID *string
+		if raw := c.Query("spec_id"); raw != "" {
+			if _, err := uuid.Parse(raw); err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, "Invalid spec_id")
+			}
+			specID = &raw
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		rows, err := db.Query(ctx, `
+			SELECT e.id, e.game_spec_id, s.title, e.state_before, e.state_after, e.detail, e.created_at
+			FROM game_spec_states e
+			JOIN game_specs s ON s.id = e.game_spec_id
+			WHERE s.workspace_id IS NOT DISTINCT FROM $1
+				AND ($2::text[] IS NULL OR e.state_after = ANY($2))
+				AND ($3::uuid IS NULL OR e.game_spec_id = $3)
+				AND ($4::timestamptz IS NULL OR (e.created_at, e.id) < ($4, $5::uuid))
+			ORDER BY e.created_at DESC, e.id DESC
+			LIMIT $6
+		`, middleware.WorkspaceID(c), states, specID, cursorTime, cursorID, limit)
+		if err != nil {
+			log.Printf("[ERROR] Failed to list activity: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		defer rows.Close()
+
+		resp := ActivityResp{Activity: []ActivityItem{}}
+		for rows.Next() {
+			var it ActivityItem
+			if err := rows.Scan(&it.ID, &it.SpecID, &it.SpecTitle, &it.StateBefore, &it.StateAfter, &it.Detail, &it.CreatedAt); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read activity")
+			}
+			resp.Activity = append(resp.Activity, it)
+		}
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read activity")
+		}
+		if len(resp.Activity) == limit {
+			last := resp.Activity[len(resp.Activity)-1]
+			next := encodeSpecCursor(last.CreatedAt, last.ID)
+			resp.NextCursor = &next
+		}
+		return c.JSON(resp)
+	}
+}
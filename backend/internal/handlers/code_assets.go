@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/utils"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const maxAssetBytes = 10 << 20
+
+type assetGenReq struct {
+	SpecID   string                 `json:"spec_id"`
+	Title    string                 `json:"title"`
+	SpecJSON map[string]interface{} `json:"spec_json"`
+}
+
+type assetGenResp struct {
+	Assets []struct {
+		Filename      string `json:"filename"`
+		ContentBase64 string `json:"content_base64,omitempty"`
+		URL           string `json:"url,omitempty"`
+	} `json:"assets"`
+}
+
+// assetGenEnabled reports whether the optional asset generation stage should run
+func assetGenEnabled() bool {
+	return config.MustGetBool("ASSET_GEN_ENABLED", false)
+}
+
+// assetGenURL returns the asset service endpoint, defaulting to the LLM backend
+func assetGenURL() string {
+	return config.GetString("ASSET_GEN_URL", config.GetString("LLM_BACKEND_URL", "http://localhost:8000")+"/assets/generate")
+}
+
+// generateAssets asks the asset service for placeholder art and returns the files to place under assets/
+func generateAssets(specID, title string, specJSON map[string]interface{}) ([]utils.GeneratedFile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), llmCodeTimeout())
+	defer cancel()
+
+	body, _ := json.Marshal(assetGenReq{SpecID: specID, Title: title, SpecJSON: specJSON})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, assetGenURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create asset request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("asset service unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("asset service status %d", resp.StatusCode)
+	}
+
+	var out assetGenResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode asset response: %v", err)
+	}
+
+	files := make([]utils.GeneratedFile, 0, len(out.Assets))
+	for _, a := range out.Assets {
+		// Only keep the base name so the service can't write outside assets/
+		name := filepath.Base(a.Filename)
+		if name == "." || name == "/" || name == "" {
+			continue
+		}
+
+		var content []byte
+		switch {
+		case a.ContentBase64 != "":
+			content, err = base64.StdEncoding.DecodeString(a.ContentBase64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 for asset %s: %v", name, err)
+			}
+		case a.URL != "":
+			content, err = downloadAsset(ctx, a.URL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download asset %s: %v", name, err)
+			}
+		default:
+			continue
+		}
+
+		files = append(files, utils.GeneratedFile{
+			Path:     path.Join("assets", name),
+			Content:  content,
+			FileType: strings.TrimPrefix(filepath.Ext(name), "."),
+		})
+	}
+	return files, nil
+}
+
+func downloadAsset(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxAssetBytes))
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"fmt"
+	"strings"
+)
+
+var defaultCodeFrameworks = []string{"vanilla-js", "phaser", "pixijs", "threejs", "unity-webgl"}
+
+// codeFrameworkAllowlist returns the accepted target_framework values, overridable via CODE_FRAMEWORK_ALLOWLIST (comma-separated)
+func codeFrameworkAllowlist() []string {
+	v := config.GetString("CODE_FRAMEWORK_ALLOWLIST", "")
+	if v == "" {
+		return defaultCodeFrameworks
+	}
+	var frameworks []string
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(strings.ToLower(f)); f != "" {
+			frameworks = append(frameworks, f)
+		}
+	}
+	return frameworks
+}
+
+// normalizeTargetFramework validates a requested framework; empty means the generator picks the stack
+func normalizeTargetFramework(framework string) (string, error) {
+	framework = strings.TrimSpace(strings.ToLower(framework))
+	if framework == "" {
+		return "", nil
+	}
+	for _, allowed := range codeFrameworkAllowlist() {
+		if framework == allowed {
+			return framework, nil
+		}
+	}
+	return "", fmt.Errorf("target_framework %q is not supported, expected one of %v", framework, codeFrameworkAllowlist())
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"backend/internal/codegen"
+	"backend/internal/dbtest"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestProcessCodeGenerationResumes(t *testing.T) {
+	pool := dbtest.New(t)
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+
+	// The local repository pushes to a remote that doesn't exist yet, so the first run fails at the push
+	repoPath, remote := t.TempDir(), filepath.Join(t.TempDir(), "games.git")
+	for _, args := range [][]string{{"init", "-q"}, {"checkout", "-q", "-b", "main"}, {"remote", "add", "origin", remote}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	t.Setenv("GIT_REPO_PATH", repoPath)
+	t.Setenv("GIT_REPO_URL", "https://github.com/acme/games")
+	t.Setenv("GIT_TOKEN", "token")
+	t.Setenv("GIT_REPO_PER_GAME", "false")
+	t.Setenv("GIT_DEPLOY_GITHUB_PAGES", "false")
+	t.Setenv("ASSET_GEN_ENABLED", "true")
+
+	var assetCalls atomic.Int32
+	srv := newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		assetCalls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"assets": []map[string]string{
+			{"filename": "cat.png", "content_base64": base64.StdEncoding.EncodeToString([]byte("png!"))},
+		}})
+	})
+	t.Setenv("ASSET_GEN_URL", srv.URL+"/assets/generate")
+
+	specID := newTestSpec(t, pool, nil, nil)
+	jobID := uuid.New().String()
+	if _, err := pool.Exec(ctx, `INSERT INTO code_jobs (id, game_spec_id, status, created_at, updated_at) VALUES ($1, $2, 'pending', now(), now())`, jobID, specID); err != nil {
+		t.Fatal(err)
+	}
+	noDevin := false
+	req := CreateCodeJobReq{GameSpecID: specID, TriggerDevin: &noDevin}
+	codeJobStatus := func() string {
+		var status string
+		if err := pool.QueryRow(ctx, `SELECT status FROM code_jobs WHERE id = $1`, jobID).Scan(&status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	processCodeGeneration(ctx, pool, jobID, req)
+	if status := codeJobStatus(); status != "failed" {
+		t.Fatalf("first run status = %s, want failed at the push", status)
+	}
+	checkpoint, err := codegen.LoadCheckpoint(ctx, pool, jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint == nil || checkpoint.Stage != codegen.StageAssets {
+		t.Fatalf("checkpoint = %+v, want the assets stage", checkpoint)
+	}
+	if n := assetCalls.Load(); n != 1 {
+		t.Fatalf("%d asset generation calls, want 1", n)
+	}
+
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	processCodeGeneration(ctx, pool, jobID, req)
+	if status := codeJobStatus(); status != "completed" {
+		t.Fatalf("retry status = %s, want completed", status)
+	}
+	if n := assetCalls.Load(); n != 1 {
+		t.Errorf("retry called asset generation again: %d calls", n)
+	}
+	if checkpoint, _ := codegen.LoadCheckpoint(ctx, pool, jobID); checkpoint == nil || checkpoint.Stage != codegen.StagePush {
+		t.Errorf("checkpoint = %+v, want the push stage", checkpoint)
+	}
+	out, err := exec.Command("git", "--git-dir", remote, "ls-tree", "-r", "--name-only", "main").CombinedOutput()
+	if err != nil {
+		t.Fatalf("remote has no main branch: %v: %s", err, out)
+	}
+	if readme := filepath.Base(checkpoint.GamePath) + "/README.md"; !strings.Contains("\n"+string(out), "\n"+readme+"\n") {
+		t.Errorf("pushed tree lacks %s:\n%s", readme, out)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeleteCodeJob deletes a completed or failed code job, answering 409 while it is queued or
+// processing. Its local game folder is removed unless another code job still uses it; the git
+// folder belongs to the spec and is left intact.
+func DeleteCodeJob(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		if _, err := uuid.Parse(id); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid job id")
+		}
+		workspaceID := middleware.WorkspaceID(c)
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		var outputPath *string
+		err := db.QueryRow(ctx, `
+			DELETE FROM code_jobs
+			WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND status NOT IN ('queued','processing')
+			RETURNING output_path
+		`, id, workspaceID).Scan(&outputPath)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Nothing was deleted: tell a missing job apart from one that is still running
+			var status string
+			err := db.QueryRow(ctx, `SELECT status FROM code_jobs WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2`, id, workspaceID).Scan(&status)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Job not found")
+			}
+			if err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+			}
+			return middleware.NewProblem(fiber.StatusConflict, "Only completed or failed jobs can be deleted").
+				With("status", status)
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to delete code job %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to delete code job")
+		}
+
+		filesRemoved := false
+		if outputPath != nil && *outputPath != "" {
+			var shared bool
+			err := db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM code_jobs WHERE output_path = $1)`, *outputPath).Scan(&shared)
+			if err != nil {
+				log.Printf("[ERROR] Failed to check other jobs using %s: %v", *outputPath, err)
+			} else if !shared {
+				if filesRemoved, err = utils.RemoveLocalGameFolder(*outputPath); err != nil {
+					log.Printf("[ERROR] Failed to remove generated files of code job %s: %v", id, err)
+				}
+			}
+		}
+
+		log.Printf("[INFO] Deleted code job %s", id)
+		return c.JSON(fiber.Map{
+			"message":       "Code job deleted successfully",
+			"id":            id,
+			"files_removed": filesRemoved,
+		})
+	}
+}
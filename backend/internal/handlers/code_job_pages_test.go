@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeployGitHubPages(t *testing.T) {
+	pool := dbtest.New(t)
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	bare := filepath.Join(t.TempDir(), "acme", "games.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", bare).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	gitRepo := &utils.GitRepo{RepoPath: t.TempDir(), RepoURL: "file://" + bare}
+
+	ws := newTestWorkspace(t, pool, "pages-key")
+	specID := newTestSpec(t, pool, &ws, nil)
+	gamePath := t.TempDir()
+	for name, content := range map[string]string{"README.md": "# Yarn Cat", "index.html": "<html></html>", "game.js": "start()"} {
+		if err := os.WriteFile(filepath.Join(gamePath, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GENERATED_CODE_SUBDIR", "")
+
+	deployGitHubPages(pool, "no-job", gitRepo, specID, gamePath)
+
+	out, err := exec.Command("git", "--git-dir", bare, "ls-tree", "-r", "--name-only", "gh-pages").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git ls-tree: %v: %s", err, out)
+	}
+	if got, want := string(out), ".nojekyll\n"+specID+"/game.js\n"+specID+"/index.html\n"; got != want {
+		t.Errorf("gh-pages tree =\n%s\nwant\n%s", got, want)
+	}
+
+	var spec struct {
+		DeployURL *string `json:"deploy_url"`
+	}
+	_, body := apiRequest(t, newTestAPI(pool), "GET", "/api/specs/"+specID, "", map[string]string{middleware.APIKeyHeader: "pages-key"})
+	decodeJSON(t, body, &spec)
+	if want := "https://acme.github.io/games/" + specID + "/"; spec.DeployURL == nil || *spec.DeployURL != want {
+		t.Errorf("deploy_url = %v, want %s", spec.DeployURL, want)
+	}
+
+	// A failed deploy leaves the stored URL alone
+	gitRepo.RepoURL = "file://" + filepath.Join(t.TempDir(), "acme", "missing.git")
+	deployGitHubPages(pool, "no-job", gitRepo, specID, gamePath)
+	_, body = apiRequest(t, newTestAPI(pool), "GET", "/api/specs/"+specID, "", map[string]string{middleware.APIKeyHeader: "pages-key"})
+	decodeJSON(t, body, &spec)
+	if spec.DeployURL == nil {
+		t.Error("failed deploy cleared deploy_url")
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// statusPreviewCompleted marks a code job that only listed the files it would generate
+const statusPreviewCompleted = "preview_completed"
+
+type previewFile struct {
+	Path      string `json:"path"`
+	SizeBytes int    `json:"size_bytes"`
+	FileType  string `json:"file_type"`
+}
+
+// previewCodeJob builds the game folder of a spec in memory, running the asset stage when it
+// is enabled, and returns the paths and sizes of the files instead of writing them.
+func previewCodeJob(c *fiber.Ctx, db *pgxpool.Pool, jobID string, req CreateCodeJobReq) error {
+	updateJobStatus(db, jobID, "processing", 20, []string{"Starting code generation preview"})
+
+	sc, err := readSpecContent(context.Background(), db, req.GameSpecID)
+	if err != nil {
+		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to retrieve game spec: %v", err)})
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to retrieve game spec")
+	}
+	title := sc.Title
+	var specJSON map[string]interface{}
+	if err := json.Unmarshal(sc.SpecJSON, &specJSON); err != nil {
+		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to parse spec JSON: %v", err)})
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+	}
+
+	files, err := utils.PreviewGameFolder(req.GameSpecID, title, map[string]interface{}{
+		"spec_json":     specJSON,
+		"spec_markdown": sc.SpecMarkdown,
+		"title":         title,
+	})
+	if err != nil {
+		updateJobStatus(db, jobID, "failed", 0, []string{err.Error()})
+		return middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+
+	logs := []string{"Preview generated, nothing was written"}
+	if assetGenEnabled() {
+		updateJobStatus(db, jobID, "processing", 70, []string{"Generating placeholder assets"})
+		assets, err := generateAssets(req.GameSpecID, title, specJSON)
+		if err != nil {
+			log.Printf("[WARNING] Asset generation skipped for preview of spec %s: %v", req.GameSpecID, err)
+			logs = append(logs, fmt.Sprintf("Asset generation skipped: %v", err))
+		} else {
+			var skipped []string
+			assets, skipped = dropDisallowedFiles(jobID, assets)
+			logs = append(logs, skipped...)
+			if subdir := utils.GeneratedCodeSubdir(); subdir != "" {
+				for i := range assets {
+					assets[i].Path = path.Join(subdir, assets[i].Path)
+				}
+			}
+			files = append(files, assets...)
+		}
+	}
+
+	out := make([]previewFile, 0, len(files))
+	for _, f := range files {
+		out = append(out, previewFile{Path: f.Path, SizeBytes: len(f.Content), FileType: f.FileType})
+	}
+	updateJobStatus(db, jobID, statusPreviewCompleted, 100, logs)
+
+	return c.JSON(fiber.Map{
+		"job_id": jobID,
+		"status": statusPreviewCompleted,
+		"files":  out,
+	})
+}
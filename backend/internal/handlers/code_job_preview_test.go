@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/es"
+	"backend/internal/middleware"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPostCodeJobPreviewValidation(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	// Validation fails before the database is used
+	app.Post("/api/code-jobs", PostCodeJob(nil))
+
+	status, body := apiRequest(t, app, "POST", "/api/code-jobs", `{"preview":true,"game_spec":{"title":"Yarn Cat"}}`, nil)
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", status, body)
+	}
+	var p struct {
+		Detail string `json:"detail"`
+	}
+	decodeJSON(t, body, &p)
+	if p.Detail != "preview requires game_spec_id" {
+		t.Errorf("detail = %q", p.Detail)
+	}
+}
+
+// assertEmptyDir fails when anything was written under dir
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && p != dir {
+			t.Errorf("preview wrote %s", p)
+		}
+		return nil
+	})
+}
+
+func TestPostCodeJobPreview(t *testing.T) {
+	pool := dbtest.New(t)
+	ws := newTestWorkspace(t, pool, "preview-key")
+	key := map[string]string{middleware.APIKeyHeader: "preview-key"}
+	specID := newTestSpec(t, pool, &ws, nil)
+
+	outputPath, repoPath := t.TempDir(), t.TempDir()
+	t.Setenv("GIT_REPO_PATH", repoPath)
+	t.Setenv("GENERATED_CODE_SUBDIR", "src")
+	t.Setenv("ASSET_GEN_ENABLED", "true")
+	srv := newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"assets": []map[string]string{
+			{"filename": "cat.png", "content_base64": base64.StdEncoding.EncodeToString([]byte("png!"))},
+			{"filename": "run.sh", "content_base64": base64.StdEncoding.EncodeToString([]byte("rm -rf /"))},
+		}})
+	})
+	t.Setenv("ASSET_GEN_URL", srv.URL+"/assets/generate")
+
+	app := newTestAPI(pool)
+	app.Post("/api/code-jobs", PostCodeJob(pool))
+
+	reqBody, _ := json.Marshal(map[string]interface{}{"game_spec_id": specID, "preview": true, "output_path": outputPath})
+	status, body := apiRequest(t, app, "POST", "/api/code-jobs", string(reqBody), key)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	var resp struct {
+		JobID  string        `json:"job_id"`
+		Status string        `json:"status"`
+		Files  []previewFile `json:"files"`
+	}
+	decodeJSON(t, body, &resp)
+
+	if resp.Status != statusPreviewCompleted {
+		t.Errorf("status = %q, want %q", resp.Status, statusPreviewCompleted)
+	}
+	want := map[string]string{"README.md": "md", "src/assets/cat.png": "png"}
+	if len(resp.Files) != len(want) {
+		t.Errorf("files = %+v, want %v", resp.Files, want)
+	}
+	for _, f := range resp.Files {
+		if want[f.Path] != f.FileType || f.SizeBytes == 0 {
+			t.Errorf("unexpected file %+v", f)
+		}
+	}
+
+	// Nothing reached the disk, git or the spec state
+	assertEmptyDir(t, outputPath)
+	assertEmptyDir(t, repoPath)
+	var jobStatus string
+	if err := pool.QueryRow(context.Background(), `SELECT status FROM code_jobs WHERE id = $1`, resp.JobID).Scan(&jobStatus); err != nil {
+		t.Fatal(err)
+	}
+	if jobStatus != statusPreviewCompleted {
+		t.Errorf("code job status = %q, want %q", jobStatus, statusPreviewCompleted)
+	}
+	events, err := es.ListEvents(context.Background(), pool, specID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("preview logged %d state transitions", len(events))
+	}
+
+	// A finished preview doesn't block the next job for the spec
+	status, body = apiRequest(t, app, "POST", "/api/code-jobs", string(reqBody), key)
+	if status != fiber.StatusOK {
+		t.Errorf("second preview: status = %d: %s", status, body)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	retrySkipSuperseded = "superseded by a newer job"
+	retrySkipActive     = "spec already has an active job"
+)
+
+type retrySkipped struct {
+	JobID      string `json:"job_id"`
+	GameSpecID string `json:"game_spec_id"`
+	Reason     string `json:"reason"`
+}
+
+// RetryFailedCodeJobs requeues failed code jobs, optionally limited to ?spec_ids= (comma-separated).
+// Only the latest job of a spec is retried, and a spec that already has a queued or processing job
+// is skipped so the one-active-job-per-spec guard holds. This route is guarded by
+// middleware.RequireAdmin.
+func RetryFailedCodeJobs(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var specIDs []string
+		for _, id := range strings.Split(c.Query("spec_ids"), ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			if _, err := uuid.Parse(id); err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, "spec_ids must be a comma-separated list of spec ids").
+					With("spec_id", id)
+			}
+			specIDs = append(specIDs, id)
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		rows, err := db.Query(ctx, `
+			SELECT j.id, j.game_spec_id, COALESCE(j.output_path, ''), COALESCE(j.target_framework, ''), j.trigger_devin, j.workspace_id,
+				NOT EXISTS (SELECT 1 FROM code_jobs n WHERE n.game_spec_id = j.game_spec_id AND n.created_at > j.created_at)
+			FROM code_jobs j
+			WHERE j.status = 'failed' AND j.game_spec_id IS NOT NULL
+				AND ($1::uuid[] IS NULL OR j.game_spec_id = ANY($1::uuid[]))
+			ORDER BY j.created_at
+		`, specIDs)
+		if err != nil {
+			log.Printf("[ERROR] Failed to list failed code jobs: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+
+		type failedJob struct {
+			jobID       string
+			workspaceID *string
+			latest      bool
+			req         CreateCodeJobReq
+		}
+		var jobs []failedJob
+		for rows.Next() {
+			var j failedJob
+			if err := rows.Scan(&j.jobID, &j.req.GameSpecID, &j.req.OutputPath, &j.req.TargetFramework, &j.req.TriggerDevin, &j.workspaceID, &j.latest); err != nil {
+				rows.Close()
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read code jobs")
+			}
+			jobs = append(jobs, j)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read code jobs")
+		}
+
+		enqueued := []string{}
+		skipped := []retrySkipped{}
+		for _, j := range jobs {
+			if !j.latest {
+				skipped = append(skipped, retrySkipped{JobID: j.jobID, GameSpecID: j.req.GameSpecID, Reason: retrySkipSuperseded})
+				continue
+			}
+			ok, err := requeueFailedCodeJob(ctx, db, j.jobID)
+			if err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == activeCodeJobIndex {
+					skipped = append(skipped, retrySkipped{JobID: j.jobID, GameSpecID: j.req.GameSpecID, Reason: retrySkipActive})
+					continue
+				}
+				log.Printf("[ERROR] Failed to requeue code job %s: %v", j.jobID, err)
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to requeue code jobs").
+					With("enqueued", len(enqueued))
+			}
+			if !ok {
+				// Retried by a concurrent request since the listing
+				continue
+			}
+
+			if err := updateGameSpecState(db, j.req.GameSpecID, StateCreating, "Code generation job retried"); err != nil {
+				log.Printf("[ERROR] Failed to update state of spec %s: %v", j.req.GameSpecID, err)
+			}
+			dispatchCodeJob(c.UserContext(), db, j.jobID, j.workspaceID, j.req)
+			enqueued = append(enqueued, j.jobID)
+		}
+		log.Printf("[INFO] Retried %d failed code jobs, skipped %d", len(enqueued), len(skipped))
+
+		return c.JSON(fiber.Map{
+			"enqueued":      len(enqueued),
+			"skipped":       len(skipped),
+			"enqueued_jobs": enqueued,
+			"skipped_jobs":  skipped,
+		})
+	}
+}
+
+// requeueFailedCodeJob resets a failed code job to queued, reporting false when it is no longer
+// failed. The job gave back its code job quota when it failed, so requeueing charges it again;
+// admin retries are not held to the quota.
+func requeueFailedCodeJob(ctx context.Context, db *pgxpool.Pool, jobID string) (bool, error) {
+	logsJSON, _ := json.Marshal([]string{"Job retried by an admin"})
+	var id string
+	err := db.QueryRow(ctx, `
+		WITH requeued AS (
+			UPDATE code_jobs
+			SET status = 'queued', progress = 0, error = NULL, logs = COALESCE(logs, '[]'::jsonb) || $2::jsonb, updated_at = now()
+			WHERE id = $1 AND status = 'failed'
+			RETURNING id, workspace_id
+		), charged AS (
+			UPDATE workspaces SET used_code_jobs = used_code_jobs + 1
+			WHERE id = (SELECT workspace_id FROM requeued)
+		)
+		SELECT id FROM requeued
+	`, jobID, logsJSON).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultSyncGenTimeout = 5 * time.Minute
+
+// waitForCodeJob runs a code job inline for up to SYNC_GEN_TIMEOUT (default 5m) and returns the
+// finished job with its artifact URL and generated files. When the deadline is hit it answers
+// 504 and the pipeline carries on in the background.
+func waitForCodeJob(c *fiber.Ctx, db *pgxpool.Pool, jobID string, req CreateCodeJobReq) error {
+	timeout := config.MustGetDuration("SYNC_GEN_TIMEOUT", defaultSyncGenTimeout)
+	ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+	defer cancel()
+
+	// The pipeline must outlive the request, so it only inherits the trace
+	spanCtx := trace.SpanContextFromContext(c.UserContext())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		processCodeGeneration(trace.ContextWithSpanContext(context.Background(), spanCtx), db, jobID, req)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("[WARNING] Code job %s still running after %s, continuing in background", jobID, timeout)
+		return middleware.NewProblem(fiber.StatusGatewayTimeout, "Code generation did not finish in time").
+			With("job_id", jobID).
+			With("timeout_seconds", int(timeout.Seconds()))
+	}
+
+	queryContext, queryCancel := queryCtx(context.Background())
+	defer queryCancel()
+	var resp CodeJobStatusResp
+	err := db.QueryRow(queryContext, `
+		SELECT id, status, progress, target_framework, output_path, artifact_url, error, logs, created_at, updated_at
+		FROM code_jobs WHERE id = $1
+	`, jobID).Scan(
+		&resp.JobID, &resp.Status, &resp.Progress, &resp.TargetFramework, &resp.OutputPath, &resp.ArtifactURL, &resp.Error, &resp.Logs, &resp.CreatedAt, &resp.UpdatedAt,
+	)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load finished code job %s: %v", jobID, err)
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+
+	resp.RetryAfterMS = pollInterval(resp.Status)
+
+	files := []utils.FileInfo{}
+	if resp.OutputPath != nil {
+		if info, err := os.Stat(*resp.OutputPath); err == nil && info.IsDir() {
+			if files, err = utils.ListFiles(*resp.OutputPath); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to list generated files")
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"job":   resp,
+		"files": files,
+	})
+}
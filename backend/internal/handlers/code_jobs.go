@@ -1,100 +1,247 @@
 package handlers
 
 import (
+	"backend/internal/codegen"
+	"backend/internal/config"
+	"backend/internal/eventbus"
+	"backend/internal/middleware"
+	"backend/internal/tracing"
 	"backend/internal/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type CreateCodeJobReq struct {
-	GameSpecID string                 `json:"game_spec_id"`
-	GameSpec   map[string]interface{} `json:"game_spec"`
-	OutputPath string                 `json:"output_path,omitempty"`
+	GameSpecID      string                 `json:"game_spec_id"`
+	GameSpec        map[string]interface{} `json:"game_spec"`
+	OutputPath      string                 `json:"output_path,omitempty"`
+	TargetFramework string                 `json:"target_framework,omitempty"`
+	// TriggerDevin overrides DEVIN_AUTO_TRIGGER for this job
+	TriggerDevin *bool `json:"trigger_devin,omitempty"`
+	// Preview runs the generation and returns the files it would create without writing them
+	Preview bool `json:"preview,omitempty"`
 }
 
 type CodeJobStatusResp struct {
-	JobID       string    `json:"job_id"`
-	Status      string    `json:"status"`
-	Progress    int       `json:"progress"`
-	OutputPath  *string   `json:"output_path,omitempty"`
-	ArtifactURL *string   `json:"artifact_url,omitempty"`
-	Error       *string   `json:"error,omitempty"`
-	Logs        []string  `json:"logs,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	JobID           string    `json:"job_id"`
+	Status          string    `json:"status"`
+	Progress        int       `json:"progress"`
+	TargetFramework *string   `json:"target_framework,omitempty"`
+	OutputPath      *string   `json:"output_path,omitempty"`
+	ArtifactURL     *string   `json:"artifact_url,omitempty"`
+	Error           *string   `json:"error,omitempty"`
+	Logs            []string  `json:"logs,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	// RetryAfterMS suggests how long a client should wait before polling the job again:
+	// CODE_JOB_POLL_QUEUED while queued, CODE_JOB_POLL_PROCESSING while processing and 0 once
+	// the job is finished and there is nothing left to poll for
+	RetryAfterMS int64 `json:"retry_after_ms"`
+	// Timing has the duration in milliseconds of each stage the job ran
+	Timing map[string]int64 `json:"timing,omitempty"`
+}
+
+// pollInterval returns the retry_after_ms hint for a code job status
+func pollInterval(status string) int64 {
+	switch status {
+	case "queued":
+		return config.MustGetDuration("CODE_JOB_POLL_QUEUED", 5*time.Second).Milliseconds()
+	case "processing":
+		return config.MustGetDuration("CODE_JOB_POLL_PROCESSING", 2*time.Second).Milliseconds()
+	}
+	return 0
 }
 
 func PostCodeJob(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req CreateCodeJobReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+			return middleware.NewProblem(400, "Invalid request body")
 		}
 
 		// Validate GameSpec
 		if req.GameSpecID == "" && len(req.GameSpec) == 0 {
-			return c.Status(400).JSON(fiber.Map{"error": "Either game_spec_id or game_spec must be provided"})
+			return middleware.NewProblem(400, "Either game_spec_id or game_spec must be provided")
+		}
+		if req.Preview && req.GameSpecID == "" {
+			return middleware.NewProblem(400, "preview requires game_spec_id")
 		}
 
-		// Set default output path
-		if req.OutputPath == "" {
-			req.OutputPath = "/tmp"
+		return startCodeJob(c, db, req, false)
+	}
+}
+
+// GenerateSpecCode starts a code job for the spec in the path. With ?wait=true the pipeline
+// runs inline and the response is the finished job; see waitForCodeJob.
+func GenerateSpecCode(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// The body is optional and only used for target_framework and output_path
+		var req CreateCodeJobReq
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return middleware.NewProblem(400, "Invalid request body")
+			}
 		}
+		req.GameSpecID = c.Params("id")
+		req.GameSpec = nil
+
+		return startCodeJob(c, db, req, c.QueryBool("wait"))
+	}
+}
+
+// startCodeJob validates and records a code job, then dispatches it to the workers, or runs it
+// inline when wait is set
+func startCodeJob(c *fiber.Ctx, db *pgxpool.Pool, req CreateCodeJobReq, wait bool) error {
+	framework, err := normalizeTargetFramework(req.TargetFramework)
+	if err != nil {
+		return middleware.NewProblem(400, err.Error())
+	}
+	req.TargetFramework = framework
 
-		jobID := uuid.New().String()
-		now := time.Now()
+	// Set default output path
+	if req.OutputPath == "" {
+		req.OutputPath = "/tmp"
+	}
+
+	workspaceID := middleware.WorkspaceID(c)
+	ctx, cancel := queryCtx(c.UserContext())
+	defer cancel()
 
-		// Insert job into database
-		_, err := db.Exec(context.Background(), `
-			INSERT INTO code_jobs (id, game_spec_id, game_spec, output_path, status, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, 'queued', $5, $6)
-		`, jobID, req.GameSpecID, req.GameSpec, req.OutputPath, now, now)
+	// The spec must belong to the caller's workspace
+	if req.GameSpecID != "" {
+		if err := requireSpec(ctx, db, c, req.GameSpecID); err != nil {
+			return err
+		}
 
+		activeID, activeStatus, err := findActiveCodeJob(ctx, db, req.GameSpecID)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to create job"})
+			log.Printf("[ERROR] Failed to look up active code job for spec %s: %v", req.GameSpecID, err)
+			return middleware.NewProblem(500, "Database error")
+		}
+		if activeID != "" {
+			return activeCodeJobResponse(c, activeID, activeStatus)
 		}
+	}
+
+	if err := consumeQuota(c.UserContext(), db, workspaceID, quotaCodeJobs); err != nil {
+		return quotaErrorResponse(c, err)
+	}
+
+	jobID := uuid.New().String()
+	now := time.Now()
 
-		// Step 1: Update game spec state to 'creating' and return immediately
-		if err := updateGameSpecState(db, req.GameSpecID, StateCreating, "Code generation job created"); err != nil {
-			log.Printf("Failed to update initial state: %v", err)
+	// Insert job into database
+	_, err = db.Exec(ctx, `
+		INSERT INTO code_jobs (id, game_spec_id, game_spec, output_path, target_framework, trigger_devin, workspace_id, user_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, (SELECT user_id FROM game_specs WHERE id = $2), 'queued', $8, $9)
+	`, jobID, req.GameSpecID, req.GameSpec, req.OutputPath, req.TargetFramework, req.TriggerDevin, workspaceID, now, now)
+
+	if err != nil {
+		releaseQuota(context.Background(), db, workspaceID, quotaCodeJobs)
+		// Another request started a job for this spec after the lookup above (23505 is unique_violation)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == activeCodeJobIndex {
+			if activeID, activeStatus, lookupErr := findActiveCodeJob(ctx, db, req.GameSpecID); lookupErr == nil && activeID != "" {
+				return activeCodeJobResponse(c, activeID, activeStatus)
+			}
 		}
+		return middleware.NewProblem(500, "Failed to create job")
+	}
 
-		// Steps 2-5: Start background processing in goroutine
-		go processCodeGeneration(db, jobID, req)
+	// Previews never touch the spec state, the disk or git
+	if req.Preview {
+		return previewCodeJob(c, db, jobID, req)
+	}
 
-		return c.JSON(fiber.Map{
-			"job_id": jobID,
-			"status": "queued",
-		})
+	// Step 1: Update game spec state to 'creating' and return immediately
+	if err := updateGameSpecState(db, req.GameSpecID, StateCreating, "Code generation job created"); err != nil {
+		log.Printf("Failed to update initial state: %v", err)
+	}
+
+	if wait {
+		return waitForCodeJob(c, db, jobID, req)
 	}
+
+	// Steps 2-5: Start background processing in goroutine
+	dispatchCodeJob(c.UserContext(), db, jobID, workspaceID, req)
+
+	return c.JSON(fiber.Map{
+		"job_id": jobID,
+		"status": "queued",
+	})
+}
+
+// activeCodeJobIndex allows one queued or processing code job per spec (migration 0018)
+const activeCodeJobIndex = "code_jobs_active_spec_key"
+
+// findActiveCodeJob returns the queued or processing code job of a spec, or "" when there is none
+func findActiveCodeJob(ctx context.Context, db *pgxpool.Pool, specID string) (id, status string, err error) {
+	err = db.QueryRow(ctx, `
+		SELECT id, status FROM code_jobs
+		WHERE game_spec_id = $1 AND status IN ('queued', 'processing')
+	`, specID).Scan(&id, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", nil
+	}
+	return id, status, err
+}
+
+// activeCodeJobResponse answers a request for a spec that already has an active code job. By default
+// the existing job is returned; CODE_JOB_CONFLICT=reject answers 409 instead.
+func activeCodeJobResponse(c *fiber.Ctx, jobID, status string) error {
+	if strings.EqualFold(config.GetString("CODE_JOB_CONFLICT", "return"), "reject") {
+		return middleware.NewProblem(409, "A code job is already active for this spec").
+			WithCode(codeDuplicate).
+			With("job_id", jobID).
+			With("status", status)
+	}
+	return c.JSON(fiber.Map{
+		"job_id":   jobID,
+		"status":   status,
+		"existing": true,
+	})
 }
 
 func GetCodeJob(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		jobID := c.Params("id")
 		if jobID == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Job ID is required"})
+			return middleware.NewProblem(400, "Job ID is required")
 		}
 
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
 		var resp CodeJobStatusResp
-		err := db.QueryRow(context.Background(), `
-			SELECT id, status, progress, artifact_url, error, logs, created_at, updated_at
-			FROM code_jobs WHERE id = $1
-		`, jobID).Scan(
-			&resp.JobID, &resp.Status, &resp.Progress, &resp.ArtifactURL, &resp.Error, &resp.Logs, &resp.CreatedAt, &resp.UpdatedAt,
+		err := db.QueryRow(ctx, `
+			SELECT id, status, progress, target_framework, artifact_url, error, logs, created_at, updated_at, timing
+			FROM code_jobs WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2
+		`, jobID, middleware.WorkspaceID(c)).Scan(
+			&resp.JobID, &resp.Status, &resp.Progress, &resp.TargetFramework, &resp.ArtifactURL, &resp.Error, &resp.Logs, &resp.CreatedAt, &resp.UpdatedAt, &resp.Timing,
 		)
 
 		if err != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(404, "Job not found")
+			}
+			log.Printf("[ERROR] Failed to load code job %s: %v", jobID, err)
+			return middleware.NewProblem(500, "Database error")
 		}
 
+		resp.RetryAfterMS = pollInterval(resp.Status)
 		return c.JSON(resp)
 	}
 }
@@ -104,34 +251,48 @@ func GetCodeJobBySpecID(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		specID := c.Params("spec_id")
 		if specID == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Spec ID is required"})
+			return middleware.NewProblem(400, "Spec ID is required")
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, specID); err != nil {
+			return err
 		}
 
 		var resp CodeJobStatusResp
-		err := db.QueryRow(context.Background(), `
-			SELECT id, status, progress, output_path, artifact_url, error, logs, created_at, updated_at
+		err := db.QueryRow(ctx, `
+			SELECT id, status, progress, target_framework, output_path, artifact_url, error, logs, created_at, updated_at, timing
 			FROM code_jobs
-			WHERE game_spec_id = $1
+			WHERE game_spec_id = $1 AND workspace_id IS NOT DISTINCT FROM $2
 			ORDER BY created_at DESC
 			LIMIT 1
-		`, specID).Scan(
-			&resp.JobID, &resp.Status, &resp.Progress, &resp.OutputPath, &resp.ArtifactURL, &resp.Error, &resp.Logs, &resp.CreatedAt, &resp.UpdatedAt,
+		`, specID, middleware.WorkspaceID(c)).Scan(
+			&resp.JobID, &resp.Status, &resp.Progress, &resp.TargetFramework, &resp.OutputPath, &resp.ArtifactURL, &resp.Error, &resp.Logs, &resp.CreatedAt, &resp.UpdatedAt, &resp.Timing,
 		)
 
 		if err != nil {
-			// No code job found for this spec
-			return c.JSON(fiber.Map{"status": "not_started"})
+			if errors.Is(err, pgx.ErrNoRows) {
+				// No code job found for this spec
+				return c.JSON(fiber.Map{"status": "not_started"})
+			}
+			log.Printf("[ERROR] Failed to load code job for spec %s: %v", specID, err)
+			return middleware.NewProblem(500, "Database error")
 		}
 
+		resp.RetryAfterMS = pollInterval(resp.Status)
 		return c.JSON(resp)
 	}
 }
 
-func processCodeGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq) {
+func processCodeGeneration(ctx context.Context, db *pgxpool.Pool, jobID string, req CreateCodeJobReq) {
+	_, span := tracing.Start(ctx, "code_job.process",
+		attribute.String("code_job.id", jobID), attribute.String("spec.id", req.GameSpecID))
+	defer span.End()
+
 	updateJobStatus(db, jobID, "processing", 20, []string{"Starting automated git folder generation"})
 
-	// Retrieve game spec from database using GameSpecID
-	ctx := context.Background()
+	// Retrieve game spec from database using GameSpecID, restoring it when it was archived
 	var gameSpec struct {
 		ID           string                 `json:"id"`
 		Title        string                 `json:"title"`
@@ -139,20 +300,18 @@ func processCodeGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq)
 		SpecJSON     map[string]interface{} `json:"spec_json"`
 	}
 
-	var specJSONBytes []byte
-	err := db.QueryRow(ctx, `
-		SELECT id, title, spec_markdown, spec_json
-		FROM game_specs
-		WHERE id = $1
-	`, req.GameSpecID).Scan(&gameSpec.ID, &gameSpec.Title, &gameSpec.SpecMarkdown, &specJSONBytes)
+	fetchStart := time.Now()
+	sc, err := readSpecContent(context.Background(), db, req.GameSpecID)
+	recordCodeJobTiming(db, jobID, timingSpecFetch, time.Since(fetchStart))
 
 	if err != nil {
 		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to retrieve game spec: %v", err)})
 		return
 	}
+	gameSpec.ID, gameSpec.Title, gameSpec.SpecMarkdown = req.GameSpecID, sc.Title, sc.SpecMarkdown
 
 	// Parse spec_json
-	if err := json.Unmarshal(specJSONBytes, &gameSpec.SpecJSON); err != nil {
+	if err := json.Unmarshal(sc.SpecJSON, &gameSpec.SpecJSON); err != nil {
 		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to parse spec JSON: %v", err)})
 		return
 	}
@@ -168,25 +327,63 @@ func processCodeGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq)
 	// Initialize git repository
 	gitRepo := utils.NewGitRepo()
 	if !gitRepo.IsConfigured() {
-		updateJobStatus(db, jobID, "failed", 0, []string{"Git repository not configured"})
+		processLocalGeneration(db, jobID, req, gameSpec.Title, gameSpec.SpecJSON, combinedGameSpec)
 		return
 	}
 
-	updateJobStatus(db, jobID, "processing", 60, []string{"Creating game folder with README.md"})
+	// A retried job resumes after the last stage it completed, as long as its folder is still there
+	checkpoint := loadCodeGenCheckpoint(db, jobID)
+	var gamePath string
+	if checkpoint.Done(codegen.StageFolder) && dirExists(checkpoint.GamePath) {
+		gamePath = checkpoint.GamePath
+		updateJobStatus(db, jobID, "processing", 60, []string{fmt.Sprintf("Resuming after the %s stage", checkpoint.Stage)})
+	} else {
+		checkpoint = nil
+		updateJobStatus(db, jobID, "processing", 60, []string{"Creating game folder with README.md"})
+
+		// Create game folder with README.md (correct function signature: gameID, gameTitle, gameSpec)
+		writeStart := time.Now()
+		gamePath, err = gitRepo.CreateGameFolder(req.GameSpecID, gameSpec.Title, combinedGameSpec)
+		recordCodeJobTiming(db, jobID, timingFileWrite, time.Since(writeStart))
+		if err != nil {
+			updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to create game folder: %v", err)})
+			return
+		}
+		storeOutputPath(db, jobID, gamePath)
+		saveCodeGenCheckpoint(db, jobID, codegen.StageFolder, gamePath)
+	}
 
-	// Create game folder with README.md (correct function signature: gameID, gameTitle, gameSpec)
-	gamePath, err := gitRepo.CreateGameFolder(req.GameSpecID, gameSpec.Title, combinedGameSpec)
-	if err != nil {
-		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to create game folder: %v", err)})
-		return
+	if !checkpoint.Done(codegen.StageAssets) {
+		if runAssetStage(db, jobID, req.GameSpecID, gameSpec.Title, gameSpec.SpecJSON, gamePath) {
+			saveCodeGenCheckpoint(db, jobID, codegen.StageAssets, gamePath)
+		}
 	}
 
-	updateJobStatus(db, jobID, "processing", 80, []string{"Committing and pushing to repository"})
+	if !checkpoint.Done(codegen.StagePush) {
+		updateJobStatus(db, jobID, "processing", 80, []string{"Committing and pushing to repository"})
 
-	// Commit and push changes (correct function signature: gamePath, gameTitle, gameID)
-	if err := gitRepo.CommitAndPush(gamePath, gameSpec.Title, req.GameSpecID); err != nil {
-		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to commit and push: %v", err)})
-		return
+		// Commit and push changes (correct function signature: gamePath, gameTitle, gameID)
+		genre, _ := gameSpec.SpecJSON["genre"].(string)
+		commit := utils.CommitInfo{Title: gameSpec.Title, GameID: req.GameSpecID, JobID: jobID, Genre: genre}
+		pushStart := time.Now()
+		err := gitRepo.CommitAndPush(gamePath, commit)
+		recordCodeJobTiming(db, jobID, timingGitPush, time.Since(pushStart))
+		if err != nil {
+			updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to commit and push: %v", err)})
+			return
+		}
+
+		// Point the job at where the game can be browsed
+		ctx, cancel := queryCtx(context.Background())
+		if _, err := db.Exec(ctx, `UPDATE code_jobs SET artifact_url = $1 WHERE id = $2`, gitRepo.GameURL(req.GameSpecID, gameSpec.Title), jobID); err != nil {
+			log.Printf("[ERROR] Failed to store artifact URL for job %s: %v", jobID, err)
+		}
+		cancel()
+		saveCodeGenCheckpoint(db, jobID, codegen.StagePush, gamePath)
+	}
+
+	if config.MustGetBool("GIT_DEPLOY_GITHUB_PAGES", false) {
+		deployGitHubPages(db, jobID, gitRepo, req.GameSpecID, gamePath)
 	}
 
 	// Step 3: Update to git_inited after successful git operations
@@ -194,6 +391,16 @@ func processCodeGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq)
 		log.Printf("Failed to update to git_inited state: %v", err)
 	}
 
+	if !devinTriggerEnabled(req) {
+		log.Printf("[INFO] Devin trigger skipped for spec %s, code was pushed without a Devin session", req.GameSpecID)
+		updateJobStatus(db, jobID, "completed", 100, []string{
+			"Git repository setup completed",
+			"Devin code generation skipped (trigger disabled)",
+		})
+		return
+	}
+	log.Printf("[INFO] Triggering Devin for spec %s", req.GameSpecID)
+
 	updateJobStatus(db, jobID, "processing", 85, []string{"Git operations completed, starting Devin code generation"})
 
 	// Step 4: Update to code_generating and create Devin task
@@ -202,7 +409,7 @@ func processCodeGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq)
 	}
 
 	// Create Devin task for actual code generation
-	sessionID, err := gitRepo.CreateDevinTask(req.GameSpecID, gameSpec.Title)
+	session, err := gitRepo.CreateDevinTask(req.GameSpecID, gameSpec.Title, req.TargetFramework)
 	if err != nil {
 		log.Printf("[ERROR] Failed to create Devin task for spec %s: %v", req.GameSpecID, err)
 		updateJobStatus(db, jobID, "failed", 85, []string{fmt.Sprintf("Failed to create Devin task: %v", err)})
@@ -210,27 +417,179 @@ func processCodeGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq)
 	}
 
 	// Store session ID in database
-	_, err = db.Exec(ctx, `UPDATE game_specs SET devin_session_id = $1 WHERE id = $2`, sessionID, req.GameSpecID)
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	err = recordDevinSession(ctx, db, req.GameSpecID, session)
+	invalidateSpec(req.GameSpecID)
 	if err != nil {
 		log.Printf("[ERROR] Failed to store Devin session ID in database: %v", err)
 	}
 
-	updateJobStatus(db, jobID, "processing", 90, []string{fmt.Sprintf("Devin task created with session ID: %s", sessionID)})
+	updateJobStatus(db, jobID, "processing", 90, []string{fmt.Sprintf("Devin task created with session ID: %s", session.ID)})
 
 	updateJobStatus(db, jobID, "completed", 100, []string{
 		"Git repository setup completed and Devin task created",
-		fmt.Sprintf("Devin session: https://app.devin.ai/sessions/%s", sessionID),
+		fmt.Sprintf("Devin session: %s", session.URL),
 		"Monitoring Devin progress for completion...",
 	})
 
-	log.Printf("[SUCCESS] Code generation pipeline initiated for spec %s with Devin session %s", req.GameSpecID, sessionID)
+	log.Printf("[SUCCESS] Code generation pipeline initiated for spec %s with Devin session %s", req.GameSpecID, session.ID)
+}
+
+// devinTriggerEnabled reports whether a pushed code job hands the game to Devin: the job's
+// trigger_devin when set, otherwise DEVIN_AUTO_TRIGGER (default true)
+func devinTriggerEnabled(req CreateCodeJobReq) bool {
+	if req.TriggerDevin != nil {
+		return *req.TriggerDevin
+	}
+	return config.MustGetBool("DEVIN_AUTO_TRIGGER", true)
+}
+
+// deployGitHubPages publishes the game's HTML, CSS and JS files to GitHub Pages and stores the
+// URL on the spec. A failed deploy is logged but doesn't fail the pipeline.
+func deployGitHubPages(db *pgxpool.Pool, jobID string, gitRepo *utils.GitRepo, specID, gamePath string) {
+	files, err := utils.ReadPagesFiles(utils.GeneratedCodeDir(gamePath))
+	if err != nil {
+		log.Printf("[WARNING] GitHub Pages deploy skipped for spec %s: %v", specID, err)
+		return
+	}
+	if len(files) == 0 {
+		log.Printf("[INFO] No HTML, CSS or JS files to deploy to GitHub Pages for spec %s", specID)
+		return
+	}
+
+	updateJobStatus(db, jobID, "processing", 82, []string{"Deploying to GitHub Pages"})
+	deployURL, err := gitRepo.DeployToGitHubPages(specID, files)
+	if err != nil {
+		log.Printf("[ERROR] GitHub Pages deploy failed for spec %s: %v", specID, err)
+		return
+	}
+
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	if _, err := db.Exec(ctx, `UPDATE game_specs SET deploy_url = $1 WHERE id = $2`, deployURL, specID); err != nil {
+		log.Printf("[ERROR] Failed to store deploy URL for spec %s: %v", specID, err)
+	}
+	invalidateSpec(specID)
+	log.Printf("[SUCCESS] Deployed spec %s to %s", specID, deployURL)
+}
+
+// processLocalGeneration writes the game folder under LOCAL_OUTPUT_DIR when git isn't configured.
+// Devin needs the git repository, so the pipeline stops once the folder is written.
+func processLocalGeneration(db *pgxpool.Pool, jobID string, req CreateCodeJobReq, title string, specJSON, combinedGameSpec map[string]interface{}) {
+	updateJobStatus(db, jobID, "processing", 60, []string{"Git repository not configured, creating local game folder"})
+
+	writeStart := time.Now()
+	gamePath, err := utils.CreateLocalGameFolder(req.GameSpecID, title, combinedGameSpec)
+	recordCodeJobTiming(db, jobID, timingFileWrite, time.Since(writeStart))
+	if err != nil {
+		updateJobStatus(db, jobID, "failed", 0, []string{fmt.Sprintf("Failed to create game folder: %v", err)})
+		return
+	}
+
+	storeOutputPath(db, jobID, gamePath)
+
+	runAssetStage(db, jobID, req.GameSpecID, title, specJSON, gamePath)
+
+	updateJobStatus(db, jobID, "completed", 100, []string{
+		fmt.Sprintf("Game folder written to %s", gamePath),
+		"Git repository not configured, Devin code generation skipped",
+	})
+
+	log.Printf("[SUCCESS] Local game folder created for spec %s at %s", req.GameSpecID, gamePath)
+}
+
+// storeOutputPath records the resolved game folder so the generated files can be found from the job
+func storeOutputPath(db *pgxpool.Pool, jobID, gamePath string) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	if _, err := db.Exec(ctx, `UPDATE code_jobs SET output_path = $1 WHERE id = $2`, gamePath, jobID); err != nil {
+		log.Printf("[ERROR] Failed to store output path for job %s: %v", jobID, err)
+	}
+}
+
+// runAssetStage runs the optional asset generation, which is never fatal for the pipeline. It
+// reports whether the assets were written, or false when asset generation is disabled.
+func runAssetStage(db *pgxpool.Pool, jobID, specID, title string, specJSON map[string]interface{}, gamePath string) bool {
+	if !assetGenEnabled() {
+		return false
+	}
+	updateJobStatus(db, jobID, "processing", 70, []string{"Generating placeholder assets"})
+	defer func(start time.Time) { recordCodeJobTiming(db, jobID, timingLLMCode, time.Since(start)) }(time.Now())
+	var logs []string
+	assets, err := generateAssets(specID, title, specJSON)
+	if err == nil {
+		assets, logs = dropDisallowedFiles(jobID, assets)
+		err = utils.WriteGeneratedFiles(utils.GeneratedCodeDir(gamePath), assets)
+	}
+	if err != nil {
+		log.Printf("[WARNING] Asset generation skipped for spec %s: %v", specID, err)
+		updateJobStatus(db, jobID, "processing", 75, append(logs, fmt.Sprintf("Asset generation skipped: %v", err)))
+		return false
+	}
+	updateJobStatus(db, jobID, "processing", 75, append(logs, fmt.Sprintf("Generated %d assets", len(assets))))
+	return true
+}
+
+// loadCodeGenCheckpoint returns the checkpoint of a job, treating one that can't be read as none
+func loadCodeGenCheckpoint(db *pgxpool.Pool, jobID string) *codegen.CodeGenCheckpoint {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	checkpoint, err := codegen.LoadCheckpoint(ctx, db, jobID)
+	if err != nil {
+		log.Printf("[WARNING] Ignoring checkpoint of job %s: %v", jobID, err)
+		return nil
+	}
+	return checkpoint
+}
+
+// saveCodeGenCheckpoint records a completed stage; a lost checkpoint only means a retry redoes it
+func saveCodeGenCheckpoint(db *pgxpool.Pool, jobID, stage, gamePath string) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	if err := codegen.SaveCheckpoint(ctx, db, jobID, codegen.CodeGenCheckpoint{Stage: stage, GamePath: gamePath}); err != nil {
+		log.Printf("[ERROR] Failed to save %s checkpoint of job %s: %v", stage, jobID, err)
+	}
+}
+
+func dirExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// dropDisallowedFiles removes files whose type isn't allowed or doesn't match their extension and
+// returns a job log line for each one
+func dropDisallowedFiles(jobID string, files []utils.GeneratedFile) ([]utils.GeneratedFile, []string) {
+	kept, skipped := utils.FilterGeneratedFiles(files)
+	logs := make([]string, 0, len(skipped))
+	for _, s := range skipped {
+		log.Printf("[WARNING] Skipped generated file %s for job %s: %s", s.Path, jobID, s.Reason)
+		logs = append(logs, fmt.Sprintf("Skipped %s: %s", s.Path, s.Reason))
+	}
+	return kept, logs
 }
 
 func updateJobStatus(db *pgxpool.Pool, jobID, status string, progress int, logs []string) {
 	logsJSON, _ := json.Marshal(logs)
-	db.Exec(context.Background(), `
-		UPDATE code_jobs
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	// prev is the row before the update, so a job failing for the first time gives back its quota once
+	var prevStatus string
+	err := db.QueryRow(ctx, `
+		UPDATE code_jobs j
 		SET status = $1, progress = $2, logs = $3, updated_at = $4
-		WHERE id = $5
-	`, status, progress, logsJSON, time.Now(), jobID)
+		FROM (SELECT id, status FROM code_jobs WHERE id = $5 FOR UPDATE) prev
+		WHERE j.id = prev.id
+		RETURNING prev.status
+	`, status, progress, logsJSON, time.Now(), jobID).Scan(&prevStatus)
+	if err == nil && status == "failed" && prevStatus != "failed" {
+		releaseCodeJobQuota(ctx, db, jobID)
+	}
+
+	if status == "completed" || status == "failed" {
+		_ = publishJobEvent(eventbus.TopicJobCompleted, JobEvent{Kind: jobKindCode, JobID: jobID, Status: status})
+	}
 }
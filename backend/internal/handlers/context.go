@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"context"
+	"log"
+	"time"
+)
+
+const defaultDBQueryTimeout = 10 * time.Second
+
+// dbQueryTimeout returns the per-operation database timeout configured via DB_QUERY_TIMEOUT (e.g. "5s")
+func dbQueryTimeout() time.Duration {
+	d := config.MustGetDuration("DB_QUERY_TIMEOUT", defaultDBQueryTimeout)
+	if d <= 0 {
+		log.Printf("[WARNING] DB_QUERY_TIMEOUT must be positive, using default %s", defaultDBQueryTimeout)
+		return defaultDBQueryTimeout
+	}
+	return d
+}
+
+// queryCtx derives a context bounded by the database query timeout from parent.
+// Handlers pass c.UserContext() so queries are cancelled together with the request,
+// background jobs pass context.Background().
+func queryCtx(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, dbQueryTimeout())
+}
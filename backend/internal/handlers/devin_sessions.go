@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"context"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DevinSession is a Devin session started for a spec
+type DevinSession struct {
+	SessionID  string    `json:"session_id"`
+	SessionURL string    `json:"session_url"`
+	Status     *string   `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// devinSessionExecer is satisfied by both the pool and a transaction
+type devinSessionExecer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// devinClaimTTL is how long a claim to create a Devin session blocks other requests. It outlasts
+// the Devin call with its retries; a request that died mid call doesn't block the spec for longer.
+const devinClaimTTL = 10 * time.Minute
+
+// claimDevinTask marks the spec as having a Devin session being created, under the spec lock.
+// It fails with a 409 when another request holds a claim or the current session changed since it was read.
+func claimDevinTask(parent context.Context, db *pgxpool.Pool, specID string, current *string) error {
+	tx, err := db.Begin(parent)
+	if err != nil {
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+	defer tx.Rollback(context.Background())
+	if err := lockSpec(parent, tx, specID); err != nil {
+		return err
+	}
+
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	tag, err := tx.Exec(ctx, `
+		UPDATE game_specs SET devin_claimed_at = now()
+		WHERE id = $1 AND devin_session_id IS NOT DISTINCT FROM $2
+			AND (devin_claimed_at IS NULL OR devin_claimed_at < now() - make_interval(secs => $3))
+	`, specID, current, devinClaimTTL.Seconds())
+	if err == nil && tag.RowsAffected() > 0 {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to claim Devin task for spec %s: %v", specID, err)
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+	if tag.RowsAffected() == 0 {
+		return middleware.NewProblem(fiber.StatusConflict, "A Devin task is already being created for this spec").
+			WithCode(codeSpecLocked)
+	}
+	return nil
+}
+
+// releaseDevinClaim drops the claim taken by claimDevinTask when no session was created
+func releaseDevinClaim(db *pgxpool.Pool, specID string) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	if _, err := db.Exec(ctx, `UPDATE game_specs SET devin_claimed_at = NULL WHERE id = $1`, specID); err != nil {
+		log.Printf("[ERROR] Failed to release Devin task claim of spec %s: %v", specID, err)
+	}
+}
+
+// recordDevinSession stores a new session of a spec in devin_sessions and makes it the spec's
+// current session, dropping any claim to create one. Earlier sessions stay in devin_sessions.
+func recordDevinSession(ctx context.Context, q devinSessionExecer, specID string, session utils.DevinSession) error {
+	_, err := q.Exec(ctx, `
+		WITH recorded AS (
+			INSERT INTO devin_sessions (spec_id, session_id, session_url, status)
+			VALUES ($1, $2, $3, $4)
+		)
+		UPDATE game_specs
+		SET devin_session_id = $2, devin_session_url = $3, devin_status = $4, devin_claimed_at = NULL
+		WHERE id = $1
+	`, specID, session.ID, session.URL, utils.DevinStatusWorking)
+	return err
+}
+
+// updateDevinSessionStatus stores the latest status of a session, in game_specs too while it is
+// the spec's current session
+func updateDevinSessionStatus(ctx context.Context, q devinSessionExecer, specID, sessionID, status string) error {
+	_, err := q.Exec(ctx, `
+		WITH updated AS (
+			UPDATE devin_sessions SET status = $3, updated_at = now() WHERE spec_id = $1 AND session_id = $2
+		)
+		UPDATE game_specs SET devin_status = $3 WHERE id = $1 AND devin_session_id = $2
+	`, specID, sessionID, status)
+	return err
+}
+
+// GetDevinSessions lists the Devin sessions started for a spec, newest first
+func GetDevinSessions(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		rows, err := db.Query(ctx, `
+			SELECT session_id, session_url, status, created_at, updated_at
+			FROM devin_sessions
+			WHERE spec_id = $1
+			ORDER BY created_at DESC, id DESC
+		`, id)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to fetch Devin sessions")
+		}
+		defer rows.Close()
+
+		sessions := []DevinSession{}
+		for rows.Next() {
+			var s DevinSession
+			var url *string
+			if err := rows.Scan(&s.SessionID, &url, &s.Status, &s.CreatedAt, &s.UpdatedAt); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read Devin sessions")
+			}
+			s.SessionURL = utils.DevinSessionURL(s.SessionID, url)
+			sessions = append(sessions, s)
+		}
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read Devin sessions")
+		}
+
+		return c.JSON(sessions)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+// Error codes of problems specific to the handlers. Generic codes such as not_found and
+// validation_error are set by middleware.ProblemDetails from the status.
+const (
+	// codeLLMUnavailable marks a failed or unreadable response from the LLM backend
+	codeLLMUnavailable = "llm_unavailable"
+	// codeLLMMalformedSpec marks a generated spec missing the fields the pipeline relies on
+	codeLLMMalformedSpec = "llm_malformed_spec"
+	// llmTimeoutError marks an LLM call that ran out of time; it's also recorded on the job
+	llmTimeoutError = "llm_timeout"
+	// codeVectorUnavailable marks a failed similarity search or vector upsert
+	codeVectorUnavailable = "vector_unavailable"
+	// codeDevinError marks a Devin API error
+	codeDevinError = "devin_error"
+	// codeGitError marks a failed git commit or push
+	codeGitError = "git_error"
+	// codeDuplicate marks a request that would duplicate existing work
+	codeDuplicate = "duplicate"
+	// codeMatureContent marks a spec rated M in a workspace without allow_mature
+	codeMatureContent = "mature_content"
+	// codeBriefLanguageUnsupported marks a brief rejected by BRIEF_LANGUAGE_POLICY=reject
+	codeBriefLanguageUnsupported = "brief_language_unsupported"
+	// codeBriefRejected marks a brief flagged by the moderation API
+	codeBriefRejected = "brief_rejected"
+	// codeSpecLocked marks a request that raced another mutation of the same spec
+	codeSpecLocked = "spec_locked"
+)
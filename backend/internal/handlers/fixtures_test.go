@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestAPI serves the /api routes the integration tests call, behind the same middleware as
+// cmd/server
+func newTestAPI(pool *pgxpool.Pool) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/api/share/:token", GetSharedSpec(pool))
+	api := app.Group("/api", middleware.Workspace(pool), middleware.User())
+	api.Get("/specs", ListSpecs(pool))
+	api.Get("/specs/:id", GetSpec(pool))
+	api.Get("/specs/:id/json", GetSpecJSON(pool))
+	api.Get("/specs/:id/markdown", GetSpecMarkdown(pool))
+	api.Get("/specs/:id/files", GetSpecFiles(pool))
+	api.Get("/specs/:id/files/*", GetSpecFile(pool))
+	api.Post("/specs/:id/share", CreateSpecShare(pool))
+	api.Delete("/specs/:id/share", RevokeSpecShares(pool))
+	api.Post("/specs/bulk-delete", BulkDeleteSpecs(pool))
+	api.Delete("/specs/:id", DeleteSpec(pool))
+	api.Get("/specs/:id/embed", GetSpecEmbedding(pool))
+	return app
+}
+
+// newTestWorkspace creates a workspace whose API key is apiKey and returns its id
+func newTestWorkspace(t *testing.T, pool *pgxpool.Pool, apiKey string) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := pool.Exec(context.Background(), `INSERT INTO workspaces (id, name, api_key_hash) VALUES ($1, $2, $3)`,
+		id, "test "+apiKey, middleware.HashAPIKey(apiKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// newTestSpec inserts a spec into a workspace (nil for the shared one) owned by userID and
+// returns its id
+func newTestSpec(t testing.TB, pool *pgxpool.Pool, workspaceID, userID *string) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO game_specs (id, title, brief, spec_markdown, spec_json, spec_hash, genre, workspace_id, user_id)
+		VALUES ($1, 'Test game', 'A test brief', '# Test game', '{"genre":"puzzle"}', $1, 'puzzle', $2, $3)
+	`, id, workspaceID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// newTestCodeJob inserts a completed code job of a spec whose output is in outputPath
+func newTestCodeJob(t *testing.T, pool *pgxpool.Pool, specID string, workspaceID *string, outputPath string) {
+	t.Helper()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO code_jobs (id, game_spec_id, status, output_path, workspace_id, created_at, updated_at)
+		VALUES ($1, $2, 'completed', $3, $4, now(), now())
+	`, uuid.New().String(), specID, outputPath, workspaceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// apiRequest sends a request to app with the given headers and returns the status and body
+func apiRequest(t *testing.T, app *fiber.App, method, path, body string, headers map[string]string) (int, []byte) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, respBody
+}
+
+// decodeJSON unmarshals a response body, failing the test when it isn't JSON
+func decodeJSON(t *testing.T, body []byte, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, body)
+	}
+}
+
+// newLLMBackend serves handler as the LLM backend for the rest of the test
+func newLLMBackend(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv("LLM_BACKEND_URL", srv.URL)
+	return srv
+}
+
+// testGenSpecResp is a generated spec that passes validateGenSpecResp
+func testGenSpecResp() genSpecResp {
+	return genSpecResp{
+		Title:        "Yarn Cat",
+		SpecMarkdown: "# Yarn Cat\n\nCollect yarn.",
+		SpecJSON: map[string]interface{}{
+			"genre":     "platformer",
+			"controls":  map[string]interface{}{"keyboard": "arrows"},
+			"mechanics": []interface{}{"jump", "collect"},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"backend/internal/cache"
+	"backend/internal/middleware"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultPopularGenres = 10
+	maxPopularGenres     = 100
+)
+
+type GenreCount struct {
+	Genre string `json:"genre"`
+	Count int    `json:"count"`
+}
+
+// genreCache holds popular genre lists per workspace and limit for five minutes. Counts drift
+// slightly as specs come and go, which is fine for browsing.
+var genreCache = cache.NewLRU[string, []GenreCount](64, 5*time.Minute)
+
+// GetPopularGenres lists the genres of the workspace's specs ordered by how many specs use them.
+// ?limit= defaults to 10 and is capped at 100. It reads the genre column rather than spec_json so
+// archived specs still count.
+func GetPopularGenres(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := c.QueryInt("limit", defaultPopularGenres)
+		if limit < 1 || limit > maxPopularGenres {
+			return middleware.NewProblem(fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPopularGenres))
+		}
+
+		workspaceID := middleware.WorkspaceID(c)
+		key := fmt.Sprintf("%d", limit)
+		if workspaceID != nil {
+			key = *workspaceID + ":" + key
+		}
+		if cached, ok := genreCache.Get(key); ok {
+			return c.JSON(cached)
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		rows, err := db.Query(ctx, `
+			SELECT genre, COUNT(*) AS count
+			FROM game_specs
+			WHERE workspace_id IS NOT DISTINCT FROM $2 AND genre IS NOT NULL AND genre <> ''
+			GROUP BY genre
+			ORDER BY count DESC, genre
+			LIMIT $1
+		`, limit, workspaceID)
+		if err != nil {
+			log.Printf("[ERROR] Failed to count genres: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		defer rows.Close()
+
+		genres := []GenreCount{}
+		for rows.Next() {
+			var g GenreCount
+			if err := rows.Scan(&g.Genre, &g.Count); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read genres")
+			}
+			genres = append(genres, g)
+		}
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read genres")
+		}
+
+		genreCache.Set(key, genres)
+		return c.JSON(genres)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"backend/internal/archiver"
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedGenres inserts count specs of each genre into a workspace
+func seedGenres(t *testing.T, pool *pgxpool.Pool, workspaceID string, counts map[string]int) {
+	t.Helper()
+	for genre, n := range counts {
+		for i := 0; i < n; i++ {
+			id := newTestSpec(t, pool, &workspaceID, nil)
+			if _, err := pool.Exec(context.Background(), `UPDATE game_specs SET spec_json = jsonb_build_object('genre', $2::text), genre = $2 WHERE id = $1`, id, genre); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestGetPopularGenres(t *testing.T) {
+	pool := dbtest.New(t)
+	workspaceID := newTestWorkspace(t, pool, "genres-key")
+	seedGenres(t, pool, workspaceID, map[string]int{"platformer": 3, "puzzle": 1, "shooter": 2, "racing": 2, "": 4})
+	// Registered after the /api group, so it sits behind its middleware
+	app := newTestAPI(pool)
+	app.Get("/api/genres/popular", GetPopularGenres(pool))
+	headers := map[string]string{middleware.APIKeyHeader: "genres-key"}
+
+	popular := func(t *testing.T, query string) []GenreCount {
+		t.Helper()
+		status, body := apiRequest(t, app, "GET", "/api/genres/popular"+query, "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var genres []GenreCount
+		decodeJSON(t, body, &genres)
+		return genres
+	}
+
+	t.Run("ordered by count then name", func(t *testing.T) {
+		want := []GenreCount{{"platformer", 3}, {"racing", 2}, {"shooter", 2}, {"puzzle", 1}}
+		if got := popular(t, ""); !reflect.DeepEqual(got, want) {
+			t.Errorf("genres = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		want := []GenreCount{{"platformer", 3}, {"racing", 2}}
+		if got := popular(t, "?limit=2"); !reflect.DeepEqual(got, want) {
+			t.Errorf("genres = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		seedGenres(t, pool, workspaceID, map[string]int{"puzzle": 5})
+		if got := popular(t, "?limit=2"); got[0].Genre != "platformer" {
+			t.Errorf("cached genres were recomputed: %v", got)
+		}
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		newTestWorkspace(t, pool, "genres-other")
+		status, body := apiRequest(t, app, "GET", "/api/genres/popular", "", map[string]string{middleware.APIKeyHeader: "genres-other"})
+		if status != fiber.StatusOK || string(body) != "[]" {
+			t.Errorf("status = %d, body = %s, want an empty list", status, body)
+		}
+	})
+
+	t.Run("archived specs still count", func(t *testing.T) {
+		archiver.UseStore(archiveStore{})
+		t.Cleanup(func() { archiver.UseStore(nil) })
+		archivedID := newTestWorkspace(t, pool, "genres-archived")
+		seedGenres(t, pool, archivedID, map[string]int{"arcade": 2})
+		var specID string
+		if err := pool.QueryRow(context.Background(), `SELECT id FROM game_specs WHERE workspace_id = $1 LIMIT 1`, archivedID).Scan(&specID); err != nil {
+			t.Fatal(err)
+		}
+		if err := archiver.ArchiveSpec(context.Background(), pool, specID); err != nil {
+			t.Fatal(err)
+		}
+		status, body := apiRequest(t, app, "GET", "/api/genres/popular", "", map[string]string{middleware.APIKeyHeader: "genres-archived"})
+		if status != fiber.StatusOK || string(body) != `[{"genre":"arcade","count":2}]` {
+			t.Errorf("status = %d, body = %s, want both arcade specs", status, body)
+		}
+	})
+
+	for _, query := range []string{"?limit=0", "?limit=101"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			if status, body := apiRequest(t, app, "GET", "/api/genres/popular"+query, "", headers); status != fiber.StatusBadRequest {
+				t.Errorf("status = %d, want 400: %s", status, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/eventbus"
+	"backend/internal/tracing"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	jobKindSpec = "spec_job"
+	jobKindCode = "code_job"
+
+	codeJobWorkerGroup = "code-job-workers"
+)
+
+// JobEvent is the payload of job.created and job.completed events
+type JobEvent struct {
+	Kind            string  `json:"kind"`
+	JobID           string  `json:"job_id"`
+	GameSpecID      string  `json:"game_spec_id,omitempty"`
+	WorkspaceID     *string `json:"workspace_id,omitempty"`
+	OutputPath      string  `json:"output_path,omitempty"`
+	TargetFramework string  `json:"target_framework,omitempty"`
+	TriggerDevin    *bool   `json:"trigger_devin,omitempty"`
+	Status          string  `json:"status,omitempty"`
+	// TraceParent carries the trace of the dispatching request to the worker
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// events is the bus job events are published to, replaced at startup by UseEventBus
+var events eventbus.EventBus = eventbus.NewMemoryEventBus()
+
+// UseEventBus sets the bus job events are published to
+func UseEventBus(bus eventbus.EventBus) {
+	events = bus
+}
+
+func publishJobEvent(topic string, ev JobEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if err := events.Publish(topic, payload); err != nil {
+		log.Printf("[ERROR] Failed to publish %s for job %s: %v", topic, ev.JobID, err)
+		return err
+	}
+	return nil
+}
+
+// dispatchCodeJob hands a queued code job to the workers through job.created.
+// If the event can't be published the job runs in this process instead.
+func dispatchCodeJob(ctx context.Context, db *pgxpool.Pool, jobID string, workspaceID *string, req CreateCodeJobReq) {
+	err := publishJobEvent(eventbus.TopicJobCreated, JobEvent{
+		Kind:            jobKindCode,
+		JobID:           jobID,
+		GameSpecID:      req.GameSpecID,
+		WorkspaceID:     workspaceID,
+		OutputPath:      req.OutputPath,
+		TargetFramework: req.TargetFramework,
+		TriggerDevin:    req.TriggerDevin,
+		TraceParent:     tracing.Inject(ctx),
+	})
+	if err != nil {
+		go processCodeGeneration(trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx)), db, jobID, req)
+	}
+}
+
+// RunCodeJobWorkers listens to job.created and fans code jobs out to CODE_JOB_WORKERS workers (default 4).
+// Instances share a subscriber group, so each job runs once across the deployment.
+func RunCodeJobWorkers(ctx context.Context, db *pgxpool.Pool, bus eventbus.EventBus) error {
+	workers := config.MustGetInt("CODE_JOB_WORKERS", 4)
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan JobEvent)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev := <-queue:
+					processCodeGeneration(tracing.Extract(context.Background(), ev.TraceParent), db, ev.JobID, CreateCodeJobReq{
+						GameSpecID:      ev.GameSpecID,
+						OutputPath:      ev.OutputPath,
+						TargetFramework: ev.TargetFramework,
+						TriggerDevin:    ev.TriggerDevin,
+					})
+				}
+			}
+		}()
+	}
+
+	return bus.Subscribe(ctx, eventbus.TopicJobCreated, codeJobWorkerGroup, func(payload []byte) {
+		var ev JobEvent
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			log.Printf("[ERROR] Invalid job.created event: %v", err)
+			return
+		}
+		if ev.Kind != jobKindCode {
+			return
+		}
+
+		// Blocks while every worker is busy
+		select {
+		case queue <- ev:
+			log.Printf("[INFO] Dispatched code job %s to a worker", ev.JobID)
+		case <-ctx.Done():
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"backend/internal/eventbus"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPublishJobEvent(t *testing.T) {
+	bus := eventbus.NewMemoryEventBus()
+	orig := events
+	UseEventBus(bus)
+	t.Cleanup(func() { UseEventBus(orig) })
+
+	got := make(chan []byte, 1)
+	if err := bus.Subscribe(context.Background(), eventbus.TopicJobCreated, codeJobWorkerGroup, func(payload []byte) { got <- payload }); err != nil {
+		t.Fatal(err)
+	}
+
+	workspaceID := "ws-1"
+	devin := true
+	want := JobEvent{Kind: jobKindCode, JobID: "job-1", GameSpecID: "spec-1", WorkspaceID: &workspaceID, OutputPath: "/tmp/out", TriggerDevin: &devin}
+	if err := publishJobEvent(eventbus.TopicJobCreated, want); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case payload := <-got:
+		var ev JobEvent
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Kind != want.Kind || ev.JobID != want.JobID || ev.GameSpecID != want.GameSpecID ||
+			ev.WorkspaceID == nil || *ev.WorkspaceID != workspaceID || ev.TriggerDevin == nil || !*ev.TriggerDevin {
+			t.Errorf("event = %+v, want %+v", ev, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("job.created was not delivered")
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	recoveryModeFail    = "fail"
+	recoveryModeRequeue = "requeue"
+
+	interruptedDetail = "interrupted by restart"
+)
+
+// RecoverInterruptedJobs handles jobs orphaned by a restart: spec jobs still RUNNING and code jobs
+// still queued or processing that haven't progressed for JOB_RECOVERY_THRESHOLD (default 10m).
+// The threshold keeps jobs that another instance is working on untouched.
+//
+// Spec jobs are always marked FAILED since the request waiting for them is gone. Code jobs are
+// marked failed too, or dispatched to the workers again with JOB_RECOVERY_MODE=requeue.
+func RecoverInterruptedJobs(db *pgxpool.Pool) error {
+	mode := strings.ToLower(config.GetString("JOB_RECOVERY_MODE", recoveryModeFail))
+	if mode != recoveryModeFail && mode != recoveryModeRequeue {
+		return fmt.Errorf("JOB_RECOVERY_MODE must be %q or %q, got %q", recoveryModeFail, recoveryModeRequeue, mode)
+	}
+	threshold := config.MustGetDuration("JOB_RECOVERY_THRESHOLD", 10*time.Minute)
+	cutoff := time.Now().Add(-threshold)
+
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+
+	tag, err := db.Exec(ctx, `
+		UPDATE gen_spec_jobs
+		SET status = 'FAILED', error = $2, finished_at = now()
+		WHERE status IN ('QUEUED', 'RUNNING') AND COALESCE(started_at, created_at) < $1
+	`, cutoff, interruptedDetail)
+	if err != nil {
+		return fmt.Errorf("failed to recover spec jobs: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("[WARNING] Marked %d interrupted spec jobs as FAILED", tag.RowsAffected())
+	}
+
+	if mode == recoveryModeRequeue {
+		return requeueCodeJobs(ctx, db, cutoff)
+	}
+
+	// Failed jobs give back their code job quota, as they do when they fail on their own
+	logsJSON, _ := json.Marshal([]string{"Job " + interruptedDetail})
+	var failed int
+	err = db.QueryRow(ctx, `
+		WITH failed AS (
+			UPDATE code_jobs
+			SET status = 'failed', error = $2, logs = COALESCE(logs, '[]'::jsonb) || $3::jsonb, updated_at = now()
+			WHERE status IN ('queued', 'processing') AND updated_at < $1
+			RETURNING workspace_id
+		), released AS (
+			UPDATE workspaces w SET used_code_jobs = GREATEST(w.used_code_jobs - f.jobs, 0)
+			FROM (SELECT workspace_id, COUNT(*) AS jobs FROM failed WHERE workspace_id IS NOT NULL GROUP BY workspace_id) f
+			WHERE w.id = f.workspace_id
+		)
+		SELECT COUNT(*) FROM failed
+	`, cutoff, interruptedDetail, logsJSON).Scan(&failed)
+	if err != nil {
+		return fmt.Errorf("failed to recover code jobs: %w", err)
+	}
+	if failed > 0 {
+		log.Printf("[WARNING] Marked %d interrupted code jobs as failed", failed)
+	}
+	return nil
+}
+
+// requeueCodeJobs resets interrupted code jobs to queued and dispatches them again. Bumping
+// updated_at in the same statement keeps other instances starting up from claiming them too.
+func requeueCodeJobs(ctx context.Context, db *pgxpool.Pool, cutoff time.Time) error {
+	logsJSON, _ := json.Marshal([]string{"Job " + interruptedDetail + ", requeued"})
+	rows, err := db.Query(ctx, `
+		UPDATE code_jobs
+		SET status = 'queued', progress = 0, logs = COALESCE(logs, '[]'::jsonb) || $2::jsonb, updated_at = now()
+		WHERE status IN ('queued', 'processing') AND updated_at < $1 AND game_spec_id IS NOT NULL
+		RETURNING id, game_spec_id, COALESCE(output_path, ''), COALESCE(target_framework, ''), trigger_devin, workspace_id
+	`, cutoff, logsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to requeue code jobs: %w", err)
+	}
+	defer rows.Close()
+
+	type requeued struct {
+		jobID       string
+		workspaceID *string
+		req         CreateCodeJobReq
+	}
+	var jobs []requeued
+	for rows.Next() {
+		var j requeued
+		if err := rows.Scan(&j.jobID, &j.req.GameSpecID, &j.req.OutputPath, &j.req.TargetFramework, &j.req.TriggerDevin, &j.workspaceID); err != nil {
+			return fmt.Errorf("failed to read requeued code job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to requeue code jobs: %w", err)
+	}
+
+	for _, j := range jobs {
+		dispatchCodeJob(context.Background(), db, j.jobID, j.workspaceID, j.req)
+	}
+	if len(jobs) > 0 {
+		log.Printf("[WARNING] Requeued %d interrupted code jobs", len(jobs))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const jobCleanupBatch = 1000
+
+// RunJobCleanup deletes finished spec and code jobs older than JOB_RETENTION_DAYS (default 30)
+// every JOB_CLEANUP_INTERVAL (default 24h). The specs they produced are kept, and so is the latest
+// completed code job of each spec since its files are served from that job's output. It does
+// nothing unless JOB_CLEANUP_ENABLED is set.
+func RunJobCleanup(ctx context.Context, db *pgxpool.Pool) {
+	if !config.MustGetBool("JOB_CLEANUP_ENABLED", false) {
+		return
+	}
+	retention := time.Duration(config.MustGetInt("JOB_RETENTION_DAYS", 30)) * 24 * time.Hour
+	interval := config.MustGetDuration("JOB_CLEANUP_INTERVAL", 24*time.Hour)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cleanupJobs(ctx, db, time.Now().Add(-retention))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func cleanupJobs(ctx context.Context, db *pgxpool.Pool, cutoff time.Time) {
+	specJobs, err := deleteInBatches(ctx, db, `
+		DELETE FROM gen_spec_jobs WHERE id IN (
+			SELECT id FROM gen_spec_jobs
+			WHERE status NOT IN ('QUEUED','RUNNING') AND COALESCE(finished_at, created_at) < $1
+			LIMIT $2
+		)
+	`, cutoff)
+	if err != nil {
+		log.Printf("[ERROR] Failed to clean up spec jobs: %v", err)
+	}
+
+	codeJobs, err := deleteInBatches(ctx, db, `
+		DELETE FROM code_jobs WHERE id IN (
+			SELECT j.id FROM code_jobs j
+			WHERE j.status NOT IN ('queued','processing') AND j.updated_at < $1
+				AND NOT (j.status = 'completed' AND j.game_spec_id IS NOT NULL AND NOT EXISTS (
+					SELECT 1 FROM code_jobs n
+					WHERE n.game_spec_id = j.game_spec_id AND n.status = 'completed' AND n.created_at > j.created_at
+				))
+			LIMIT $2
+		)
+	`, cutoff)
+	if err != nil {
+		log.Printf("[ERROR] Failed to clean up code jobs: %v", err)
+	}
+
+	log.Printf("[INFO] Job cleanup removed %d spec jobs and %d code jobs finished before %s", specJobs, codeJobs, cutoff.Format(time.RFC3339))
+}
+
+// deleteInBatches runs a DELETE taking the cutoff and a batch size until it removes fewer rows
+// than a batch, returning how many rows were removed
+func deleteInBatches(parent context.Context, db *pgxpool.Pool, sql string, cutoff time.Time) (int64, error) {
+	var removed int64
+	for {
+		ctx, cancel := queryCtx(parent)
+		tag, err := db.Exec(ctx, sql, cutoff, jobCleanupBatch)
+		cancel()
+		if err != nil {
+			return removed, err
+		}
+		removed += tag.RowsAffected()
+		if tag.RowsAffected() < jobCleanupBatch {
+			return removed, nil
+		}
+	}
+}
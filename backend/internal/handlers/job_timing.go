@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Stages recorded in gen_spec_jobs.timing
+const (
+	timingLLM          = "llm_duration_ms"
+	timingVectorSearch = "vector_search_duration_ms"
+	timingVectorUpsert = "vector_upsert_duration_ms"
+)
+
+// Stages recorded in code_jobs.timing. llm_code_ms covers the asset stage, the only LLM call of
+// the code pipeline.
+const (
+	timingSpecFetch = "spec_fetch_ms"
+	timingLLMCode   = "llm_code_ms"
+	timingFileWrite = "file_write_ms"
+	timingGitPush   = "git_push_ms"
+)
+
+// recordSpecJobTiming adds the duration of a stage to a spec job's timing. Timing is informative,
+// so a failed update is only logged.
+func recordSpecJobTiming(db *pgxpool.Pool, jobID, stage string, d time.Duration) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	_, err := db.Exec(ctx, `UPDATE gen_spec_jobs SET timing = COALESCE(timing, '{}'::jsonb) || jsonb_build_object($2::text, $3::bigint) WHERE id = $1`,
+		jobID, stage, d.Milliseconds())
+	if err != nil {
+		log.Printf("[ERROR] Failed to record %s of spec job %s: %v", stage, jobID, err)
+	}
+}
+
+// recordCodeJobTiming adds the duration of a stage to a code job's timing
+func recordCodeJobTiming(db *pgxpool.Pool, jobID, stage string, d time.Duration) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	_, err := db.Exec(ctx, `UPDATE code_jobs SET timing = COALESCE(timing, '{}'::jsonb) || jsonb_build_object($2::text, $3::bigint) WHERE id = $1`,
+		jobID, stage, d.Milliseconds())
+	if err != nil {
+		log.Printf("[ERROR] Failed to record %s of code job %s: %v", stage, jobID, err)
+	}
+}
+
+type StageLatency struct {
+	Stage   string  `json:"stage"`
+	Samples int     `json:"samples"`
+	P50MS   float64 `json:"p50_ms"`
+	P95MS   float64 `json:"p95_ms"`
+	P99MS   float64 `json:"p99_ms"`
+}
+
+type LatencyStatsResp struct {
+	SpecJobs []StageLatency `json:"spec_jobs"`
+	CodeJobs []StageLatency `json:"code_jobs"`
+}
+
+// GetLatencyStats returns the P50/P95/P99 duration of each pipeline stage across the workspace's
+// spec and code jobs, limited to jobs created within ?since= (a duration such as 24h) when set
+func GetLatencyStats(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var since *time.Time
+		if raw := c.Query("since"); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil || d <= 0 {
+				return middleware.NewProblem(fiber.StatusBadRequest, "since must be a positive duration such as 24h")
+			}
+			t := time.Now().Add(-d)
+			since = &t
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		workspaceID := middleware.WorkspaceID(c)
+		var resp LatencyStatsResp
+		var err error
+		if resp.SpecJobs, err = stageLatencies(ctx, db, "gen_spec_jobs", workspaceID, since); err != nil {
+			log.Printf("[ERROR] Failed to compute spec job latencies: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		if resp.CodeJobs, err = stageLatencies(ctx, db, "code_jobs", workspaceID, since); err != nil {
+			log.Printf("[ERROR] Failed to compute code job latencies: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		return c.JSON(resp)
+	}
+}
+
+// stageLatencies computes the percentiles of every stage recorded in the timing column of table,
+// which is one of the job tables and never user input
+func stageLatencies(ctx context.Context, db *pgxpool.Pool, table string, workspaceID *string, since *time.Time) ([]StageLatency, error) {
+	rows, err := db.Query(ctx, `
+		SELECT t.key, count(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY t.value::float8),
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY t.value::float8),
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY t.value::float8)
+		FROM `+table+` j, jsonb_each_text(j.timing) t
+		WHERE j.timing IS NOT NULL
+			AND j.workspace_id IS NOT DISTINCT FROM $1
+			AND ($2::timestamptz IS NULL OR j.created_at >= $2)
+		GROUP BY t.key
+		ORDER BY t.key
+	`, workspaceID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stages := []StageLatency{}
+	for rows.Next() {
+		var s StageLatency
+		if err := rows.Scan(&s.Stage, &s.Samples, &s.P50MS, &s.P95MS, &s.P99MS); err != nil {
+			return nil, err
+		}
+		stages = append(stages, s)
+	}
+	return stages, rows.Err()
+}
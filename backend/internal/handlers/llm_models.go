@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"fmt"
+	"strings"
+)
+
+// defaultLLMModel tells the LLM backend to pick its own model
+const defaultLLMModel = "default"
+
+var defaultLLMModelAllowlist = []string{defaultLLMModel, "gpt-4", "gpt-4o", "gpt-4o-mini"}
+
+// llmModelAllowlist returns the models accepted for LLM_SPEC_MODEL, overridable via LLM_MODEL_ALLOWLIST (comma-separated)
+func llmModelAllowlist() []string {
+	v := config.GetString("LLM_MODEL_ALLOWLIST", "")
+	if v == "" {
+		return defaultLLMModelAllowlist
+	}
+	models := []string{defaultLLMModel}
+	for _, m := range strings.Split(v, ",") {
+		if m = strings.TrimSpace(m); m != "" && m != defaultLLMModel {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// specModel returns the model used for spec generation
+func specModel() string {
+	return config.GetString("LLM_SPEC_MODEL", defaultLLMModel)
+}
+
+// ValidateLLMModels checks the configured models against the allowlist, called once at startup
+func ValidateLLMModels() error {
+	model := specModel()
+	for _, allowed := range llmModelAllowlist() {
+		if model == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("LLM_SPEC_MODEL %q is not in the model allowlist %v", model, llmModelAllowlist())
+}
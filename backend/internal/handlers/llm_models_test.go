@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestLLMModelAllowlist(t *testing.T) {
+	t.Setenv("LLM_MODEL_ALLOWLIST", "")
+	if got := llmModelAllowlist(); !reflect.DeepEqual(got, defaultLLMModelAllowlist) {
+		t.Errorf("default allowlist = %v", got)
+	}
+	t.Setenv("LLM_MODEL_ALLOWLIST", " claude-3 , default,,gpt-4o ")
+	if got, want := llmModelAllowlist(), []string{"default", "claude-3", "gpt-4o"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("allowlist = %v, want %v", got, want)
+	}
+}
+
+func TestValidateLLMModels(t *testing.T) {
+	tests := []struct {
+		model     string
+		allowlist string
+		wantErr   bool
+	}{
+		{model: "", allowlist: ""},
+		{model: "default", allowlist: "claude-3"},
+		{model: "gpt-4o", allowlist: ""},
+		{model: "claude-3", allowlist: "claude-3"},
+		{model: "claude-3", allowlist: "", wantErr: true},
+		{model: "gpt-4o", allowlist: "claude-3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("LLM_SPEC_MODEL", tt.model)
+		t.Setenv("LLM_MODEL_ALLOWLIST", tt.allowlist)
+		if err := ValidateLLMModels(); (err != nil) != tt.wantErr {
+			t.Errorf("LLM_SPEC_MODEL=%q LLM_MODEL_ALLOWLIST=%q: err = %v, wantErr %v", tt.model, tt.allowlist, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateSpecForwardsModel(t *testing.T) {
+	var got genSpecReq
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/llm/generate-spec" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		json.NewEncoder(w).Encode(testGenSpecResp())
+	})
+
+	for _, model := range []string{"", "gpt-4o"} {
+		t.Setenv("LLM_SPEC_MODEL", model)
+		want := specModel()
+		if _, err := generateSpec(context.Background(), genSpecReq{Brief: "A cat game", Model: want}); err != nil {
+			t.Fatal(err)
+		}
+		if got.Model != want {
+			t.Errorf("LLM_SPEC_MODEL=%q: forwarded model = %q, want %q", model, got.Model, want)
+		}
+	}
+	if specModel() != "gpt-4o" {
+		t.Errorf("specModel() = %q", specModel())
+	}
+	t.Setenv("LLM_SPEC_MODEL", "")
+	if specModel() != defaultLLMModel {
+		t.Errorf("unset LLM_SPEC_MODEL: specModel() = %q, want %q", specModel(), defaultLLMModel)
+	}
+}
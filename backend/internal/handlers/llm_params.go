@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var defaultLLMParams = []string{"temperature", "max_tokens", "complexity_level"}
+
+// llmParamAllowlist returns the generation params forwarded to the LLM backend, overridable via LLM_PARAM_ALLOWLIST (comma-separated)
+func llmParamAllowlist() []string {
+	v := config.GetString("LLM_PARAM_ALLOWLIST", "")
+	if v == "" {
+		return defaultLLMParams
+	}
+	var params []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// validateLLMParams rejects params with keys outside the allowlist so arbitrary data never reaches the LLM backend
+func validateLLMParams(params map[string]interface{}) error {
+	allowed := map[string]bool{}
+	for _, p := range llmParamAllowlist() {
+		allowed[p] = true
+	}
+	var unknown []string
+	for k := range params {
+		if !allowed[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("params %v are not supported, expected any of %v", unknown, llmParamAllowlist())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// llmSpecTimeout bounds spec generation calls, LLM_SPEC_TIMEOUT_SECONDS (default 120)
+func llmSpecTimeout() time.Duration {
+	return time.Duration(config.MustGetInt("LLM_SPEC_TIMEOUT_SECONDS", 120)) * time.Second
+}
+
+// llmCodeTimeout bounds code stage calls such as asset generation, LLM_CODE_TIMEOUT_SECONDS (default 300)
+func llmCodeTimeout() time.Duration {
+	return time.Duration(config.MustGetInt("LLM_CODE_TIMEOUT_SECONDS", 300)) * time.Second
+}
+
+// llmCallError turns a failed LLM request into a 504 tagged llm_timeout when ctx ran out,
+// a 503 while the LLM backend's circuit breaker is open, or a 502 otherwise
+func llmCallError(ctx context.Context, timeout time.Duration, err error) error {
+	if errors.Is(err, llm.ErrCircuitOpen) {
+		return middleware.NewProblem(fiber.StatusServiceUnavailable, "LLM backend is unavailable, try again later").
+			WithCode(codeLLMUnavailable)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return middleware.NewProblem(fiber.StatusGatewayTimeout, fmt.Sprintf("LLM did not respond within %s", timeout)).
+			WithCode(llmTimeoutError)
+	}
+	return middleware.NewProblem(fiber.StatusBadGateway, "llm generate-spec failed: "+err.Error()).
+		WithCode(codeLLMUnavailable)
+}
+
+// specJobFailure returns the error recorded on a spec job that failed with err
+func specJobFailure(err error) string {
+	var p *middleware.Problem
+	if errors.As(err, &p) && p.Code == llmTimeoutError {
+		return llmTimeoutError
+	}
+	return err.Error()
+}
+
+// failSpecJob marks a spec job that is still in progress as FAILED and gives back its quota
+func failSpecJob(db *pgxpool.Pool, jobID, reason string) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+	tag, err := db.Exec(ctx, `UPDATE gen_spec_jobs SET status='FAILED', error=$2, finished_at=now() WHERE id=$1 AND status IN ('QUEUED','RUNNING')`, jobID, reason)
+	if err != nil {
+		log.Printf("[ERROR] Failed to mark spec job %s as FAILED: %v", jobID, err)
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		releaseSpecQuota(ctx, db, jobID)
+	}
+}
+
+// Healthz reports whether the database is reachable, along with the state of the LLM backend's
+// circuit breaker and the configured LLM timeouts. An open breaker doesn't make the service unready.
+func Healthz(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), 2*time.Second)
+		defer cancel()
+
+		status, database := "ok", "ok"
+		code := fiber.StatusOK
+		if err := db.Ping(ctx); err != nil {
+			status, database = "unavailable", "unreachable"
+			code = fiber.StatusServiceUnavailable
+		}
+		return c.Status(code).JSON(fiber.Map{
+			"status":      status,
+			"database":    database,
+			"llm_breaker": llm.Backend().State(),
+			"llm_timeouts": fiber.Map{
+				"spec_seconds": int(llmSpecTimeout().Seconds()),
+				"code_seconds": int(llmCodeTimeout().Seconds()),
+			},
+		})
+	}
+}
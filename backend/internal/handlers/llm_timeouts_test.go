@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// stalledServer starts an LLM backend whose handler blocks until the test ends, after
+// calling before
+func stalledServer(t *testing.T, before func(w http.ResponseWriter)) string {
+	t.Helper()
+	done := make(chan struct{})
+	srv := newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		before(w)
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	})
+	// Cleanups run last in first out, so the handlers return before the server is closed
+	t.Cleanup(func() { close(done) })
+	return srv.URL
+}
+
+// slowLLM answers like an LLM backend that never finishes, until the client gives up
+func slowLLM(t *testing.T, sendHeaders bool) {
+	t.Helper()
+	stalledServer(t, func(w http.ResponseWriter) {
+		if sendHeaders {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"title":`))
+			w.(http.Flusher).Flush()
+		}
+	})
+}
+
+func TestGenerateSpecTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		sendHeaders bool
+	}{
+		{name: "no response", sendHeaders: false},
+		{name: "stalled body", sendHeaders: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_SPEC_TIMEOUT_SECONDS", "1")
+			slowLLM(t, tt.sendHeaders)
+
+			start := time.Now()
+			_, err := generateSpec(context.Background(), genSpecReq{Brief: "A cat game"})
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("generateSpec returned after %s, want about 1s", elapsed)
+			}
+			var p *middleware.Problem
+			if !errors.As(err, &p) || p.Status != fiber.StatusGatewayTimeout || p.Code != llmTimeoutError {
+				t.Fatalf("err = %v, want a 504 %s problem", err, llmTimeoutError)
+			}
+			if got := specJobFailure(err); got != llmTimeoutError {
+				t.Errorf("specJobFailure = %q, want %q", got, llmTimeoutError)
+			}
+		})
+	}
+}
+
+func TestGenerateAssetsTimeout(t *testing.T) {
+	t.Setenv("LLM_CODE_TIMEOUT_SECONDS", "1")
+	url := stalledServer(t, func(http.ResponseWriter) {})
+	t.Setenv("ASSET_GEN_URL", url+"/assets/generate")
+
+	start := time.Now()
+	if _, err := generateAssets("spec-slow", "Yarn Cat", nil); err == nil {
+		t.Fatal("generateAssets against a stalled service succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("generateAssets returned after %s, want about 1s", elapsed)
+	}
+}
+
+func TestLLMCallError(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "deadline", ctx: expired, err: context.DeadlineExceeded, wantStatus: fiber.StatusGatewayTimeout, wantCode: llmTimeoutError},
+		{name: "circuit open", ctx: expired, err: llm.ErrCircuitOpen, wantStatus: fiber.StatusServiceUnavailable, wantCode: codeLLMUnavailable},
+		{name: "connection refused", ctx: context.Background(), err: errors.New("connection refused"), wantStatus: fiber.StatusBadGateway, wantCode: codeLLMUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := llmCallError(tt.ctx, time.Second, tt.err)
+			var p *middleware.Problem
+			if !errors.As(err, &p) || p.Status != tt.wantStatus || p.Code != tt.wantCode {
+				t.Fatalf("err = %#v, want %d %s", err, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	if got := specJobFailure(errors.New("llm status 500")); got != "llm status 500" {
+		t.Errorf("specJobFailure of another error = %q", got)
+	}
+}
+
+func TestLLMTimeoutDefaults(t *testing.T) {
+	t.Setenv("LLM_SPEC_TIMEOUT_SECONDS", "")
+	t.Setenv("LLM_CODE_TIMEOUT_SECONDS", "")
+	if got := llmSpecTimeout(); got != 120*time.Second {
+		t.Errorf("llmSpecTimeout = %s, want 2m", got)
+	}
+	if got := llmCodeTimeout(); got != 300*time.Second {
+		t.Errorf("llmCodeTimeout = %s, want 5m", got)
+	}
+}
+
+func TestFailSpecJobTimeout(t *testing.T) {
+	pool := dbtest.New(t)
+	ws := newTestWorkspace(t, pool, "timeout-key")
+	jobID := newTestSpecJob(t, pool, ws)
+
+	failSpecJob(pool, jobID, llmTimeoutError)
+	var status, reason string
+	if err := pool.QueryRow(context.Background(), `SELECT status, error FROM gen_spec_jobs WHERE id = $1`, jobID).Scan(&status, &reason); err != nil {
+		t.Fatal(err)
+	}
+	if status != "FAILED" || reason != llmTimeoutError {
+		t.Errorf("job = %s / %q, want FAILED / %q", status, reason, llmTimeoutError)
+	}
+}
+
+func TestHealthzTimeouts(t *testing.T) {
+	pool := dbtest.New(t)
+	t.Setenv("LLM_SPEC_TIMEOUT_SECONDS", "45")
+	t.Setenv("LLM_CODE_TIMEOUT_SECONDS", "")
+
+	app := fiber.New()
+	app.Get("/healthz", Healthz(pool))
+	status, body := apiRequest(t, app, "GET", "/healthz", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	var resp struct {
+		Status      string `json:"status"`
+		LLMTimeouts struct {
+			Spec int `json:"spec_seconds"`
+			Code int `json:"code_seconds"`
+		} `json:"llm_timeouts"`
+	}
+	decodeJSON(t, body, &resp)
+	if resp.Status != "ok" || resp.LLMTimeouts.Spec != 45 || resp.LLMTimeouts.Code != 300 {
+		t.Errorf("healthz = %s", body)
+	}
+}
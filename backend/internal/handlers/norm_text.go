@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// normTextFields are the spec_json fields embedded for similarity search, in output order
+var normTextFields = []string{"genre", "controls", "mechanics", "constraints"}
+
+// buildNormText builds the text embedded for duplicate detection: the title followed by one
+// "field: value" line per non-empty field, with lists joined by commas
+func buildNormText(g genSpecResp) string {
+	lines := []string{strings.TrimSpace(g.Title)}
+	for _, field := range normTextFields {
+		if v := normValue(g.SpecJSON[field]); v != "" {
+			lines = append(lines, field+": "+v)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normValue flattens a JSON value into plain text. Map keys are sorted so the same spec
+// always produces the same text.
+func normValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			if s := normValue(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if s := normValue(val[k]); s != "" {
+				parts = append(parts, k+" "+s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuildNormText(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		specJSON string
+		want     string
+	}{
+		{
+			name:     "all fields",
+			title:    "Yarn Cat",
+			specJSON: `{"genre":"puzzle","controls":["arrows","space"],"mechanics":["jump","roll"],"constraints":["no text"]}`,
+			want:     "Yarn Cat\ngenre: puzzle\ncontrols: arrows, space\nmechanics: jump, roll\nconstraints: no text",
+		},
+		{
+			name:     "missing fields are omitted",
+			title:    "Yarn Cat",
+			specJSON: `{"mechanics":["jump"]}`,
+			want:     "Yarn Cat\nmechanics: jump",
+		},
+		{
+			name:     "empty values are omitted",
+			title:    "Yarn Cat",
+			specJSON: `{"genre":"  ","controls":[],"mechanics":["", "jump", null],"constraints":null}`,
+			want:     "Yarn Cat\nmechanics: jump",
+		},
+		{
+			name:     "no spec_json",
+			title:    " Yarn Cat ",
+			specJSON: `null`,
+			want:     "Yarn Cat",
+		},
+		{
+			name:     "objects are flattened with sorted keys",
+			title:    "Yarn Cat",
+			specJSON: `{"controls":{"touch":"tap","keyboard":["arrows","space"],"gamepad":null}}`,
+			want:     "Yarn Cat\ncontrols: keyboard arrows, space, touch tap",
+		},
+		{
+			name:     "scalars",
+			title:    "Yarn Cat",
+			specJSON: `{"constraints":[300, true],"genre":"arcade"}`,
+			want:     "Yarn Cat\ngenre: arcade\nconstraints: 300, true",
+		},
+		{
+			name:     "unlisted fields are ignored",
+			title:    "Yarn Cat",
+			specJSON: `{"art_style":"pixel","genre":"puzzle"}`,
+			want:     "Yarn Cat\ngenre: puzzle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := genSpecResp{Title: tt.title}
+			if err := json.Unmarshal([]byte(tt.specJSON), &g.SpecJSON); err != nil {
+				t.Fatal(err)
+			}
+			got := buildNormText(g)
+			if got != tt.want {
+				t.Errorf("buildNormText =\n%q\nwant\n%q", got, tt.want)
+			}
+			if strings.Contains(got, "map[") || strings.Contains(got, "[]") {
+				t.Errorf("Go formatting leaked into %q", got)
+			}
+		})
+	}
+}
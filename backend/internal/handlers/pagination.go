@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+
+	// nextCursorHeader carries the cursor of the next page so list responses stay plain arrays
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+// specCursor is the keyset position after the last spec or spec job of a page
+type specCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+func encodeSpecCursor(createdAt time.Time, id string) string {
+	b, _ := json.Marshal(specCursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSpecCursor(s string) (specCursor, error) {
+	var cur specCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(b, &cur); err != nil || cur.ID == "" || cur.CreatedAt.IsZero() {
+		return cur, errors.New("invalid cursor")
+	}
+	return cur, nil
+}
+
+// pageSize clamps a requested page size to 1..maxPageSize
+func pageSize(requested int) int {
+	if requested < 1 {
+		return defaultPageSize
+	}
+	if requested > maxPageSize {
+		return maxPageSize
+	}
+	return requested
+}
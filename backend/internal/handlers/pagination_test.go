@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestSpecCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	raw := encodeSpecCursor(createdAt, "2b1e4c1a-5d57-4a8e-9f7c-0d6f0a3c2b11")
+	cur, err := decodeSpecCursor(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cur.CreatedAt.Equal(createdAt) || cur.ID != "2b1e4c1a-5d57-4a8e-9f7c-0d6f0a3c2b11" {
+		t.Errorf("decoded %+v", cur)
+	}
+
+	for _, bad := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"created_at":"2024-05-01T12:30:00Z"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":"x"}`)),
+	} {
+		if _, err := decodeSpecCursor(bad); err == nil {
+			t.Errorf("decodeSpecCursor(%q) accepted", bad)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for requested, want := range map[int]int{-1: defaultPageSize, 0: defaultPageSize, 1: 1, 20: 20, maxPageSize: maxPageSize, maxPageSize + 1: maxPageSize} {
+		if got := pageSize(requested); got != want {
+			t.Errorf("pageSize(%d) = %d, want %d", requested, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type quotaResource int
+
+const (
+	quotaSpecs quotaResource = iota
+	quotaCodeJobs
+)
+
+// Each resource maps to a fixed statement so column names never come from input.
+// The increment only happens while usage is below the quota, which makes check and use atomic.
+var quotaConsumeSQL = map[quotaResource]string{
+	quotaSpecs: `UPDATE workspaces SET used_specs = used_specs + 1
+		WHERE id = $1 AND (quota_specs IS NULL OR used_specs < quota_specs)
+		RETURNING used_specs`,
+	quotaCodeJobs: `UPDATE workspaces SET used_code_jobs = used_code_jobs + 1
+		WHERE id = $1 AND (quota_code_jobs IS NULL OR used_code_jobs < quota_code_jobs)
+		RETURNING used_code_jobs`,
+}
+
+var quotaReleaseSQL = map[quotaResource]string{
+	quotaSpecs:    `UPDATE workspaces SET used_specs = GREATEST(used_specs - 1, 0) WHERE id = $1`,
+	quotaCodeJobs: `UPDATE workspaces SET used_code_jobs = GREATEST(used_code_jobs - 1, 0) WHERE id = $1`,
+}
+
+var quotaUsageSQL = map[quotaResource]string{
+	quotaSpecs:    `SELECT COALESCE(quota_specs, 0), used_specs FROM workspaces WHERE id = $1`,
+	quotaCodeJobs: `SELECT COALESCE(quota_code_jobs, 0), used_code_jobs FROM workspaces WHERE id = $1`,
+}
+
+type quotaExceededError struct {
+	Limit int
+	Used  int
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded (%d/%d)", e.Used, e.Limit)
+}
+
+// consumeQuota records one use of resource for the workspace, failing with *quotaExceededError
+// when the quota is used up. The shared workspace has no quota.
+func consumeQuota(parent context.Context, db *pgxpool.Pool, workspaceID *string, resource quotaResource) error {
+	if workspaceID == nil {
+		return nil
+	}
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+
+	var used int
+	err := db.QueryRow(ctx, quotaConsumeSQL[resource], *workspaceID).Scan(&used)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	var limit int
+	if err := db.QueryRow(ctx, quotaUsageSQL[resource], *workspaceID).Scan(&limit, &used); err != nil {
+		return err
+	}
+	return &quotaExceededError{Limit: limit, Used: used}
+}
+
+// releaseQuota gives back a use consumeQuota recorded when the request fails before its job
+// exists. Once the job row is written, release through the job instead (releaseSpecQuota,
+// releaseCodeJobQuota) so the refund follows its status.
+func releaseQuota(parent context.Context, db *pgxpool.Pool, workspaceID *string, resource quotaResource) {
+	if workspaceID == nil {
+		return
+	}
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	if _, err := db.Exec(ctx, quotaReleaseSQL[resource], *workspaceID); err != nil {
+		log.Printf("[ERROR] Failed to release the quota of workspace %s: %v", *workspaceID, err)
+	}
+}
+
+// releaseSpecQuota gives back the spec quota a job consumed when the job ends without a spec:
+// it failed, or its spec duplicated an existing one. Call it only when the statement ending the
+// job changed its status, so a job is never refunded twice. Jobs of the shared workspace have no
+// quota and change nothing.
+func releaseSpecQuota(ctx context.Context, q quotaExecer, jobID string) {
+	_, err := q.Exec(ctx, `
+		UPDATE workspaces SET used_specs = GREATEST(used_specs - 1, 0)
+		WHERE id = (SELECT workspace_id FROM gen_spec_jobs WHERE id = $1)
+	`, jobID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to release the spec quota of job %s: %v", jobID, err)
+	}
+}
+
+// releaseCodeJobQuota gives back the code job quota a job consumed when the job fails. Like
+// releaseSpecQuota, call it only when the statement failing the job changed its status.
+func releaseCodeJobQuota(ctx context.Context, q quotaExecer, jobID string) {
+	_, err := q.Exec(ctx, `
+		UPDATE workspaces SET used_code_jobs = GREATEST(used_code_jobs - 1, 0)
+		WHERE id = (SELECT workspace_id FROM code_jobs WHERE id = $1)
+	`, jobID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to release the code job quota of job %s: %v", jobID, err)
+	}
+}
+
+// quotaExecer is satisfied by both the pool and a transaction
+type quotaExecer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// quotaErrorResponse writes the response for an error returned by consumeQuota
+func quotaErrorResponse(c *fiber.Ctx, err error) error {
+	var qe *quotaExceededError
+	if errors.As(err, &qe) {
+		return middleware.NewProblem(fiber.StatusPaymentRequired, "quota exceeded").
+			With("limit", qe.Limit).
+			With("used", qe.Used)
+	}
+	log.Printf("[ERROR] Failed to check workspace quota: %v", err)
+	return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to check quota")
+}
+
+// RunQuotaReset resets workspace usage counters at the start of every month.
+// It checks every QUOTA_RESET_CHECK_INTERVAL (default 1h) so a restart never skips a reset.
+func RunQuotaReset(ctx context.Context, db *pgxpool.Pool) {
+	interval := config.MustGetDuration("QUOTA_RESET_CHECK_INTERVAL", time.Hour)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		resetQuotas(db)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func resetQuotas(db *pgxpool.Pool) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+
+	tag, err := db.Exec(ctx, `
+		UPDATE workspaces
+		SET used_specs = 0, used_code_jobs = 0, quota_reset_at = now()
+		WHERE quota_reset_at < date_trunc('month', now())
+	`)
+	if err != nil {
+		log.Printf("[ERROR] Failed to reset workspace quotas: %v", err)
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("[INFO] Reset monthly quotas for %d workspaces", tag.RowsAffected())
+	}
+}
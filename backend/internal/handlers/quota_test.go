@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// setSpecQuota sets the spec quota and usage of a workspace
+func setSpecQuota(t *testing.T, pool *pgxpool.Pool, workspaceID string, quota, used int) {
+	t.Helper()
+	_, err := pool.Exec(context.Background(), `UPDATE workspaces SET quota_specs=$2, used_specs=$3 WHERE id=$1`, workspaceID, quota, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func usedSpecs(t *testing.T, pool *pgxpool.Pool, workspaceID string) int {
+	t.Helper()
+	var used int
+	if err := pool.QueryRow(context.Background(), `SELECT used_specs FROM workspaces WHERE id=$1`, workspaceID).Scan(&used); err != nil {
+		t.Fatal(err)
+	}
+	return used
+}
+
+// newTestSpecJob inserts a RUNNING spec job into a workspace and returns its id
+func newTestSpecJob(t *testing.T, pool *pgxpool.Pool, workspaceID string) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := pool.Exec(context.Background(), `INSERT INTO gen_spec_jobs (id, status, brief, workspace_id) VALUES ($1, 'RUNNING', 'A test brief', $2)`, id, workspaceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestConsumeQuota(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+
+	t.Run("below limit", func(t *testing.T) {
+		ws := newTestWorkspace(t, pool, "quota-below")
+		setSpecQuota(t, pool, ws, 2, 0)
+		if err := consumeQuota(ctx, pool, &ws, quotaSpecs); err != nil {
+			t.Fatal(err)
+		}
+		if got := usedSpecs(t, pool, ws); got != 1 {
+			t.Errorf("used_specs = %d, want 1", got)
+		}
+	})
+
+	t.Run("last unit", func(t *testing.T) {
+		ws := newTestWorkspace(t, pool, "quota-last")
+		setSpecQuota(t, pool, ws, 2, 1)
+		if err := consumeQuota(ctx, pool, &ws, quotaSpecs); err != nil {
+			t.Fatal(err)
+		}
+		if got := usedSpecs(t, pool, ws); got != 2 {
+			t.Errorf("used_specs = %d, want 2", got)
+		}
+	})
+
+	t.Run("at limit", func(t *testing.T) {
+		ws := newTestWorkspace(t, pool, "quota-at")
+		setSpecQuota(t, pool, ws, 2, 2)
+		err := consumeQuota(ctx, pool, &ws, quotaSpecs)
+		var qe *quotaExceededError
+		if !errors.As(err, &qe) {
+			t.Fatalf("err = %v, want *quotaExceededError", err)
+		}
+		if qe.Limit != 2 || qe.Used != 2 {
+			t.Errorf("got %d/%d, want 2/2", qe.Used, qe.Limit)
+		}
+		if got := usedSpecs(t, pool, ws); got != 2 {
+			t.Errorf("used_specs = %d, want 2", got)
+		}
+	})
+
+	t.Run("over quota", func(t *testing.T) {
+		// Lowering a quota below the usage must not let further uses through
+		ws := newTestWorkspace(t, pool, "quota-over")
+		setSpecQuota(t, pool, ws, 1, 3)
+		err := consumeQuota(ctx, pool, &ws, quotaSpecs)
+		var qe *quotaExceededError
+		if !errors.As(err, &qe) {
+			t.Fatalf("err = %v, want *quotaExceededError", err)
+		}
+		if qe.Limit != 1 || qe.Used != 3 {
+			t.Errorf("got %d/%d, want 3/1", qe.Used, qe.Limit)
+		}
+	})
+
+	t.Run("shared workspace", func(t *testing.T) {
+		if err := consumeQuota(ctx, pool, nil, quotaSpecs); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestFailSpecJobReleasesQuota(t *testing.T) {
+	pool := dbtest.New(t)
+	ws := newTestWorkspace(t, pool, "quota-release")
+	setSpecQuota(t, pool, ws, 1, 0)
+
+	if err := consumeQuota(context.Background(), pool, &ws, quotaSpecs); err != nil {
+		t.Fatal(err)
+	}
+	jobID := newTestSpecJob(t, pool, ws)
+	failSpecJob(pool, jobID, "llm failed")
+	if got := usedSpecs(t, pool, ws); got != 0 {
+		t.Fatalf("used_specs after failure = %d, want 0", got)
+	}
+
+	// A job that already ended is not refunded again
+	failSpecJob(pool, jobID, "llm failed")
+	setSpecQuota(t, pool, ws, 1, 1)
+	failSpecJob(pool, jobID, "llm failed")
+	if got := usedSpecs(t, pool, ws); got != 1 {
+		t.Errorf("used_specs after repeated failure = %d, want 1", got)
+	}
+}
+
+func TestHashDuplicateReleasesQuota(t *testing.T) {
+	pool := dbtest.New(t)
+	ws := newTestWorkspace(t, pool, "quota-duplicate")
+	setSpecQuota(t, pool, ws, 1, 1)
+	existing := newTestSpec(t, pool, &ws, nil)
+	jobID := newTestSpecJob(t, pool, ws)
+
+	hashDuplicateResult(context.Background(), pool, jobID, existing, "test-model")
+	if got := usedSpecs(t, pool, ws); got != 0 {
+		t.Errorf("used_specs = %d, want 0", got)
+	}
+}
+
+func usedCodeJobs(t *testing.T, pool *pgxpool.Pool, workspaceID string) int {
+	t.Helper()
+	var used int
+	if err := pool.QueryRow(context.Background(), `SELECT used_code_jobs FROM workspaces WHERE id=$1`, workspaceID).Scan(&used); err != nil {
+		t.Fatal(err)
+	}
+	return used
+}
+
+func TestCodeJobQuotaRelease(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	ws := newTestWorkspace(t, pool, "quota-code-jobs")
+	if _, err := pool.Exec(ctx, `UPDATE workspaces SET quota_code_jobs=2, used_code_jobs=1 WHERE id=$1`, ws); err != nil {
+		t.Fatal(err)
+	}
+	specID := newTestSpec(t, pool, &ws, nil)
+	jobID := uuid.New().String()
+	if _, err := pool.Exec(ctx, `INSERT INTO code_jobs (id, game_spec_id, status, workspace_id) VALUES ($1, $2, 'processing', $3)`, jobID, specID, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	updateJobStatus(pool, jobID, "failed", 0, []string{"git push failed"})
+	if got := usedCodeJobs(t, pool, ws); got != 0 {
+		t.Fatalf("used_code_jobs after failure = %d, want 0", got)
+	}
+	// Failing it again, with more logs, refunds nothing
+	updateJobStatus(pool, jobID, "failed", 0, []string{"git push failed", "cleanup failed"})
+	if got := usedCodeJobs(t, pool, ws); got != 0 {
+		t.Errorf("used_code_jobs after repeated failure = %d, want 0", got)
+	}
+
+	// An admin retry charges the job again, even over the quota
+	if _, err := pool.Exec(ctx, `UPDATE workspaces SET used_code_jobs=2 WHERE id=$1`, ws); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := requeueFailedCodeJob(ctx, pool, jobID); err != nil || !ok {
+		t.Fatalf("requeueFailedCodeJob = %v, %v", ok, err)
+	}
+	if got := usedCodeJobs(t, pool, ws); got != 3 {
+		t.Errorf("used_code_jobs after retry = %d, want 3", got)
+	}
+
+	releaseQuota(ctx, pool, &ws, quotaCodeJobs)
+	if got := usedCodeJobs(t, pool, ws); got != 2 {
+		t.Errorf("used_code_jobs after releaseQuota = %d, want 2", got)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"backend/internal/content"
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRateSpec(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	strict := newTestWorkspace(t, pool, "rating-strict")
+	lenient := newTestWorkspace(t, pool, "rating-lenient")
+	if _, err := pool.Exec(ctx, `UPDATE workspaces SET allow_mature = true WHERE id = $1`, lenient); err != nil {
+		t.Fatal(err)
+	}
+
+	violent := testGenSpecResp()
+	violent.SpecMarkdown = "# Arena\n\nFight zombies with a sword."
+	gory := testGenSpecResp()
+	gory.SpecMarkdown = "# Butcher\n\nEvery fight ends in gore and dismemberment."
+
+	tests := []struct {
+		name        string
+		workspaceID *string
+		spec        genSpecResp
+		want        content.AgeRating
+		wantReject  bool
+	}{
+		{name: "everyone", workspaceID: &strict, spec: testGenSpecResp(), want: content.RatingEveryone},
+		{name: "teen is allowed everywhere", workspaceID: &strict, spec: violent, want: content.RatingTeen},
+		{name: "mature is rejected by default", workspaceID: &strict, spec: gory, wantReject: true},
+		{name: "mature is rejected in the shared workspace", workspaceID: nil, spec: gory, wantReject: true},
+		{name: "mature with allow_mature", workspaceID: &lenient, spec: gory, want: content.RatingMature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := strict
+			if tt.workspaceID != nil {
+				ws = *tt.workspaceID
+			}
+			jobID := newTestSpecJob(t, pool, ws)
+
+			rating, err := rateSpec(ctx, pool, tt.workspaceID, jobID, tt.spec)
+			if tt.wantReject {
+				var p *middleware.Problem
+				if !errors.As(err, &p) || p.Status != fiber.StatusUnprocessableEntity || p.Code != codeMatureContent || p.Extensions["age_rating"] != content.RatingMature {
+					t.Fatalf("err = %v, want a 422 %s problem", err, codeMatureContent)
+				}
+				if got := jobStatus(t, pool, jobID); got != "FAILED" {
+					t.Errorf("job status = %s, want FAILED", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rating != tt.want {
+				t.Errorf("rating = %s, want %s", rating, tt.want)
+			}
+			if got := jobStatus(t, pool, jobID); got != "RUNNING" {
+				t.Errorf("job status = %s, want RUNNING", got)
+			}
+		})
+	}
+
+	t.Run("workspace lookup fails", func(t *testing.T) {
+		setSpecQuota(t, pool, strict, 1, 1)
+		jobID := newTestSpecJob(t, pool, strict)
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := rateSpec(canceled, pool, &strict, jobID, gory)
+		var p *middleware.Problem
+		if !errors.As(err, &p) || p.Status != fiber.StatusInternalServerError {
+			t.Fatalf("err = %v, want a 500 problem", err)
+		}
+		if got := jobStatus(t, pool, jobID); got != "FAILED" {
+			t.Errorf("job status = %s, want FAILED", got)
+		}
+		if got := usedSpecs(t, pool, strict); got != 0 {
+			t.Errorf("used_specs = %d, want the quota given back", got)
+		}
+	})
+}
+
+func TestListSpecsAgeRatingFilter(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+	ws := newTestWorkspace(t, pool, "age-key")
+	key := map[string]string{middleware.APIKeyHeader: "age-key"}
+
+	ids := map[content.AgeRating]string{}
+	for _, r := range []content.AgeRating{content.RatingEveryone, content.RatingTeen, content.RatingMature} {
+		id := newTestSpec(t, pool, &ws, nil)
+		if _, err := pool.Exec(context.Background(), `UPDATE game_specs SET age_rating = $1 WHERE id = $2`, r, id); err != nil {
+			t.Fatal(err)
+		}
+		ids[r] = id
+	}
+
+	type item struct {
+		ID        string  `json:"id"`
+		AgeRating *string `json:"age_rating"`
+	}
+	for _, query := range []string{"E", "t", "m"} {
+		want, _ := content.ParseAgeRating(query)
+		status, body := apiRequest(t, app, "GET", "/api/specs?age_rating="+query, "", key)
+		if status != fiber.StatusOK {
+			t.Fatalf("age_rating=%q: status = %d: %s", query, status, body)
+		}
+		var items []item
+		decodeJSON(t, body, &items)
+		if len(items) != 1 || items[0].ID != ids[want] || items[0].AgeRating == nil || *items[0].AgeRating != string(want) {
+			t.Errorf("age_rating=%q lists %+v, want only %s", query, items, ids[want])
+		}
+	}
+
+	if status, _ := apiRequest(t, app, "GET", "/api/specs?age_rating=PG", "", key); status != fiber.StatusBadRequest {
+		t.Errorf("age_rating=PG: status = %d, want 400", status)
+	}
+
+	var spec item
+	_, body := apiRequest(t, app, "GET", "/api/specs/"+ids[content.RatingTeen], "", key)
+	decodeJSON(t, body, &spec)
+	if spec.AgeRating == nil || *spec.AgeRating != "T" {
+		t.Errorf("GetSpec age_rating = %v, want T", spec.AgeRating)
+	}
+}
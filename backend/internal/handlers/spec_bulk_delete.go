@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"log"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxBulkDelete = 100
+
+type BulkDeleteReq struct {
+	IDs []string `json:"ids"`
+}
+
+type bulkDeleteFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// BulkDeleteSpecs deletes up to 100 specs with the same cleanup as DeleteSpec. The request is
+// rejected with 404 when any id belongs to another workspace. Ids that are malformed, unknown or whose
+// vector can't be removed are reported in failed; the rest are deleted in one transaction and
+// their git folders removed concurrently afterwards.
+func BulkDeleteSpecs(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req BulkDeleteReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid request body")
+		}
+		if len(req.IDs) == 0 {
+			return middleware.NewProblem(fiber.StatusBadRequest, "ids is required")
+		}
+		if len(req.IDs) > maxBulkDelete {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Too many ids").
+				With("max_ids", maxBulkDelete)
+		}
+
+		failed := []bulkDeleteFailure{}
+		seen := map[string]bool{}
+		var ids []string
+		for _, id := range req.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if _, err := uuid.Parse(id); err != nil {
+				failed = append(failed, bulkDeleteFailure{ID: id, Error: "invalid id"})
+				continue
+			}
+			ids = append(ids, id)
+		}
+
+		workspaceID := middleware.WorkspaceID(c)
+		ctx, cancel := queryCtx(c.UserContext())
+		// Specs of other users are left out, so they are reported as not found
+		rows, err := db.Query(ctx, `SELECT id, workspace_id, title FROM game_specs WHERE id = ANY($1::uuid[]) AND ($2::text IS NULL OR user_id = $2)`, ids, specOwnerFilter(c))
+		if err != nil {
+			cancel()
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		titles := map[string]string{}
+		var foreign []string
+		for rows.Next() {
+			var id, title string
+			var specWorkspace *string
+			if err := rows.Scan(&id, &specWorkspace, &title); err != nil {
+				rows.Close()
+				cancel()
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+			}
+			if !sameWorkspace(specWorkspace, workspaceID) {
+				foreign = append(foreign, id)
+				continue
+			}
+			titles[id] = title
+		}
+		rows.Close()
+		cancel()
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		// Specs of other workspaces answer like unknown ones, so a key can't probe for them
+		if len(foreign) > 0 {
+			return middleware.NewProblem(fiber.StatusNotFound, "Some specs were not found").
+				With("ids", foreign)
+		}
+
+		// The vector store can't join the transaction, so a spec is only deleted once its vector is gone
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		var deletable []string
+		for _, id := range ids {
+			if _, ok := titles[id]; !ok {
+				failed = append(failed, bulkDeleteFailure{ID: id, Error: "spec not found"})
+				continue
+			}
+			if err := deleteSpecVector(c.UserContext(), llmBackend, vectorID(workspaceID, id)); err != nil {
+				log.Printf("[ERROR] Failed to delete vector of spec %s: %v", id, err)
+				failed = append(failed, bulkDeleteFailure{ID: id, Error: "failed to delete from vector database"})
+				continue
+			}
+			deletable = append(deletable, id)
+		}
+
+		if len(deletable) > 0 {
+			ctx, cancel := queryCtx(c.UserContext())
+			defer cancel()
+			tx, err := db.Begin(ctx)
+			if err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+			}
+			defer tx.Rollback(ctx)
+			// Related code_jobs go first to avoid the foreign key violation
+			if _, err := tx.Exec(ctx, "DELETE FROM code_jobs WHERE game_spec_id = ANY($1::uuid[])", deletable); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to delete related code jobs")
+			}
+			if _, err := tx.Exec(ctx, "DELETE FROM game_specs WHERE id = ANY($1::uuid[]) AND workspace_id IS NOT DISTINCT FROM $2", deletable, workspaceID); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to delete from database")
+			}
+			if err := tx.Commit(ctx); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to delete from database")
+			}
+			for _, id := range deletable {
+				invalidateSpec(id)
+			}
+		}
+
+		response := fiber.Map{
+			"deleted": len(deletable),
+			"failed":  failed,
+		}
+		if gitFailed := removeGameFoldersConcurrently(deletable, titles); len(gitFailed) > 0 {
+			response["git_cleanup_failed"] = gitFailed
+		}
+		log.Printf("[INFO] Bulk deleted %d specs, %d failed", len(deletable), len(failed))
+		return c.JSON(response)
+	}
+}
+
+// removeGameFoldersConcurrently removes the git folders of deleted specs and returns the ids whose
+// folder may still exist. Nothing is done when git isn't configured. Pushes to a shared repository are
+// serialized inside GitRepo.
+func removeGameFoldersConcurrently(ids []string, titles map[string]string) []string {
+	gitRepo := utils.NewGitRepo()
+	if !gitRepo.IsConfigured() || len(ids) == 0 {
+		return nil
+	}
+	if err := gitRepo.InitializeRepo(); err != nil {
+		log.Printf("[ERROR] Failed to initialize git repo for cleanup: %v", err)
+		return ids
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := []string{}
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if err := gitRepo.RemoveGameFolders(id, titles[id]); err != nil {
+				log.Printf("[ERROR] Failed to remove game folders of spec %s from git: %v", id, err)
+				mu.Lock()
+				failed = append(failed, id)
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	return failed
+}
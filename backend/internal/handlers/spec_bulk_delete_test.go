@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func bulkDeleteBody(ids ...string) string {
+	b, _ := json.Marshal(BulkDeleteReq{IDs: ids})
+	return string(b)
+}
+
+func TestBulkDeleteSpecs(t *testing.T) {
+	pool := dbtest.New(t)
+	t.Setenv("GIT_REPO_URL", "")
+	mock := &vectorMock{}
+	newLLMBackend(t, mock.ServeHTTP)
+	app := newTestAPI(pool)
+
+	ws := newTestWorkspace(t, pool, "bulk-key")
+	other := newTestWorkspace(t, pool, "bulk-other")
+	headers := map[string]string{middleware.APIKeyHeader: "bulk-key"}
+
+	t.Run("mixed ids", func(t *testing.T) {
+		a, b, stuck := newTestSpec(t, pool, &ws, nil), newTestSpec(t, pool, &ws, nil), newTestSpec(t, pool, &ws, nil)
+		newTestCodeJob(t, pool, a, &ws, t.TempDir())
+		unknown := uuid.New().String()
+		mock.failing = map[string]bool{stuck: true}
+		defer func() { mock.failing = nil }()
+
+		status, body := apiRequest(t, app, "POST", "/api/specs/bulk-delete", bulkDeleteBody(a, "not-a-uuid", b, unknown, a, stuck), headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var resp struct {
+			Deleted int                 `json:"deleted"`
+			Failed  []bulkDeleteFailure `json:"failed"`
+		}
+		decodeJSON(t, body, &resp)
+		if resp.Deleted != 2 {
+			t.Errorf("deleted = %d, want 2", resp.Deleted)
+		}
+		sort.Slice(resp.Failed, func(i, j int) bool { return resp.Failed[i].ID < resp.Failed[j].ID })
+		want := []bulkDeleteFailure{
+			{"not-a-uuid", "invalid id"},
+			{unknown, "spec not found"},
+			{stuck, "failed to delete from vector database"},
+		}
+		sort.Slice(want, func(i, j int) bool { return want[i].ID < want[j].ID })
+		if !reflect.DeepEqual(resp.Failed, want) {
+			t.Errorf("failed = %+v, want %+v", resp.Failed, want)
+		}
+
+		for id, want := range map[string]int{a: 0, b: 0, stuck: 1} {
+			if n := countRows(t, pool, "game_specs", "id = $1", id); n != want {
+				t.Errorf("spec %s: %d rows, want %d", id, n, want)
+			}
+		}
+		if n := countRows(t, pool, "code_jobs", "game_spec_id = $1", a); n != 0 {
+			t.Errorf("%d code jobs left for a deleted spec", n)
+		}
+		_, deletes := mock.take()
+		wantDeletes := []string{ws + ":" + a, ws + ":" + b}
+		sort.Strings(wantDeletes)
+		if strings.Join(deletes, ",") != strings.Join(wantDeletes, ",") {
+			t.Errorf("vector deletes = %v, want %v", deletes, wantDeletes)
+		}
+	})
+
+	t.Run("another workspace's id rejects the request", func(t *testing.T) {
+		mine, theirs := newTestSpec(t, pool, &ws, nil), newTestSpec(t, pool, &other, nil)
+		status, body := apiRequest(t, app, "POST", "/api/specs/bulk-delete", bulkDeleteBody(mine, theirs), headers)
+		if status != fiber.StatusNotFound {
+			t.Fatalf("status = %d, want 404: %s", status, body)
+		}
+		if n := countRows(t, pool, "game_specs", "id = ANY($1::uuid[])", []string{mine, theirs}); n != 2 {
+			t.Errorf("%d of 2 specs left after a rejected request", n)
+		}
+		if _, deletes := mock.take(); len(deletes) != 0 {
+			t.Errorf("vectors deleted for a rejected request: %v", deletes)
+		}
+	})
+
+	tooMany := make([]string, maxBulkDelete+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprint(i)
+	}
+	for _, tt := range []struct{ name, body string }{
+		{"no ids", `{"ids":[]}`},
+		{"too many ids", bulkDeleteBody(tooMany...)},
+		{"not json", `ids`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := apiRequest(t, app, "POST", "/api/specs/bulk-delete", tt.body, headers); status != fiber.StatusBadRequest {
+				t.Errorf("status = %d, want 400: %s", status, body)
+			}
+		})
+	}
+}
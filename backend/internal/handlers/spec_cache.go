@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"backend/internal/archiver"
+	"backend/internal/cache"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// cachedSpec is a GetSpec response together with the workspace and user it belongs to
+type cachedSpec struct {
+	workspaceID *string
+	userID      *string
+	response    fiber.Map
+}
+
+// specCache holds recent GetSpec responses. Anything that changes a spec must call invalidateSpec.
+var specCache = cache.NewLRU[string, cachedSpec](256, 30*time.Second)
+
+func invalidateSpec(specID string) {
+	specCache.Delete(specID)
+	embeddingCache.Delete(specID)
+}
+
+// RunArchiver runs archiver.Run, dropping each spec it archives from the caches so readers stop
+// serving the content that was moved to S3
+func RunArchiver(ctx context.Context, db *pgxpool.Pool) {
+	archiver.OnArchive(invalidateSpec)
+	archiver.Run(ctx, db)
+}
+
+func sameWorkspace(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetCacheStats reports hit and miss counters of the in-memory caches
+func GetCacheStats() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"spec":      specCache.Stats(),
+			"genres":    genreCache.Stats(),
+			"embedding": embeddingCache.Stats(),
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"backend/internal/cache"
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetSpecCacheInvalidation(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+	app.Get("/debug/cache-stats", GetCacheStats())
+
+	workspaceID := newTestWorkspace(t, pool, "cache-key")
+	key := map[string]string{middleware.APIKeyHeader: "cache-key"}
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	path := "/api/specs/" + specID
+
+	getState := func() string {
+		t.Helper()
+		status, body := apiRequest(t, app, "GET", path, "", key)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var resp struct {
+			State string `json:"state"`
+			Title string `json:"title"`
+		}
+		decodeJSON(t, body, &resp)
+		return resp.State + "/" + resp.Title
+	}
+
+	before := specCache.Stats()
+	first := getState()
+	if _, ok := specCache.Get(specID); !ok {
+		t.Fatal("GetSpec did not populate the cache")
+	}
+
+	// A write behind the cache's back is not seen until the entry is invalidated
+	if _, err := pool.Exec(context.Background(), `UPDATE game_specs SET title = 'Renamed' WHERE id = $1`, specID); err != nil {
+		t.Fatal(err)
+	}
+	if got := getState(); got != first {
+		t.Fatalf("second read = %q, want the cached %q", got, first)
+	}
+
+	// A state transition evicts the stale entry
+	if err := updateGameSpecState(pool, specID, StateGitIniting, "cache test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := specCache.Get(specID); ok {
+		t.Fatal("updateGameSpecState kept the cached response")
+	}
+	if got, want := getState(), string(StateGitIniting)+"/Renamed"; got != want {
+		t.Errorf("read after transition = %q, want %q", got, want)
+	}
+
+	var stats map[string]cache.Stats
+	_, body := apiRequest(t, app, "GET", "/debug/cache-stats", "", nil)
+	decodeJSON(t, body, &stats)
+	if stats["spec"].Hits <= before.Hits || stats["spec"].Misses <= before.Misses {
+		t.Errorf("spec cache stats = %+v, before %+v", stats["spec"], before)
+	}
+
+	// Deleting the spec evicts it too
+	if status, body := apiRequest(t, app, "DELETE", path, "", key); status != fiber.StatusOK {
+		t.Fatalf("delete status = %d: %s", status, body)
+	}
+	if _, ok := specCache.Get(specID); ok {
+		t.Error("DeleteSpec kept the cached response")
+	}
+	if status, _ := apiRequest(t, app, "GET", path, "", key); status != fiber.StatusNotFound {
+		t.Errorf("status after delete = %d, want 404", status)
+	}
+}
+
+func TestGetSpecCacheWorkspace(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+
+	workspaceID := newTestWorkspace(t, pool, "cache-owner")
+	newTestWorkspace(t, pool, "cache-other")
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	path := "/api/specs/" + specID
+
+	if status, body := apiRequest(t, app, "GET", path, "", map[string]string{middleware.APIKeyHeader: "cache-owner"}); status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	// The cached response belongs to its workspace and is never served to another one
+	if status, _ := apiRequest(t, app, "GET", path, "", map[string]string{middleware.APIKeyHeader: "cache-other"}); status != fiber.StatusNotFound {
+		t.Errorf("status for another workspace = %d, want 404", status)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// changelogLLM answers every completion with reply or err
+type changelogLLM struct {
+	reply string
+	err   error
+}
+
+func (m changelogLLM) Complete(context.Context, string, string) (string, error) {
+	return m.reply, m.err
+}
+
+func TestSpecChangelogs(t *testing.T) {
+	pool := dbtest.New(t)
+	workspaceID := newTestWorkspace(t, pool, "changelog-key")
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	// Registered after the /api group, so it sits behind its middleware
+	app := newTestAPI(pool)
+	app.Get("/api/specs/:id/changelogs", GetSpecChangelogs(pool))
+	headers := map[string]string{middleware.APIKeyHeader: "changelog-key"}
+
+	ctx := context.Background()
+	oldJSON, newJSON := llm.SpecJSON{"lives": 3.0}, llm.SpecJSON{"lives": 5.0}
+	if _, err := recordChangelog(ctx, pool, changelogLLM{reply: "- More lives"}, specID, oldJSON, newJSON); err != nil {
+		t.Fatal(err)
+	}
+	// A failing model still leaves the raw diff behind
+	changes, err := recordChangelog(ctx, pool, changelogLLM{err: errors.New("backend down")}, specID, newJSON, llm.SpecJSON{"lives": 9.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "~ lives: 5 -> 9\n"; changes != want {
+		t.Errorf("fallback changelog = %q, want %q", changes, want)
+	}
+
+	status, body := apiRequest(t, app, "GET", "/api/specs/"+specID+"/changelogs", "", headers)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	var changelogs []SpecChangelog
+	decodeJSON(t, body, &changelogs)
+	if len(changelogs) != 2 {
+		t.Fatalf("%d changelogs, want 2", len(changelogs))
+	}
+	if changelogs[0].ChangesMarkdown != "~ lives: 5 -> 9\n" || changelogs[1].ChangesMarkdown != "- More lives" {
+		t.Errorf("changelogs are not newest first: %+v", changelogs)
+	}
+	for _, cl := range changelogs {
+		if cl.SpecID != specID || cl.ParentSpecID == nil || *cl.ParentSpecID != specID {
+			t.Errorf("changelog %s: spec %s, parent %v", cl.ID, cl.SpecID, cl.ParentSpecID)
+		}
+	}
+
+	newTestWorkspace(t, pool, "changelog-other")
+	if status, _ := apiRequest(t, app, "GET", "/api/specs/"+specID+"/changelogs", "", map[string]string{middleware.APIKeyHeader: "changelog-other"}); status != fiber.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", status)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestListSpecsComplexityFilter(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+	ws := newTestWorkspace(t, pool, "complexity-key")
+	key := map[string]string{middleware.APIKeyHeader: "complexity-key"}
+
+	levels := map[string]string{}
+	for _, c := range []struct {
+		score int
+		level string
+	}{{12, "low"}, {45, "medium"}, {80, "high"}} {
+		id := newTestSpec(t, pool, &ws, nil)
+		if _, err := pool.Exec(context.Background(), `UPDATE game_specs SET complexity_score = $1, complexity_level = $2 WHERE id = $3`, c.score, c.level, id); err != nil {
+			t.Fatal(err)
+		}
+		levels[c.level] = id
+	}
+	unscored := newTestSpec(t, pool, &ws, nil)
+
+	type item struct {
+		ID              string  `json:"id"`
+		ComplexityScore *int    `json:"complexity_score"`
+		ComplexityLevel *string `json:"complexity_level"`
+	}
+	list := func(query string) []item {
+		t.Helper()
+		status, body := apiRequest(t, app, "GET", "/api/specs"+query, "", key)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var items []item
+		decodeJSON(t, body, &items)
+		return items
+	}
+
+	if items := list(""); len(items) != 4 {
+		t.Fatalf("%d specs listed, want 4", len(items))
+	}
+	for level, id := range levels {
+		items := list("?complexity_level=" + level)
+		if len(items) != 1 || items[0].ID != id {
+			t.Errorf("complexity_level=%s lists %+v, want only %s", level, items, id)
+			continue
+		}
+		if items[0].ComplexityLevel == nil || *items[0].ComplexityLevel != level || items[0].ComplexityScore == nil {
+			t.Errorf("complexity_level=%s: item = %+v", level, items[0])
+		}
+	}
+	if items := list("?complexity_level=extreme"); len(items) != 0 {
+		t.Errorf("unknown level lists %+v", items)
+	}
+
+	// GetSpec returns the stored score, and null for specs scored before the column existed
+	var spec item
+	_, body := apiRequest(t, app, "GET", "/api/specs/"+levels["high"], "", key)
+	decodeJSON(t, body, &spec)
+	if spec.ComplexityScore == nil || *spec.ComplexityScore != 80 || spec.ComplexityLevel == nil || *spec.ComplexityLevel != "high" {
+		t.Errorf("GetSpec complexity = %+v", spec)
+	}
+	spec = item{}
+	_, body = apiRequest(t, app, "GET", "/api/specs/"+unscored, "", key)
+	decodeJSON(t, body, &spec)
+	if spec.ComplexityScore != nil || spec.ComplexityLevel != nil {
+		t.Errorf("unscored spec complexity = %+v, want null", spec)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDeleteSpecCleanup(t *testing.T) {
+	pool := dbtest.New(t)
+	t.Setenv("GIT_REPO_URL", "")
+	mock := &vectorMock{failing: map[string]bool{}}
+	newLLMBackend(t, mock.ServeHTTP)
+	app := newTestAPI(pool)
+	ws := newTestWorkspace(t, pool, "delete-key")
+	headers := map[string]string{middleware.APIKeyHeader: "delete-key"}
+
+	for _, tt := range []struct {
+		name        string
+		vectorFails bool
+		want        string
+	}{
+		{"vector deleted", false, "success"},
+		// The spec stays deleted when the vector store fails after the commit
+		{"vector store down", true, "failed"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			specID := newTestSpec(t, pool, &ws, nil)
+			mock.mu.Lock()
+			mock.failing[specID] = tt.vectorFails
+			mock.mu.Unlock()
+			mock.take()
+
+			status, body := apiRequest(t, app, "DELETE", "/api/specs/"+specID, "", headers)
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d: %s", status, body)
+			}
+			var got map[string]string
+			decodeJSON(t, body, &got)
+			if got["vector_cleanup"] != tt.want {
+				t.Errorf("vector_cleanup = %q, want %q", got["vector_cleanup"], tt.want)
+			}
+			if n := countRows(t, pool, "game_specs", "id = $1", specID); n != 0 {
+				t.Errorf("spec still exists")
+			}
+			var wantDeletes []string
+			if !tt.vectorFails {
+				wantDeletes = []string{vectorID(&ws, specID)}
+			}
+			if _, deletes := mock.take(); !reflect.DeepEqual(deletes, wantDeletes) {
+				t.Errorf("deletes = %v, want %v", deletes, wantDeletes)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// devinMock creates numbered sessions and reports the status set for each
+type devinMock struct {
+	mu       sync.Mutex
+	created  int
+	statuses map[string]string
+}
+
+func (m *devinMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/sessions":
+		m.created++
+		id := fmt.Sprintf("s%d", m.created)
+		json.NewEncoder(w).Encode(map[string]string{"session_id": "devin-" + id, "url": "https://app.devin.ai/sessions/" + id})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/session/devin-"):
+		status, ok := m.statuses[strings.TrimPrefix(r.URL.Path, "/session/devin-")]
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status_enum": status})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (m *devinMock) sessionsCreated() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.created
+}
+
+func (m *devinMock) setStatus(id, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses[id] = status
+}
+
+func TestCreateDevinTask(t *testing.T) {
+	pool := dbtest.New(t)
+	mock := &devinMock{statuses: map[string]string{}}
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+	t.Setenv("DEVIN_API_KEY", "devin-key")
+	t.Setenv("DEVIN_API_URL", srv.URL+"/sessions")
+	t.Setenv("DEVIN_SESSION_API_URL", srv.URL+"/session")
+	t.Setenv("DEVIN_MAX_ATTEMPTS", "1")
+	t.Setenv("DEVIN_PROMPT_TEMPLATE_FILE", "")
+	t.Setenv("GIT_REPO_PATH", t.TempDir())
+	t.Setenv("GIT_REPO_URL", "https://github.com/acme/games")
+	t.Setenv("GIT_TOKEN", "token")
+	t.Setenv("GIT_REPO_PER_GAME", "false")
+
+	ws := newTestWorkspace(t, pool, "devin-key")
+	specID := newTestSpec(t, pool, &ws, nil)
+	// Registered after the /api group, so it sits behind its middleware
+	app := newTestAPI(pool)
+	app.Post("/api/specs/:id/devin-task", CreateDevinTask(pool))
+	app.Get("/api/specs/:id/devin-sessions", GetDevinSessions(pool))
+	headers := map[string]string{middleware.APIKeyHeader: "devin-key"}
+
+	type taskResp struct {
+		SessionID     string `json:"session_id"`
+		SessionURL    string `json:"session_url"`
+		SessionStatus string `json:"session_status"`
+		AlreadyExists bool   `json:"already_exists"`
+	}
+	createTask := func(t *testing.T, query string) taskResp {
+		t.Helper()
+		status, body := apiRequest(t, app, "POST", "/api/specs/"+specID+"/devin-task"+query, "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var resp taskResp
+		decodeJSON(t, body, &resp)
+		return resp
+	}
+	stored := func(t *testing.T) (string, string) {
+		t.Helper()
+		var sessionID, status string
+		err := pool.QueryRow(context.Background(), `SELECT devin_session_id, devin_status FROM game_specs WHERE id = $1`, specID).Scan(&sessionID, &status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sessionID, status
+	}
+	check := func(t *testing.T, resp taskResp, sessionID string, alreadyExists bool, created int) {
+		t.Helper()
+		if resp.SessionID != sessionID || resp.AlreadyExists != alreadyExists {
+			t.Errorf("session %s (already_exists %v), want %s (%v)", resp.SessionID, resp.AlreadyExists, sessionID, alreadyExists)
+		}
+		if n := mock.sessionsCreated(); n != created {
+			t.Errorf("%d sessions created, want %d", n, created)
+		}
+		if id, _ := stored(t); id != sessionID {
+			t.Errorf("stored session %s, want %s", id, sessionID)
+		}
+	}
+
+	t.Run("first call creates a session", func(t *testing.T) {
+		resp := createTask(t, "")
+		check(t, resp, "s1", false, 1)
+		if resp.SessionURL != "https://app.devin.ai/sessions/s1" {
+			t.Errorf("session_url = %s", resp.SessionURL)
+		}
+		if _, status := stored(t); status != "working" {
+			t.Errorf("stored status %s, want working", status)
+		}
+	})
+
+	t.Run("running session is reused", func(t *testing.T) {
+		mock.setStatus("s1", "blocked")
+		resp := createTask(t, "")
+		check(t, resp, "s1", true, 1)
+		if resp.SessionStatus != "blocked" || resp.SessionURL != "https://app.devin.ai/sessions/s1" {
+			t.Errorf("response = %+v", resp)
+		}
+		if _, status := stored(t); status != "blocked" {
+			t.Errorf("refreshed status not stored: %s", status)
+		}
+	})
+
+	t.Run("unreachable status counts as running", func(t *testing.T) {
+		mock.mu.Lock()
+		delete(mock.statuses, "s1")
+		mock.mu.Unlock()
+		check(t, createTask(t, ""), "s1", true, 1)
+	})
+
+	t.Run("force creates a new session", func(t *testing.T) {
+		mock.setStatus("s1", "working")
+		check(t, createTask(t, "?force=true"), "s2", false, 2)
+	})
+
+	t.Run("finished session is replaced", func(t *testing.T) {
+		mock.setStatus("s2", "finished")
+		check(t, createTask(t, ""), "s3", false, 3)
+		if _, status := stored(t); status != "working" {
+			t.Errorf("stored status %s, want working", status)
+		}
+	})
+
+	t.Run("every session is kept", func(t *testing.T) {
+		status, body := apiRequest(t, app, "GET", "/api/specs/"+specID+"/devin-sessions", "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var sessions []DevinSession
+		decodeJSON(t, body, &sessions)
+		var got []string
+		for _, s := range sessions {
+			st := "<nil>"
+			if s.Status != nil {
+				st = *s.Status
+			}
+			got = append(got, s.SessionID+" "+st)
+		}
+		// s1 keeps the last status read before it was replaced by force
+		want := []string{"s3 working", "s2 finished", "s1 blocked"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sessions = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		newTestWorkspace(t, pool, "devin-other")
+		status, _ := apiRequest(t, app, "POST", "/api/specs/"+specID+"/devin-task", "", map[string]string{middleware.APIKeyHeader: "devin-other"})
+		if status != fiber.StatusNotFound {
+			t.Errorf("status = %d, want 404", status)
+		}
+		if n := mock.sessionsCreated(); n != 3 {
+			t.Errorf("%d sessions created, want 3", n)
+		}
+		status, _ = apiRequest(t, app, "GET", "/api/specs/"+specID+"/devin-sessions", "", map[string]string{middleware.APIKeyHeader: "devin-other"})
+		if status != fiber.StatusNotFound {
+			t.Errorf("sessions: status = %d, want 404", status)
+		}
+	})
+
+	claim := func(t *testing.T, age string) {
+		t.Helper()
+		if _, err := pool.Exec(context.Background(), `UPDATE game_specs SET devin_claimed_at = now() - $2::interval WHERE id = $1`, specID, age); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("pending claim is a conflict", func(t *testing.T) {
+		claim(t, "1 minute")
+		status, body := apiRequest(t, app, "POST", "/api/specs/"+specID+"/devin-task?force=true", "", headers)
+		if status != fiber.StatusConflict {
+			t.Errorf("status = %d, want 409: %s", status, body)
+		}
+		if n := mock.sessionsCreated(); n != 3 {
+			t.Errorf("%d sessions created, want 3", n)
+		}
+	})
+
+	t.Run("stale claim is ignored", func(t *testing.T) {
+		claim(t, "1 hour")
+		check(t, createTask(t, "?force=true"), "s4", false, 4)
+		if n := countRows(t, pool, "game_specs", "id = $1 AND devin_claimed_at IS NULL", specID); n != 1 {
+			t.Errorf("claim not dropped once the session was stored")
+		}
+	})
+}
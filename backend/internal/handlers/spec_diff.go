@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DiffSpecs compares the spec_json of two specs and returns the added, removed and changed fields
+func DiffSpecs(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		otherID := c.Params("other_id")
+		if id == otherID {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		load := func(specID string) (map[string]interface{}, error) {
+			sc, err := loadSpec(c, db, specID)
+			if err != nil {
+				return nil, err
+			}
+			var specJSON map[string]interface{}
+			if err := json.Unmarshal(sc.SpecJSON, &specJSON); err != nil {
+				return nil, middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+			}
+			return specJSON, nil
+		}
+
+		from, err := load(id)
+		if err != nil {
+			return err
+		}
+		to, err := load(otherID)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(specschema.DiffSpecJSON(from, to))
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetSpecDuplicates lists the spec jobs that were rejected as duplicates of this spec
+func GetSpecDuplicates(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		rows, err := db.Query(ctx, `
+			SELECT id, brief, status, score_similarity, created_at
+			FROM gen_spec_jobs
+			WHERE duplicate_of @> ARRAY[$1]::uuid[] AND workspace_id IS NOT DISTINCT FROM $2
+			ORDER BY created_at DESC
+		`, id, middleware.WorkspaceID(c))
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to fetch duplicates")
+		}
+		defer rows.Close()
+
+		type duplicate struct {
+			JobID     string    `json:"job_id"`
+			Brief     string    `json:"brief"`
+			Status    string    `json:"status"`
+			Score     *float64  `json:"score"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+
+		duplicates := []duplicate{}
+		for rows.Next() {
+			var d duplicate
+			if err := rows.Scan(&d.JobID, &d.Brief, &d.Status, &d.Score, &d.CreatedAt); err != nil {
+				continue
+			}
+			duplicates = append(duplicates, d)
+		}
+
+		return c.JSON(fiber.Map{
+			"spec_id":         id,
+			"duplicate_count": len(duplicates),
+			"duplicates":      duplicates,
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"backend/internal/cache"
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// embeddingCache holds the embeddings returned by GetSpecEmbedding, keyed by spec id. It is
+// cleared by invalidateSpec and whenever the spec's vector is upserted.
+var embeddingCache = cache.NewLRU[string, []float64](256, 10*time.Minute)
+
+type SpecEmbeddingResp struct {
+	SpecID     string    `json:"spec_id"`
+	Embedding  []float64 `json:"embedding"`
+	Dimensions int       `json:"dimensions"`
+}
+
+// GetSpecEmbedding returns the embedding the vector store holds for a spec, or 404 while the
+// spec has none
+func GetSpecEmbedding(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		workspaceID := middleware.WorkspaceID(c)
+
+		ctx, cancel := queryCtx(c.UserContext())
+		err := requireSpec(ctx, db, c, id)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		embedding, ok := embeddingCache.Get(id)
+		if !ok {
+			embedding, err = fetchSpecEmbedding(c.UserContext(), vectorID(workspaceID, id))
+			if err != nil {
+				return err
+			}
+			embeddingCache.Set(id, embedding)
+		}
+		return c.JSON(SpecEmbeddingResp{SpecID: id, Embedding: embedding, Dimensions: len(embedding)})
+	}
+}
+
+// fetchSpecEmbedding reads the embedding stored under a vector id from the LLM backend
+func fetchSpecEmbedding(ctx context.Context, vectorSpecID string) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.MustGetDuration("VECTOR_HTTP_TIMEOUT", 10*time.Second))
+	defer cancel()
+	llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, llmBackend+"/vector/spec/"+url.PathEscape(vectorSpecID)+"/embedding", nil)
+	if err != nil {
+		return nil, middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, middleware.NewProblem(fiber.StatusBadGateway, "vector lookup failed: "+err.Error()).
+			WithCode(codeVectorUnavailable)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, middleware.NewProblem(fiber.StatusNotFound, "Embedding not computed yet")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, middleware.NewProblem(fiber.StatusBadGateway, fmt.Sprintf("vector status %d", resp.StatusCode)).
+			WithCode(codeVectorUnavailable)
+	}
+	var out struct {
+		Embedding []float64 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, middleware.NewProblem(fiber.StatusBadGateway, err.Error()).
+			WithCode(codeVectorUnavailable)
+	}
+	if len(out.Embedding) == 0 {
+		return nil, middleware.NewProblem(fiber.StatusNotFound, "Embedding not computed yet")
+	}
+	return out.Embedding, nil
+}
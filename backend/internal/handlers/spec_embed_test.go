@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// vectorService mocks the vector endpoints of the LLM backend. Upserting a spec replaces its
+// embedding with next.
+type vectorService struct {
+	mu         sync.Mutex
+	embeddings map[string][]float64
+	next       []float64
+	lookups    int
+	url        string
+	status     int
+}
+
+func newVectorService(t *testing.T) *vectorService {
+	t.Helper()
+	vs := &vectorService{embeddings: map[string][]float64{}}
+	srv := httptest.NewServer(vs)
+	t.Cleanup(srv.Close)
+	vs.url = srv.URL
+	t.Setenv("LLM_BACKEND_URL", srv.URL)
+	return vs
+}
+
+func (vs *vectorService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.status != 0 {
+		w.WriteHeader(vs.status)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/vector/upsert":
+		var up upsertReq
+		if err := json.NewDecoder(r.Body).Decode(&up); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		vs.embeddings[up.SpecID] = vs.next
+		w.Write([]byte(`{}`))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/vector/spec/") && strings.HasSuffix(r.URL.Path, "/embedding"):
+		vs.lookups++
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/vector/spec/"), "/embedding")
+		embedding, ok := vs.embeddings[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embedding": embedding})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (vs *vectorService) set(id string, embedding []float64) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.embeddings[id] = embedding
+}
+
+func (vs *vectorService) lookupCount() int {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	return vs.lookups
+}
+
+func TestFetchSpecEmbedding(t *testing.T) {
+	vs := newVectorService(t)
+	vs.set("ws:spec-1", []float64{0.1, 0.2})
+	vs.set("spec-empty", []float64{})
+
+	got, err := fetchSpecEmbedding(context.Background(), "ws:spec-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []float64{0.1, 0.2}) {
+		t.Errorf("embedding = %v", got)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		status     int
+		wantStatus int
+	}{
+		{name: "no embedding", id: "spec-missing", wantStatus: fiber.StatusNotFound},
+		{name: "empty embedding", id: "spec-empty", wantStatus: fiber.StatusNotFound},
+		{name: "vector service error", id: "ws:spec-1", status: http.StatusInternalServerError, wantStatus: fiber.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs.mu.Lock()
+			vs.status = tt.status
+			vs.mu.Unlock()
+			_, err := fetchSpecEmbedding(context.Background(), tt.id)
+			var p *middleware.Problem
+			if !errors.As(err, &p) || p.Status != tt.wantStatus {
+				t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+
+	t.Setenv("LLM_BACKEND_URL", "http://127.0.0.1:1")
+	_, err = fetchSpecEmbedding(context.Background(), "ws:spec-1")
+	var p *middleware.Problem
+	if !errors.As(err, &p) || p.Status != fiber.StatusBadGateway || p.Code != codeVectorUnavailable {
+		t.Errorf("unreachable service: err = %v, want a 502 %s problem", err, codeVectorUnavailable)
+	}
+}
+
+func TestGetSpecEmbedding(t *testing.T) {
+	pool := dbtest.New(t)
+	vs := newVectorService(t)
+	app := newTestAPI(pool)
+
+	workspaceID := newTestWorkspace(t, pool, "embed-key")
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	key := map[string]string{middleware.APIKeyHeader: "embed-key"}
+	path := "/api/specs/" + specID + "/embed"
+
+	// No embedding upserted yet
+	if status, body := apiRequest(t, app, "GET", path, "", key); status != fiber.StatusNotFound {
+		t.Fatalf("status without embedding = %d, body %s", status, body)
+	}
+
+	vs.set(vectorID(&workspaceID, specID), []float64{1, 2, 3})
+	var resp SpecEmbeddingResp
+	for i := 0; i < 2; i++ {
+		status, body := apiRequest(t, app, "GET", path, "", key)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d, body %s", status, body)
+		}
+		decodeJSON(t, body, &resp)
+	}
+	if resp.SpecID != specID || resp.Dimensions != 3 || !reflect.DeepEqual(resp.Embedding, []float64{1, 2, 3}) {
+		t.Errorf("response = %+v", resp)
+	}
+	// The 404 above and the first successful read hit the service, the second read is cached
+	if n := vs.lookupCount(); n != 2 {
+		t.Errorf("vector service lookups = %d, want 2", n)
+	}
+
+	// Updating the spec upserts a new vector, which evicts the cached embedding
+	vs.mu.Lock()
+	vs.next = []float64{4, 5}
+	vs.mu.Unlock()
+	up := upsertReq{SpecID: vectorID(&workspaceID, specID), Text: "updated", Namespace: vectorNamespace(&workspaceID)}
+	if _, err := upsertSpecVector(context.Background(), vs.url, up); err != nil {
+		t.Fatal(err)
+	}
+	status, body := apiRequest(t, app, "GET", path, "", key)
+	if status != fiber.StatusOK {
+		t.Fatalf("status after update = %d, body %s", status, body)
+	}
+	decodeJSON(t, body, &resp)
+	if !reflect.DeepEqual(resp.Embedding, []float64{4, 5}) {
+		t.Errorf("embedding after update = %v, want [4 5]", resp.Embedding)
+	}
+
+	// So does any other change to the spec
+	vs.set(vectorID(&workspaceID, specID), []float64{6})
+	invalidateSpec(specID)
+	_, body = apiRequest(t, app, "GET", path, "", key)
+	decodeJSON(t, body, &resp)
+	if !reflect.DeepEqual(resp.Embedding, []float64{6}) {
+		t.Errorf("embedding after invalidateSpec = %v, want [6]", resp.Embedding)
+	}
+
+	// Other workspaces can't read it
+	newTestWorkspace(t, pool, "other-key")
+	if status, _ := apiRequest(t, app, "GET", path, "", map[string]string{middleware.APIKeyHeader: "other-key"}); status != fiber.StatusNotFound {
+		t.Errorf("status for another workspace = %d, want 404", status)
+	}
+}
+
+func TestEmbeddingCacheEviction(t *testing.T) {
+	vs := newVectorService(t)
+	workspaceID := "ws-evict"
+	specID := "spec-evict"
+
+	embeddingCache.Set(specID, []float64{1})
+	up := upsertReq{SpecID: vectorID(&workspaceID, specID), Text: "updated", Namespace: vectorNamespace(&workspaceID)}
+	if _, err := upsertSpecVector(context.Background(), vs.url, up); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := embeddingCache.Get(specID); ok {
+		t.Error("upsertSpecVector kept the cached embedding")
+	}
+
+	embeddingCache.Set(specID, []float64{1})
+	invalidateSpec(specID)
+	if _, ok := embeddingCache.Get(specID); ok {
+		t.Error("invalidateSpec kept the cached embedding")
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"context"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	estimateTimeout = 10 * time.Second
+	// tokensPerWord approximates the tokens of an English brief when the LLM backend can't count them
+	tokensPerWord = 1.3
+	// The completion budgets of /llm/generate-spec, which are assumed to be used in full
+	specCompletionTokens     = 3000
+	tutorialCompletionTokens = 1500
+)
+
+// EstimateSpec predicts the tokens and cost of a spec job for a brief without generating it. The
+// LLM backend counts the tokens of the full prompt; when it can't, the brief's words are counted
+// instead. The cost uses LLM_COST_PER_1K_TOKENS.
+func EstimateSpec() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req CreateJobReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+		}
+		if err := normalizeJobReq(&req); err != nil {
+			return err
+		}
+
+		method := "llm"
+		ctx, cancel := context.WithTimeout(c.UserContext(), estimateTimeout)
+		defer cancel()
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		est, err := llm.NewHTTPClient(llmBackend, specModel()).EstimateTokens(ctx, req.Brief, req.Constraints, req.Params, req.IncludeTutorial)
+		if err != nil {
+			log.Printf("[WARNING] Falling back to the word count estimate: %v", err)
+			est = heuristicEstimate(req)
+			method = "heuristic"
+		}
+
+		cost := float64(est.EstimatedTotalTokens) / 1000 * config.MustGetFloat("LLM_COST_PER_1K_TOKENS", 0.005)
+		return c.JSON(fiber.Map{
+			"prompt_tokens":          est.PromptTokens,
+			"estimated_total_tokens": est.EstimatedTotalTokens,
+			"estimated_cost_usd":     math.Round(cost*1e6) / 1e6,
+			"method":                 method,
+		})
+	}
+}
+
+// heuristicEstimate counts ~1.3 tokens per word of the brief plus the completion budgets
+func heuristicEstimate(req CreateJobReq) llm.TokenEstimate {
+	prompt := int(math.Ceil(float64(len(strings.Fields(req.Brief))) * tokensPerWord))
+	completion := specCompletionTokens
+	if maxTokens, ok := req.Params["max_tokens"].(float64); ok && maxTokens > 0 {
+		completion = int(maxTokens)
+	}
+	if req.IncludeTutorial {
+		completion += tutorialCompletionTokens
+	}
+	return llm.TokenEstimate{PromptTokens: prompt, EstimatedTotalTokens: prompt + completion}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type estimateResp struct {
+	PromptTokens         int     `json:"prompt_tokens"`
+	EstimatedTotalTokens int     `json:"estimated_total_tokens"`
+	EstimatedCostUSD     float64 `json:"estimated_cost_usd"`
+	Method               string  `json:"method"`
+}
+
+func newEstimateApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Post("/api/spec-jobs/estimate", EstimateSpec())
+	return app
+}
+
+func TestEstimateSpecLLM(t *testing.T) {
+	t.Setenv("LLM_COST_PER_1K_TOKENS", "0.002")
+	var sent map[string]interface{}
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/llm/estimate-tokens" {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"prompt_tokens": 420, "estimated_total_tokens": 2100}`))
+	})
+
+	status, body := apiRequest(t, newEstimateApp(), "POST", "/api/spec-jobs/estimate",
+		`{"brief":"  A cat collects yarn  ","constraints":{"target_platform":"web"},"include_tutorial":true}`, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	var got estimateResp
+	decodeJSON(t, body, &got)
+	if want := (estimateResp{420, 2100, 0.0042, "llm"}); got != want {
+		t.Errorf("estimate = %+v, want %+v", got, want)
+	}
+	if sent["brief"] != "A cat collects yarn" || sent["include_tutorial"] != true || sent["constraints"] == nil || sent["model"] == "" {
+		t.Errorf("LLM backend was sent %v", sent)
+	}
+}
+
+func TestEstimateSpecHeuristic(t *testing.T) {
+	t.Setenv("LLM_COST_PER_1K_TOKENS", "0.005")
+	// The backend predates /llm/estimate-tokens
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	tests := []struct {
+		name string
+		body string
+		want estimateResp
+	}{
+		// 10 words * 1.3 = 13 prompt tokens
+		{"brief only", `{"brief":"one two three four five six seven eight nine ten"}`, estimateResp{13, 13 + 3000, 0.015065, "heuristic"}},
+		{"with tutorial", `{"brief":"one two three","include_tutorial":true}`, estimateResp{4, 4 + 3000 + 1500, 0.02252, "heuristic"}},
+		{"max_tokens caps the completion", `{"brief":"one two three","params":{"max_tokens":500}}`, estimateResp{4, 504, 0.00252, "heuristic"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := apiRequest(t, newEstimateApp(), "POST", "/api/spec-jobs/estimate", tt.body, nil)
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d: %s", status, body)
+			}
+			var got estimateResp
+			decodeJSON(t, body, &got)
+			if got != tt.want {
+				t.Errorf("estimate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateSpecValidation(t *testing.T) {
+	for _, body := range []string{`{"brief":"   "}`, `{"brief":"a","constraints":{"target_platform":"tv"}}`, `not json`} {
+		if status, resp := apiRequest(t, newEstimateApp(), "POST", "/api/spec-jobs/estimate", body, nil); status < 400 || status >= 500 {
+			t.Errorf("%s: status = %d, want a client error: %s", body, status, resp)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxFeedbackCommentLength = 2000
+
+type CreateFeedbackReq struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type SpecFeedback struct {
+	ID        string    `json:"id"`
+	SpecID    string    `json:"spec_id"`
+	Rating    int       `json:"rating"`
+	Comment   *string   `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostSpecFeedback records a 1-5 quality rating for a spec. A trigger keeps the spec's
+// average_rating and feedback_count up to date (migration 0020).
+func PostSpecFeedback(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		var req CreateFeedbackReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid request body")
+		}
+		if req.Rating < 1 || req.Rating > 5 {
+			return middleware.NewProblem(fiber.StatusBadRequest, "rating must be between 1 and 5")
+		}
+		req.Comment = strings.TrimSpace(req.Comment)
+		if len(req.Comment) > maxFeedbackCommentLength {
+			return middleware.NewProblem(fiber.StatusBadRequest, "comment is too long").
+				With("max_length", maxFeedbackCommentLength)
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		fb := SpecFeedback{ID: uuid.New().String(), SpecID: id, Rating: req.Rating}
+		if req.Comment != "" {
+			fb.Comment = &req.Comment
+		}
+		err := db.QueryRow(ctx, `
+			INSERT INTO spec_feedback (id, spec_id, rating, comment)
+			VALUES ($1, $2, $3, $4)
+			RETURNING created_at
+		`, fb.ID, fb.SpecID, fb.Rating, fb.Comment).Scan(&fb.CreatedAt)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to save feedback")
+		}
+		invalidateSpec(id)
+
+		return c.Status(fiber.StatusCreated).JSON(fb)
+	}
+}
+
+// GetSpecFeedback lists all feedback of a spec, newest first. This route is guarded by
+// middleware.RequireAdmin.
+func GetSpecFeedback(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		var exists bool
+		if err := db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM game_specs WHERE id = $1)", id).Scan(&exists); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		if !exists {
+			return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
+		}
+
+		rows, err := db.Query(ctx, `
+			SELECT id, spec_id, rating, comment, created_at
+			FROM spec_feedback
+			WHERE spec_id = $1
+			ORDER BY created_at DESC
+		`, id)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to fetch feedback")
+		}
+		defer rows.Close()
+
+		feedback := []SpecFeedback{}
+		for rows.Next() {
+			var fb SpecFeedback
+			if err := rows.Scan(&fb.ID, &fb.SpecID, &fb.Rating, &fb.Comment, &fb.CreatedAt); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read feedback")
+			}
+			feedback = append(feedback, fb)
+		}
+
+		return c.JSON(feedback)
+	}
+}
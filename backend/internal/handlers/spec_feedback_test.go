@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newFeedbackApp serves the feedback routes like cmd/server: the admin listing is registered
+// before the workspace group
+func newFeedbackApp(pool *pgxpool.Pool) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/api/specs/:id/feedback", middleware.RequireAdmin(), GetSpecFeedback(pool))
+	api := app.Group("/api", middleware.Workspace(pool), middleware.User())
+	api.Get("/specs", ListSpecs(pool))
+	api.Get("/specs/:id", GetSpec(pool))
+	api.Post("/specs/:id/feedback", PostSpecFeedback(pool))
+	return app
+}
+
+func TestSpecFeedback(t *testing.T) {
+	pool := dbtest.New(t)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	app := newFeedbackApp(pool)
+
+	ws := newTestWorkspace(t, pool, "feedback-key")
+	newTestWorkspace(t, pool, "feedback-other")
+	key := map[string]string{middleware.APIKeyHeader: "feedback-key"}
+	admin := map[string]string{middleware.APIKeyHeader: "admin-secret"}
+	rated := newTestSpec(t, pool, &ws, nil)
+	unrated := newTestSpec(t, pool, &ws, nil)
+	path := "/api/specs/" + rated + "/feedback"
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			body       string
+			headers    map[string]string
+			path       string
+			wantStatus int
+		}{
+			{name: "rating too low", body: `{"rating":0}`, wantStatus: fiber.StatusBadRequest},
+			{name: "rating too high", body: `{"rating":6}`, wantStatus: fiber.StatusBadRequest},
+			{name: "missing rating", body: `{"comment":"nice"}`, wantStatus: fiber.StatusBadRequest},
+			{name: "malformed", body: `{"rating":`, wantStatus: fiber.StatusBadRequest},
+			{name: "comment too long", body: fmt.Sprintf(`{"rating":3,"comment":%q}`, strings.Repeat("x", maxFeedbackCommentLength+1)), wantStatus: fiber.StatusBadRequest},
+			{name: "unknown spec", body: `{"rating":3}`, path: "/api/specs/00000000-0000-0000-0000-000000000000/feedback", wantStatus: fiber.StatusNotFound},
+			{name: "other workspace", body: `{"rating":3}`, headers: map[string]string{middleware.APIKeyHeader: "feedback-other"}, wantStatus: fiber.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				p, h := path, key
+				if tt.path != "" {
+					p = tt.path
+				}
+				if tt.headers != nil {
+					h = tt.headers
+				}
+				if status, body := apiRequest(t, app, "POST", p, tt.body, h); status != tt.wantStatus {
+					t.Errorf("status = %d, want %d: %s", status, tt.wantStatus, body)
+				}
+			})
+		}
+	})
+
+	// Read the spec first so the aggregate must come from a fresh query, not the cache
+	apiRequest(t, app, "GET", "/api/specs/"+rated, "", key)
+
+	for _, fb := range []string{`{"rating":5,"comment":"  Loved it  "}`, `{"rating":4}`, `{"rating":2,"comment":"too hard"}`} {
+		status, body := apiRequest(t, app, "POST", path, fb, key)
+		if status != fiber.StatusCreated {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+	}
+
+	type aggregate struct {
+		ID            string   `json:"id"`
+		AverageRating *float64 `json:"average_rating"`
+		FeedbackCount int      `json:"feedback_count"`
+	}
+	var spec aggregate
+	_, body := apiRequest(t, app, "GET", "/api/specs/"+rated, "", key)
+	decodeJSON(t, body, &spec)
+	if spec.FeedbackCount != 3 || spec.AverageRating == nil || math.Abs(*spec.AverageRating-11.0/3) > 1e-9 {
+		t.Errorf("GetSpec aggregate = count %d, average %v, want 3 and 3.67", spec.FeedbackCount, spec.AverageRating)
+	}
+
+	t.Run("min_rating", func(t *testing.T) {
+		for _, tt := range []struct {
+			query string
+			want  []string
+		}{
+			{"1", []string{rated}},
+			{"3.5", []string{rated}},
+			{"4", nil},
+		} {
+			status, body := apiRequest(t, app, "GET", "/api/specs?min_rating="+tt.query, "", key)
+			if status != fiber.StatusOK {
+				t.Fatalf("min_rating=%s: status = %d: %s", tt.query, status, body)
+			}
+			var items []aggregate
+			decodeJSON(t, body, &items)
+			if len(items) != len(tt.want) || (len(items) == 1 && items[0].ID != tt.want[0]) {
+				t.Errorf("min_rating=%s lists %+v, want %v", tt.query, items, tt.want)
+			}
+		}
+		for _, bad := range []string{"0", "6", "good"} {
+			if status, _ := apiRequest(t, app, "GET", "/api/specs?min_rating="+bad, "", key); status != fiber.StatusBadRequest {
+				t.Errorf("min_rating=%s: status = %d, want 400", bad, status)
+			}
+		}
+
+		// Specs without feedback are listed without a filter, with a null average
+		var items []aggregate
+		_, body := apiRequest(t, app, "GET", "/api/specs", "", key)
+		decodeJSON(t, body, &items)
+		for _, it := range items {
+			if it.ID == unrated && (it.AverageRating != nil || it.FeedbackCount != 0) {
+				t.Errorf("unrated spec = %+v", it)
+			}
+		}
+	})
+
+	t.Run("admin listing", func(t *testing.T) {
+		if status, _ := apiRequest(t, app, "GET", path, "", key); status != fiber.StatusUnauthorized {
+			t.Errorf("workspace key: status = %d, want 401", status)
+		}
+		status, body := apiRequest(t, app, "GET", path, "", admin)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var feedback []SpecFeedback
+		decodeJSON(t, body, &feedback)
+		if len(feedback) != 3 {
+			t.Fatalf("%d feedback entries, want 3", len(feedback))
+		}
+		// Newest first
+		if feedback[0].Rating != 2 || feedback[2].Rating != 5 {
+			t.Errorf("order = %d, %d, %d", feedback[0].Rating, feedback[1].Rating, feedback[2].Rating)
+		}
+		if feedback[2].Comment == nil || *feedback[2].Comment != "Loved it" || feedback[1].Comment != nil {
+			t.Errorf("comments = %v, %v", feedback[2].Comment, feedback[1].Comment)
+		}
+
+		status, body = apiRequest(t, app, "GET", "/api/specs/"+unrated+"/feedback", "", admin)
+		if status != fiber.StatusOK || strings.TrimSpace(string(body)) != "[]" {
+			t.Errorf("spec without feedback: %d %s, want an empty list", status, body)
+		}
+		if status, _ := apiRequest(t, app, "GET", "/api/specs/00000000-0000-0000-0000-000000000000/feedback", "", admin); status != fiber.StatusNotFound {
+			t.Errorf("unknown spec: status = %d, want 404", status)
+		}
+	})
+
+	t.Run("admin disabled", func(t *testing.T) {
+		t.Setenv("ADMIN_API_KEY", "")
+		if status, _ := apiRequest(t, app, "GET", path, "", admin); status != fiber.StatusForbidden {
+			t.Errorf("status = %d, want 403", status)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// completedOutputPath returns the game folder of the latest completed code job of a spec
+func completedOutputPath(c *fiber.Ctx, db *pgxpool.Pool, specID string) (string, error) {
+	ctx, cancel := queryCtx(c.UserContext())
+	defer cancel()
+	if err := requireSpec(ctx, db, c, specID); err != nil {
+		return "", err
+	}
+
+	var outputPath *string
+	err := db.QueryRow(ctx, `
+		SELECT output_path
+		FROM code_jobs
+		WHERE game_spec_id = $1 AND status = 'completed' AND workspace_id IS NOT DISTINCT FROM $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, specID, middleware.WorkspaceID(c)).Scan(&outputPath)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", middleware.NewProblem(fiber.StatusNotFound, "No completed code job for this spec")
+		}
+		return "", middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+	if outputPath == nil || *outputPath == "" {
+		return "", middleware.NewProblem(fiber.StatusNotFound, "Generated files not found")
+	}
+	if info, err := os.Stat(*outputPath); err != nil || !info.IsDir() {
+		return "", middleware.NewProblem(fiber.StatusNotFound, "Generated files not found")
+	}
+	return *outputPath, nil
+}
+
+// GetSpecFiles lists the files generated by the latest completed code job of a spec
+func GetSpecFiles(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		outputPath, err := completedOutputPath(c, db, id)
+		if err != nil {
+			return err
+		}
+
+		files, err := utils.ListFiles(outputPath)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to list generated files")
+		}
+		return c.JSON(files)
+	}
+}
+
+const defaultMaxFileServeBytes = 2 << 20
+
+// GetSpecFile serves a single generated file inline. Files are sandboxed so generated scripts
+// can't act on the API origin.
+func GetSpecFile(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		requested, err := url.PathUnescape(c.Params("*"))
+		if err != nil || requested == "" {
+			return middleware.NewProblem(fiber.StatusBadRequest, "File path is required")
+		}
+		// Reject traversal before touching the filesystem
+		if err := checkRequestedPath(requested); err != nil {
+			return err
+		}
+
+		outputPath, err := completedOutputPath(c, db, id)
+		if err != nil {
+			return err
+		}
+		return serveGeneratedFile(c, outputPath, requested, "sandbox")
+	}
+}
+
+// checkRequestedPath rejects a requested file path with a .. segment, or one inside .git
+func checkRequestedPath(requested string) error {
+	for _, part := range strings.Split(filepath.ToSlash(requested), "/") {
+		if part == ".." {
+			return middleware.NewProblem(fiber.StatusForbidden, "Invalid file path")
+		}
+		// .git is never listed, and clones keep the authenticated remote URL in .git/config
+		if part == ".git" {
+			return middleware.NewProblem(fiber.StatusNotFound, "File not found")
+		}
+	}
+	return nil
+}
+
+// inlineFileTypes are the extensions served inline. Anything else, notably svg, xhtml and xml
+// which browsers run scripts in, is sent as an attachment.
+var inlineFileTypes = map[string]bool{
+	".html": true, ".htm": true, ".js": true, ".mjs": true, ".css": true, ".json": true, ".map": true,
+	".txt": true, ".md": true, ".wasm": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".ico": true,
+	".mp3": true, ".ogg": true, ".wav": true, ".woff": true, ".woff2": true, ".ttf": true,
+}
+
+// serveGeneratedFile sends the file at requested under root with its content type, refusing
+// anything that resolves outside root. Every response carries csp as its Content-Security-Policy.
+func serveGeneratedFile(c *fiber.Ctx, root, requested, csp string) error {
+	root = filepath.Clean(root)
+	target := filepath.Join(root, filepath.Clean("/"+requested))
+	if !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+		return middleware.NewProblem(fiber.StatusForbidden, "Invalid file path")
+	}
+	// Symlinks must not lead outside the game folder either
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		if realRoot, err := filepath.EvalSymlinks(root); err == nil && !strings.HasPrefix(resolved, realRoot+string(os.PathSeparator)) {
+			return middleware.NewProblem(fiber.StatusForbidden, "Invalid file path")
+		}
+	}
+
+	info, err := os.Stat(target)
+	if err != nil || info.IsDir() {
+		return middleware.NewProblem(fiber.StatusNotFound, "File not found")
+	}
+	maxBytes := int64(config.MustGetInt("MAX_FILE_SERVE_BYTES", defaultMaxFileServeBytes))
+	if info.Size() > maxBytes {
+		return middleware.NewProblem(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds %d bytes", maxBytes))
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	ext := strings.ToLower(filepath.Ext(target))
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set("Content-Security-Policy", csp)
+	if !inlineFileTypes[ext] {
+		c.Attachment(filepath.Base(target))
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set("X-Content-Type-Options", "nosniff")
+	return c.Send(content)
+}
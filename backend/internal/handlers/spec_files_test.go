@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckRequestedPath(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"index.html", 0},
+		{"assets/sprite.png", 0},
+		{"..", fiber.StatusForbidden},
+		{"../secret", fiber.StatusForbidden},
+		{"assets/../../secret", fiber.StatusForbidden},
+		{`assets\..\..\secret`, 0}, // a backslash is part of the name on Linux
+		{".git/config", fiber.StatusNotFound},
+		{"sub/.git/HEAD", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := checkRequestedPath(tt.path)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("checkRequestedPath() = %v, want nil", err)
+				}
+				return
+			}
+			p, ok := err.(*middleware.Problem)
+			if !ok || p.Status != tt.wantStatus {
+				t.Fatalf("checkRequestedPath() = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// newGeneratedFileApp serves root like GetSpecFile does, taking the file path from ?path= so the
+// router doesn't clean it first
+func newGeneratedFileApp(root string) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/file", func(c *fiber.Ctx) error {
+		requested, err := url.QueryUnescape(c.Query("path"))
+		if err != nil {
+			return err
+		}
+		if err := checkRequestedPath(requested); err != nil {
+			return err
+		}
+		return serveGeneratedFile(c, root, requested, "sandbox")
+	})
+	return app
+}
+
+func TestServeGeneratedFile(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "game")
+	files := map[string]string{
+		"index.html":        "<html></html>",
+		"game.js":           "console.log(1)",
+		"assets/logo.svg":   "<svg><script>alert(1)</script></svg>",
+		"page.xhtml":        "<html/>",
+		"data.xml":          "<a/>",
+		"assets/sprite.png": "png",
+	}
+	for name, body := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	app := newGeneratedFileApp(root)
+
+	tests := []struct {
+		path           string
+		wantStatus     int
+		wantAttachment bool
+	}{
+		{"index.html", fiber.StatusOK, false},
+		{"game.js", fiber.StatusOK, false},
+		{"assets/sprite.png", fiber.StatusOK, false},
+		{"assets/logo.svg", fiber.StatusOK, true},
+		{"page.xhtml", fiber.StatusOK, true},
+		{"data.xml", fiber.StatusOK, true},
+		{"../secret.txt", fiber.StatusForbidden, false},
+		{"assets/../../secret.txt", fiber.StatusForbidden, false},
+		{"link.txt", fiber.StatusForbidden, false},
+		{"missing.js", fiber.StatusNotFound, false},
+		{"assets", fiber.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/file?path="+url.QueryEscape(tt.path), nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			if got := resp.Header.Get("Content-Security-Policy"); got != "sandbox" {
+				t.Errorf("Content-Security-Policy = %q, want sandbox", got)
+			}
+			if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+			disposition := resp.Header.Get(fiber.HeaderContentDisposition)
+			if attachment := strings.HasPrefix(disposition, "attachment"); attachment != tt.wantAttachment {
+				t.Errorf("Content-Disposition = %q, want attachment %v", disposition, tt.wantAttachment)
+			}
+		})
+	}
+}
+
+func TestServeGeneratedFileTooLarge(t *testing.T) {
+	t.Setenv("MAX_FILE_SERVE_BYTES", "4")
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "big.js"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := newGeneratedFileApp(root).Test(httptest.NewRequest("GET", "/file?path=big.js", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestGetSpecFiles(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+
+	workspaceID := newTestWorkspace(t, pool, "files-key")
+	key := map[string]string{middleware.APIKeyHeader: "files-key"}
+
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	path := "/api/specs/" + specID + "/files"
+	if status, body := apiRequest(t, app, "GET", path, "", key); status != fiber.StatusNotFound {
+		t.Fatalf("status without a completed job = %d, want 404: %s", status, body)
+	}
+
+	outputPath := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html":     "<html></html>",
+		"js/game.js":     "start()",
+		".git/HEAD":      "ref: refs/heads/main",
+		"assets/cat.png": "png",
+	} {
+		p := filepath.Join(outputPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newTestCodeJob(t, pool, specID, &workspaceID, outputPath)
+
+	status, body := apiRequest(t, app, "GET", path, "", key)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	var files []utils.FileInfo
+	decodeJSON(t, body, &files)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if strings.Join(paths, ",") != "assets/cat.png,index.html,js/game.js" {
+		t.Errorf("paths = %v", paths)
+	}
+	if len(files) > 1 && (files[1].SizeBytes != 13 || files[1].FileType != "html") {
+		t.Errorf("index.html = %+v", files[1])
+	}
+
+	// The job is still recorded but its folder is gone
+	if err := os.RemoveAll(outputPath); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := apiRequest(t, app, "GET", path, "", key); status != fiber.StatusNotFound {
+		t.Errorf("status after removing output_path = %d, want 404: %s", status, body)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"backend/internal/content"
+	"backend/internal/dbtest"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHashSpec(t *testing.T) {
+	parse := func(s string) map[string]interface{} {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	a, err := hashSpec(parse(`{"title":"Yarn Cat","controls":{"jump":"space","move":"arrows"},"mechanics":["jump"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Key order doesn't matter, the document does
+	b, _ := hashSpec(parse(`{"mechanics":["jump"],"controls":{"move":"arrows","jump":"space"},"title":"Yarn Cat"}`))
+	if a != b {
+		t.Errorf("reordered keys hash differently: %s != %s", a, b)
+	}
+	if len(a) != 64 {
+		t.Errorf("hash %q is not a hex sha256", a)
+	}
+	for _, other := range []string{
+		`{"title":"Yarn Cat","controls":{"jump":"space","move":"arrows"},"mechanics":["roll"]}`,
+		`{"title":"Yarn Cat","controls":{"jump":"space","move":"arrows"},"mechanics":["jump"],"genre":"puzzle"}`,
+	} {
+		if h, _ := hashSpec(parse(other)); h == a {
+			t.Errorf("%s hashes like the original", other)
+		}
+	}
+}
+
+func TestFindSpecByHash(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	ws := newTestWorkspace(t, pool, "hash-find")
+	other := newTestWorkspace(t, pool, "hash-find-other")
+	specID := newTestSpec(t, pool, &ws, nil)
+
+	// newTestSpec uses the spec id as its hash
+	if got, err := findSpecByHash(ctx, pool, &ws, specID); err != nil || got != specID {
+		t.Errorf("findSpecByHash = %q, %v, want %q", got, err, specID)
+	}
+	if got, err := findSpecByHash(ctx, pool, &other, specID); err != nil || got != "" {
+		t.Errorf("findSpecByHash in another workspace = %q, %v, want none", got, err)
+	}
+	if got, err := findSpecByHash(ctx, pool, nil, specID); err != nil || got != "" {
+		t.Errorf("findSpecByHash in the shared workspace = %q, %v, want none", got, err)
+	}
+}
+
+func TestCompleteSpecJobHashDuplicate(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/vector/search":
+			// Nothing scores above the threshold, so only the hash catches the copy
+			w.Write([]byte(`{"similar":[]}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ws := newTestWorkspace(t, pool, "hash-duplicate")
+	g := testGenSpecResp()
+	hash, err := hashSpec(g.SpecJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existingID := newTestSpec(t, pool, &ws, nil)
+	if _, err := pool.Exec(ctx, `UPDATE game_specs SET spec_hash = $1 WHERE id = $2`, hash, existingID); err != nil {
+		t.Fatal(err)
+	}
+	jobID := newTestSpecJob(t, pool, ws)
+
+	result, err := completeSpecJob(ctx, pool, &ws, jobID, CreateJobReq{Brief: "A cat game"}, "test-model", g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["status"] != "HASH_DUPLICATE" || result["result_spec_id"] != existingID {
+		t.Errorf("result = %v, want HASH_DUPLICATE of %s", result, existingID)
+	}
+
+	if got := jobStatus(t, pool, jobID); got != "HASH_DUPLICATE" {
+		t.Errorf("job status = %s, want HASH_DUPLICATE", got)
+	}
+	var resultSpecID string
+	if err := pool.QueryRow(ctx, `SELECT result_spec_id FROM gen_spec_jobs WHERE id = $1`, jobID).Scan(&resultSpecID); err != nil {
+		t.Fatal(err)
+	}
+	if resultSpecID != existingID {
+		t.Errorf("result_spec_id = %s, want %s", resultSpecID, existingID)
+	}
+	if n := countRows(t, pool, "game_specs", "workspace_id = $1", ws); n != 1 {
+		t.Errorf("%d specs in the workspace, want only the existing one", n)
+	}
+}
+
+// TestPersistSpecConcurrentDuplicates races identical jobs past the hash lookup, as two jobs
+// created close together do, and resolves the losers the way completeSpecJob does
+func TestPersistSpecConcurrentDuplicates(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	ws := newTestWorkspace(t, pool, "hash-race")
+	g := testGenSpecResp()
+	hash, err := hashSpec(g.SpecJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const jobs = 8
+	jobIDs := make([]string, jobs)
+	for i := range jobIDs {
+		jobIDs[i] = newTestSpecJob(t, pool, ws)
+	}
+	if existing, err := findSpecByHash(ctx, pool, &ws, hash); err != nil || existing != "" {
+		t.Fatalf("findSpecByHash before the race = %q, %v", existing, err)
+	}
+
+	start := make(chan struct{})
+	errs := make([]error, jobs)
+	var wg sync.WaitGroup
+	for i := range jobIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = persistSpec(ctx, pool, &ws, jobIDs[i], uuid.New().String(), CreateJobReq{Brief: "A cat game"}, g, hash, content.RatingEveryone)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	winners := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			winners++
+		case errors.Is(err, errSpecHashConflict):
+			existingID, err := findSpecByHash(ctx, pool, &ws, hash)
+			if err != nil || existingID == "" {
+				t.Fatalf("job %d: findSpecByHash = %q, %v", i, existingID, err)
+			}
+			hashDuplicateResult(ctx, pool, jobIDs[i], existingID, "test-model")
+		default:
+			t.Fatalf("job %d: %v", i, err)
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("%d jobs inserted the spec, want 1", winners)
+	}
+	if n := countRows(t, pool, "game_specs", "spec_hash = $1", hash); n != 1 {
+		t.Errorf("%d specs with the hash, want 1", n)
+	}
+	if n := countRows(t, pool, "gen_spec_jobs", "id = ANY($1) AND status = 'COMPLETED'", jobIDs); n != 1 {
+		t.Errorf("%d jobs COMPLETED, want 1", n)
+	}
+	existingID, _ := findSpecByHash(ctx, pool, &ws, hash)
+	if n := countRows(t, pool, "gen_spec_jobs", "id = ANY($1) AND status = 'HASH_DUPLICATE' AND result_spec_id = $2", jobIDs, existingID); n != jobs-1 {
+		t.Errorf("%d jobs HASH_DUPLICATE of %s, want %d", n, existingID, jobs-1)
+	}
+}
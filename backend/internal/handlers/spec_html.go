@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetSpecHTML renders the spec markdown as a sanitized HTML page, honouring If-Modified-Since
+func GetSpecHTML(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sc, err := loadSpec(c, db, c.Params("id"))
+		if err != nil {
+			return err
+		}
+		return sendMarkdownHTML(c, sc.Title, sc.SpecMarkdown, sc.UpdatedAt)
+	}
+}
+
+// sendMarkdownHTML renders markdown as a sanitized HTML page, answering 304 when the client's
+// If-Modified-Since is not older than updatedAt
+func sendMarkdownHTML(c *fiber.Ctx, title, md string, updatedAt time.Time) error {
+	// HTTP dates have second precision
+	lastModified := updatedAt.UTC().Truncate(time.Second)
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	if since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince)); err == nil && !lastModified.After(since) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	page, err := specschema.RenderMarkdownHTML(title, md)
+	if err != nil {
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to render markdown")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/html; charset=utf-8")
+	return c.Send(page)
+}
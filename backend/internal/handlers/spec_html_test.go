@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSendMarkdownHTML(t *testing.T) {
+	updatedAt := time.Date(2026, 3, 4, 10, 30, 15, 500_000_000, time.FixedZone("ICT", 7*3600))
+	md := "# Cat\n\n<script>alert(1)</script>\n\n[x](javascript:alert(2))"
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/spec.html", func(c *fiber.Ctx) error {
+		return sendMarkdownHTML(c, "Cat", md, updatedAt)
+	})
+
+	lastModified := "Wed, 04 Mar 2026 03:30:15 GMT"
+	tests := []struct {
+		name            string
+		ifModifiedSince string
+		wantStatus      int
+	}{
+		{name: "no validator", wantStatus: fiber.StatusOK},
+		{name: "same time", ifModifiedSince: lastModified, wantStatus: fiber.StatusNotModified},
+		{name: "later", ifModifiedSince: "Thu, 05 Mar 2026 00:00:00 GMT", wantStatus: fiber.StatusNotModified},
+		{name: "earlier", ifModifiedSince: "Wed, 04 Mar 2026 03:30:14 GMT", wantStatus: fiber.StatusOK},
+		{name: "malformed", ifModifiedSince: "yesterday", wantStatus: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/spec.html", nil)
+			if tt.ifModifiedSince != "" {
+				req.Header.Set(fiber.HeaderIfModifiedSince, tt.ifModifiedSince)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get(fiber.HeaderLastModified); got != lastModified {
+				t.Errorf("Last-Modified = %q, want %q", got, lastModified)
+			}
+			if tt.wantStatus == fiber.StatusNotModified {
+				if len(body) != 0 {
+					t.Errorf("304 has a body: %s", body)
+				}
+				return
+			}
+			if ct := resp.Header.Get(fiber.HeaderContentType); ct != "text/html; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			s := string(body)
+			if !strings.Contains(s, "Cat</h1>") {
+				t.Errorf("body lacks the heading:\n%s", s)
+			}
+			for _, banned := range []string{"<script", "alert(1)", "javascript:"} {
+				if strings.Contains(s, banned) {
+					t.Errorf("body contains %q:\n%s", banned, s)
+				}
+			}
+		})
+	}
+}
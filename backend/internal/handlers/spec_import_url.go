@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+const (
+	importFetchTimeout = 5 * time.Second
+	maxImportBytes     = 1 << 20
+	maxImportRedirects = 5
+)
+
+var defaultImportDomains = []string{"gist.github.com", "gist.githubusercontent.com", "raw.githubusercontent.com", "docs.google.com", "notion.site"}
+
+var errPrivateAddress = errors.New("address is not public")
+
+// cgnatRange is the carrier-grade NAT range, which net.IP.IsPrivate doesn't cover
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// importClient only connects to public addresses. The check runs on the address actually dialed,
+// so a hostname re-resolving to a private address can't slip past it, and every redirect is
+// checked against the domain allowlist again.
+var importClient = &http.Client{
+	Timeout: importFetchTimeout,
+	Transport: &http.Transport{
+		Proxy:       nil,
+		DialContext: (&net.Dialer{Timeout: importFetchTimeout, Control: publicOnlyControl}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxImportRedirects {
+			return errors.New("too many redirects")
+		}
+		return checkImportURL(req.URL)
+	},
+}
+
+var htmlStripper = bluemonday.StrictPolicy()
+
+type ImportURLReq struct {
+	URL             string                 `json:"url"`
+	Constraints     map[string]interface{} `json:"constraints,omitempty"`
+	IncludeTutorial bool                   `json:"include_tutorial,omitempty"`
+}
+
+// ImportSpecURL fetches a design document and generates a spec from it like PostSpecJob, using
+// its text as the brief. Markdown, plain text and HTML (reduced to its text) are supported, as is
+// JSON shaped like a spec job request. Only hosts in IMPORT_ALLOWED_DOMAINS (or their subdomains)
+// that resolve to public addresses are fetched.
+func ImportSpecURL(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body ImportURLReq
+		if err := c.BodyParser(&body); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid request body")
+		}
+		u, err := url.Parse(strings.TrimSpace(body.URL))
+		if err != nil || body.URL == "" {
+			return middleware.NewProblem(fiber.StatusBadRequest, "url must be an absolute http(s) URL")
+		}
+		if err := checkImportURL(u); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, err.Error()).
+				With("allowed_domains", importAllowedDomains())
+		}
+
+		req, err := fetchImportDocument(c, u)
+		if err != nil {
+			return err
+		}
+		if req.Constraints == nil {
+			req.Constraints = body.Constraints
+		}
+		req.IncludeTutorial = req.IncludeTutorial || body.IncludeTutorial
+		log.Printf("[INFO] Importing spec brief from %s", u.Host)
+		return runSpecJob(c, db, req)
+	}
+}
+
+// importAllowedDomains returns the hosts documents may be imported from, overridable via IMPORT_ALLOWED_DOMAINS (comma-separated)
+func importAllowedDomains() []string {
+	v := config.GetString("IMPORT_ALLOWED_DOMAINS", "")
+	if v == "" {
+		return defaultImportDomains
+	}
+	var domains []string
+	for _, d := range strings.Split(v, ",") {
+		if d = strings.TrimSpace(strings.ToLower(d)); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// checkImportURL accepts http(s) URLs whose host is an allowed domain or one of its subdomains
+func checkImportURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range importAllowedDomains() {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s is not in IMPORT_ALLOWED_DOMAINS", host)
+}
+
+func publicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%s: %w", host, errPrivateAddress)
+	}
+	return nil
+}
+
+// isPublicIP rejects loopback, private, link-local, CGNAT, multicast and unspecified addresses
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnatRange.Contains(ip))
+}
+
+// fetchImportDocument downloads the document and turns it into a spec job request
+func fetchImportDocument(c *fiber.Ctx, u *url.URL) (CreateJobReq, error) {
+	var req CreateJobReq
+	httpReq, err := http.NewRequestWithContext(c.UserContext(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return req, middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+	}
+	httpReq.Header.Set("Accept", "text/markdown, text/plain, application/json, text/html")
+	resp, err := importClient.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			return req, middleware.NewProblem(fiber.StatusBadRequest, "url resolves to a private address")
+		}
+		log.Printf("[ERROR] Failed to fetch %s: %v", u.Redacted(), err)
+		return req, middleware.NewProblem(fiber.StatusBadGateway, "Failed to fetch the document")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return req, middleware.NewProblem(fiber.StatusBadGateway, fmt.Sprintf("Document URL returned status %d", resp.StatusCode))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportBytes+1))
+	if err != nil {
+		return req, middleware.NewProblem(fiber.StatusBadGateway, "Failed to read the document")
+	}
+	if len(data) > maxImportBytes {
+		return req, middleware.NewProblem(fiber.StatusRequestEntityTooLarge, "Document is too large").
+			With("max_bytes", maxImportBytes)
+	}
+	return documentJobReq(resp.Header.Get(fiber.HeaderContentType), data)
+}
+
+// documentJobReq extracts the brief of a fetched document according to its content type
+func documentJobReq(contentType string, data []byte) (CreateJobReq, error) {
+	var req CreateJobReq
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/markdown", "text/x-markdown", "text/plain":
+		req.Brief = string(data)
+	case "text/html":
+		req.Brief = htmlText(string(data))
+	case "application/json":
+		if err := json.Unmarshal(data, &req); err != nil {
+			return req, middleware.NewProblem(fiber.StatusUnprocessableEntity, "Document is not valid JSON")
+		}
+	default:
+		return req, middleware.NewProblem(fiber.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported document type %q", mediaType)).
+			With("supported_types", []string{"text/markdown", "text/plain", "text/html", "application/json"})
+	}
+	return req, nil
+}
+
+// htmlText strips the tags (and script and style contents) of an HTML page and collapses the
+// whitespace left behind
+func htmlText(page string) string {
+	text := html.UnescapeString(htmlStripper.Sanitize(page))
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
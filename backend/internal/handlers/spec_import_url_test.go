@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckImportURL(t *testing.T) {
+	t.Setenv("IMPORT_ALLOWED_DOMAINS", "example.com, Docs.Test")
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/spec.md", false},
+		{"http://gist.example.com/spec.md", false},
+		{"https://DOCS.test/page", false},
+		{"https://example.com.evil.test/spec.md", true},
+		{"https://notexample.com/spec.md", true},
+		{"ftp://example.com/spec.md", true},
+		{"file:///etc/passwd", true},
+		{"/relative/path", true},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkImportURL(u); (err != nil) != tt.wantErr {
+			t.Errorf("checkImportURL(%s) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+// newImportApp serves fetchImportDocument, answering with the job request it builds
+func newImportApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/", func(c *fiber.Ctx) error {
+		u, err := url.Parse(c.Query("url"))
+		if err != nil {
+			return err
+		}
+		req, err := fetchImportDocument(c, u)
+		if err != nil {
+			return err
+		}
+		return c.JSON(req)
+	})
+	return app
+}
+
+func importDocument(t *testing.T, target string) (int, []byte) {
+	t.Helper()
+	resp, err := newImportApp().Test(httptest.NewRequest("GET", "/?url="+url.QueryEscape(target), nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+// localhostURL points at srv through the localhost name, so the domain allowlist applies
+func localhostURL(srv *httptest.Server, path string) string {
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	return "http://localhost:" + port + path
+}
+
+// allowLoopbackDials lets importClient reach the test servers while keeping its redirect checks
+func allowLoopbackDials(t *testing.T) {
+	orig := importClient
+	importClient = &http.Client{Timeout: orig.Timeout, CheckRedirect: orig.CheckRedirect}
+	t.Cleanup(func() { importClient = orig })
+}
+
+func TestImportRejectsPrivateAddress(t *testing.T) {
+	t.Setenv("IMPORT_ALLOWED_DOMAINS", "localhost")
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("internal"))
+	}))
+	defer srv.Close()
+
+	// localhost is allowed by name but resolves to loopback, which the dialer refuses
+	status, body := importDocument(t, localhostURL(srv, "/"))
+	if status != fiber.StatusBadRequest || !strings.Contains(string(body), "private address") {
+		t.Errorf("status = %d, body %s", status, body)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("private server received %d requests", hits.Load())
+	}
+
+	for _, addr := range []string{"127.0.0.1:80", "[::1]:80", "10.0.0.1:80", "100.64.1.1:443", "169.254.169.254:80", "not-an-ip:80"} {
+		if err := publicOnlyControl("tcp", addr, nil); err == nil {
+			t.Errorf("publicOnlyControl(%s) allowed the dial", addr)
+		}
+	}
+	if err := publicOnlyControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("publicOnlyControl refused a public address: %v", err)
+	}
+}
+
+func TestImportRedirectOutsideAllowlist(t *testing.T) {
+	t.Setenv("IMPORT_ALLOWED_DOMAINS", "localhost")
+	allowLoopbackDials(t)
+
+	var hits atomic.Int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("metadata"))
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 127.0.0.1 is the same machine but not an allowed domain
+		http.Redirect(w, r, other.URL+"/latest/meta-data", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	if status, body := importDocument(t, localhostURL(srv, "/")); status != fiber.StatusBadGateway {
+		t.Errorf("status = %d, body %s", status, body)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("redirect target received %d requests", hits.Load())
+	}
+}
+
+func TestImportDocumentSize(t *testing.T) {
+	t.Setenv("IMPORT_ALLOWED_DOMAINS", "localhost")
+	allowLoopbackDials(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := maxImportBytes
+		if r.URL.Path == "/large" {
+			size++
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", size)))
+	}))
+	defer srv.Close()
+
+	if status, body := importDocument(t, localhostURL(srv, "/fits")); status != fiber.StatusOK {
+		t.Errorf("document of exactly the limit: status = %d, body %.200s", status, body)
+	}
+	if status, _ := importDocument(t, localhostURL(srv, "/large")); status != fiber.StatusRequestEntityTooLarge {
+		t.Errorf("oversized document: status = %d, want %d", status, fiber.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestImportDocumentStatus(t *testing.T) {
+	t.Setenv("IMPORT_ALLOWED_DOMAINS", "localhost")
+	allowLoopbackDials(t)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	if status, _ := importDocument(t, localhostURL(srv, "/")); status != fiber.StatusBadGateway {
+		t.Errorf("status = %d, want %d", status, fiber.StatusBadGateway)
+	}
+}
+
+func TestDocumentJobReq(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        string
+		wantStatus  int
+		wantBrief   string
+		wantTut     bool
+	}{
+		{name: "markdown", contentType: "text/markdown; charset=utf-8", data: "# Cat game\nCollect yarn", wantBrief: "# Cat game\nCollect yarn"},
+		{name: "x-markdown", contentType: "text/x-markdown", data: "Collect yarn", wantBrief: "Collect yarn"},
+		{name: "plain text", contentType: "text/plain", data: "Collect yarn", wantBrief: "Collect yarn"},
+		{
+			name:        "html",
+			contentType: "text/html; charset=utf-8",
+			data:        "<html><head><style>p{}</style><script>alert(1)</script></head><body><h1>Cat  game</h1>\n<p>Collect &amp; keep yarn</p></body></html>",
+			wantBrief:   "Cat game\nCollect & keep yarn",
+		},
+		{name: "json", contentType: "application/json", data: `{"brief":"Collect yarn","include_tutorial":true}`, wantBrief: "Collect yarn", wantTut: true},
+		{name: "invalid json", contentType: "application/json", data: `{"brief":`, wantStatus: fiber.StatusUnprocessableEntity},
+		{name: "pdf", contentType: "application/pdf", data: "%PDF", wantStatus: fiber.StatusUnsupportedMediaType},
+		{name: "missing type", data: "Collect yarn", wantStatus: fiber.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := documentJobReq(tt.contentType, []byte(tt.data))
+			if tt.wantStatus != 0 {
+				var p *middleware.Problem
+				if !errors.As(err, &p) || p.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Brief != tt.wantBrief {
+				t.Errorf("brief = %q, want %q", req.Brief, tt.wantBrief)
+			}
+			if req.IncludeTutorial != tt.wantTut {
+				t.Errorf("include_tutorial = %v, want %v", req.IncludeTutorial, tt.wantTut)
+			}
+		})
+	}
+}
+
+func TestImportDocumentContentTypes(t *testing.T) {
+	t.Setenv("IMPORT_ALLOWED_DOMAINS", "localhost")
+	allowLoopbackDials(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		w.Write([]byte(r.URL.Query().Get("body")))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		contentType string
+		body        string
+		wantStatus  int
+		wantBrief   string
+	}{
+		{"text/markdown", "# Cat game", fiber.StatusOK, "# Cat game"},
+		{"text/plain", "Cat game", fiber.StatusOK, "Cat game"},
+		{"text/html", "<p>Cat game</p>", fiber.StatusOK, "Cat game"},
+		{"application/json", `{"brief":"Cat game"}`, fiber.StatusOK, "Cat game"},
+		{"image/png", "png", fiber.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			q := url.Values{"type": {tt.contentType}, "body": {tt.body}}
+			status, body := importDocument(t, localhostURL(srv, "/?"+q.Encode()))
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", status, tt.wantStatus, body)
+			}
+			if status != fiber.StatusOK {
+				return
+			}
+			var req CreateJobReq
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatal(err)
+			}
+			if req.Brief != tt.wantBrief {
+				t.Errorf("brief = %q, want %q", req.Brief, tt.wantBrief)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/constraints"
+	"backend/internal/middleware"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultMaxBriefLength    = 5000
+	defaultMaxConstraintKeys = 50
+
+	// briefOverflowTruncate is the BRIEF_OVERFLOW_POLICY cutting long briefs instead of rejecting them
+	briefOverflowTruncate = "truncate"
+)
+
+// normalizeJobReq trims the brief, enforces MAX_BRIEF_LENGTH (characters, default 5000) as set by
+// BRIEF_OVERFLOW_POLICY (reject with a 400 by default, or truncate keeping the original) and
+// MAX_CONSTRAINT_KEYS (default 50) and checks constraints against their schema before anything
+// is stored or sent to the LLM. Params must be in LLM_PARAM_ALLOWLIST. A tutorial is requested for every job when AUTO_GENERATE_TUTORIAL is set.
+func normalizeJobReq(req *CreateJobReq) error {
+	if config.MustGetBool("AUTO_GENERATE_TUTORIAL", false) {
+		req.IncludeTutorial = true
+	}
+
+	req.Brief = strings.TrimSpace(req.Brief)
+	if req.Brief == "" {
+		return middleware.NewProblem(fiber.StatusBadRequest, "brief is required")
+	}
+
+	maxLength := config.MustGetInt("MAX_BRIEF_LENGTH", defaultMaxBriefLength)
+	if n := utf8.RuneCountInString(req.Brief); maxLength > 0 && n > maxLength {
+		if config.GetString("BRIEF_OVERFLOW_POLICY", "") != briefOverflowTruncate {
+			return middleware.NewProblem(fiber.StatusBadRequest, fmt.Sprintf("brief is %d characters, the maximum is %d", n, maxLength))
+		}
+		// A retried job's brief may already be a truncation of its original
+		if req.BriefOriginal == "" {
+			req.BriefOriginal = req.Brief
+		}
+		req.Brief = truncateBrief(req.Brief, maxLength)
+	}
+
+	maxKeys := config.MustGetInt("MAX_CONSTRAINT_KEYS", defaultMaxConstraintKeys)
+	if maxKeys > 0 && len(req.Constraints) > maxKeys {
+		return middleware.NewProblem(fiber.StatusBadRequest, fmt.Sprintf("constraints has %d keys, the maximum is %d", len(req.Constraints), maxKeys))
+	}
+	if errs := constraints.Validate(req.Constraints); len(errs) > 0 {
+		return middleware.NewProblem(fiber.StatusUnprocessableEntity, "constraints are invalid").
+			With("errors", errs)
+	}
+	if err := validateLLMParams(req.Params); err != nil {
+		return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// truncateBrief cuts a brief to at most maxLength characters after the last sentence ending within
+// the limit. Without one it cuts at the last whitespace, and failing that mid-word.
+func truncateBrief(brief string, maxLength int) string {
+	runes := []rune(brief)
+	if len(runes) <= maxLength {
+		return brief
+	}
+	for i := maxLength - 1; i > 0; i-- {
+		if strings.ContainsRune(".!?", runes[i]) && unicode.IsSpace(runes[i+1]) {
+			return string(runes[:i+1])
+		}
+	}
+	for i := maxLength; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return strings.TrimSpace(string(runes[:i]))
+		}
+	}
+	return string(runes[:maxLength])
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTruncateBrief(t *testing.T) {
+	tests := []struct {
+		name      string
+		brief     string
+		maxLength int
+		want      string
+	}{
+		{name: "within limit", brief: "A cat game.", maxLength: 20, want: "A cat game."},
+		{name: "exactly the limit", brief: "A cat game.", maxLength: 11, want: "A cat game."},
+		{name: "sentence boundary", brief: "A cat game. It collects yarn. Dogs chase it.", maxLength: 35, want: "A cat game. It collects yarn."},
+		{name: "question and exclamation", brief: "Can cats fly? Yes! They glide over rooftops.", maxLength: 25, want: "Can cats fly? Yes!"},
+		{name: "sentence ending right at the limit", brief: "A cat game. More text", maxLength: 11, want: "A cat game."},
+		{name: "dot inside a word is no boundary", brief: "Visit example.com for cat games and more", maxLength: 20, want: "Visit example.com"},
+		{name: "whitespace fallback", brief: "a cat game about collecting yarn balls", maxLength: 20, want: "a cat game about"},
+		{name: "whitespace right after the limit", brief: "a cat game about yarn", maxLength: 16, want: "a cat game about"},
+		{name: "single long word", brief: strings.Repeat("yarn", 10), maxLength: 10, want: "yarnyarnya"},
+		{name: "limit counts characters, not bytes", brief: "猫のゲーム。毛糸を集める。犬が追いかける。", maxLength: 12, want: "猫のゲーム。毛糸を集める"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateBrief(tt.brief, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("truncateBrief(%q, %d) = %q, want %q", tt.brief, tt.maxLength, got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > tt.maxLength {
+				t.Errorf("result has %d characters, limit is %d", n, tt.maxLength)
+			}
+		})
+	}
+}
+
+func TestNormalizeJobReqOverflowPolicy(t *testing.T) {
+	long := "A cat game. It collects yarn. Dogs chase it around the garden."
+	t.Setenv("MAX_BRIEF_LENGTH", "30")
+
+	t.Run("reject", func(t *testing.T) {
+		t.Setenv("BRIEF_OVERFLOW_POLICY", "")
+		req := CreateJobReq{Brief: long}
+		err := normalizeJobReq(&req)
+		var p *middleware.Problem
+		if !errors.As(err, &p) || p.Status != fiber.StatusBadRequest {
+			t.Fatalf("err = %v, want a 400 problem", err)
+		}
+		if req.BriefOriginal != "" {
+			t.Errorf("BriefOriginal = %q, want empty", req.BriefOriginal)
+		}
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		t.Setenv("BRIEF_OVERFLOW_POLICY", briefOverflowTruncate)
+		req := CreateJobReq{Brief: "  " + long + "  "}
+		if err := normalizeJobReq(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Brief != "A cat game. It collects yarn." {
+			t.Errorf("Brief = %q", req.Brief)
+		}
+		if req.BriefOriginal != long {
+			t.Errorf("BriefOriginal = %q, want the trimmed original", req.BriefOriginal)
+		}
+	})
+
+	t.Run("truncate keeps the first original", func(t *testing.T) {
+		t.Setenv("BRIEF_OVERFLOW_POLICY", briefOverflowTruncate)
+		req := CreateJobReq{Brief: long, BriefOriginal: long + " And more."}
+		if err := normalizeJobReq(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.BriefOriginal != long+" And more." {
+			t.Errorf("BriefOriginal = %q", req.BriefOriginal)
+		}
+	})
+}
+
+func TestNormalizeJobReq(t *testing.T) {
+	t.Setenv("MAX_BRIEF_LENGTH", "20")
+	t.Setenv("BRIEF_OVERFLOW_POLICY", "")
+	t.Setenv("MAX_CONSTRAINT_KEYS", "2")
+	t.Setenv("LLM_PARAM_ALLOWLIST", "")
+	t.Setenv("AUTO_GENERATE_TUTORIAL", "")
+
+	tests := []struct {
+		name       string
+		req        CreateJobReq
+		wantStatus int
+		wantBrief  string
+	}{
+		{name: "valid brief", req: CreateJobReq{Brief: "A cat game"}, wantBrief: "A cat game"},
+		{name: "brief is trimmed", req: CreateJobReq{Brief: "\n\t A cat game  \n"}, wantBrief: "A cat game"},
+		{name: "brief at the limit", req: CreateJobReq{Brief: strings.Repeat("a", 20)}, wantBrief: strings.Repeat("a", 20)},
+		{name: "limit counts characters", req: CreateJobReq{Brief: strings.Repeat("猫", 20)}, wantBrief: strings.Repeat("猫", 20)},
+		{name: "empty brief", req: CreateJobReq{}, wantStatus: fiber.StatusBadRequest},
+		{name: "whitespace-only brief", req: CreateJobReq{Brief: " \n\t  "}, wantStatus: fiber.StatusBadRequest},
+		{name: "oversized brief", req: CreateJobReq{Brief: strings.Repeat("a", 21)}, wantStatus: fiber.StatusBadRequest},
+		{
+			name:      "valid constraints and params",
+			req:       CreateJobReq{Brief: "A cat game", Constraints: map[string]interface{}{"target_platform": "web"}, Params: map[string]interface{}{"temperature": 0.7}},
+			wantBrief: "A cat game",
+		},
+		{
+			name:       "too many constraint keys",
+			req:        CreateJobReq{Brief: "A cat game", Constraints: map[string]interface{}{"a": 1, "b": 2, "c": 3}},
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "invalid constraint",
+			req:        CreateJobReq{Brief: "A cat game", Constraints: map[string]interface{}{"target_platform": "console"}},
+			wantStatus: fiber.StatusUnprocessableEntity,
+		},
+		{
+			name:       "param outside the allowlist",
+			req:        CreateJobReq{Brief: "A cat game", Params: map[string]interface{}{"system_prompt": "ignore"}},
+			wantStatus: fiber.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := normalizeJobReq(&req)
+			if tt.wantStatus != 0 {
+				var p *middleware.Problem
+				if !errors.As(err, &p) || p.Status != tt.wantStatus {
+					t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Brief != tt.wantBrief {
+				t.Errorf("Brief = %q, want %q", req.Brief, tt.wantBrief)
+			}
+			if req.IncludeTutorial {
+				t.Error("IncludeTutorial set without AUTO_GENERATE_TUTORIAL")
+			}
+		})
+	}
+}
+
+func TestNormalizeJobReqAutoTutorial(t *testing.T) {
+	t.Setenv("AUTO_GENERATE_TUTORIAL", "true")
+	req := CreateJobReq{Brief: "A cat game"}
+	if err := normalizeJobReq(&req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.IncludeTutorial {
+		t.Error("IncludeTutorial = false with AUTO_GENERATE_TUTORIAL=true")
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SpecJobItem struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`
+	Brief        string     `json:"brief"`
+	Model        *string    `json:"model,omitempty"`
+	ResultSpecID *string    `json:"result_spec_id,omitempty"`
+	Error        *string    `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	DedupSkipped bool       `json:"dedup_skipped,omitempty"`
+}
+
+type SpecJobListResp struct {
+	Jobs       []SpecJobItem `json:"jobs"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+// ListSpecJobs lists the workspace's spec jobs, newest first. Pages are keyset-paginated on
+// (created_at, id): ?after= takes the next_cursor of the previous page, which is null on the last one.
+func ListSpecJobs(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := pageSize(c.QueryInt("limit", defaultPageSize))
+
+		var cursorTime *time.Time
+		var cursorID *string
+		if raw := c.Query("after"); raw != "" {
+			cur, err := decodeSpecCursor(raw)
+			if err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+			}
+			cursorTime, cursorID = &cur.CreatedAt, &cur.ID
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		rows, err := db.Query(ctx, `
+			SELECT id, status, brief, model, result_spec_id, error, created_at, finished_at, dedup_skipped
+			FROM gen_spec_jobs
+			WHERE workspace_id IS NOT DISTINCT FROM $1
+				AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+			ORDER BY created_at DESC, id DESC
+			LIMIT $4
+		`, middleware.WorkspaceID(c), cursorTime, cursorID, limit)
+		if err != nil {
+			log.Printf("[ERROR] Failed to list spec jobs: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		defer rows.Close()
+
+		resp := SpecJobListResp{Jobs: []SpecJobItem{}}
+		for rows.Next() {
+			var it SpecJobItem
+			if err := rows.Scan(&it.ID, &it.Status, &it.Brief, &it.Model, &it.ResultSpecID, &it.Error, &it.CreatedAt, &it.FinishedAt, &it.DedupSkipped); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read spec jobs")
+			}
+			resp.Jobs = append(resp.Jobs, it)
+		}
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read spec jobs")
+		}
+		if len(resp.Jobs) == limit {
+			last := resp.Jobs[len(resp.Jobs)-1]
+			next := encodeSpecCursor(last.CreatedAt, last.ID)
+			resp.NextCursor = &next
+		}
+		return c.JSON(resp)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestListSpecJobsCursor(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	ws := newTestWorkspace(t, pool, "jobs-key")
+	other := newTestWorkspace(t, pool, "jobs-other")
+	// Registered after the /api group, so it sits behind its middleware
+	app := newTestAPI(pool)
+	app.Get("/api/spec-jobs", ListSpecJobs(pool))
+	headers := map[string]string{middleware.APIKeyHeader: "jobs-key"}
+
+	// Jobs share timestamps three at a time so page boundaries fall inside ties on created_at
+	const total = 23
+	base := time.Now().UTC().Truncate(time.Second)
+	type job struct {
+		id        string
+		createdAt time.Time
+	}
+	var jobs []job
+	for i := 0; i < total; i++ {
+		j := job{uuid.New().String(), base.Add(-time.Duration(i/3) * time.Minute)}
+		if _, err := pool.Exec(ctx, `INSERT INTO gen_spec_jobs (id, status, brief, workspace_id, created_at) VALUES ($1, 'COMPLETED', 'brief', $2, $3)`, j.id, ws, j.createdAt); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, j)
+	}
+	newTestSpecJob(t, pool, other)
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].createdAt.Equal(jobs[j].createdAt) {
+			return jobs[i].createdAt.After(jobs[j].createdAt)
+		}
+		return jobs[i].id > jobs[j].id
+	})
+
+	page := func(t *testing.T, query string) SpecJobListResp {
+		t.Helper()
+		status, body := apiRequest(t, app, "GET", "/api/spec-jobs?"+query, "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var resp SpecJobListResp
+		decodeJSON(t, body, &resp)
+		return resp
+	}
+
+	for _, limit := range []int{1, 4, 7, total, 50} {
+		t.Run("limit "+strconv.Itoa(limit), func(t *testing.T) {
+			var got []string
+			query := "limit=" + strconv.Itoa(limit)
+			for pages := 0; ; pages++ {
+				if pages > total {
+					t.Fatal("pagination doesn't end")
+				}
+				resp := page(t, query)
+				if len(resp.Jobs) > limit {
+					t.Fatalf("page has %d jobs, limit %d", len(resp.Jobs), limit)
+				}
+				for _, j := range resp.Jobs {
+					got = append(got, j.ID)
+				}
+				if resp.NextCursor == nil {
+					break
+				}
+				query = "limit=" + strconv.Itoa(limit) + "&after=" + url.QueryEscape(*resp.NextCursor)
+			}
+			if len(got) != total {
+				t.Fatalf("listed %d jobs, want %d", len(got), total)
+			}
+			for i, j := range jobs {
+				if got[i] != j.id {
+					t.Fatalf("job %d = %s, want %s: rows were skipped, repeated or out of order", i, got[i], j.id)
+				}
+			}
+		})
+	}
+
+	t.Run("after the last job", func(t *testing.T) {
+		last := jobs[total-1]
+		resp := page(t, "after="+encodeSpecCursor(last.createdAt, last.id))
+		if len(resp.Jobs) != 0 || resp.NextCursor != nil {
+			t.Errorf("page after the last job = %+v", resp)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		if status, body := apiRequest(t, app, "GET", "/api/spec-jobs?after=garbage", "", headers); status != fiber.StatusBadRequest {
+			t.Errorf("status = %d, want 400: %s", status, body)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// specJobParentIndex allows one retry per spec job
+const specJobParentIndex = "gen_spec_jobs_parent_job_key"
+
+// RetrySpecJob re-runs a FAILED or DUPLICATE spec job with its original brief, constraints and params. The
+// retry is a new job whose parent_job_id is the retried one, generated synchronously like
+// PostSpecJob. A job can be retried once and a chain of retries is capped at MAX_SPEC_JOB_RETRIES
+// (default 3); both, like any other status, are reported as 409.
+func RetrySpecJob(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		if _, err := uuid.Parse(id); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid job id")
+		}
+		workspaceID := middleware.WorkspaceID(c)
+
+		ctx, cancel := queryCtx(c.UserContext())
+		var status string
+		var retryCount int
+		var constraints, params []byte
+		var briefOriginal *string
+		req := CreateJobReq{}
+		err := db.QueryRow(ctx, `
+			SELECT status, brief, brief_original, constraints, include_tutorial, params, retry_count
+			FROM gen_spec_jobs
+			WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2
+		`, id, workspaceID).Scan(&status, &req.Brief, &briefOriginal, &constraints, &req.IncludeTutorial, &params, &retryCount)
+		cancel()
+		if errors.Is(err, pgx.ErrNoRows) {
+			return middleware.NewProblem(fiber.StatusNotFound, "job not found")
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to load spec job %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		if status != "FAILED" && status != "DUPLICATE" {
+			return middleware.NewProblem(fiber.StatusConflict, "Only FAILED or DUPLICATE jobs can be retried").
+				With("status", status)
+		}
+		maxRetries := config.MustGetInt("MAX_SPEC_JOB_RETRIES", 3)
+		if retryCount >= maxRetries {
+			return middleware.NewProblem(fiber.StatusConflict, "Job has been retried too many times").
+				With("retry_count", retryCount).
+				With("max_retries", maxRetries)
+		}
+		// The retry reuses the stored, possibly truncated, brief and keeps the original with it
+		if briefOriginal != nil {
+			req.BriefOriginal = *briefOriginal
+		}
+		if len(constraints) > 0 {
+			if err := json.Unmarshal(constraints, &req.Constraints); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse job constraints")
+			}
+		}
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &req.Params); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse job params")
+			}
+		}
+		if err := normalizeJobReq(&req); err != nil {
+			return err
+		}
+
+		if err := consumeQuota(c.UserContext(), db, workspaceID, quotaSpecs); err != nil {
+			return quotaErrorResponse(c, err)
+		}
+
+		jobID, model, err := startSpecJob(c.UserContext(), db, workspaceID, middleware.UserID(c), req, &id)
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] Retrying spec job %s as %s", id, jobID)
+
+		llmStart := time.Now()
+		g, err := generateSpec(c.UserContext(), genSpecReq{Brief: req.Brief, Constraints: req.Constraints, Model: model, IncludeTutorial: req.IncludeTutorial, Params: req.Params})
+		recordSpecJobTiming(db, jobID, timingLLM, time.Since(llmStart))
+		if err != nil {
+			failSpecJob(db, jobID, specJobFailure(err))
+			return err
+		}
+
+		result, err := completeSpecJob(c.UserContext(), db, workspaceID, jobID, req, model, g)
+		if err != nil {
+			failSpecJob(db, jobID, specJobFailure(err))
+			return err
+		}
+		result["parent_job_id"] = id
+		return c.Status(200).JSON(result)
+	}
+}
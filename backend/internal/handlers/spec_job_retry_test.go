@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestSpecJobWithStatus inserts a spec job in status with the given constraints
+func newTestSpecJobWithStatus(t *testing.T, pool *pgxpool.Pool, workspaceID, status string, constraints map[string]interface{}) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := pool.Exec(context.Background(), `INSERT INTO gen_spec_jobs (id, status, brief, constraints, workspace_id) VALUES ($1, $2, 'A retried cat game', $3, $4)`,
+		id, status, constraints, workspaceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRetrySpecJob(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	t.Setenv("MAX_SPEC_JOB_RETRIES", "2")
+	// The LLM rejects every retry, so none goes on to persist a spec and start code generation. A
+	// 4xx keeps the shared circuit breaker closed.
+	var sent []genSpecReq
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		var greq genSpecReq
+		json.NewDecoder(r.Body).Decode(&greq)
+		sent = append(sent, greq)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	app := newTestAPI(pool)
+	app.Post("/api/spec-jobs/:id/retry", RetrySpecJob(pool))
+
+	ws := newTestWorkspace(t, pool, "retry-key")
+	headers := map[string]string{middleware.APIKeyHeader: "retry-key"}
+	retry := func(t *testing.T, id string) (int, []byte) {
+		t.Helper()
+		return apiRequest(t, app, "POST", "/api/spec-jobs/"+id+"/retry", "", headers)
+	}
+	// childOf returns the retry of a job and its retry_count
+	childOf := func(t *testing.T, parentID string) (string, int) {
+		t.Helper()
+		var id string
+		var retryCount int
+		if err := pool.QueryRow(ctx, `SELECT id, retry_count FROM gen_spec_jobs WHERE parent_job_id = $1`, parentID).Scan(&id, &retryCount); err != nil {
+			t.Fatalf("no retry of %s: %v", parentID, err)
+		}
+		return id, retryCount
+	}
+
+	constraints := map[string]interface{}{"target_platform": "mobile"}
+	original := newTestSpecJobWithStatus(t, pool, ws, "FAILED", constraints)
+
+	t.Run("failed job", func(t *testing.T) {
+		sent = nil
+		if status, body := retry(t, original); status < 400 {
+			t.Fatalf("status = %d, want the LLM failure: %s", status, body)
+		}
+		if len(sent) != 1 || sent[0].Brief != "A retried cat game" || sent[0].Constraints["target_platform"] != "mobile" {
+			t.Errorf("LLM was sent %+v, want the original brief and constraints", sent)
+		}
+		child, retryCount := childOf(t, original)
+		if retryCount != 1 {
+			t.Errorf("retry_count = %d, want 1", retryCount)
+		}
+		if status := jobStatus(t, pool, child); status != "FAILED" {
+			t.Errorf("retry status = %s, want FAILED", status)
+		}
+	})
+
+	t.Run("a job is retried once", func(t *testing.T) {
+		if status, body := retry(t, original); status != fiber.StatusConflict {
+			t.Errorf("status = %d, want 409: %s", status, body)
+		}
+		// The failed retry gave its quota back and the rejected one must not keep any
+		var used int
+		if err := pool.QueryRow(ctx, `SELECT used_specs FROM workspaces WHERE id = $1`, ws).Scan(&used); err != nil {
+			t.Fatal(err)
+		}
+		if used != 0 {
+			t.Errorf("used_specs = %d, want 0", used)
+		}
+	})
+
+	t.Run("retries are capped", func(t *testing.T) {
+		child, _ := childOf(t, original)
+		if status, body := retry(t, child); status == fiber.StatusConflict {
+			t.Fatalf("second retry rejected: %s", body)
+		}
+		grandchild, retryCount := childOf(t, child)
+		if retryCount != 2 {
+			t.Errorf("retry_count = %d, want 2", retryCount)
+		}
+		status, body := retry(t, grandchild)
+		if status != fiber.StatusConflict {
+			t.Fatalf("status = %d, want 409: %s", status, body)
+		}
+		var problem map[string]interface{}
+		decodeJSON(t, body, &problem)
+		if problem["max_retries"] != float64(2) {
+			t.Errorf("problem = %v, want max_retries 2", problem)
+		}
+	})
+
+	t.Run("duplicate job", func(t *testing.T) {
+		id := newTestSpecJobWithStatus(t, pool, ws, "DUPLICATE", nil)
+		if status, body := retry(t, id); status == fiber.StatusConflict {
+			t.Fatalf("DUPLICATE job rejected: %s", body)
+		}
+		childOf(t, id)
+	})
+
+	for _, status := range []string{"QUEUED", "RUNNING", "COMPLETED", "HASH_DUPLICATE"} {
+		t.Run(status+" job", func(t *testing.T) {
+			id := newTestSpecJobWithStatus(t, pool, ws, status, nil)
+			if got, body := retry(t, id); got != fiber.StatusConflict {
+				t.Errorf("status = %d, want 409: %s", got, body)
+			}
+			if n := countRows(t, pool, "gen_spec_jobs", "parent_job_id = $1", id); n != 0 {
+				t.Errorf("%d retries created", n)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		other := newTestWorkspace(t, pool, "retry-other")
+		for _, id := range []string{uuid.New().String(), newTestSpecJobWithStatus(t, pool, other, "FAILED", nil)} {
+			if status, body := retry(t, id); status != fiber.StatusNotFound {
+				t.Errorf("%s: status = %d, want 404: %s", id, status, body)
+			}
+		}
+		if status, _ := retry(t, "nope"); status != fiber.StatusBadRequest {
+			t.Errorf("invalid id: status = %d, want 400", status)
+		}
+	})
+}
@@ -1,35 +1,60 @@
 package handlers
 
 import (
+	"backend/internal/config"
+	"backend/internal/content"
+	database "backend/internal/db"
+	"backend/internal/es"
+	"backend/internal/eventbus"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"backend/internal/tracing"
 	"backend/internal/utils"
+	"backend/internal/webhook"
 	"bytes"
 	"context"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type CreateJobReq struct {
-	Brief       string                 `json:"brief"`
-	Constraints map[string]interface{} `json:"constraints,omitempty"`
+	Brief           string                 `json:"brief"`
+	Constraints     map[string]interface{} `json:"constraints,omitempty"`
+	IncludeTutorial bool                   `json:"include_tutorial,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+	// BriefOriginal is the brief as submitted when BRIEF_OVERFLOW_POLICY=truncate shortened it
+	BriefOriginal string `json:"-"`
 }
 
 type JobStatusResp struct {
-	Status        string        `json:"status"`
-	ResultSpecID  *string       `json:"result_spec_id,omitempty"`
-	DuplicateList []SimilarSpec `json:"duplicate_list,omitempty"`
-	Error         *string       `json:"error,omitempty"`
+	Status         string        `json:"status"`
+	Model          *string       `json:"model,omitempty"`
+	ResultSpecID   *string       `json:"result_spec_id,omitempty"`
+	DuplicateList  []SimilarSpec `json:"duplicate_list,omitempty"`
+	Error          *string       `json:"error,omitempty"`
+	DedupSkipped   bool          `json:"dedup_skipped,omitempty"`
+	BriefTruncated bool          `json:"brief_truncated,omitempty"`
+	// Timing has the duration in milliseconds of each stage the job ran
+	Timing map[string]int64 `json:"timing,omitempty"`
 }
 
 type SimilarSpec struct {
@@ -39,19 +64,24 @@ type SimilarSpec struct {
 }
 
 type genSpecReq struct {
-	Brief       string                 `json:"brief"`
-	Constraints map[string]interface{} `json:"constraints,omitempty"`
+	Brief           string                 `json:"brief"`
+	Constraints     map[string]interface{} `json:"constraints,omitempty"`
+	Model           string                 `json:"model"`
+	IncludeTutorial bool                   `json:"include_tutorial,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
 }
 type genSpecResp struct {
-	Title        string                 `json:"title"`
-	SpecMarkdown string                 `json:"spec_markdown"`
-	SpecJSON     map[string]interface{} `json:"spec_json"`
+	Title            string                 `json:"title"`
+	SpecMarkdown     string                 `json:"spec_markdown"`
+	SpecJSON         map[string]interface{} `json:"spec_json"`
+	TutorialMarkdown string                 `json:"tutorial_markdown,omitempty"`
 }
 
 type searchReq struct {
 	Text      string  `json:"text"`
 	TopK      int     `json:"top_k"`
 	Threshold float64 `json:"threshold"`
+	Namespace string  `json:"namespace,omitempty"`
 }
 type searchResp struct {
 	Similar []struct {
@@ -62,9 +92,10 @@ type searchResp struct {
 }
 
 type upsertReq struct {
-	SpecID  string                 `json:"spec_id"`
-	Text    string                 `json:"text"`
-	Payload map[string]interface{} `json:"payload"`
+	SpecID    string                 `json:"spec_id"`
+	Text      string                 `json:"text"`
+	Payload   map[string]interface{} `json:"payload"`
+	Namespace string                 `json:"namespace,omitempty"`
 }
 
 func hashSpec(specJSON map[string]interface{}) (string, error) {
@@ -76,42 +107,40 @@ func hashSpec(specJSON map[string]interface{}) (string, error) {
 	return hex.EncodeToString(h[:]), nil
 }
 
-// State constants
-const (
-	StateCreating       = "creating"
-	StateGitIniting     = "git_initing"
-	StateGitInited      = "git_inited"
-	StateCodeGenerating = "code_generating"
-	StateCodeGenerated  = "code_generated"
-)
-
-// Helper function to update game spec state and log the transition
-func updateGameSpecState(db *pgxpool.Pool, specID, newState, detail string) error {
-	ctx := context.Background()
+// Helper function to move a game spec to a new state by appending a transition event.
+// The row is locked for the duration of the transaction so concurrent transitions
+// are serialized and each event records the state it actually replaced.
+func updateGameSpecState(db *pgxpool.Pool, specID string, newState GameSpecState, detail string) error {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
 
-	// Get current state
-	var currentState string
-	err := db.QueryRow(ctx, "SELECT state FROM game_specs WHERE id = $1", specID).Scan(&currentState)
+	tx, err := db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get current state: %v", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Update game spec state
-	_, err = db.Exec(ctx, "UPDATE game_specs SET state = $1 WHERE id = $2", newState, specID)
+	// Transitions wait for a concurrent mutation of the spec instead of being dropped
+	if err := database.LockSpec(ctx, tx, specID); err != nil {
+		return fmt.Errorf("failed to lock spec: %v", err)
+	}
+	currentState, err := es.AppendEvent(ctx, tx, specID, newState.String(), detail)
 	if err != nil {
-		return fmt.Errorf("failed to update state: %v", err)
+		return err
+	}
+	if err := es.Project(ctx, tx, specID); err != nil {
+		return fmt.Errorf("failed to project state: %v", err)
 	}
 
-	// Log state transition
-	_, err = db.Exec(ctx, `
-		INSERT INTO game_spec_states (game_spec_id, state_before, state_after, detail)
-		VALUES ($1, $2, $3, $4)
-	`, specID, currentState, newState, detail)
-	if err != nil {
-		return fmt.Errorf("failed to log state transition: %v", err)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit state transition: %v", err)
 	}
+	invalidateSpec(specID)
 
 	log.Printf("[STATE] Spec %s: %s → %s (%s)", specID, currentState, newState, detail)
+	webhook.NotifyStateTransition(webhook.StateTransition{
+		SpecID: specID, StateBefore: currentState, StateAfter: newState.String(), Detail: detail, Timestamp: time.Now().UTC(),
+	})
 	return nil
 }
 
@@ -119,167 +148,515 @@ func PostSpecJob(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req CreateJobReq
 		if err := c.BodyParser(&req); err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-		if req.Brief == "" {
-			return fiber.NewError(fiber.StatusBadRequest, "brief is required")
+			return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
 		}
+		return runSpecJob(c, db, req)
+	}
+}
 
-		ctx := context.Background()
-		jobID := uuid.New().String()
-		_, err := db.Exec(ctx, `INSERT INTO gen_spec_jobs (id,status,brief,created_at) VALUES ($1,'QUEUED',$2,now())`, jobID, req.Brief)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+// runSpecJob validates a spec job request and generates its spec synchronously, answering with the job result
+func runSpecJob(c *fiber.Ctx, db *pgxpool.Pool, req CreateJobReq) error {
+	if err := normalizeJobReq(&req); err != nil {
+		return err
+	}
+	if err := checkBriefSafety(c.UserContext(), req.Brief); err != nil {
+		return err
+	}
+	if err := checkBriefLanguage(c.UserContext(), &req); err != nil {
+		return err
+	}
 
-		_, err = db.Exec(ctx, `UPDATE gen_spec_jobs SET status='RUNNING', started_at=now() WHERE id=$1`, jobID)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+	workspaceID := middleware.WorkspaceID(c)
+	if err := consumeQuota(c.UserContext(), db, workspaceID, quotaSpecs); err != nil {
+		return quotaErrorResponse(c, err)
+	}
 
-		llmBackend := os.Getenv("LLM_BACKEND_URL")
-		if llmBackend == "" {
-			llmBackend = "http://localhost:8000"
-		}
+	jobID, model, err := startSpecJob(c.UserContext(), db, workspaceID, middleware.UserID(c), req, nil)
+	if err != nil {
+		return err
+	}
 
-		greq := genSpecReq{Brief: req.Brief, Constraints: req.Constraints}
-		gb, _ := json.Marshal(greq)
-		resp, err := http.Post(llmBackend+"/llm/generate-spec", "application/json", bytes.NewReader(gb))
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadGateway, "llm generate-spec failed: "+err.Error())
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode != 200 {
-			return fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("llm status %d", resp.StatusCode))
-		}
-		var g genSpecResp
-		if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
-			return fiber.NewError(fiber.StatusBadGateway, err.Error())
-		}
+	llmStart := time.Now()
+	g, err := generateSpec(c.UserContext(), genSpecReq{Brief: req.Brief, Constraints: req.Constraints, Model: model, IncludeTutorial: req.IncludeTutorial, Params: req.Params})
+	recordSpecJobTiming(db, jobID, timingLLM, time.Since(llmStart))
+	if err != nil {
+		failSpecJob(db, jobID, specJobFailure(err))
+		return err
+	}
 
-		normText := fmt.Sprintf("%s\ncontrols:%v\nmechanics:%v\nconstraints:%v", g.Title, g.SpecJSON["controls"], g.SpecJSON["mechanics"], g.SpecJSON["constraints"])
-		topK := 5
-		if v := os.Getenv("TOP_K"); v != "" {
-			fmt.Sscanf(v, "%d", &topK)
-		}
-		threshold := 0.86
-		if v := os.Getenv("SIM_THRESHOLD"); v != "" {
-			fmt.Sscanf(v, "%f", &threshold)
-		}
-		sreq := searchReq{Text: normText, TopK: topK, Threshold: threshold}
-		sb, _ := json.Marshal(sreq)
-		resp2, err := http.Post(llmBackend+"/vector/search", "application/json", bytes.NewReader(sb))
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadGateway, "vector search failed: "+err.Error())
+	result, err := completeSpecJob(c.UserContext(), db, workspaceID, jobID, req, model, g)
+	if err != nil {
+		failSpecJob(db, jobID, specJobFailure(err))
+		return err
+	}
+	if req.BriefOriginal != "" {
+		result["brief_truncated"] = true
+	}
+	return c.Status(200).JSON(result)
+}
+
+// startSpecJob records a new spec job and marks it RUNNING, returning the job id and the model in use.
+// retryOf is the job being retried, if any; the new job counts one more retry than it. userID is
+// the user starting the job, nil for anonymous requests. Callers consume the spec quota first;
+// a job that can't be started gives it back.
+func startSpecJob(parent context.Context, db *pgxpool.Pool, workspaceID, userID *string, req CreateJobReq, retryOf *string) (_ string, _ string, err error) {
+	jobID := uuid.New().String()
+	model := specModel()
+	parent, span := tracing.Start(parent, "db.insert_spec_job", attribute.String("job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	_, err = db.Exec(ctx, `
+		INSERT INTO gen_spec_jobs (id,status,brief,brief_original,constraints,include_tutorial,params,model,workspace_id,user_id,parent_job_id,retry_count,created_at)
+		VALUES ($1,'QUEUED',$2,NULLIF($3,''),$4,$5,$6,$7,$8,$9,$10,COALESCE((SELECT retry_count+1 FROM gen_spec_jobs WHERE id=$10),0),now())
+	`, jobID, req.Brief, req.BriefOriginal, req.Constraints, req.IncludeTutorial, req.Params, model, workspaceID, userID, retryOf)
+	if err != nil {
+		releaseQuota(context.Background(), db, workspaceID, quotaSpecs)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == specJobParentIndex {
+			return "", "", middleware.NewProblem(fiber.StatusConflict, "Job was already retried").
+				WithCode(codeDuplicate)
 		}
-		defer resp2.Body.Close()
-		if resp2.StatusCode != 200 {
-			return fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("vector status %d", resp2.StatusCode))
+		return "", "", middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+
+	_, err = db.Exec(ctx, `UPDATE gen_spec_jobs SET status='RUNNING', started_at=now() WHERE id=$1`, jobID)
+	if err != nil {
+		// The job exists, so failing it releases the quota through the job
+		failSpecJob(db, jobID, err.Error())
+		return "", "", middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+
+	_ = publishJobEvent(eventbus.TopicJobCreated, JobEvent{Kind: jobKindSpec, JobID: jobID, WorkspaceID: workspaceID})
+	return jobID, model, nil
+}
+
+// generateSpec calls the LLM backend to turn a brief into a spec, rejecting a malformed one
+func generateSpec(ctx context.Context, greq genSpecReq) (g genSpecResp, err error) {
+	ctx, span := tracing.Start(ctx, "llm.generate_spec", attribute.String("llm.model", greq.Model))
+	defer func() { tracing.End(span, err) }()
+
+	timeout := llmSpecTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+
+	gb, _ := json.Marshal(greq)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, llmBackend+"/llm/generate-spec", bytes.NewReader(gb))
+	if err != nil {
+		return g, middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := llm.Do(httpReq)
+	if err != nil {
+		return g, llmCallError(ctx, timeout, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return g, middleware.NewProblem(fiber.StatusBadGateway, fmt.Sprintf("llm status %d", resp.StatusCode)).
+			WithCode(codeLLMUnavailable)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		if ctx.Err() != nil {
+			return g, llmCallError(ctx, timeout, err)
 		}
-		var s searchResp
-		if err := json.NewDecoder(resp2.Body).Decode(&s); err != nil {
-			return fiber.NewError(fiber.StatusBadGateway, err.Error())
+		return g, middleware.NewProblem(fiber.StatusBadGateway, err.Error()).
+			WithCode(codeLLMUnavailable)
+	}
+	return g, validateGenSpecResp(g)
+}
+
+// completeSpecJob runs duplicate detection, persistence, vector upsert and the code generation
+// trigger for a generated spec, returning the response body for the job
+func completeSpecJob(parent context.Context, db *pgxpool.Pool, workspaceID *string, jobID string, req CreateJobReq, model string, g genSpecResp) (fiber.Map, error) {
+	llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+
+	rating, err := rateSpec(parent, db, workspaceID, jobID, g)
+	if err != nil {
+		return nil, err
+	}
+
+	normText := buildNormText(g)
+	topK := config.MustGetInt("TOP_K", 5)
+	threshold := config.MustGetFloat("SIM_THRESHOLD", 0.86)
+	sreq := searchReq{Text: normText, TopK: topK, Threshold: threshold, Namespace: vectorNamespace(workspaceID)}
+	// Duplicate detection is best effort: an unreachable vector store skips it instead of failing the job
+	searchStart := time.Now()
+	s, err := searchSimilarSpecs(parent, llmBackend, sreq)
+	recordSpecJobTiming(db, jobID, timingVectorSearch, time.Since(searchStart))
+	dedupSkipped := err != nil
+	if dedupSkipped {
+		log.Printf("[WARNING] Skipping duplicate detection for job %s: %v", jobID, err)
+		markDedupSkipped(parent, db, jobID)
+	}
+
+	if len(s.Similar) > 0 {
+		sort.SliceStable(s.Similar, func(i, j int) bool { return s.Similar[i].Score > s.Similar[j].Score })
+		maxScore := s.Similar[0].Score
+		if maxScore >= threshold {
+			dupIDs := make([]string, 0, len(s.Similar))
+			for _, it := range s.Similar {
+				dupIDs = append(dupIDs, specIDFromVectorID(it.SpecID))
+			}
+			dupCtx, dupCancel := queryCtx(parent)
+			tag, err := db.Exec(dupCtx, `UPDATE gen_spec_jobs SET status='DUPLICATE', duplicate_of=$2, score_similarity=$3, finished_at=now() WHERE id=$1 AND status IN ('QUEUED','RUNNING')`,
+				jobID, dupIDs, maxScore)
+			if err == nil && tag.RowsAffected() > 0 {
+				releaseSpecQuota(dupCtx, db, jobID)
+			}
+			dupCancel()
+			// Only the closest matches are returned; total_matches tells the client if there were more
+			similar := s.Similar
+			if limit := config.MustGetInt("DUPLICATE_RESULT_LIMIT", 5); limit > 0 && len(similar) > limit {
+				similar = similar[:limit]
+			}
+			list := make([]SimilarSpec, 0, len(similar))
+			for _, it := range similar {
+				list = append(list, SimilarSpec{ID: specIDFromVectorID(it.SpecID), Title: it.Title, Score: it.Score})
+			}
+			return fiber.Map{"job_id": jobID, "status": "DUPLICATE", "duplicate_list": list, "total_matches": len(s.Similar), "model": model}, nil
 		}
+	}
 
-		if len(s.Similar) > 0 {
-			maxScore := s.Similar[0].Score
-			if maxScore >= threshold {
-				dupIDs := make([]string, 0, len(s.Similar))
-				for _, it := range s.Similar {
-					dupIDs = append(dupIDs, it.SpecID)
-				}
-				_, _ = db.Exec(ctx, `UPDATE gen_spec_jobs SET status='DUPLICATE', duplicate_of=$2, score_similarity=$3, finished_at=now() WHERE id=$1`,
-					jobID, dupIDs, maxScore)
-				list := make([]SimilarSpec, 0, len(s.Similar))
-				for _, it := range s.Similar {
-					list = append(list, SimilarSpec{ID: it.SpecID, Title: it.Title, Score: it.Score})
-				}
-				return c.Status(200).JSON(fiber.Map{"job_id": jobID, "status": "DUPLICATE", "duplicate_list": list})
+	hash, err := hashSpec(g.SpecJSON)
+	if err != nil {
+		return nil, middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+	// Identical spec_json can score below the similarity threshold, so catch exact copies by hash
+	if existingID, err := findSpecByHash(parent, db, workspaceID, hash); err != nil {
+		return nil, middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	} else if existingID != "" {
+		return hashDuplicateResult(parent, db, jobID, existingID, model), nil
+	}
+
+	specID := uuid.New().String()
+	persistCtx, persistSpan := tracing.Start(parent, "db.persist_spec", attribute.String("spec.id", specID))
+	err = persistSpec(persistCtx, db, workspaceID, jobID, specID, req, g, hash, rating)
+	tracing.End(persistSpan, err)
+	if err != nil {
+		// A concurrent job inserted the same spec between the lookup and the insert
+		if errors.Is(err, errSpecHashConflict) {
+			if existingID, lookupErr := findSpecByHash(parent, db, workspaceID, hash); lookupErr == nil && existingID != "" {
+				return hashDuplicateResult(parent, db, jobID, existingID, model), nil
 			}
 		}
+		return nil, middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
 
-		hash, err := hashSpec(g.SpecJSON)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	// The vector upsert can't join the transaction, so undo the persisted spec if it fails
+	up := upsertReq{SpecID: vectorID(workspaceID, specID), Text: normText, Payload: map[string]interface{}{"title": g.Title}, Namespace: vectorNamespace(workspaceID)}
+	upsertStart := time.Now()
+	reason, err := upsertSpecVector(parent, llmBackend, up)
+	recordSpecJobTiming(db, jobID, timingVectorUpsert, time.Since(upsertStart))
+	if err != nil {
+		// The store was already unreachable for the search, so keep the spec without its vector
+		if !dedupSkipped {
+			rollbackPersistedSpec(db, jobID, specID, reason)
+			return nil, err
 		}
-		specID := uuid.New().String()
-		_, err = db.Exec(ctx, `INSERT INTO game_specs (id,title,brief,spec_markdown,spec_json,spec_hash,genre,duration_sec,state)
-			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
-			specID, g.Title, req.Brief, g.SpecMarkdown, g.SpecJSON, hash, g.SpecJSON["genre"], g.SpecJSON["duration_sec"], StateCreating)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		log.Printf("[WARNING] Spec %s was kept without a vector: %s", specID, reason)
+	}
+
+	// Always trigger code generation automatically (removed flag check).
+	// The request span ends when the handler returns, so the goroutine continues the trace from its span context.
+	codeJobID := uuid.New().String()
+	spanCtx := trace.SpanContextFromContext(parent)
+	go func() {
+		ctx, span := tracing.Start(trace.ContextWithSpanContext(context.Background(), spanCtx), "code_job.create",
+			attribute.String("spec.id", specID), attribute.String("code_job.id", codeJobID))
+		defer span.End()
+
+		// Update state to git_initing
+		if err := updateGameSpecState(db, specID, StateGitIniting, "Starting git repository initialization"); err != nil {
+			log.Printf("Failed to update state to git_initing: %v", err)
 		}
 
-		// Use updateGameSpecState instead of manual insert
-		if err := updateGameSpecState(db, specID, StateCreating, "Game spec created"); err != nil {
-			log.Printf("Failed to log initial state: %v", err)
+		// Initialize git repository
+		gitRepo := utils.NewGitRepo()
+
+		codeReq := CreateCodeJobReq{
+			GameSpecID: specID,
+			GameSpec:   g.SpecJSON,
+			OutputPath: gitRepo.RepoPath,
 		}
 
-		up := upsertReq{SpecID: specID, Text: normText, Payload: map[string]interface{}{"title": g.Title}}
-		ub, _ := json.Marshal(up)
-		resp3, err := http.Post(llmBackend+"/vector/upsert", "application/json", bytes.NewReader(ub))
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadGateway, "vector upsert failed: "+err.Error())
+		// Call the existing code generation logic
+		now := time.Now()
+
+		// Insert code job
+		insertCtx, insertCancel := queryCtx(ctx)
+		defer insertCancel()
+		_, err := db.Exec(insertCtx, `
+		INSERT INTO code_jobs (id, game_spec_id, game_spec, output_path, workspace_id, user_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, (SELECT user_id FROM game_specs WHERE id = $2), 'queued', $6, $7)
+		`, codeJobID, specID, g.SpecJSON, codeReq.OutputPath, workspaceID, now, now)
+
+		if err == nil {
+			dispatchCodeJob(ctx, db, codeJobID, workspaceID, codeReq)
+
+			log.Printf("[INFO] Auto-triggered code generation job %s for spec %s", codeJobID, specID)
+		} else {
+			log.Printf("[ERROR] Failed to create code job: %v", err)
+			span.RecordError(err)
 		}
-		defer resp3.Body.Close()
-		if resp3.StatusCode != 200 {
-			return fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("upsert status %d", resp3.StatusCode))
+	}()
+
+	result := fiber.Map{"job_id": jobID, "status": "COMPLETED", "result_spec_id": specID, "model": model}
+	if dedupSkipped {
+		result["dedup_skipped"] = true
+	}
+	return result, nil
+}
+
+// rateSpec classifies a generated spec and fails its job when it is rated M in a workspace that
+// doesn't allow mature content, or when the workspace setting can't be read
+func rateSpec(parent context.Context, db *pgxpool.Pool, workspaceID *string, jobID string, g genSpecResp) (content.AgeRating, error) {
+	rating := content.ClassifyAgeRating(g.SpecMarkdown)
+	if rating != content.RatingMature {
+		return rating, nil
+	}
+	allowed, err := workspaceAllowsMature(parent, db, workspaceID)
+	if err != nil {
+		failSpecJob(db, jobID, err.Error())
+		return "", middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		failSpecJob(db, jobID, "spec rated M, mature content is not allowed in this workspace")
+		return "", middleware.NewProblem(fiber.StatusUnprocessableEntity, "Generated spec is rated M and this workspace does not allow mature content").
+			WithCode(codeMatureContent).
+			With("age_rating", rating)
+	}
+	return rating, nil
+}
+
+// specHashConstraint keeps a spec_json unique within a workspace (migration 0010)
+const specHashConstraint = "game_specs_workspace_spec_hash_key"
+
+// errSpecHashConflict is returned by persistSpec when the workspace already has a spec with the same hash
+var errSpecHashConflict = errors.New("a spec with the same spec_json already exists in this workspace")
+
+// findSpecByHash returns the id of the workspace spec with this hash, or "" when there is none
+func findSpecByHash(parent context.Context, db *pgxpool.Pool, workspaceID *string, hash string) (string, error) {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	var id string
+	err := db.QueryRow(ctx, `SELECT id FROM game_specs WHERE spec_hash = $1 AND workspace_id IS NOT DISTINCT FROM $2`, hash, workspaceID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+// markDedupSkipped records that a job's spec was not checked against similar specs
+func markDedupSkipped(parent context.Context, db *pgxpool.Pool, jobID string) {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	if _, err := db.Exec(ctx, `UPDATE gen_spec_jobs SET dedup_skipped = true WHERE id = $1`, jobID); err != nil {
+		log.Printf("[ERROR] Failed to mark job %s dedup_skipped: %v", jobID, err)
+	}
+}
+
+// hashDuplicateResult finishes a job whose spec already exists and points it at that spec
+func hashDuplicateResult(parent context.Context, db *pgxpool.Pool, jobID, existingID, model string) fiber.Map {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	tag, err := db.Exec(ctx, `UPDATE gen_spec_jobs SET status='HASH_DUPLICATE', duplicate_of=$2, result_spec_id=$3, score_similarity=1, finished_at=now() WHERE id=$1 AND status IN ('QUEUED','RUNNING')`,
+		jobID, []string{existingID}, existingID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to mark job %s as HASH_DUPLICATE: %v", jobID, err)
+	} else if tag.RowsAffected() > 0 {
+		releaseSpecQuota(ctx, db, jobID)
+	}
+	log.Printf("[INFO] Job %s produced the same spec as %s", jobID, existingID)
+	return fiber.Map{"job_id": jobID, "status": "HASH_DUPLICATE", "result_spec_id": existingID, "model": model}
+}
+
+var (
+	vectorSearchOnce  sync.Once
+	vectorSearchSlots chan struct{}
+)
+
+// acquireVectorSearch waits for one of the VECTOR_SEARCH_CONCURRENCY slots (0, the default, is
+// unlimited) and returns the func releasing it
+func acquireVectorSearch(ctx context.Context) (func(), error) {
+	vectorSearchOnce.Do(func() {
+		if n := config.MustGetInt("VECTOR_SEARCH_CONCURRENCY", 0); n > 0 {
+			vectorSearchSlots = make(chan struct{}, n)
 		}
+	})
+	if vectorSearchSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case vectorSearchSlots <- struct{}{}:
+		return func() { <-vectorSearchSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-		_, _ = db.Exec(ctx, `UPDATE gen_spec_jobs SET status='COMPLETED', result_spec_id=$2, finished_at=now() WHERE id=$1`, jobID, specID)
+// searchSimilarSpecs asks the vector store for specs similar to a new one. Waiting for a slot
+// and the request itself are bounded by VECTOR_HTTP_TIMEOUT.
+func searchSimilarSpecs(ctx context.Context, llmBackend string, sreq searchReq) (s searchResp, err error) {
+	ctx, span := tracing.Start(ctx, "vector.search", attribute.Int("vector.top_k", sreq.TopK))
+	defer func() { tracing.End(span, err) }()
 
-		// Always trigger code generation automatically (removed flag check)
-		codeJobID := uuid.New().String()
-		go func() {
-			// Update state to git_initing
-			if err := updateGameSpecState(db, specID, StateGitIniting, "Starting git repository initialization"); err != nil {
-				log.Printf("Failed to update state to git_initing: %v", err)
-			}
+	ctx, cancel := context.WithTimeout(ctx, config.MustGetDuration("VECTOR_HTTP_TIMEOUT", 10*time.Second))
+	defer cancel()
+	release, err := acquireVectorSearch(ctx)
+	if err != nil {
+		return s, middleware.NewProblem(fiber.StatusBadGateway, "vector search failed: "+err.Error()).
+			WithCode(codeVectorUnavailable)
+	}
+	defer release()
 
-			// Initialize git repository
-			gitRepo := utils.NewGitRepo()
+	sb, _ := json.Marshal(sreq)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, llmBackend+"/vector/search", bytes.NewReader(sb))
+	if err != nil {
+		return s, middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return s, middleware.NewProblem(fiber.StatusBadGateway, "vector search failed: "+err.Error()).
+			WithCode(codeVectorUnavailable)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return s, middleware.NewProblem(fiber.StatusBadGateway, fmt.Sprintf("vector status %d", resp.StatusCode)).
+			WithCode(codeVectorUnavailable)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return s, middleware.NewProblem(fiber.StatusBadGateway, err.Error()).
+			WithCode(codeVectorUnavailable)
+	}
+	return s, nil
+}
 
-			codeReq := CreateCodeJobReq{
-				GameSpecID: specID,
-				GameSpec:   g.SpecJSON,
-				OutputPath: gitRepo.RepoPath,
-			}
+// upsertSpecVector stores the embedding of a persisted spec. On failure it also returns the
+// reason recorded on the rolled back job.
+func upsertSpecVector(ctx context.Context, llmBackend string, up upsertReq) (reason string, err error) {
+	_, span := tracing.Start(ctx, "vector.upsert", attribute.String("vector.id", up.SpecID))
+	defer func() { tracing.End(span, err) }()
+	defer embeddingCache.Delete(specIDFromVectorID(up.SpecID))
 
-			// Call the existing code generation logic
-			now := time.Now()
+	ub, _ := json.Marshal(up)
+	resp, err := http.Post(llmBackend+"/vector/upsert", "application/json", bytes.NewReader(ub))
+	if err != nil {
+		reason = "vector upsert failed: " + err.Error()
+		return reason, middleware.NewProblem(fiber.StatusBadGateway, reason).WithCode(codeVectorUnavailable)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		reason = fmt.Sprintf("upsert status %d", resp.StatusCode)
+		return reason, middleware.NewProblem(fiber.StatusBadGateway, reason).WithCode(codeVectorUnavailable)
+	}
+	return "", nil
+}
 
-			// Insert code job
-			_, err := db.Exec(context.Background(), `
-		INSERT INTO code_jobs (id, game_spec_id, game_spec, output_path, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, 'queued', $5, $6)
-		`, codeJobID, specID, g.SpecJSON, codeReq.OutputPath, now, now)
+// persistSpec inserts the spec, its initial state log and the job completion in one transaction
+// so a failure never leaves a spec without its log or a finished job still RUNNING
+func persistSpec(parent context.Context, db *pgxpool.Pool, workspaceID *string, jobID, specID string, req CreateJobReq, g genSpecResp, hash string, rating content.AgeRating) error {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
 
-			if err == nil {
-				go processCodeGeneration(db, codeJobID, codeReq)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	complexity := specschema.EstimateComplexity(g.SpecJSON)
+	// A conflict on specHashConstraint inserts nothing instead of aborting the transaction
+	var insertedID string
+	// The spec belongs to the user who started its job
+	err = tx.QueryRow(ctx, `INSERT INTO game_specs (id,title,brief,spec_markdown,spec_json,spec_hash,genre,duration_sec,state,workspace_id,complexity_score,complexity_level,age_rating,tutorial_markdown,user_id)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,NULLIF($14, ''),(SELECT user_id FROM gen_spec_jobs WHERE id = $15))
+		ON CONFLICT ON CONSTRAINT `+specHashConstraint+` DO NOTHING
+		RETURNING id`,
+		specID, g.Title, req.Brief, g.SpecMarkdown, g.SpecJSON, hash, g.SpecJSON["genre"], g.SpecJSON["duration_sec"], StateCreating, workspaceID,
+		complexity.Score, complexity.Level, rating, g.TutorialMarkdown, jobID).Scan(&insertedID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errSpecHashConflict
+	}
+	if err != nil {
+		return err
+	}
 
-				log.Printf("[INFO] Auto-triggered code generation job %s for spec %s", codeJobID, specID)
-			} else {
-				log.Printf("[ERROR] Failed to create code job: %v", err)
-			}
-		}()
+	before, err := es.AppendEvent(ctx, tx, specID, StateCreating.String(), "Game spec created")
+	if err != nil {
+		return fmt.Errorf("failed to log initial state: %v", err)
+	}
 
-		return c.Status(200).JSON(fiber.Map{"job_id": jobID, "status": "COMPLETED", "result_spec_id": specID})
+	_, err = tx.Exec(ctx, `UPDATE gen_spec_jobs SET status='COMPLETED', result_spec_id=$2, finished_at=now() WHERE id=$1`, jobID, specID)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %v", err)
 	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit spec: %v", err)
+	}
+
+	log.Printf("[STATE] Spec %s: %s → %s (%s)", specID, before, StateCreating, "Game spec created")
+	webhook.NotifyStateTransition(webhook.StateTransition{
+		SpecID: specID, StateBefore: before, StateAfter: StateCreating.String(), Detail: "Game spec created", Timestamp: time.Now().UTC(),
+	})
+	return nil
+}
+
+// rollbackPersistedSpec compensates persistSpec when a later external step fails
+func rollbackPersistedSpec(db *pgxpool.Pool, jobID, specID, reason string) {
+	ctx, cancel := queryCtx(context.Background())
+	defer cancel()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Failed to roll back spec %s: %v", specID, err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM game_specs WHERE id = $1`, specID); err != nil {
+		log.Printf("[ERROR] Failed to roll back spec %s: %v", specID, err)
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE gen_spec_jobs SET status='FAILED', result_spec_id=NULL, error=$2, finished_at=now() WHERE id=$1`, jobID, reason); err != nil {
+		log.Printf("[ERROR] Failed to mark job %s failed: %v", jobID, err)
+		return
+	}
+	releaseSpecQuota(ctx, tx, jobID)
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("[ERROR] Failed to roll back spec %s: %v", specID, err)
+		return
+	}
+	invalidateSpec(specID)
+	log.Printf("[WARNING] Rolled back spec %s for job %s: %s", specID, jobID, reason)
 }
 
 func GetJob(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		id := c.Params("id")
-		ctx := context.Background()
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
 		var status string
 		var resultID *string
 		var dupIDs []uuid.UUID
 		var errStr *string
-		row := db.QueryRow(ctx, `SELECT status, result_spec_id, duplicate_of, error FROM gen_spec_jobs WHERE id=$1`, id)
-		if err := row.Scan(&status, &resultID, &dupIDs, &errStr); err != nil {
-			return fiber.NewError(fiber.StatusNotFound, "job not found")
+		var model *string
+		var dedupSkipped bool
+		var timing map[string]int64
+		var briefTruncated bool
+		row := db.QueryRow(ctx, `SELECT status, result_spec_id, duplicate_of, error, model, dedup_skipped, timing, brief_original IS NOT NULL FROM gen_spec_jobs WHERE id=$1 AND workspace_id IS NOT DISTINCT FROM $2`, id, middleware.WorkspaceID(c))
+		if err := row.Scan(&status, &resultID, &dupIDs, &errStr, &model, &dedupSkipped, &timing, &briefTruncated); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "job not found")
+			}
+			log.Printf("[ERROR] Failed to load spec job %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
 		}
-		resp := JobStatusResp{Status: status, Error: errStr}
+		resp := JobStatusResp{Status: status, Model: model, Error: errStr, DedupSkipped: dedupSkipped, BriefTruncated: briefTruncated, Timing: timing}
 		if resultID != nil {
 			v := *resultID
 			resp.ResultSpecID = &v
@@ -300,34 +677,108 @@ func GetJob(db *pgxpool.Pool) fiber.Handler {
 
 func ListSpecs(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx := context.Background()
+		limit := pageSize(c.QueryInt("limit", defaultPageSize))
+
+		// Keyset pagination on (created_at, id) keeps pages stable while new specs are created
+		var cursorTime *time.Time
+		var cursorID *string
+		if raw := c.Query("cursor"); raw != "" {
+			cur, err := decodeSpecCursor(raw)
+			if err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+			}
+			cursorTime, cursorID = &cur.CreatedAt, &cur.ID
+		}
+
+		// Optional filters; devin_status takes a comma-separated list (e.g. working,failed)
+		var devinStatuses []string
+		for _, status := range strings.Split(c.Query("devin_status"), ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				devinStatuses = append(devinStatuses, status)
+			}
+		}
+
+		var ageRating string
+		if raw := c.Query("age_rating"); raw != "" {
+			r, ok := content.ParseAgeRating(raw)
+			if !ok {
+				return middleware.NewProblem(fiber.StatusBadRequest, "age_rating must be E, T or M")
+			}
+			ageRating = string(r)
+		}
+
+		var state GameSpecState
+		if raw := c.Query("state"); raw != "" {
+			parsed, err := ParseGameSpecState(raw)
+			if err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+			}
+			state = parsed
+		}
+
+		var minRating *float64
+		if raw := c.Query("min_rating"); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || v < 1 || v > 5 {
+				return middleware.NewProblem(fiber.StatusBadRequest, "min_rating must be a number between 1 and 5")
+			}
+			minRating = &v
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
 		rows, err := db.Query(ctx, `
-			SELECT id, title, brief, state, created_at
+			SELECT id, title, brief, state, created_at, devin_status, devin_session_id, devin_session_url, complexity_score, complexity_level, age_rating, average_rating, feedback_count
 			FROM game_specs
-			ORDER BY created_at DESC
-			LIMIT 50
-		`)
+			WHERE workspace_id IS NOT DISTINCT FROM $1
+				AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3::uuid))
+				AND ($5 = '' OR state = $5)
+				AND ($6 = '' OR lower(genre) = lower($6))
+				AND ($7::text[] IS NULL OR devin_status = ANY($7))
+				AND ($8 = '' OR complexity_level = $8)
+				AND ($9 = '' OR age_rating = $9)
+				AND ($10::float8 IS NULL OR average_rating >= $10)
+				AND ($11::text IS NULL OR user_id = $11)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $4
+		`, middleware.WorkspaceID(c), cursorTime, cursorID, limit, state, c.Query("genre"), devinStatuses, c.Query("complexity_level"), ageRating, minRating, specOwnerFilter(c))
 		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+			return middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
 		}
 		defer rows.Close()
 
 		type item struct {
-			ID        string    `json:"id"`
-			Title     string    `json:"title"`
-			Brief     string    `json:"brief"`
-			State     string    `json:"state"`
-			CreatedAt time.Time `json:"created_at"`
+			ID              string        `json:"id"`
+			Title           string        `json:"title"`
+			Brief           string        `json:"brief"`
+			State           GameSpecState `json:"state"`
+			CreatedAt       time.Time     `json:"created_at"`
+			DevinStatus     *string       `json:"devin_status,omitempty"`
+			DevinSessionID  *string       `json:"devin_session_id,omitempty"`
+			DevinSessionURL string        `json:"devin_session_url,omitempty"`
+			ComplexityScore *int          `json:"complexity_score"`
+			ComplexityLevel *string       `json:"complexity_level"`
+			AgeRating       *string       `json:"age_rating"`
+			AverageRating   *float64      `json:"average_rating"`
+			FeedbackCount   int           `json:"feedback_count"`
 		}
 
 		var out []item
 		for rows.Next() {
 			var it item
-			if err := rows.Scan(&it.ID, &it.Title, &it.Brief, &it.State, &it.CreatedAt); err != nil {
+			var devinURL *string
+			if err := rows.Scan(&it.ID, &it.Title, &it.Brief, &it.State, &it.CreatedAt, &it.DevinStatus, &it.DevinSessionID, &devinURL, &it.ComplexityScore, &it.ComplexityLevel, &it.AgeRating, &it.AverageRating, &it.FeedbackCount); err != nil {
 				continue
 			}
+			if it.DevinSessionID != nil && *it.DevinSessionID != "" {
+				it.DevinSessionURL = utils.DevinSessionURL(*it.DevinSessionID, devinURL)
+			}
 			out = append(out, it)
 		}
+		if len(out) == limit {
+			last := out[len(out)-1]
+			c.Set(nextCursorHeader, encodeSpecCursor(last.CreatedAt, last.ID))
+		}
 		return c.JSON(out)
 	}
 }
@@ -335,103 +786,157 @@ func ListSpecs(db *pgxpool.Pool) fiber.Handler {
 func GetSpec(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		id := c.Params("id")
-		ctx := context.Background()
+		workspaceID := middleware.WorkspaceID(c)
+		owner := specOwnerFilter(c)
+		if cached, ok := specCache.Get(id); ok && sameWorkspace(cached.workspaceID, workspaceID) && visibleTo(cached.userID, owner) {
+			recordSpecView(db, id, workspaceID)
+			setSpecPreloadLinks(c, id)
+			return c.JSON(cached.response)
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
 
 		var spec struct {
-			ID             string  `json:"id"`
-			Title          string  `json:"title"`
-			Brief          string  `json:"brief"`
-			SpecMarkdown   string  `json:"spec_markdown"`
-			SpecJSON       []byte  `json:"spec_json"`
-			State          string  `json:"state"`
-			DevinSessionID *string `json:"devin_session_id"`
+			ID              string        `json:"id"`
+			Title           string        `json:"title"`
+			Brief           string        `json:"brief"`
+			State           GameSpecState `json:"state"`
+			DevinSessionID  *string       `json:"devin_session_id"`
+			DevinURL        *string       `json:"-"`
+			ComplexityScore *int          `json:"complexity_score"`
+			ComplexityLevel *string       `json:"complexity_level"`
+			AgeRating       *string       `json:"age_rating"`
+			AverageRating   *float64      `json:"average_rating"`
+			FeedbackCount   int           `json:"feedback_count"`
+			DeployURL       *string       `json:"deploy_url"`
+			UserID          *string       `json:"user_id"`
 		}
 
 		err := db.QueryRow(ctx, `
-			SELECT id, title, brief, spec_markdown, spec_json, state, devin_session_id
+			SELECT id, title, brief, state, devin_session_id, devin_session_url, complexity_score, complexity_level, age_rating, average_rating, feedback_count, deploy_url, user_id
 			FROM game_specs
-			WHERE id = $1
-		`, id).Scan(&spec.ID, &spec.Title, &spec.Brief, &spec.SpecMarkdown, &spec.SpecJSON, &spec.State, &spec.DevinSessionID)
+			WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND ($3::text IS NULL OR user_id = $3)
+		`, id, workspaceID, owner).Scan(&spec.ID, &spec.Title, &spec.Brief, &spec.State, &spec.DevinSessionID, &spec.DevinURL, &spec.ComplexityScore, &spec.ComplexityLevel, &spec.AgeRating, &spec.AverageRating, &spec.FeedbackCount, &spec.DeployURL, &spec.UserID)
 
 		if err != nil {
-			if err == sql.ErrNoRows {
-				return fiber.NewError(fiber.StatusNotFound, "Spec not found")
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
 			}
-			return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+			log.Printf("[ERROR] Failed to load spec %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
 		}
 
-		// Parse spec_json
+		// Old specs may have been moved to S3, leaving a stub in the content columns
+		sc, err := readSpecContent(c.UserContext(), db, id)
+		if err != nil {
+			return specContentProblem(id, err)
+		}
 		var specJSON map[string]interface{}
-		if err := json.Unmarshal(spec.SpecJSON, &specJSON); err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+		if err := json.Unmarshal(sc.SpecJSON, &specJSON); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
 		}
 
-		// Fetch state logs
-		stateLogsRows, err := db.Query(ctx, `
-			SELECT state_before, state_after, detail, created_at
-			FROM game_spec_states
-			WHERE game_spec_id = $1
-			ORDER BY created_at ASC
-		`, id)
+		// The state is rebuilt from the event log; the column is only an eventually consistent projection
+		stateLogs, err := es.ListEvents(ctx, db, id)
 		if err != nil {
 			log.Printf("Error fetching state logs: %v", err)
-			// Continue without state logs rather than failing
-		}
-		defer stateLogsRows.Close()
-
-		type StateLog struct {
-			StateBefore *string   `json:"state_before"`
-			StateAfter  string    `json:"state_after"`
-			Detail      *string   `json:"detail,omitempty"`
-			CreatedAt   time.Time `json:"created_at"`
-		}
-
-		var stateLogs []StateLog
-		for stateLogsRows.Next() {
-			var stateLog StateLog
-			if err := stateLogsRows.Scan(&stateLog.StateBefore, &stateLog.StateAfter, &stateLog.Detail, &stateLog.CreatedAt); err != nil {
-				log.Printf("Error scanning state log: %v", err)
-				continue
-			}
-			stateLogs = append(stateLogs, stateLog)
+			// Continue with the projected state rather than failing
+		} else {
+			spec.State = GameSpecState(es.Replay(stateLogs))
 		}
 
 		response := fiber.Map{
-			"id":            spec.ID,
-			"title":         spec.Title,
-			"brief":         spec.Brief,
-			"spec_markdown": spec.SpecMarkdown,
-			"spec_json":     specJSON,
-			"state":         spec.State,
-			"state_logs":    stateLogs,
+			"id":               spec.ID,
+			"title":            spec.Title,
+			"brief":            spec.Brief,
+			"spec_markdown":    sc.SpecMarkdown,
+			"spec_json":        specJSON,
+			"state":            spec.State,
+			"state_logs":       stateLogs,
+			"complexity_score": spec.ComplexityScore,
+			"complexity_level": spec.ComplexityLevel,
+			"age_rating":       spec.AgeRating,
+			"average_rating":   spec.AverageRating,
+			"feedback_count":   spec.FeedbackCount,
+			"deploy_url":       spec.DeployURL,
+			"user_id":          spec.UserID,
 		}
 
 		// Add Devin session information if available
 		if spec.DevinSessionID != nil && *spec.DevinSessionID != "" {
 			response["devin_session_id"] = *spec.DevinSessionID
-			response["devin_session_url"] = fmt.Sprintf("https://app.devin.ai/sessions/%s", *spec.DevinSessionID)
+			response["devin_session_url"] = utils.DevinSessionURL(*spec.DevinSessionID, spec.DevinURL)
 		}
 
+		specCache.Set(spec.ID, cachedSpec{workspaceID: workspaceID, userID: spec.UserID, response: response})
+		recordSpecView(db, spec.ID, workspaceID)
+		setSpecPreloadLinks(c, spec.ID)
 		return c.JSON(response)
 	}
 }
 
+// setSpecPreloadLinks hints the detail page's follow-up requests. Fiber only serves HTTP/1.1,
+// so pushing them is left to HTTP/2 proxies.
+func setSpecPreloadLinks(c *fiber.Ctx, specID string) {
+	c.Append(fiber.HeaderLink,
+		fmt.Sprintf("</api/specs/%s/code-job>; rel=preload; as=fetch; crossorigin", specID),
+		fmt.Sprintf("</api/specs/%s/state-logs>; rel=preload; as=fetch; crossorigin", specID),
+	)
+}
+
 // DeleteSpec deletes a game spec from both database and vector database
 func DeleteSpec(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		id := c.Params("id")
-		ctx := context.Background()
+		workspaceID := middleware.WorkspaceID(c)
+		tx, err := db.Begin(c.UserContext())
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		defer tx.Rollback(context.Background())
+		if err := lockSpec(c.UserContext(), tx, id); err != nil {
+			return err
+		}
 
 		// First, check if the spec exists and get its title
-		var exists bool
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, tx, c, id); err != nil {
+			return err
+		}
 		var gameTitle string
-		err := db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM game_specs WHERE id = $1), COALESCE((SELECT title FROM game_specs WHERE id = $1), '')", id).Scan(&exists, &gameTitle)
+		err = tx.QueryRow(ctx, "SELECT title FROM game_specs WHERE id = $1", id).Scan(&gameTitle)
+		cancel()
 		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Database error")
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
 		}
 
-		if !exists {
-			return fiber.NewError(fiber.StatusNotFound, "Spec not found")
+		// Delete related code_jobs first to avoid foreign key constraint violation
+		ctx, cancel = queryCtx(c.UserContext())
+		defer cancel()
+		_, err = tx.Exec(ctx, "DELETE FROM code_jobs WHERE game_spec_id = $1", id)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to delete related code jobs")
+		}
+
+		// Now delete the game spec
+		_, err = tx.Exec(ctx, "DELETE FROM game_specs WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2", id, workspaceID)
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to delete from database")
+		}
+		invalidateSpec(id)
+
+		// The vector and the git folder are cleaned up once the spec is gone and its lock released.
+		// Neither can join the transaction, so a failure is reported instead of undoing the delete.
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		vectorCleanupSuccess := true
+		if err := deleteSpecVector(c.UserContext(), llmBackend, vectorID(workspaceID, id)); err != nil {
+			log.Printf("[ERROR] Failed to delete vector of deleted spec %s: %v", id, err)
+			vectorCleanupSuccess = false
 		}
 
 		// Initialize git repository for cleanup with enhanced error handling
@@ -455,48 +960,19 @@ func DeleteSpec(db *pgxpool.Pool) fiber.Handler {
 			log.Printf("[INFO] Git repository not configured, skipping folder cleanup for spec %s", id)
 		}
 
-		// Get LLM backend URL
-		llmBackend := os.Getenv("LLM_BACKEND_URL")
-		if llmBackend == "" {
-			llmBackend = "http://localhost:8000"
-		}
-
-		// Delete from vector database first
-		vectorDeleteURL := fmt.Sprintf("%s/vector/spec/%s", llmBackend, id)
-		req, err := http.NewRequest("DELETE", vectorDeleteURL, nil)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to create delete request")
-		}
-
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete from vector database")
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete from vector database")
-		}
-
-		// Delete related code_jobs first to avoid foreign key constraint violation
-		_, err = db.Exec(ctx, "DELETE FROM code_jobs WHERE game_spec_id = $1", id)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete related code jobs")
-		}
-
-		// Now delete the game spec
-		_, err = db.Exec(ctx, "DELETE FROM game_specs WHERE id = $1", id)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete from database")
-		}
-
 		// Prepare response with git cleanup status
 		response := fiber.Map{
 			"message": "Spec deleted successfully",
 			"id":      id,
 		}
 
+		if vectorCleanupSuccess {
+			response["vector_cleanup"] = "success"
+		} else {
+			response["vector_cleanup"] = "failed"
+			response["vector_cleanup_warning"] = "Vector may still be returned by similarity search"
+		}
+
 		if gitRepo.IsConfigured() {
 			if gitCleanupSuccess {
 				response["git_cleanup"] = "success"
@@ -517,66 +993,110 @@ func CreateDevinTask(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		specID := c.Params("id")
 		if specID == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "Spec ID is required",
-			})
+			return middleware.NewProblem(400, "Spec ID is required")
 		}
 
-		ctx := context.Background()
-
-		// Check if spec exists and get spec content
-		var gameTitle, specContent string
-		err := db.QueryRow(ctx, `SELECT title, spec_markdown FROM game_specs WHERE id = $1`, specID).Scan(&gameTitle, &specContent)
+		// Check if spec exists and get its title
+		ctx, cancel := queryCtx(c.UserContext())
+		if err := requireSpec(ctx, db, c, specID); err != nil {
+			cancel()
+			return err
+		}
+		var gameTitle string
+		var existingSessionID, existingURL, existingStatus *string
+		err := db.QueryRow(ctx, `SELECT title, devin_session_id, devin_session_url, devin_status FROM game_specs WHERE id = $1`,
+			specID).Scan(&gameTitle, &existingSessionID, &existingURL, &existingStatus)
+		cancel()
 		if err != nil {
-			if err == sql.ErrNoRows {
-				return c.Status(404).JSON(fiber.Map{
-					"error": "Game spec not found",
-				})
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(404, "Game spec not found")
 			}
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Database error",
-			})
+			log.Printf("[ERROR] Failed to load spec %s for Devin task: %v", specID, err)
+			return middleware.NewProblem(500, "Database error")
 		}
 
 		// Initialize git repository
 		gitRepo := utils.NewGitRepo()
 		if !gitRepo.IsConfigured() {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "Git repository not configured. Devin tasks require git integration.",
-			})
+			return middleware.NewProblem(400, "Git repository not configured. Devin tasks require git integration.")
+		}
+
+		// Reuse a session that is still running unless the caller forces a new one,
+		// so double clicks don't start (and pay for) duplicate sessions
+		if existingSessionID != nil && *existingSessionID != "" && !c.QueryBool("force") {
+			status := ""
+			if existingStatus != nil {
+				status = *existingStatus
+			}
+			if latest, err := utils.GetDevinSessionStatus(*existingSessionID); err != nil {
+				// When Devin can't be reached assume the session is still running
+				log.Printf("[WARNING] Failed to refresh Devin session %s status: %v", *existingSessionID, err)
+			} else if latest != status {
+				status = latest
+				ctx, cancel = queryCtx(c.UserContext())
+				if err := updateDevinSessionStatus(ctx, db, specID, *existingSessionID, status); err != nil {
+					log.Printf("[ERROR] Failed to store status of Devin session %s: %v", *existingSessionID, err)
+				}
+				cancel()
+			}
+
+			if !utils.IsTerminalDevinStatus(status) {
+				return c.JSON(fiber.Map{
+					"message":        "Devin task already exists",
+					"spec_id":        specID,
+					"game_title":     gameTitle,
+					"session_id":     *existingSessionID,
+					"session_url":    utils.DevinSessionURL(*existingSessionID, existingURL),
+					"session_status": status,
+					"repository":     gitRepo.GameURL(specID, gameTitle),
+					"already_exists": true,
+					"status":         "success",
+				})
+			}
+		}
+
+		// The claim keeps concurrent calls from both creating a session without holding the
+		// spec lock across the Devin call
+		if err := claimDevinTask(c.UserContext(), db, specID, existingSessionID); err != nil {
+			return err
 		}
 
 		// Create Devin task and get session ID
-		sessionID, err := gitRepo.CreateDevinTask(specID, gameTitle)
+		session, err := gitRepo.CreateDevinTask(specID, gameTitle, "")
 		if err != nil {
+			releaseDevinClaim(db, specID)
 			log.Printf("[ERROR] Failed to create Devin task for spec %s: %v", specID, err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": fmt.Sprintf("Failed to create Devin task: %v", err),
-			})
+			var apiErr *utils.DevinAPIError
+			if errors.As(err, &apiErr) {
+				return middleware.NewProblem(fiber.StatusBadGateway, fmt.Sprintf("Devin API returned status %d", apiErr.StatusCode)).
+					WithCode(codeDevinError).
+					With("devin_status", apiErr.StatusCode).
+					With("devin_response", apiErr.Body)
+			}
+			return middleware.NewProblem(500, fmt.Sprintf("Failed to create Devin task: %v", err))
 		}
 
-		log.Printf("[DEBUG] Original session ID from Devin: '%s' (length: %d)", sessionID, len(sessionID))
-
-		_, err = db.Exec(ctx, `UPDATE game_specs SET devin_session_id = $1 WHERE id = $2`, sessionID, specID)
+		ctx, cancel = queryCtx(context.Background())
+		defer cancel()
+		err = recordDevinSession(ctx, db, specID, session)
+		invalidateSpec(specID)
 		if err != nil {
+			releaseDevinClaim(db, specID)
 			log.Printf("[ERROR] Failed to store Devin session ID in database: %v", err)
 			// Don't fail the request since the task was created successfully
 		}
 
-		log.Printf("[SUCCESS] Created Devin task for game spec %s (%s) with session ID: %s", specID, gameTitle, sessionID)
-
-		// Get repository URL for response
-		repoURL := os.Getenv("GIT_REPO_URL")
-		cleanRepoURL := strings.TrimSuffix(repoURL, ".git")
+		log.Printf("[SUCCESS] Created Devin task for game spec %s (%s) with session ID: %s", specID, gameTitle, session.ID)
 
 		return c.JSON(fiber.Map{
-			"message":     "Devin task created successfully",
-			"spec_id":     specID,
-			"game_title":  gameTitle,
-			"session_id":  sessionID,
-			"session_url": fmt.Sprintf("https://app.devin.ai/sessions/%s", sessionID),
-			"repository":  fmt.Sprintf("%s/tree/main/%s", cleanRepoURL, specID),
-			"status":      "success",
+			"message":        "Devin task created successfully",
+			"spec_id":        specID,
+			"game_title":     gameTitle,
+			"session_id":     session.ID,
+			"session_url":    session.URL,
+			"repository":     gitRepo.GameURL(specID, gameTitle),
+			"already_exists": false,
+			"status":         "success",
 		})
 	}
 }
@@ -584,44 +1104,16 @@ func CreateDevinTask(db *pgxpool.Pool) fiber.Handler {
 func GetSpecStateLogs(db *pgxpool.Pool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		id := c.Params("id")
-		ctx := context.Background()
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
 
-		// Check if spec exists
-		var exists bool
-		err := db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM game_specs WHERE id = $1)", id).Scan(&exists)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Database error")
-		}
-		if !exists {
-			return fiber.NewError(fiber.StatusNotFound, "Spec not found")
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
 		}
 
-		// Fetch state logs
-		rows, err := db.Query(ctx, `
-			SELECT state_before, state_after, detail, created_at
-			FROM game_spec_states
-			WHERE game_spec_id = $1
-			ORDER BY created_at ASC
-		`, id)
+		stateLogs, err := es.ListEvents(ctx, db, id)
 		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch state logs")
-		}
-		defer rows.Close()
-
-		type StateLog struct {
-			StateBefore *string   `json:"state_before"`
-			StateAfter  string    `json:"state_after"`
-			Detail      *string   `json:"detail,omitempty"`
-			CreatedAt   time.Time `json:"created_at"`
-		}
-
-		var stateLogs []StateLog
-		for rows.Next() {
-			var stateLog StateLog
-			if err := rows.Scan(&stateLog.StateBefore, &stateLog.StateAfter, &stateLog.Detail, &stateLog.CreatedAt); err != nil {
-				continue
-			}
-			stateLogs = append(stateLogs, stateLog)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to fetch state logs")
 		}
 
 		return c.JSON(fiber.Map{
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"backend/internal/content"
+	"backend/internal/dbtest"
+	"backend/internal/es"
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestUpdateGameSpecStateConcurrent(t *testing.T) {
+	pool := dbtest.New(t)
+	specID := newTestSpec(t, pool, nil, nil)
+
+	const transitions = 20
+	states := []GameSpecState{StateGitIniting, StateGitInited, StateCodeGenerating, StateCodeGenerated}
+	var wg sync.WaitGroup
+	errs := make(chan error, transitions)
+	for i := 0; i < transitions; i++ {
+		wg.Add(1)
+		go func(state GameSpecState) {
+			defer wg.Done()
+			errs <- updateGameSpecState(pool, specID, state, "concurrent transition")
+		}(states[i%len(states)])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	events, err := es.ListEvents(ctx, pool, specID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != transitions {
+		t.Fatalf("%d events logged, want %d", len(events), transitions)
+	}
+	// Every transition starts from the state the previous one left, so none was lost
+	before := es.InitialState
+	for i, ev := range events {
+		if ev.StateBefore == nil || *ev.StateBefore != before {
+			t.Fatalf("event %d: state_before = %v, want %q", i, ev.StateBefore, before)
+		}
+		before = ev.StateAfter
+	}
+
+	var state string
+	if err := pool.QueryRow(ctx, `SELECT state FROM game_specs WHERE id = $1`, specID).Scan(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state != before {
+		t.Errorf("game_specs.state = %q, want the last transition's %q", state, before)
+	}
+}
+
+func TestUpdateGameSpecStateMissingSpec(t *testing.T) {
+	pool := dbtest.New(t)
+	if err := updateGameSpecState(pool, "00000000-0000-0000-0000-000000000000", StateGitIniting, "missing"); err == nil {
+		t.Error("expected an error for a missing spec")
+	}
+}
+
+// newPreloadApp answers like a cached GetSpec, with or without the preload Link headers
+func newPreloadApp(links bool) *fiber.App {
+	response := testGenSpecResp()
+	app := fiber.New()
+	app.Get("/api/specs/:id", func(c *fiber.Ctx) error {
+		if links {
+			setSpecPreloadLinks(c, c.Params("id"))
+		}
+		return c.JSON(fiber.Map{"id": c.Params("id"), "title": response.Title, "spec_markdown": response.SpecMarkdown, "spec_json": response.SpecJSON})
+	})
+	return app
+}
+
+func TestSetSpecPreloadLinks(t *testing.T) {
+	resp, err := newPreloadApp(true).Test(httptest.NewRequest("GET", "/api/specs/spec-1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"</api/specs/spec-1/code-job>; rel=preload; as=fetch; crossorigin",
+		"</api/specs/spec-1/state-logs>; rel=preload; as=fetch; crossorigin",
+	}
+	got := resp.Header.Values(fiber.HeaderLink)
+	if len(got) == 1 {
+		// fasthttp folds repeated headers into one comma-separated value
+		got = strings.Split(got[0], ", ")
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+}
+
+func TestGetSpecPreloadLinks(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+	specID := newTestSpec(t, pool, nil, nil)
+
+	// Both the database read and the cached response carry the links
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/api/specs/"+specID, nil)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+		if len(resp.Header.Values(fiber.HeaderLink)) == 0 {
+			t.Errorf("request %d has no Link header", i+1)
+		}
+	}
+}
+
+// BenchmarkSpecPreloadLinks measures what the Link headers add to a spec detail response.
+// Results are in BENCHMARKS.md.
+func BenchmarkSpecPreloadLinks(b *testing.B) {
+	for _, tt := range []struct {
+		name  string
+		links bool
+	}{{"without links", false}, {"with links", true}} {
+		b.Run(tt.name, func(b *testing.B) {
+			app := newPreloadApp(tt.links)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				resp, err := app.Test(httptest.NewRequest("GET", "/api/specs/spec-1", nil))
+				if err != nil {
+					b.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkGetSpec measures GetSpec against Postgres, where most requests hit the spec cache
+func BenchmarkGetSpec(b *testing.B) {
+	pool := dbtest.New(b)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/api/specs/:id", GetSpec(pool))
+	specID := newTestSpec(b, pool, nil, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/specs/"+specID, nil), -1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+}
+
+// countRows returns how many rows of table match where
+func countRows(t *testing.T, pool *pgxpool.Pool, table, where string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := pool.QueryRow(context.Background(), `SELECT count(*) FROM `+table+` WHERE `+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func jobStatus(t *testing.T, pool *pgxpool.Pool, jobID string) string {
+	t.Helper()
+	var status string
+	if err := pool.QueryRow(context.Background(), `SELECT status FROM gen_spec_jobs WHERE id = $1`, jobID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func TestPersistSpec(t *testing.T) {
+	pool := dbtest.New(t)
+	ctx := context.Background()
+	workspaceID := newTestWorkspace(t, pool, "persist-key")
+	g := testGenSpecResp()
+	req := CreateJobReq{Brief: "A cat game"}
+
+	t.Run("commits every write", func(t *testing.T) {
+		jobID, specID := newTestSpecJob(t, pool, workspaceID), uuid.New().String()
+		if err := persistSpec(ctx, pool, &workspaceID, jobID, specID, req, g, "hash-ok", content.RatingEveryone); err != nil {
+			t.Fatal(err)
+		}
+		if n := countRows(t, pool, "game_specs", "id = $1", specID); n != 1 {
+			t.Errorf("%d specs, want 1", n)
+		}
+		if n := countRows(t, pool, "game_spec_states", "game_spec_id = $1", specID); n != 1 {
+			t.Errorf("%d state events, want 1", n)
+		}
+		if status := jobStatus(t, pool, jobID); status != "COMPLETED" {
+			t.Errorf("job status = %s, want COMPLETED", status)
+		}
+	})
+
+	t.Run("failure after the insert leaves nothing behind", func(t *testing.T) {
+		jobID, specID := newTestSpecJob(t, pool, workspaceID), uuid.New().String()
+		// Completing the job is the last write of the transaction
+		_, err := pool.Exec(ctx, `
+			CREATE FUNCTION fail_job_completion() RETURNS trigger AS $$
+			BEGIN RAISE EXCEPTION 'forced failure'; END
+			$$ LANGUAGE plpgsql;
+			CREATE TRIGGER fail_job_completion BEFORE UPDATE ON gen_spec_jobs
+			FOR EACH ROW WHEN (NEW.status = 'COMPLETED') EXECUTE FUNCTION fail_job_completion();
+		`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pool.Exec(ctx, `DROP TRIGGER fail_job_completion ON gen_spec_jobs; DROP FUNCTION fail_job_completion()`)
+
+		if err := persistSpec(ctx, pool, &workspaceID, jobID, specID, req, g, "hash-fail", content.RatingEveryone); err == nil {
+			t.Fatal("expected the forced failure")
+		}
+		if n := countRows(t, pool, "game_specs", "id = $1", specID); n != 0 {
+			t.Errorf("%d specs left behind", n)
+		}
+		if n := countRows(t, pool, "game_spec_states", "game_spec_id = $1", specID); n != 0 {
+			t.Errorf("%d state events left behind", n)
+		}
+		if status := jobStatus(t, pool, jobID); status != "RUNNING" {
+			t.Errorf("job status = %s, want RUNNING", status)
+		}
+	})
+
+	t.Run("hash conflict writes nothing", func(t *testing.T) {
+		jobID, specID := newTestSpecJob(t, pool, workspaceID), uuid.New().String()
+		err := persistSpec(ctx, pool, &workspaceID, jobID, specID, req, g, "hash-ok", content.RatingEveryone)
+		if !errors.Is(err, errSpecHashConflict) {
+			t.Fatalf("err = %v, want errSpecHashConflict", err)
+		}
+		if n := countRows(t, pool, "game_specs", "id = $1", specID); n != 0 {
+			t.Errorf("%d specs left behind", n)
+		}
+		if status := jobStatus(t, pool, jobID); status != "RUNNING" {
+			t.Errorf("job status = %s, want RUNNING", status)
+		}
+	})
+
+	t.Run("compensation after a failed vector upsert", func(t *testing.T) {
+		jobID, specID := newTestSpecJob(t, pool, workspaceID), uuid.New().String()
+		if err := persistSpec(ctx, pool, &workspaceID, jobID, specID, req, g, "hash-compensate", content.RatingEveryone); err != nil {
+			t.Fatal(err)
+		}
+		rollbackPersistedSpec(pool, jobID, specID, "vector upsert failed")
+		if n := countRows(t, pool, "game_specs", "id = $1", specID); n != 0 {
+			t.Errorf("%d specs left behind", n)
+		}
+		if n := countRows(t, pool, "game_spec_states", "game_spec_id = $1", specID); n != 0 {
+			t.Errorf("%d state events left behind", n)
+		}
+		if status := jobStatus(t, pool, jobID); status != "FAILED" {
+			t.Errorf("job status = %s, want FAILED", status)
+		}
+	})
+}
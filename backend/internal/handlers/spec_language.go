@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"backend/internal/nlp"
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	briefLanguagePolicyReject    = "reject"
+	briefLanguagePolicyTranslate = "translate"
+
+	// briefLanguageConfidence is how sure detection must be before a brief counts as non-English
+	briefLanguageConfidence = 0.8
+)
+
+// checkBriefLanguage applies BRIEF_LANGUAGE_POLICY to a brief detected as non-English: "reject"
+// fails with a 422 and "translate" replaces the brief with its English translation from the LLM
+// backend. Any other value, the default, leaves briefs alone.
+func checkBriefLanguage(ctx context.Context, req *CreateJobReq) error {
+	policy := config.GetString("BRIEF_LANGUAGE_POLICY", "")
+	if policy != briefLanguagePolicyReject && policy != briefLanguagePolicyTranslate {
+		return nil
+	}
+
+	lang, confidence, err := nlp.DetectLanguage(req.Brief)
+	if err != nil || lang == "en" || confidence <= briefLanguageConfidence {
+		return nil
+	}
+
+	if policy == briefLanguagePolicyReject {
+		return middleware.NewProblem(fiber.StatusUnprocessableEntity, "brief must be written in English").
+			WithCode(codeBriefLanguageUnsupported).
+			With("language", lang)
+	}
+
+	timeout := llmSpecTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+	translated, err := llm.NewHTTPClient(llmBackend, specModel()).Translate(ctx, req.Brief, lang)
+	if err != nil {
+		if errors.Is(err, llm.ErrCircuitOpen) || ctx.Err() != nil {
+			return llmCallError(ctx, timeout, err)
+		}
+		return middleware.NewProblem(fiber.StatusBadGateway, "Failed to translate brief: "+err.Error()).
+			WithCode(codeLLMUnavailable)
+	}
+	if translated == "" {
+		return middleware.NewProblem(fiber.StatusBadGateway, "LLM returned an empty translation").
+			WithCode(codeLLMUnavailable)
+	}
+	log.Printf("[INFO] Translated a %s brief to English", lang)
+	req.Brief = translated
+	return nil
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const frenchBrief = "Un jeu de plateforme où un chat ramasse des pelotes de laine en évitant l'aspirateur."
+
+func TestCheckBriefLanguage(t *testing.T) {
+	var translations []map[string]string
+	translated := "A platformer where a cat collects balls of yarn while avoiding the vacuum cleaner."
+	translateStatus := http.StatusOK
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/llm/translate" {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		translations = append(translations, body)
+		w.WriteHeader(translateStatus)
+		json.NewEncoder(w).Encode(map[string]string{"text": translated})
+	})
+
+	tests := []struct {
+		name, policy, brief string
+		wantBrief           string
+		wantStatus          int
+		wantCode            string
+		wantTranslations    int
+	}{
+		{"no policy", "", frenchBrief, frenchBrief, 0, "", 0},
+		{"unknown policy", "ignore", frenchBrief, frenchBrief, 0, "", 0},
+		{"reject", "reject", frenchBrief, frenchBrief, fiber.StatusUnprocessableEntity, codeBriefLanguageUnsupported, 0},
+		{"reject leaves english alone", "reject", translated, translated, 0, "", 0},
+		{"translate", "translate", frenchBrief, translated, 0, "", 1},
+		{"translate leaves english alone", "translate", translated, translated, 0, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BRIEF_LANGUAGE_POLICY", tt.policy)
+			translations = nil
+			req := CreateJobReq{Brief: tt.brief}
+			err := checkBriefLanguage(context.Background(), &req)
+
+			if tt.wantStatus == 0 && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantStatus != 0 {
+				var p *middleware.Problem
+				if !errors.As(err, &p) || p.Status != tt.wantStatus || p.Code != tt.wantCode {
+					t.Fatalf("err = %v, want a %d %s problem", err, tt.wantStatus, tt.wantCode)
+				}
+				if p.Extensions["language"] != "fr" {
+					t.Errorf("problem extensions = %v, want language fr", p.Extensions)
+				}
+			}
+			if req.Brief != tt.wantBrief {
+				t.Errorf("brief = %q, want %q", req.Brief, tt.wantBrief)
+			}
+			if len(translations) != tt.wantTranslations {
+				t.Fatalf("%d translate calls, want %d", len(translations), tt.wantTranslations)
+			}
+			if tt.wantTranslations > 0 {
+				if got := translations[0]; got["text"] != frenchBrief || got["source_language"] != "fr" || got["target_language"] != "en" {
+					t.Errorf("translate request = %v", got)
+				}
+			}
+		})
+	}
+
+	t.Run("translation fails", func(t *testing.T) {
+		t.Setenv("BRIEF_LANGUAGE_POLICY", "translate")
+		// A 4xx keeps the shared circuit breaker closed
+		translateStatus = http.StatusBadRequest
+		defer func() { translateStatus = http.StatusOK }()
+		req := CreateJobReq{Brief: frenchBrief}
+		var p *middleware.Problem
+		if err := checkBriefLanguage(context.Background(), &req); !errors.As(err, &p) || p.Status != fiber.StatusBadGateway || p.Code != codeLLMUnavailable {
+			t.Fatalf("err = %v, want a 502 %s problem", err, codeLLMUnavailable)
+		}
+		if req.Brief != frenchBrief {
+			t.Errorf("brief changed to %q after a failed translation", req.Brief)
+		}
+	})
+
+	t.Run("empty translation", func(t *testing.T) {
+		t.Setenv("BRIEF_LANGUAGE_POLICY", "translate")
+		translated = ""
+		req := CreateJobReq{Brief: frenchBrief}
+		var p *middleware.Problem
+		if err := checkBriefLanguage(context.Background(), &req); !errors.As(err, &p) || p.Status != fiber.StatusBadGateway {
+			t.Fatalf("err = %v, want a 502 problem", err)
+		}
+	})
+}
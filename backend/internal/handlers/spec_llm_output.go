@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requiredSpecKeys are the spec_json fields duplicate detection and persistence rely on
+var requiredSpecKeys = []string{"genre", "controls", "mechanics"}
+
+// validateGenSpecResp checks the minimal structure of a generated spec before it is trusted:
+// a title, markdown and a spec_json object with the required keys. The full format is checked
+// separately by specschema.Validate, which only feeds the quality score.
+func validateGenSpecResp(g genSpecResp) error {
+	var errs []specschema.ValidationError
+	if strings.TrimSpace(g.Title) == "" {
+		errs = append(errs, specschema.ValidationError{Field: "title", Message: "must not be empty"})
+	}
+	if strings.TrimSpace(g.SpecMarkdown) == "" {
+		errs = append(errs, specschema.ValidationError{Field: "spec_markdown", Message: "must not be empty"})
+	}
+	if g.SpecJSON == nil {
+		errs = append(errs, specschema.ValidationError{Field: "spec_json", Message: "must be an object"})
+	} else {
+		for _, key := range requiredSpecKeys {
+			if v, ok := g.SpecJSON[key]; !ok || v == nil {
+				errs = append(errs, specschema.ValidationError{Field: "spec_json." + key, Message: "is required"})
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	parts := make([]string, 0, len(errs))
+	for _, e := range errs {
+		parts = append(parts, e.Field+" "+e.Message)
+	}
+	return middleware.NewProblem(fiber.StatusBadGateway, "LLM returned a malformed spec: "+strings.Join(parts, ", ")).
+		WithCode(codeLLMMalformedSpec).
+		With("errors", errs)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestValidateGenSpecResp(t *testing.T) {
+	tests := []struct {
+		name       string
+		edit       func(g *genSpecResp)
+		wantFields []string
+	}{
+		{name: "valid", edit: func(g *genSpecResp) {}},
+		{name: "extra keys", edit: func(g *genSpecResp) { g.SpecJSON["constraints"] = map[string]interface{}{"lives": 3} }},
+		{name: "empty title", edit: func(g *genSpecResp) { g.Title = "" }, wantFields: []string{"title"}},
+		{name: "blank markdown", edit: func(g *genSpecResp) { g.SpecMarkdown = " \n\t" }, wantFields: []string{"spec_markdown"}},
+		{name: "no spec_json", edit: func(g *genSpecResp) { g.SpecJSON = nil }, wantFields: []string{"spec_json"}},
+		{name: "missing controls", edit: func(g *genSpecResp) { delete(g.SpecJSON, "controls") }, wantFields: []string{"spec_json.controls"}},
+		{name: "null mechanics", edit: func(g *genSpecResp) { g.SpecJSON["mechanics"] = nil }, wantFields: []string{"spec_json.mechanics"}},
+		{
+			name: "everything missing",
+			edit: func(g *genSpecResp) { *g = genSpecResp{SpecJSON: map[string]interface{}{}} },
+			wantFields: []string{
+				"title", "spec_markdown", "spec_json.genre", "spec_json.controls", "spec_json.mechanics",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := testGenSpecResp()
+			tt.edit(&g)
+			err := validateGenSpecResp(g)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("validateGenSpecResp = %v, want nil", err)
+				}
+				return
+			}
+
+			var p *middleware.Problem
+			if !errors.As(err, &p) || p.Status != fiber.StatusBadGateway || p.Code != codeLLMMalformedSpec {
+				t.Fatalf("err = %v, want a 502 %s problem", err, codeLLMMalformedSpec)
+			}
+			errs, _ := p.Extensions["errors"].([]specschema.ValidationError)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestGenerateSpecMalformedPayloads(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode string
+	}{
+		{
+			name:     "valid",
+			body:     `{"title":"Yarn Cat","spec_markdown":"# Yarn Cat","spec_json":{"genre":"platformer","controls":{"keyboard":"arrows"},"mechanics":["jump"]}}`,
+			wantCode: "",
+		},
+		{name: "partial", body: `{"title":"Yarn Cat","spec_json":{"genre":"platformer"}}`, wantCode: codeLLMMalformedSpec},
+		{name: "empty object", body: `{}`, wantCode: codeLLMMalformedSpec},
+		{name: "null spec_json", body: `{"title":"Yarn Cat","spec_markdown":"# Yarn Cat","spec_json":null}`, wantCode: codeLLMMalformedSpec},
+		{name: "spec_json is a string", body: `{"title":"Yarn Cat","spec_markdown":"# Yarn Cat","spec_json":"platformer"}`, wantCode: codeLLMUnavailable},
+		{name: "truncated", body: `{"title":"Yarn Cat","spec_mark`, wantCode: codeLLMUnavailable},
+		{name: "garbage", body: `Sure! Here is your spec:`, wantCode: codeLLMUnavailable},
+		{name: "array", body: `[1, 2, 3]`, wantCode: codeLLMUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/llm/generate-spec" {
+					t.Errorf("unexpected call to %s", r.URL.Path)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			})
+
+			g, err := generateSpec(context.Background(), genSpecReq{Brief: "A cat collects yarn"})
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if g.Title != "Yarn Cat" || g.SpecJSON["genre"] != "platformer" {
+					t.Errorf("generateSpec = %+v", g)
+				}
+				return
+			}
+			var p *middleware.Problem
+			if !errors.As(err, &p) || p.Status != fiber.StatusBadGateway || p.Code != tt.wantCode {
+				t.Fatalf("err = %v, want a 502 %s problem", err, tt.wantCode)
+			}
+		})
+	}
+}
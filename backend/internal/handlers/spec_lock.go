@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	database "backend/internal/db"
+	"backend/internal/middleware"
+	"context"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// lockSpec takes the advisory lock of a spec for the rest of tx, answering 409 when another
+// request is mutating the same spec
+func lockSpec(parent context.Context, tx pgx.Tx, specID string) error {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	locked, err := database.TryLockSpec(ctx, tx, specID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to lock spec %s: %v", specID, err)
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+	if !locked {
+		return middleware.NewProblem(fiber.StatusConflict, "Spec is being modified by another request").
+			WithCode(codeSpecLocked)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"backend/internal/codegen"
+	"backend/internal/middleware"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetSpecManifest returns the capability manifest of a game spec, generating and caching it on first request.
+// Whatever replaces spec_json (regeneration, version restore, archiving) clears the cached manifest.
+func GetSpecManifest(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		var manifestBytes []byte
+		err := db.QueryRow(ctx, `SELECT manifest FROM game_specs WHERE id = $1`, id).Scan(&manifestBytes)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
+			}
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+
+		// Serve the cached manifest when present
+		if len(manifestBytes) > 0 {
+			var cached codegen.Manifest
+			if err := json.Unmarshal(manifestBytes, &cached); err == nil {
+				return c.JSON(cached)
+			}
+		}
+
+		sc, err := loadSpec(c, db, id)
+		if err != nil {
+			return err
+		}
+		var specJSON map[string]interface{}
+		if err := json.Unmarshal(sc.SpecJSON, &specJSON); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+		}
+
+		manifest := codegen.GenerateManifest(specJSON)
+		if _, err := db.Exec(ctx, `UPDATE game_specs SET manifest = $1 WHERE id = $2`, manifest, id); err != nil {
+			// Still return the manifest, it will be regenerated next time
+			log.Printf("[ERROR] Failed to cache manifest for spec %s: %v", id, err)
+		}
+
+		return c.JSON(manifest)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/moderation"
+	"context"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkBriefSafety rejects a brief flagged by the moderation API before anything else is done
+// with it. The check fails open: an unreachable moderation API lets the brief through.
+func checkBriefSafety(ctx context.Context, brief string) error {
+	safe, reason, err := moderation.CheckBrief(ctx, brief)
+	if err != nil {
+		log.Printf("[WARNING] Brief moderation unavailable, allowing the brief: %v", err)
+		return nil
+	}
+	if !safe {
+		log.Printf("[INFO] Brief rejected by moderation: %s", reason)
+		return middleware.NewProblem(fiber.StatusUnprocessableEntity, "Brief was rejected by content moderation").
+			WithCode(codeBriefRejected).
+			With("reason", reason)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckBriefSafety(t *testing.T) {
+	flagged := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true}}]}`))
+	}))
+	defer flagged.Close()
+	safe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"flagged":false}]}`))
+	}))
+	defer safe.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+	}{
+		{name: "flagged brief", url: flagged.URL, wantStatus: fiber.StatusUnprocessableEntity},
+		{name: "safe brief", url: safe.URL},
+		{name: "API down fails open", url: downURL},
+		{name: "API error fails open", url: failing.URL},
+		{name: "not configured", url: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MODERATION_API_URL", tt.url)
+			err := checkBriefSafety(context.Background(), "A game brief")
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			var p *middleware.Problem
+			if !errors.As(err, &p) || p.Status != tt.wantStatus {
+				t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+			}
+			if p.Code != codeBriefRejected || p.Extensions["reason"] != "brief flagged for violence" {
+				t.Errorf("problem = %+v", p)
+			}
+		})
+	}
+}
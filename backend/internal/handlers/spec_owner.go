@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// specQuerier is satisfied by both the pool and a transaction
+type specQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// specOwnerFilter returns the user whose specs a request is limited to, or nil for every spec of
+// the workspace: for requests without a user, and for admins passing ?all=true. Specs created
+// before users existed have no user_id and are only visible unfiltered.
+func specOwnerFilter(c *fiber.Ctx) *string {
+	if c.QueryBool("all") && middleware.IsAdmin(c) {
+		return nil
+	}
+	return middleware.UserID(c)
+}
+
+// visibleTo reports whether a spec owned by userID passes the owner filter of a request
+func visibleTo(userID, owner *string) bool {
+	return owner == nil || (userID != nil && *userID == *owner)
+}
+
+// requireSpec reports a 404 problem unless the spec exists in the request's workspace and passes
+// its owner filter. Every /specs/:id route checks the spec with it before reading or changing it.
+func requireSpec(ctx context.Context, q specQuerier, c *fiber.Ctx, specID string) error {
+	var exists bool
+	err := q.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM game_specs
+			WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND ($3::text IS NULL OR user_id = $3)
+		)
+	`, specID, middleware.WorkspaceID(c), specOwnerFilter(c)).Scan(&exists)
+	if err != nil {
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+	if !exists {
+		return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestVisibleTo(t *testing.T) {
+	alice, bob := "alice", "bob"
+	tests := []struct {
+		name          string
+		userID, owner *string
+		want          bool
+	}{
+		{"no filter", &alice, nil, true},
+		{"no filter, no owner", nil, nil, true},
+		{"own spec", &alice, &alice, true},
+		{"other user's spec", &bob, &alice, false},
+		{"spec without owner", nil, &alice, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visibleTo(tt.userID, tt.owner); got != tt.want {
+				t.Errorf("visibleTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecOwnerScoping(t *testing.T) {
+	t.Setenv("USER_ID_HEADER", "X-User-Id")
+	t.Setenv("ADMIN_USER_IDS", "root")
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+
+	alice := "alice"
+	specID := newTestSpec(t, pool, nil, &alice)
+	as := func(user string) map[string]string { return map[string]string{"X-User-Id": user} }
+
+	paths := []string{"/api/specs/" + specID, "/api/specs/" + specID + "/json", "/api/specs/" + specID + "/markdown"}
+	for _, path := range paths {
+		if status, body := apiRequest(t, app, "GET", path, "", as("alice")); status != fiber.StatusOK {
+			t.Errorf("owner GET %s = %d: %s", path, status, body)
+		}
+		if status, _ := apiRequest(t, app, "GET", path, "", as("bob")); status != fiber.StatusNotFound {
+			t.Errorf("other user GET %s = %d, want 404", path, status)
+		}
+		if status, _ := apiRequest(t, app, "GET", path+"?all=true", "", as("bob")); status != fiber.StatusNotFound {
+			t.Errorf("non-admin GET %s?all=true = %d, want 404", path, status)
+		}
+		if status, _ := apiRequest(t, app, "GET", path+"?all=true", "", as("root")); status != fiber.StatusOK {
+			t.Errorf("admin GET %s?all=true = %d, want 200", path, status)
+		}
+		if status, _ := apiRequest(t, app, "GET", path, "", nil); status != fiber.StatusUnauthorized {
+			t.Errorf("GET %s without a user = %d, want 401", path, status)
+		}
+	}
+
+	for _, r := range []struct{ method, path, body string }{
+		{"POST", "/api/specs/" + specID + "/share", ""},
+		{"POST", "/api/specs/bulk-delete", `{"ids":["` + specID + `"]}`},
+		{"DELETE", "/api/specs/" + specID, ""},
+	} {
+		status, body := apiRequest(t, app, r.method, r.path, r.body, as("bob"))
+		if r.method == "POST" && r.path == "/api/specs/bulk-delete" {
+			// Specs of other users are reported as failed, not deleted
+			var resp struct {
+				Deleted int `json:"deleted"`
+			}
+			decodeJSON(t, body, &resp)
+			if status != fiber.StatusOK || resp.Deleted != 0 {
+				t.Errorf("bulk delete by another user = %d: %s", status, body)
+			}
+			continue
+		}
+		if status != fiber.StatusNotFound {
+			t.Errorf("%s %s by another user = %d, want 404", r.method, r.path, status)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// previewCSP lets the game's scripts run, but in an opaque origin so they can't use the API's
+// cookies or storage
+const previewCSP = "sandbox allow-scripts allow-pointer-lock"
+
+// GetSpecPreview serves the generated game of a spec so it can be played in the browser, from the
+// generated code folder of its latest completed code job (local or git). A path ending in / serves
+// its index.html, and /preview redirects to /preview/ so the game's relative links resolve.
+func GetSpecPreview(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		requested, err := url.PathUnescape(c.Params("*"))
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid file path")
+		}
+		if requested == "" && !strings.HasSuffix(c.Path(), "/") {
+			return c.Redirect(c.Path()+"/", fiber.StatusMovedPermanently)
+		}
+		if requested == "" || strings.HasSuffix(requested, "/") {
+			requested += "index.html"
+		}
+		if err := checkRequestedPath(requested); err != nil {
+			return err
+		}
+
+		outputPath, err := completedOutputPath(c, db, id)
+		if err != nil {
+			return err
+		}
+		return serveGeneratedFile(c, utils.GeneratedCodeDir(outputPath), requested, previewCSP)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PushSpec commits and pushes the game folder of a spec again, so a push that failed can be
+// retried without generating anything. The folder is recreated from the spec when it is missing.
+// It responds with the repository URL, or a 502 carrying the git error.
+func PushSpec(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			cancel()
+			return err
+		}
+		var title string
+		var genre *string
+		err := db.QueryRow(ctx, `SELECT title, genre FROM game_specs WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2`, id, middleware.WorkspaceID(c)).Scan(&title, &genre)
+		cancel()
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
+			}
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+
+		gitRepo := utils.NewGitRepo()
+		if !gitRepo.IsConfigured() {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Git repository not configured")
+		}
+		if err := gitRepo.InitializeRepo(); err != nil {
+			log.Printf("[ERROR] Failed to initialize git repo for spec %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusBadGateway, err.Error()).
+				WithCode(codeGitError)
+		}
+
+		gamePath, found := gitRepo.FindGameFolder(id, title)
+		if !found {
+			sc, err := loadSpec(c, db, id)
+			if err != nil {
+				return err
+			}
+			var specJSON map[string]interface{}
+			if err := json.Unmarshal(sc.SpecJSON, &specJSON); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+			}
+			combinedGameSpec := map[string]interface{}{
+				"spec_json":     specJSON,
+				"spec_markdown": sc.SpecMarkdown,
+				"title":         title,
+			}
+			if gamePath, err = gitRepo.CreateGameFolder(id, title, combinedGameSpec); err != nil {
+				log.Printf("[ERROR] Failed to create game folder for spec %s: %v", id, err)
+				return middleware.NewProblem(fiber.StatusBadGateway, err.Error()).
+					WithCode(codeGitError)
+			}
+		}
+
+		commit := utils.CommitInfo{Title: title, GameID: id}
+		if genre != nil {
+			commit.Genre = *genre
+		}
+		if err := gitRepo.CommitAndPush(gamePath, commit); err != nil {
+			log.Printf("[ERROR] Failed to push spec %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusBadGateway, err.Error()).
+				WithCode(codeGitError)
+		}
+
+		repoURL := gitRepo.GameURL(id, title)
+		log.Printf("[SUCCESS] Pushed spec %s to %s", id, repoURL)
+		return c.JSON(fiber.Map{
+			"spec_id":        id,
+			"repo_url":       repoURL,
+			"folder_created": !found,
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"backend/internal/archiver"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// errArchiveRestore is returned by readSpecContent when an archived spec can't be fetched back
+var errArchiveRestore = errors.New("failed to restore archived spec")
+
+// specContent is a spec as its readers see it, with the markdown, spec_json and tutorial that
+// archiving moves to S3 put back in place
+type specContent struct {
+	Title            string
+	Brief            string
+	SpecMarkdown     string
+	SpecJSON         []byte
+	TutorialMarkdown *string
+	UpdatedAt        time.Time
+}
+
+// readSpecContent loads a spec, restoring its content from the archive when it was archived.
+// Everything that reads spec_markdown, spec_json or tutorial_markdown goes through it, as an
+// archived spec only keeps a stub in those columns. A missing spec returns pgx.ErrNoRows.
+func readSpecContent(parent context.Context, q specQuerier, specID string) (specContent, error) {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+
+	var sc specContent
+	var archived bool
+	err := q.QueryRow(ctx, `
+		SELECT title, brief, spec_markdown, spec_json, tutorial_markdown, updated_at, archived_at IS NOT NULL
+		FROM game_specs
+		WHERE id = $1
+	`, specID).Scan(&sc.Title, &sc.Brief, &sc.SpecMarkdown, &sc.SpecJSON, &sc.TutorialMarkdown, &sc.UpdatedAt, &archived)
+	if err != nil || !archived {
+		return sc, err
+	}
+	return restoreSpecContent(parent, sc)
+}
+
+// restoreSpecContent replaces the archive stub in sc with the content stored in the archive
+func restoreSpecContent(ctx context.Context, sc specContent) (specContent, error) {
+	var stub map[string]interface{}
+	if err := json.Unmarshal(sc.SpecJSON, &stub); err != nil {
+		return sc, fmt.Errorf("failed to parse spec JSON: %w", err)
+	}
+	restored, err := archiver.Restore(ctx, stub)
+	if err != nil {
+		return sc, fmt.Errorf("%w: %v", errArchiveRestore, err)
+	}
+	specJSON, err := json.Marshal(restored.SpecJSON)
+	if err != nil {
+		return sc, fmt.Errorf("%w: %v", errArchiveRestore, err)
+	}
+	sc.SpecMarkdown, sc.SpecJSON, sc.TutorialMarkdown = restored.SpecMarkdown, specJSON, restored.TutorialMarkdown
+	return sc, nil
+}
+
+// loadSpec checks the request may see a spec and reads it with readSpecContent, reporting
+// failures as problems
+func loadSpec(c *fiber.Ctx, q specQuerier, specID string) (specContent, error) {
+	ctx, cancel := queryCtx(c.UserContext())
+	err := requireSpec(ctx, q, c, specID)
+	cancel()
+	if err != nil {
+		return specContent{}, err
+	}
+
+	sc, err := readSpecContent(c.UserContext(), q, specID)
+	return sc, specContentProblem(specID, err)
+}
+
+// specContentProblem reports an error of readSpecContent as a problem
+func specContentProblem(specID string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
+	case errors.Is(err, errArchiveRestore):
+		log.Printf("[ERROR] Failed to restore archived spec %s: %v", specID, err)
+		return middleware.NewProblem(fiber.StatusBadGateway, "Failed to restore archived spec")
+	default:
+		log.Printf("[ERROR] Failed to load spec %s: %v", specID, err)
+		return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+	}
+}
+
+// GetSpecJSON returns only the spec_json of a spec
+func GetSpecJSON(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sc, err := loadSpec(c, db, c.Params("id"))
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(sc.SpecJSON)
+	}
+}
+
+// GetSpecMarkdown returns only the spec_markdown of a spec as text/markdown
+func GetSpecMarkdown(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sc, err := loadSpec(c, db, c.Params("id"))
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+		return c.SendString(sc.SpecMarkdown)
+	}
+}
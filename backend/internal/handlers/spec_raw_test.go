@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"backend/internal/archiver"
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// archiveStore keeps archives in memory for archiver.UseStore
+type archiveStore map[string][]byte
+
+func (s archiveStore) Put(_ context.Context, key string, body []byte) error {
+	s[key] = body
+	return nil
+}
+
+func (s archiveStore) Get(_ context.Context, key string) ([]byte, error) {
+	body, ok := s[key]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return body, nil
+}
+
+func gzipJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRestoreSpecContent(t *testing.T) {
+	tutorial := "# Tutorial"
+	store := archiveStore{"game-specs/s1.json.gz": gzipJSON(t, archiver.Archive{
+		SpecMarkdown:     "# Spec",
+		SpecJSON:         map[string]interface{}{"genre": "arcade"},
+		TutorialMarkdown: &tutorial,
+	})}
+	archiver.UseStore(store)
+	t.Cleanup(func() { archiver.UseStore(nil) })
+
+	stub := specContent{Title: "Game", SpecJSON: []byte(`{"archived":true,"s3_key":"game-specs/s1.json.gz"}`)}
+	sc, err := restoreSpecContent(context.Background(), stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Title != "Game" || sc.SpecMarkdown != "# Spec" {
+		t.Errorf("got title %q markdown %q", sc.Title, sc.SpecMarkdown)
+	}
+	if string(sc.SpecJSON) != `{"genre":"arcade"}` {
+		t.Errorf("SpecJSON = %s", sc.SpecJSON)
+	}
+	if sc.TutorialMarkdown == nil || *sc.TutorialMarkdown != tutorial {
+		t.Errorf("TutorialMarkdown = %v, want %q", sc.TutorialMarkdown, tutorial)
+	}
+
+	missing := specContent{SpecJSON: []byte(`{"archived":true,"s3_key":"game-specs/gone.json.gz"}`)}
+	if _, err := restoreSpecContent(context.Background(), missing); !errors.Is(err, errArchiveRestore) {
+		t.Errorf("missing archive: err = %v, want errArchiveRestore", err)
+	}
+}
+
+func TestArchivedSpecReaders(t *testing.T) {
+	pool := dbtest.New(t)
+	archiver.UseStore(archiveStore{})
+	t.Cleanup(func() { archiver.UseStore(nil) })
+	workspaceID := newTestWorkspace(t, pool, "archived-key")
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	if err := archiver.ArchiveSpec(context.Background(), pool, specID); err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string]string{middleware.APIKeyHeader: "archived-key"}
+
+	t.Run("GetSpec", func(t *testing.T) {
+		status, body := apiRequest(t, newTestAPI(pool), "GET", "/api/specs/"+specID, "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var got struct {
+			SpecMarkdown string                 `json:"spec_markdown"`
+			SpecJSON     map[string]interface{} `json:"spec_json"`
+		}
+		decodeJSON(t, body, &got)
+		if got.SpecMarkdown != "# Test game" || got.SpecJSON["genre"] != "puzzle" {
+			t.Errorf("GetSpec returned the stub: %s", body)
+		}
+	})
+
+	t.Run("vector migration", func(t *testing.T) {
+		mock := &vectorMock{}
+		srv := httptest.NewServer(mock)
+		t.Cleanup(srv.Close)
+		if err := migrateSpecVector(context.Background(), pool, srv.URL, &workspaceID, "", specID); err != nil {
+			t.Fatal(err)
+		}
+		if len(mock.upserts) != 1 || !strings.Contains(mock.upserts[0].Text, "puzzle") {
+			t.Errorf("upserts = %+v, want the archived content embedded", mock.upserts)
+		}
+	})
+}
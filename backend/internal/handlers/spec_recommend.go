@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/middleware"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultRecommendations = 5
+	maxRecommendations     = 50
+)
+
+// recordSpecView stores a view of a spec in the background so GetSpec never waits on it
+func recordSpecView(db *pgxpool.Pool, specID string, workspaceID *string) {
+	ws := ""
+	if workspaceID != nil {
+		ws = *workspaceID
+	}
+	go func() {
+		ctx, cancel := queryCtx(context.Background())
+		defer cancel()
+		if err := analytics.RecordView(ctx, db, specID, ws); err != nil {
+			log.Printf("[WARNING] Failed to record view of spec %s: %v", specID, err)
+		}
+	}()
+}
+
+// GetRecommendedSpecs suggests specs the caller's workspace hasn't viewed yet, from the genres
+// it views most. ?limit= defaults to 5.
+func GetRecommendedSpecs(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := c.QueryInt("limit", defaultRecommendations)
+		if limit < 1 || limit > maxRecommendations {
+			return middleware.NewProblem(fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxRecommendations))
+		}
+
+		ws := ""
+		if id := middleware.WorkspaceID(c); id != nil {
+			ws = *id
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		recs, err := analytics.Recommend(ctx, db, ws, limit)
+		if err != nil {
+			log.Printf("[ERROR] Failed to recommend specs: %v", err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		return c.JSON(recs)
+	}
+}
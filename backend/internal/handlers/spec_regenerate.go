@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/content"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RegenerateSpecReq struct {
+	Constraints     map[string]interface{} `json:"constraints,omitempty"`
+	IncludeTutorial bool                   `json:"include_tutorial,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+}
+
+type SpecChangelog struct {
+	ID              string    `json:"id"`
+	SpecID          string    `json:"spec_id"`
+	ParentSpecID    *string   `json:"parent_spec_id"`
+	ChangesMarkdown string    `json:"changes_markdown"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// RegenerateSpec generates a spec again from its stored brief and replaces it in place, then
+// records a changelog of what changed in spec_json. The changelog is written by the LLM and
+// falls back to the raw field diff when the model can't be reached.
+func RegenerateSpec(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		var body RegenerateSpecReq
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&body); err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+			}
+		}
+
+		workspaceID := middleware.WorkspaceID(c)
+		old, err := loadSpec(c, db, id)
+		if err != nil {
+			return err
+		}
+		var oldJSON map[string]interface{}
+		if err := json.Unmarshal(old.SpecJSON, &oldJSON); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+		}
+
+		req := CreateJobReq{Brief: old.Brief, Constraints: body.Constraints, IncludeTutorial: body.IncludeTutorial, Params: body.Params}
+		if err := normalizeJobReq(&req); err != nil {
+			return err
+		}
+		if err := consumeQuota(c.UserContext(), db, workspaceID, quotaSpecs); err != nil {
+			return quotaErrorResponse(c, err)
+		}
+
+		jobID, model, err := startSpecJob(c.UserContext(), db, workspaceID, middleware.UserID(c), req, nil)
+		if err != nil {
+			return err
+		}
+		llmStart := time.Now()
+		g, err := generateSpec(c.UserContext(), genSpecReq{Brief: req.Brief, Constraints: req.Constraints, Model: model, IncludeTutorial: req.IncludeTutorial, Params: req.Params})
+		recordSpecJobTiming(db, jobID, timingLLM, time.Since(llmStart))
+		if err != nil {
+			failSpecJob(db, jobID, specJobFailure(err))
+			return err
+		}
+
+		rating, err := rateSpec(c.UserContext(), db, workspaceID, jobID, g)
+		if err != nil {
+			return err
+		}
+		hash, err := hashSpec(g.SpecJSON)
+		if err != nil {
+			failSpecJob(db, jobID, err.Error())
+			return middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+		}
+
+		if err := replaceSpec(c.UserContext(), db, jobID, id, g, hash, rating); err != nil {
+			// Another spec in the workspace already has this exact spec_json (23505 is unique_violation)
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == specHashConstraint {
+				failSpecJob(db, jobID, "regenerated spec duplicates an existing spec")
+				existingID, _ := findSpecByHash(c.UserContext(), db, workspaceID, hash)
+				return middleware.NewProblem(fiber.StatusConflict, "Regenerated spec is identical to an existing spec").
+					WithCode(codeDuplicate).
+					With("duplicate_of", existingID)
+			}
+			failSpecJob(db, jobID, err.Error())
+			return middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+		}
+		invalidateSpec(id)
+
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		up := upsertReq{SpecID: vectorID(workspaceID, id), Text: buildNormText(g), Payload: map[string]interface{}{"title": g.Title}, Namespace: vectorNamespace(workspaceID)}
+		if reason, err := upsertSpecVector(c.UserContext(), llmBackend, up); err != nil {
+			// The spec is already replaced; similarity search keeps the old vector until the next upsert
+			log.Printf("[WARNING] Failed to update vector of regenerated spec %s: %s", id, reason)
+		}
+
+		changelog, err := recordChangelog(c.UserContext(), db, llm.NewHTTPClient(llmBackend, model), id, oldJSON, g.SpecJSON)
+		if err != nil {
+			log.Printf("[ERROR] Failed to save changelog of spec %s: %v", id, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"job_id":         jobID,
+			"status":         "COMPLETED",
+			"result_spec_id": id,
+			"model":          model,
+			"changelog":      changelog,
+		})
+	}
+}
+
+// replaceSpec saves the current content of a spec as a version, overwrites it with the regenerated
+// content and completes the job in one transaction
+func replaceSpec(parent context.Context, db *pgxpool.Pool, jobID, specID string, g genSpecResp, hash string, rating content.AgeRating) error {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := saveSpecVersion(ctx, tx, specID); err != nil {
+		return fmt.Errorf("failed to save spec version: %w", err)
+	}
+
+	complexity := specschema.EstimateComplexity(g.SpecJSON)
+	_, err = tx.Exec(ctx, `UPDATE game_specs
+		SET title=$2, spec_markdown=$3, spec_json=$4, spec_hash=$5, genre=$6, duration_sec=$7,
+			complexity_score=$8, complexity_level=$9, age_rating=$10, tutorial_markdown=NULLIF($11, ''), archived_at=NULL, manifest=NULL
+		WHERE id=$1`,
+		specID, g.Title, g.SpecMarkdown, g.SpecJSON, hash, g.SpecJSON["genre"], g.SpecJSON["duration_sec"],
+		complexity.Score, complexity.Level, rating, g.TutorialMarkdown)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE gen_spec_jobs SET status='COMPLETED', result_spec_id=$2, finished_at=now() WHERE id=$1`, jobID, specID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// recordChangelog summarises the spec_json changes of a regeneration and stores them. When the
+// LLM fails the plain diff is stored instead so every regeneration has a changelog.
+func recordChangelog(parent context.Context, db *pgxpool.Pool, client llm.Client, specID string, oldJSON, newJSON llm.SpecJSON) (string, error) {
+	llmCtx, llmCancel := context.WithTimeout(parent, llmSpecTimeout())
+	changes, err := llm.GenerateChangelog(llmCtx, client, oldJSON, newJSON)
+	llmCancel()
+	if err != nil {
+		log.Printf("[WARNING] Changelog generation failed for spec %s, storing the raw diff: %v", specID, err)
+		changes = llm.FormatDiff(specschema.DiffSpecJSON(oldJSON, newJSON))
+	}
+
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	// Regeneration replaces the spec in place, so the old content came from the same spec
+	_, err = db.Exec(ctx, `INSERT INTO spec_changelogs (id, spec_id, parent_spec_id, changes_markdown) VALUES ($1, $2, $3, $4)`,
+		uuid.New().String(), specID, specID, changes)
+	return changes, err
+}
+
+// GetSpecChangelogs lists the changelogs of a spec, newest first
+func GetSpecChangelogs(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		rows, err := db.Query(ctx, `
+			SELECT id, spec_id, parent_spec_id, changes_markdown, created_at
+			FROM spec_changelogs
+			WHERE spec_id = $1
+			ORDER BY created_at DESC
+		`, id)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to fetch changelogs")
+		}
+		defer rows.Close()
+
+		changelogs := []SpecChangelog{}
+		for rows.Next() {
+			var cl SpecChangelog
+			if err := rows.Scan(&cl.ID, &cl.SpecID, &cl.ParentSpecID, &cl.ChangesMarkdown, &cl.CreatedAt); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read changelogs")
+			}
+			changelogs = append(changelogs, cl)
+		}
+
+		return c.JSON(changelogs)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const defaultShareTTL = 7 * 24 * time.Hour
+
+// newRandomToken returns 32 random bytes encoded as URL-safe base64
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateSpecShare creates a read-only share link for a spec, valid for SHARE_LINK_TTL (default 7 days)
+func CreateSpecShare(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		token, err := newRandomToken()
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to generate share token")
+		}
+		expiresAt := time.Now().Add(config.MustGetDuration("SHARE_LINK_TTL", defaultShareTTL)).UTC()
+
+		_, err = db.Exec(ctx, `INSERT INTO spec_shares (token, spec_id, expires_at) VALUES ($1, $2, $3)`, token, id, expiresAt)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to create share link")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"spec_id":    id,
+			"token":      token,
+			"url":        "/api/share/" + token,
+			"expires_at": expiresAt,
+		})
+	}
+}
+
+// RevokeSpecShares revokes every share link of a spec
+func RevokeSpecShares(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		tag, err := db.Exec(ctx, `
+			DELETE FROM spec_shares
+			WHERE spec_id = (SELECT id FROM game_specs WHERE id = $1 AND workspace_id IS NOT DISTINCT FROM $2)
+		`, id, middleware.WorkspaceID(c))
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to revoke share links")
+		}
+
+		return c.JSON(fiber.Map{
+			"spec_id": id,
+			"revoked": tag.RowsAffected(),
+		})
+	}
+}
+
+// GetSharedSpec returns a spec for a valid, unexpired share token. This route is public.
+func GetSharedSpec(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Params("token")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		var id string
+		var expiresAt time.Time
+		err := db.QueryRow(ctx, `
+			SELECT spec_id, expires_at
+			FROM spec_shares
+			WHERE token = $1 AND expires_at > now()
+		`, token).Scan(&id, &expiresAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Share link not found or expired")
+			}
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+
+		sc, err := readSpecContent(c.UserContext(), db, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Share link not found or expired")
+			}
+			if errors.Is(err, errArchiveRestore) {
+				log.Printf("[ERROR] Failed to restore archived spec %s: %v", id, err)
+				return middleware.NewProblem(fiber.StatusBadGateway, "Failed to restore archived spec")
+			}
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+
+		var specJSON map[string]interface{}
+		if err := json.Unmarshal(sc.SpecJSON, &specJSON); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+		}
+
+		return c.JSON(fiber.Map{
+			"id":            id,
+			"title":         sc.Title,
+			"spec_markdown": sc.SpecMarkdown,
+			"spec_json":     specJSON,
+			"expires_at":    expiresAt,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSpecShare(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+	workspaceID := newTestWorkspace(t, pool, "share-key")
+	specID := newTestSpec(t, pool, &workspaceID, nil)
+	key := map[string]string{middleware.APIKeyHeader: "share-key"}
+
+	createShare := func() string {
+		t.Helper()
+		status, body := apiRequest(t, app, "POST", "/api/specs/"+specID+"/share", "", key)
+		if status != fiber.StatusCreated {
+			t.Fatalf("create share: status = %d, body %s", status, body)
+		}
+		var share struct {
+			Token     string    `json:"token"`
+			URL       string    `json:"url"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		decodeJSON(t, body, &share)
+		if share.URL != "/api/share/"+share.Token {
+			t.Errorf("url = %q", share.URL)
+		}
+		if d := time.Until(share.ExpiresAt); d < defaultShareTTL-time.Minute || d > defaultShareTTL {
+			t.Errorf("expires_at is %s away, want %s", d, defaultShareTTL)
+		}
+		return share.Token
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		token := createShare()
+		// The public route needs no API key
+		status, body := apiRequest(t, app, "GET", "/api/share/"+token, "", nil)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d, body %s", status, body)
+		}
+		var shared struct {
+			ID           string                 `json:"id"`
+			SpecMarkdown string                 `json:"spec_markdown"`
+			SpecJSON     map[string]interface{} `json:"spec_json"`
+		}
+		decodeJSON(t, body, &shared)
+		if shared.ID != specID || shared.SpecMarkdown != "# Test game" || shared.SpecJSON["genre"] != "puzzle" {
+			t.Errorf("shared spec = %+v", shared)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := createShare()
+		if _, err := pool.Exec(context.Background(), `UPDATE spec_shares SET expires_at = now() - interval '1 second' WHERE token = $1`, token); err != nil {
+			t.Fatal(err)
+		}
+		if status, _ := apiRequest(t, app, "GET", "/api/share/"+token, "", nil); status != fiber.StatusNotFound {
+			t.Errorf("status = %d, want 404", status)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		if status, _ := apiRequest(t, app, "GET", "/api/share/not-a-token", "", nil); status != fiber.StatusNotFound {
+			t.Errorf("status = %d, want 404", status)
+		}
+	})
+
+	t.Run("revocation", func(t *testing.T) {
+		first, second := createShare(), createShare()
+		status, body := apiRequest(t, app, "DELETE", "/api/specs/"+specID+"/share", "", key)
+		if status != fiber.StatusOK {
+			t.Fatalf("revoke: status = %d, body %s", status, body)
+		}
+		var revoked struct {
+			Revoked int `json:"revoked"`
+		}
+		decodeJSON(t, body, &revoked)
+		if revoked.Revoked < 2 {
+			t.Errorf("revoked = %d, want at least 2", revoked.Revoked)
+		}
+		for _, token := range []string{first, second} {
+			if status, _ := apiRequest(t, app, "GET", "/api/share/"+token, "", nil); status != fiber.StatusNotFound {
+				t.Errorf("revoked token: status = %d, want 404", status)
+			}
+		}
+	})
+
+	t.Run("TTL from SHARE_LINK_TTL", func(t *testing.T) {
+		t.Setenv("SHARE_LINK_TTL", "1h")
+		status, body := apiRequest(t, app, "POST", "/api/specs/"+specID+"/share", "", key)
+		if status != fiber.StatusCreated {
+			t.Fatalf("status = %d, body %s", status, body)
+		}
+		var share struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		decodeJSON(t, body, &share)
+		if d := time.Until(share.ExpiresAt); d < 59*time.Minute || d > time.Hour {
+			t.Errorf("expires_at is %s away, want 1h", d)
+		}
+	})
+}
+
+func TestNewRandomToken(t *testing.T) {
+	a, err := newRandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newRandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 43 || a == b {
+		t.Errorf("tokens %q and %q", a, b)
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import "fmt"
+
+// GameSpecState is the lifecycle state of a game spec, as stored in game_specs.state
+type GameSpecState string
+
+// State constants
+const (
+	StateCreating       GameSpecState = "creating"
+	StateGitIniting     GameSpecState = "git_initing"
+	StateGitInited      GameSpecState = "git_inited"
+	StateCodeGenerating GameSpecState = "code_generating"
+	StateCodeGenerated  GameSpecState = "code_generated"
+)
+
+var gameSpecStates = []GameSpecState{StateCreating, StateGitIniting, StateGitInited, StateCodeGenerating, StateCodeGenerated}
+
+func (s GameSpecState) String() string {
+	return string(s)
+}
+
+// IsValid reports whether s is one of the known states
+func (s GameSpecState) IsValid() bool {
+	for _, state := range gameSpecStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGameSpecState converts a state from a request or the database, rejecting unknown ones
+func ParseGameSpecState(s string) (GameSpecState, error) {
+	state := GameSpecState(s)
+	if !state.IsValid() {
+		return "", fmt.Errorf("state %q is not valid, expected one of %v", s, gameSpecStates)
+	}
+	return state, nil
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGameSpecStateIsValid(t *testing.T) {
+	for _, tt := range []struct {
+		state GameSpecState
+		want  bool
+	}{
+		{StateCreating, true},
+		{StateGitIniting, true},
+		{StateGitInited, true},
+		{StateCodeGenerating, true},
+		{StateCodeGenerated, true},
+		{"", false},
+		{"bad", false},
+		{"Creating", false},
+		{" creating", false},
+		{"code-generated", false},
+	} {
+		if got := tt.state.IsValid(); got != tt.want {
+			t.Errorf("GameSpecState(%q).IsValid() = %v, want %v", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestParseGameSpecState(t *testing.T) {
+	for _, s := range []string{"creating", "git_initing", "git_inited", "code_generating", "code_generated"} {
+		state, err := ParseGameSpecState(s)
+		if err != nil {
+			t.Errorf("ParseGameSpecState(%q): %v", s, err)
+			continue
+		}
+		if state.String() != s {
+			t.Errorf("ParseGameSpecState(%q).String() = %q", s, state.String())
+		}
+	}
+	for _, s := range []string{"", "bad", "CODE_GENERATED", "git_inited "} {
+		if state, err := ParseGameSpecState(s); err == nil {
+			t.Errorf("ParseGameSpecState(%q) = %q, want an error", s, state)
+		}
+	}
+}
+
+func TestGameSpecStateJSON(t *testing.T) {
+	b, err := json.Marshal(struct {
+		State GameSpecState `json:"state"`
+	}{StateCodeGenerating})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"state":"code_generating"}` {
+		t.Errorf("json = %s, want the plain state string", b)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetSpecStatus returns the spec state, its latest code job and Devin session info in a single response
+func GetSpecStatus(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		// Read the spec and its latest code job in one statement so both reflect the same moment
+		var (
+			state          GameSpecState
+			devinSessionID *string
+			devinURL       *string
+			jobID          *string
+			jobStatus      *string
+			progress       *int
+			outputPath     *string
+			logsJSON       []byte
+			updatedAt      *time.Time
+		)
+		err := db.QueryRow(ctx, `
+			SELECT s.state, s.devin_session_id, s.devin_session_url, j.id, j.status, j.progress, j.output_path, j.logs, j.updated_at
+			FROM game_specs s
+			LEFT JOIN LATERAL (
+				SELECT id, status, progress, output_path, logs, updated_at
+				FROM code_jobs
+				WHERE game_spec_id = s.id
+				ORDER BY created_at DESC
+				LIMIT 1
+			) j ON true
+			WHERE s.id = $1 AND s.workspace_id IS NOT DISTINCT FROM $2
+		`, id, middleware.WorkspaceID(c)).Scan(&state, &devinSessionID, &devinURL, &jobID, &jobStatus, &progress, &outputPath, &logsJSON, &updatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return middleware.NewProblem(fiber.StatusNotFound, "Spec not found")
+			}
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+
+		response := fiber.Map{
+			"spec_id":     id,
+			"state":       state,
+			"code_status": "not_started",
+		}
+
+		if jobID != nil {
+			response["code_status"] = *jobStatus
+			response["code_job_id"] = *jobID
+			response["progress"] = 0
+			if progress != nil {
+				response["progress"] = *progress
+			}
+			if outputPath != nil {
+				response["output_path"] = *outputPath
+			}
+			if updatedAt != nil {
+				response["updated_at"] = *updatedAt
+			}
+
+			// The current step is the most recent log line written by the pipeline
+			var logs []string
+			if len(logsJSON) > 0 && json.Unmarshal(logsJSON, &logs) == nil && len(logs) > 0 {
+				response["current_step"] = logs[len(logs)-1]
+			}
+		}
+
+		if devinSessionID != nil && *devinSessionID != "" {
+			response["devin_session_id"] = *devinSessionID
+			response["devin_session_url"] = utils.DevinSessionURL(*devinSessionID, devinURL)
+		}
+
+		return c.JSON(response)
+	}
+}
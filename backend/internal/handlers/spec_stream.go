@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/llm"
+	"backend/internal/middleware"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// specStreamChunk is one data line of the LLM backend's generate-spec stream.
+// Token chunks carry partial text, the final chunk carries the complete spec.
+type specStreamChunk struct {
+	Token  string       `json:"token,omitempty"`
+	Result *genSpecResp `json:"result,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// StreamSpecJob generates a spec like PostSpecJob but relays LLM tokens to the client as server-sent events.
+// It emits "token" events while the spec is generated and a final "done" (or "error") event once the
+// spec has gone through duplicate detection and persistence. Backends that don't stream are handled
+// by decoding their plain JSON response and emitting "done" directly.
+func StreamSpecJob(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req CreateJobReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+		}
+		if err := normalizeJobReq(&req); err != nil {
+			return err
+		}
+		if err := checkBriefSafety(c.UserContext(), req.Brief); err != nil {
+			return err
+		}
+		if err := checkBriefLanguage(c.UserContext(), &req); err != nil {
+			return err
+		}
+
+		workspaceID := middleware.WorkspaceID(c)
+		if err := consumeQuota(c.UserContext(), db, workspaceID, quotaSpecs); err != nil {
+			return quotaErrorResponse(c, err)
+		}
+
+		jobID, model, err := startSpecJob(c.UserContext(), db, workspaceID, middleware.UserID(c), req, nil)
+		if err != nil {
+			return err
+		}
+
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		gb, _ := json.Marshal(genSpecReq{Brief: req.Brief, Constraints: req.Constraints, Model: model, IncludeTutorial: req.IncludeTutorial, Params: req.Params})
+		// The stream outlives the handler, so the timeout isn't tied to the request context
+		timeout := llmSpecTimeout()
+		llmCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		httpReq, err := http.NewRequestWithContext(llmCtx, http.MethodPost, llmBackend+"/llm/generate-spec?stream=true", bytes.NewReader(gb))
+		if err != nil {
+			cancel()
+			return middleware.NewProblem(fiber.StatusInternalServerError, err.Error())
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Accept", "text/event-stream, application/json")
+
+		llmStart := time.Now()
+		resp, err := llm.Do(httpReq)
+		if err != nil {
+			err = llmCallError(llmCtx, timeout, err)
+			cancel()
+			failSpecJob(db, jobID, specJobFailure(err))
+			return err
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			cancel()
+			failSpecJob(db, jobID, fmt.Sprintf("llm status %d", resp.StatusCode))
+			return middleware.NewProblem(fiber.StatusBadGateway, fmt.Sprintf("llm status %d", resp.StatusCode)).
+				WithCode(codeLLMUnavailable)
+		}
+
+		streaming := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+		if !streaming {
+			log.Printf("[INFO] LLM backend did not stream generate-spec for job %s, falling back to buffered response", jobID)
+		}
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		// The fiber context is released once the handler returns, so the writer only uses captured values
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+			defer resp.Body.Close()
+
+			var g genSpecResp
+			var err error
+			if streaming {
+				g, err = relaySpecStream(resp.Body, w)
+			} else {
+				err = json.NewDecoder(resp.Body).Decode(&g)
+			}
+			recordSpecJobTiming(db, jobID, timingLLM, time.Since(llmStart))
+			if err == nil {
+				err = validateGenSpecResp(g)
+			}
+			if err != nil {
+				if llmCtx.Err() != nil {
+					err = llmCallError(llmCtx, timeout, err)
+				}
+				log.Printf("[ERROR] Streaming spec generation failed for job %s: %v", jobID, err)
+				failSpecJob(db, jobID, specJobFailure(err))
+				writeSSE(w, "error", fiber.Map{"job_id": jobID, "error": specJobFailure(err)})
+				return
+			}
+
+			result, err := completeSpecJob(context.Background(), db, workspaceID, jobID, req, model, g)
+			if err != nil {
+				log.Printf("[ERROR] Failed to complete streamed spec job %s: %v", jobID, err)
+				failSpecJob(db, jobID, specJobFailure(err))
+				writeSSE(w, "error", fiber.Map{"job_id": jobID, "error": err.Error()})
+				return
+			}
+			if req.BriefOriginal != "" {
+				result["brief_truncated"] = true
+			}
+			writeSSE(w, "done", result)
+		})
+		return nil
+	}
+}
+
+// relaySpecStream forwards token chunks from the LLM stream to w and returns the final spec
+func relaySpecStream(body io.Reader, w *bufio.Writer) (genSpecResp, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+
+		var chunk specStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return genSpecResp{}, fmt.Errorf("invalid stream chunk: %v", err)
+		}
+		switch {
+		case chunk.Error != "":
+			return genSpecResp{}, fmt.Errorf("llm stream error: %s", chunk.Error)
+		case chunk.Result != nil:
+			return *chunk.Result, nil
+		case chunk.Token != "":
+			if err := writeSSE(w, "token", fiber.Map{"token": chunk.Token}); err != nil {
+				return genSpecResp{}, fmt.Errorf("client disconnected: %v", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return genSpecResp{}, fmt.Errorf("llm stream read failed: %v", err)
+	}
+	return genSpecResp{}, fmt.Errorf("llm stream ended without a result")
+}
+
+// writeSSE writes a single server-sent event and flushes it to the client
+func writeSSE(w *bufio.Writer, event string, payload interface{}) error {
+	b, _ := json.Marshal(payload)
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// loadTutorial returns the title, tutorial markdown and last update of a spec. Specs generated
+// without include_tutorial answer 404.
+func loadTutorial(c *fiber.Ctx, db *pgxpool.Pool) (string, string, time.Time, error) {
+	sc, err := loadSpec(c, db, c.Params("id"))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	if sc.TutorialMarkdown == nil || *sc.TutorialMarkdown == "" {
+		return "", "", time.Time{}, middleware.NewProblem(fiber.StatusNotFound, "This spec has no tutorial")
+	}
+	return sc.Title, *sc.TutorialMarkdown, sc.UpdatedAt, nil
+}
+
+// GetSpecTutorial returns the beginner tutorial generated with a spec
+func GetSpecTutorial(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		title, tutorial, _, err := loadTutorial(c, db)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{
+			"spec_id":           c.Params("id"),
+			"title":             title,
+			"tutorial_markdown": tutorial,
+		})
+	}
+}
+
+// GetSpecTutorialHTML renders the tutorial of a spec as a sanitized HTML page
+func GetSpecTutorialHTML(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		title, tutorial, updatedAt, err := loadTutorial(c, db)
+		if err != nil {
+			return err
+		}
+		return sendMarkdownHTML(c, title+" tutorial", tutorial, updatedAt)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"backend/internal/content"
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestGenerateSpecTutorial(t *testing.T) {
+	var sent map[string]interface{}
+	newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = nil
+		json.NewDecoder(r.Body).Decode(&sent)
+		g := testGenSpecResp()
+		if sent["include_tutorial"] == true {
+			g.TutorialMarkdown = "# Getting started\n\nPress the arrows."
+		}
+		json.NewEncoder(w).Encode(g)
+	})
+
+	for _, include := range []bool{false, true} {
+		g, err := generateSpec(context.Background(), genSpecReq{Brief: "A cat game", Model: "test", IncludeTutorial: include})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := sent["include_tutorial"]; ok != include {
+			t.Errorf("include=%v: request has include_tutorial = %v", include, sent["include_tutorial"])
+		}
+		if (g.TutorialMarkdown != "") != include {
+			t.Errorf("include=%v: tutorial = %q", include, g.TutorialMarkdown)
+		}
+	}
+}
+
+func TestSpecTutorial(t *testing.T) {
+	pool := dbtest.New(t)
+	workspaceID := newTestWorkspace(t, pool, "tutorial-key")
+	// Registered after the /api group, so they sit behind its middleware
+	app := newTestAPI(pool)
+	app.Get("/api/specs/:id/tutorial", GetSpecTutorial(pool))
+	app.Get("/api/specs/:id/tutorial.html", GetSpecTutorialHTML(pool))
+	headers := map[string]string{middleware.APIKeyHeader: "tutorial-key"}
+
+	g := testGenSpecResp()
+	g.TutorialMarkdown = "# Getting started\n\nPress the **arrows**.<script>alert(1)</script>"
+	jobID, specID := newTestSpecJob(t, pool, workspaceID), uuid.New().String()
+	if err := persistSpec(context.Background(), pool, &workspaceID, jobID, specID, CreateJobReq{Brief: "A cat game", IncludeTutorial: true}, g, "hash-tutorial", content.RatingEveryone); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		status, body := apiRequest(t, app, "GET", "/api/specs/"+specID+"/tutorial", "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var got map[string]string
+		decodeJSON(t, body, &got)
+		if got["tutorial_markdown"] != g.TutorialMarkdown || got["title"] != g.Title {
+			t.Errorf("tutorial = %v", got)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		status, body := apiRequest(t, app, "GET", "/api/specs/"+specID+"/tutorial.html", "", headers)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		html := string(body)
+		if !strings.Contains(html, "<strong>arrows</strong>") || strings.Contains(html, "<script>") {
+			t.Errorf("html = %s", html)
+		}
+		if !strings.Contains(html, "Yarn Cat tutorial") {
+			t.Errorf("html title missing: %s", html)
+		}
+	})
+
+	t.Run("no tutorial", func(t *testing.T) {
+		otherID := newTestSpec(t, pool, &workspaceID, nil)
+		for _, path := range []string{"/tutorial", "/tutorial.html"} {
+			if status, body := apiRequest(t, app, "GET", "/api/specs/"+otherID+path, "", headers); status != fiber.StatusNotFound {
+				t.Errorf("%s: status = %d, want 404: %s", path, status, body)
+			}
+		}
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		newTestWorkspace(t, pool, "tutorial-other")
+		status, _ := apiRequest(t, app, "GET", "/api/specs/"+specID+"/tutorial", "", map[string]string{middleware.APIKeyHeader: "tutorial-other"})
+		if status != fiber.StatusNotFound {
+			t.Errorf("status = %d, want 404", status)
+		}
+	})
+}
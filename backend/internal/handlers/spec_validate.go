@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"backend/internal/codegen"
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type validateSpecReq struct {
+	SpecJSON     map[string]interface{} `json:"spec_json"`
+	SpecMarkdown string                 `json:"spec_markdown"`
+}
+
+// ValidateSpec lints a spec without persisting it: schema validation, quality score and the
+// manifest it would get. Neither the database nor the vector service is touched.
+func ValidateSpec() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req validateSpecReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid request body")
+		}
+
+		errs := specschema.Validate(req.SpecJSON)
+		return c.JSON(fiber.Map{
+			"valid":         len(errs) == 0,
+			"errors":        errs,
+			"quality_score": specschema.QualityScore(req.SpecJSON, req.SpecMarkdown),
+			"manifest":      codegen.GenerateManifest(req.SpecJSON),
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"backend/internal/codegen"
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestValidateSpec(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Post("/api/specs/validate", ValidateSpec())
+
+	valid := map[string]interface{}{
+		"title":        "Yarn Cat",
+		"genre":        "platformer",
+		"description":  "A cat collects yarn",
+		"duration_sec": 300,
+		"platform":     []string{"web"},
+		"controls":     []string{"arrow keys", "spacebar"},
+		"mechanics":    []string{"jump", "collect yarn", "background music"},
+		"game_modes":   []map[string]string{{"mode": "story"}},
+		"scoring":      map[string]string{"win_condition": "collect 10 balls of yarn"},
+	}
+	partial := map[string]interface{}{
+		"title":     "Yarn Cat",
+		"genre":     "platformer",
+		"controls":  []string{"tap"},
+		"mechanics": []string{"jump"},
+	}
+
+	tests := []struct {
+		name         string
+		body         interface{}
+		wantValid    bool
+		wantFields   []string
+		wantScore    int
+		wantKeyboard bool
+	}{
+		{
+			name:         "valid",
+			body:         map[string]interface{}{"spec_json": valid, "spec_markdown": "# Overview\n## Mechanics\n## Controls"},
+			wantValid:    true,
+			wantScore:    60 + 0 + 9,
+			wantKeyboard: true,
+		},
+		{
+			name:       "partial",
+			body:       map[string]interface{}{"spec_json": partial},
+			wantFields: []string{"description", "duration_sec", "platform", "game_modes", "scoring"},
+			wantScore:  60 - 5*6,
+		},
+		{
+			name:       "completely invalid",
+			body:       map[string]interface{}{"spec_json": map[string]interface{}{"title": 3, "controls": "keyboard"}},
+			wantFields: []string{"title", "genre", "description", "duration_sec", "platform", "controls", "mechanics", "game_modes", "scoring"},
+			wantScore:  6,
+			// The manifest is still derived from whatever the spec has
+			wantKeyboard: true,
+		},
+		{
+			name:       "missing spec_json",
+			body:       map[string]interface{}{"spec_markdown": "# Yarn Cat"},
+			wantFields: []string{"spec_json"},
+			wantScore:  54,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := json.Marshal(tt.body)
+			status, body := apiRequest(t, app, "POST", "/api/specs/validate", string(b), nil)
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d: %s", status, body)
+			}
+			var resp struct {
+				Valid        bool                         `json:"valid"`
+				Errors       []specschema.ValidationError `json:"errors"`
+				QualityScore int                          `json:"quality_score"`
+				Manifest     *codegen.Manifest            `json:"manifest"`
+			}
+			decodeJSON(t, body, &resp)
+
+			if resp.Valid != tt.wantValid {
+				t.Errorf("valid = %t, want %t", resp.Valid, tt.wantValid)
+			}
+			if resp.Errors == nil {
+				t.Error("errors is not a list")
+			}
+			var fields []string
+			for _, e := range resp.Errors {
+				fields = append(fields, e.Field)
+			}
+			if len(fields) != len(tt.wantFields) {
+				t.Errorf("error fields = %v, want %v", fields, tt.wantFields)
+			} else {
+				for i := range fields {
+					if fields[i] != tt.wantFields[i] {
+						t.Errorf("error fields = %v, want %v", fields, tt.wantFields)
+						break
+					}
+				}
+			}
+			if resp.QualityScore != tt.wantScore {
+				t.Errorf("quality_score = %d, want %d", resp.QualityScore, tt.wantScore)
+			}
+			if resp.Manifest == nil {
+				t.Fatal("no manifest")
+			}
+			if resp.Manifest.RequiresKeyboard != tt.wantKeyboard {
+				t.Errorf("manifest = %+v", resp.Manifest)
+			}
+		})
+	}
+
+	if status, _ := apiRequest(t, app, "POST", "/api/specs/validate", "{not json", nil); status != fiber.StatusBadRequest {
+		t.Errorf("status for a malformed body = %d, want 400", status)
+	}
+}
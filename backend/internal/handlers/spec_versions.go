@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"backend/internal/specschema"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SpecVersionSummary struct {
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	SpecHash  string    `json:"spec_hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SpecVersion struct {
+	SpecVersionSummary
+	SpecMarkdown     string          `json:"spec_markdown"`
+	SpecJSON         json.RawMessage `json:"spec_json"`
+	TutorialMarkdown *string         `json:"tutorial_markdown"`
+	AgeRating        *string         `json:"age_rating"`
+}
+
+// saveSpecVersion copies the current content of a spec into game_spec_versions as its next version
+// and returns the version number. The spec row stays locked until tx ends. An archived spec is
+// restored first, as its archive is overwritten when the spec is archived again.
+func saveSpecVersion(ctx context.Context, tx pgx.Tx, specID string) (int, error) {
+	var hash string
+	var ageRating *string
+	if err := tx.QueryRow(ctx, `SELECT spec_hash, age_rating FROM game_specs WHERE id = $1 FOR UPDATE`, specID).Scan(&hash, &ageRating); err != nil {
+		return 0, err
+	}
+	sc, err := readSpecContent(ctx, tx, specID)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO game_spec_versions (spec_id, version, title, spec_markdown, spec_json, spec_hash, tutorial_markdown, age_rating)
+		VALUES ($1, COALESCE((SELECT MAX(version) FROM game_spec_versions WHERE spec_id = $1), 0) + 1, $2, $3, $4, $5, $6, $7)
+		RETURNING version
+	`, specID, sc.Title, sc.SpecMarkdown, sc.SpecJSON, hash, sc.TutorialMarkdown, ageRating).Scan(&version)
+	return version, err
+}
+
+// specVersionParam parses the :n route parameter
+func specVersionParam(c *fiber.Ctx) (int, error) {
+	n, err := c.ParamsInt("n")
+	if err != nil || n < 1 {
+		return 0, middleware.NewProblem(fiber.StatusBadRequest, "Version must be a positive integer")
+	}
+	return n, nil
+}
+
+// GetSpecVersions lists the saved versions of a spec, newest first
+func GetSpecVersions(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		rows, err := db.Query(ctx, `
+			SELECT version, title, spec_hash, created_at
+			FROM game_spec_versions
+			WHERE spec_id = $1
+			ORDER BY version DESC
+		`, id)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to fetch versions")
+		}
+		defer rows.Close()
+
+		versions := []SpecVersionSummary{}
+		for rows.Next() {
+			var v SpecVersionSummary
+			if err := rows.Scan(&v.Version, &v.Title, &v.SpecHash, &v.CreatedAt); err != nil {
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read versions")
+			}
+			versions = append(versions, v)
+		}
+		if err := rows.Err(); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to read versions")
+		}
+		return c.JSON(versions)
+	}
+}
+
+// GetSpecVersion returns the full content of one saved version of a spec
+func GetSpecVersion(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		n, err := specVersionParam(c)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		v := SpecVersion{}
+		err = db.QueryRow(ctx, `
+			SELECT version, title, spec_hash, created_at, spec_markdown, spec_json, tutorial_markdown, age_rating
+			FROM game_spec_versions
+			WHERE spec_id = $1 AND version = $2
+		`, id, n).Scan(&v.Version, &v.Title, &v.SpecHash, &v.CreatedAt, &v.SpecMarkdown, &v.SpecJSON, &v.TutorialMarkdown, &v.AgeRating)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return middleware.NewProblem(fiber.StatusNotFound, "Version not found")
+		}
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		return c.JSON(v)
+	}
+}
+
+// RestoreSpecVersion rolls a spec back to a saved version. The content it replaces is saved as a
+// new version first, so a restore can itself be undone.
+func RestoreSpecVersion(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		n, err := specVersionParam(c)
+		if err != nil {
+			return err
+		}
+		workspaceID := middleware.WorkspaceID(c)
+
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+		if err := requireSpec(ctx, db, c, id); err != nil {
+			return err
+		}
+
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		defer tx.Rollback(ctx)
+
+		var g genSpecResp
+		var hash string
+		var tutorial, ageRating *string
+		var specJSON []byte
+		err = tx.QueryRow(ctx, `
+			SELECT title, spec_markdown, spec_json, spec_hash, tutorial_markdown, age_rating
+			FROM game_spec_versions
+			WHERE spec_id = $1 AND version = $2
+		`, id, n).Scan(&g.Title, &g.SpecMarkdown, &specJSON, &hash, &tutorial, &ageRating)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return middleware.NewProblem(fiber.StatusNotFound, "Version not found")
+		}
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		if err := json.Unmarshal(specJSON, &g.SpecJSON); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to parse spec JSON")
+		}
+
+		saved, err := saveSpecVersion(ctx, tx, id)
+		if err != nil {
+			log.Printf("[ERROR] Failed to save version of spec %s: %v", id, err)
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to save the current version")
+		}
+
+		complexity := specschema.EstimateComplexity(g.SpecJSON)
+		_, err = tx.Exec(ctx, `UPDATE game_specs
+			SET title=$2, spec_markdown=$3, spec_json=$4, spec_hash=$5, genre=$6, duration_sec=$7,
+				complexity_score=$8, complexity_level=$9, age_rating=$10, tutorial_markdown=$11, archived_at=NULL, manifest=NULL
+			WHERE id=$1`,
+			id, g.Title, g.SpecMarkdown, g.SpecJSON, hash, g.SpecJSON["genre"], g.SpecJSON["duration_sec"],
+			complexity.Score, complexity.Level, ageRating, tutorial)
+		if err != nil {
+			// Another spec in the workspace now has this exact spec_json (23505 is unique_violation)
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == specHashConstraint {
+				tx.Rollback(ctx)
+				existingID, _ := findSpecByHash(c.UserContext(), db, workspaceID, hash)
+				return middleware.NewProblem(fiber.StatusConflict, "Version is identical to an existing spec").
+					WithCode(codeDuplicate).
+					With("duplicate_of", existingID)
+			}
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to restore version")
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to restore version")
+		}
+		invalidateSpec(id)
+		log.Printf("[INFO] Restored spec %s to version %d, saved the previous content as version %d", id, n, saved)
+
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		up := upsertReq{SpecID: vectorID(workspaceID, id), Text: buildNormText(g), Payload: map[string]interface{}{"title": g.Title}, Namespace: vectorNamespace(workspaceID)}
+		if reason, err := upsertSpecVector(c.UserContext(), llmBackend, up); err != nil {
+			log.Printf("[WARNING] Failed to update vector of restored spec %s: %s", id, reason)
+		}
+
+		return c.JSON(fiber.Map{
+			"spec_id":       id,
+			"restored":      n,
+			"saved_version": saved,
+			"title":         g.Title,
+			"age_rating":    ageRating,
+		})
+	}
+}
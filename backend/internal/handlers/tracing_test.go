@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider recording every ended span for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return rec
+}
+
+func TestSpecJobChildSpans(t *testing.T) {
+	rec := recordSpans(t)
+	srv := newLLMBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/llm/generate-spec":
+			json.NewEncoder(w).Encode(testGenSpecResp())
+		case "/vector/search":
+			w.Write([]byte(`{"similar":[]}`))
+		case "/vector/upsert":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(middleware.Tracing(), middleware.ProblemDetails())
+	app.Post("/spec-job", func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		if _, err := generateSpec(ctx, genSpecReq{Brief: "A cat game", Model: specModel()}); err != nil {
+			return err
+		}
+		if _, err := searchSimilarSpecs(ctx, srv.URL, searchReq{Text: "Yarn Cat", TopK: 3}); err != nil {
+			return err
+		}
+		if _, err := upsertSpecVector(ctx, srv.URL, upsertReq{SpecID: "spec-traced", Text: "Yarn Cat"}); err == nil {
+			t.Error("upsert against a failing vector service succeeded")
+		}
+		return c.SendStatus(fiber.StatusAccepted)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/spec-job", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		spans[s.Name()] = s
+	}
+	server, ok := spans["POST /spec-job"]
+	if !ok {
+		t.Fatalf("no server span in %v", spans)
+	}
+	for _, name := range []string{"llm.generate_spec", "vector.search", "vector.upsert"} {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if s.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the request span", name)
+		}
+	}
+	if s, ok := spans["vector.upsert"]; ok && len(s.Events()) == 0 {
+		t.Error("vector.upsert span did not record the failure")
+	}
+}
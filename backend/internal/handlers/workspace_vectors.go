@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/middleware"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const vectorMigrationBatch = 100
+
+// MigrateWorkspaceVectors moves the vectors of a workspace's specs to the ids and namespace the
+// workspace uses now. Each spec is upserted under vectorID and its old entry deleted; the old id
+// is the bare spec id, or "<from>:<spec id>" with ?from=<old workspace id>. Migrated specs are
+// recorded in vector_migrations so calling it again only retries the ones that failed. This
+// route is guarded by middleware.RequireAdmin.
+func MigrateWorkspaceVectors(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		if _, err := uuid.Parse(id); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, "Invalid workspace id")
+		}
+		from := c.Query("from")
+		if from != "" {
+			if _, err := uuid.Parse(from); err != nil {
+				return middleware.NewProblem(fiber.StatusBadRequest, "from must be a workspace id")
+			}
+		}
+
+		ctx, cancel := queryCtx(c.UserContext())
+		var exists bool
+		err := db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM workspaces WHERE id = $1)", id).Scan(&exists)
+		cancel()
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Database error")
+		}
+		if !exists {
+			return middleware.NewProblem(fiber.StatusNotFound, "Workspace not found")
+		}
+
+		llmBackend := config.GetString("LLM_BACKEND_URL", "http://localhost:8000")
+		workspaceID := &id
+		migrated, failed := 0, 0
+		after := uuid.Nil.String()
+		for {
+			batch, err := pendingVectorMigrations(c.UserContext(), db, id, from, after)
+			if err != nil {
+				log.Printf("[ERROR] Failed to list specs of workspace %s: %v", id, err)
+				return middleware.NewProblem(fiber.StatusInternalServerError, "Database error").
+					With("migrated", migrated).
+					With("failed", failed)
+			}
+			for _, specID := range batch {
+				after = specID
+				if err := migrateSpecVector(c.UserContext(), db, llmBackend, workspaceID, from, specID); err != nil {
+					log.Printf("[ERROR] Failed to migrate vector of spec %s: %v", specID, err)
+					failed++
+					continue
+				}
+				migrated++
+			}
+			if len(batch) < vectorMigrationBatch {
+				break
+			}
+		}
+
+		log.Printf("[INFO] Migrated %d vectors of workspace %s, %d failed", migrated, id, failed)
+		return c.JSON(fiber.Map{"migrated": migrated, "failed": failed})
+	}
+}
+
+// pendingVectorMigrations returns the ids of the next page of workspace specs, after the given
+// id, that haven't been migrated from this prefix yet
+func pendingVectorMigrations(parent context.Context, db *pgxpool.Pool, workspaceID, from, after string) ([]string, error) {
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	rows, err := db.Query(ctx, `
+		SELECT s.id
+		FROM game_specs s
+		WHERE s.workspace_id = $1 AND s.id > $3::uuid
+			AND NOT EXISTS (
+				SELECT 1 FROM vector_migrations m
+				WHERE m.workspace_id = s.workspace_id AND m.spec_id = s.id AND m.from_vector_prefix = $2
+			)
+		ORDER BY s.id
+		LIMIT $4
+	`, workspaceID, from, after, vectorMigrationBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// migrateSpecVector upserts a spec under its current vector id, deletes the old entry and records
+// the migration. The spec is read with readSpecContent so an archived one is embedded from its
+// archived content rather than the stub.
+func migrateSpecVector(ctx context.Context, db *pgxpool.Pool, llmBackend string, workspaceID *string, from, specID string) error {
+	sc, err := readSpecContent(ctx, db, specID)
+	if err != nil {
+		return err
+	}
+	g := genSpecResp{Title: sc.Title}
+	if err := json.Unmarshal(sc.SpecJSON, &g.SpecJSON); err != nil {
+		return fmt.Errorf("failed to parse spec JSON: %v", err)
+	}
+
+	up := upsertReq{
+		SpecID:    vectorID(workspaceID, specID),
+		Text:      buildNormText(g),
+		Payload:   map[string]interface{}{"title": g.Title},
+		Namespace: vectorNamespace(workspaceID),
+	}
+	if reason, err := upsertSpecVector(ctx, llmBackend, up); err != nil {
+		return fmt.Errorf("upsert failed: %s", reason)
+	}
+
+	oldID := specID
+	if from != "" {
+		oldID = from + ":" + specID
+	}
+	if oldID != up.SpecID {
+		if err := deleteSpecVector(ctx, llmBackend, oldID); err != nil {
+			return err
+		}
+	}
+
+	queryContext, cancel := queryCtx(ctx)
+	defer cancel()
+	_, err = db.Exec(queryContext, `
+		INSERT INTO vector_migrations (workspace_id, spec_id, from_vector_prefix)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, *workspaceID, specID, from)
+	return err
+}
+
+// deleteSpecVector removes a vector by id from the vector store
+func deleteSpecVector(ctx context.Context, llmBackend, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/vector/spec/%s", llmBackend, url.PathEscape(id)), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("delete status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// vectorMock records the upserts and deletes sent to the vector service and fails both for the
+// spec ids in failing
+type vectorMock struct {
+	mu      sync.Mutex
+	failing map[string]bool
+	upserts []upsertReq
+	deletes []string
+}
+
+func (m *vectorMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/vector/upsert":
+		var up upsertReq
+		json.NewDecoder(r.Body).Decode(&up)
+		if m.failing[specIDFromVectorID(up.SpecID)] {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		m.upserts = append(m.upserts, up)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/vector/spec/"):
+		id := strings.TrimPrefix(r.URL.Path, "/vector/spec/")
+		if m.failing[specIDFromVectorID(id)] {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		m.deletes = append(m.deletes, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// take returns the recorded upsert ids and deletes, sorted, and forgets them
+func (m *vectorMock) take() (upserts, deletes []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, up := range m.upserts {
+		upserts = append(upserts, up.SpecID)
+	}
+	deletes = m.deletes
+	m.upserts, m.deletes = nil, nil
+	sort.Strings(upserts)
+	sort.Strings(deletes)
+	return upserts, deletes
+}
+
+func TestMigrateWorkspaceVectors(t *testing.T) {
+	pool := dbtest.New(t)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	mock := &vectorMock{}
+	newLLMBackend(t, mock.ServeHTTP)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Post("/api/admin/workspaces/:id/migrate-vectors", middleware.RequireAdmin(), MigrateWorkspaceVectors(pool))
+	admin := map[string]string{middleware.APIKeyHeader: "admin-secret"}
+
+	ws := newTestWorkspace(t, pool, "vectors-key")
+	other := newTestWorkspace(t, pool, "vectors-other")
+	newTestSpec(t, pool, &other, nil)
+	var specs []string
+	for i := 0; i < 3; i++ {
+		specs = append(specs, newTestSpec(t, pool, &ws, nil))
+	}
+	sort.Strings(specs)
+	oldWS := uuid.New().String()
+	path := "/api/admin/workspaces/" + ws + "/migrate-vectors?from=" + oldWS
+
+	migrate := func(t *testing.T) (migrated, failed int) {
+		t.Helper()
+		status, body := apiRequest(t, app, "POST", path, "", admin)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var summary struct{ Migrated, Failed int }
+		decodeJSON(t, body, &summary)
+		return summary.Migrated, summary.Failed
+	}
+	ids := func(prefix string, specIDs ...string) []string {
+		var out []string
+		for _, id := range specIDs {
+			out = append(out, prefix+":"+id)
+		}
+		return out
+	}
+
+	t.Run("failed upserts are counted", func(t *testing.T) {
+		mock.failing = map[string]bool{specs[2]: true}
+		if migrated, failed := migrate(t); migrated != 2 || failed != 1 {
+			t.Errorf("migrated %d, failed %d, want 2 and 1", migrated, failed)
+		}
+		for _, up := range mock.upserts {
+			if up.Namespace != ws {
+				t.Errorf("%s upserted into namespace %q", up.SpecID, up.Namespace)
+			}
+		}
+		upserts, deletes := mock.take()
+		if want := ids(ws, specs[0], specs[1]); strings.Join(upserts, ",") != strings.Join(want, ",") {
+			t.Errorf("upserts = %v, want %v", upserts, want)
+		}
+		if want := ids(oldWS, specs[0], specs[1]); strings.Join(deletes, ",") != strings.Join(want, ",") {
+			t.Errorf("deletes = %v, want %v", deletes, want)
+		}
+	})
+
+	t.Run("second call retries only the failures", func(t *testing.T) {
+		mock.failing = nil
+		if migrated, failed := migrate(t); migrated != 1 || failed != 0 {
+			t.Errorf("migrated %d, failed %d, want 1 and 0", migrated, failed)
+		}
+		upserts, deletes := mock.take()
+		if want := ids(ws, specs[2]); strings.Join(upserts, ",") != strings.Join(want, ",") {
+			t.Errorf("upserts = %v, want %v", upserts, want)
+		}
+		if want := ids(oldWS, specs[2]); strings.Join(deletes, ",") != strings.Join(want, ",") {
+			t.Errorf("deletes = %v, want %v", deletes, want)
+		}
+	})
+
+	t.Run("nothing left", func(t *testing.T) {
+		if migrated, failed := migrate(t); migrated != 0 || failed != 0 {
+			t.Errorf("migrated %d, failed %d, want nothing", migrated, failed)
+		}
+		if upserts, deletes := mock.take(); len(upserts)+len(deletes) != 0 {
+			t.Errorf("vector service called again: %v %v", upserts, deletes)
+		}
+		if n := countRows(t, pool, "vector_migrations", "workspace_id = $1 AND from_vector_prefix = $2", ws, oldWS); n != 3 {
+			t.Errorf("%d migrations recorded, want 3", n)
+		}
+	})
+
+	for _, tt := range []struct {
+		name, path string
+		headers    map[string]string
+		want       int
+	}{
+		{"not admin", path, map[string]string{middleware.APIKeyHeader: "vectors-key"}, fiber.StatusUnauthorized},
+		{"invalid id", "/api/admin/workspaces/nope/migrate-vectors", admin, fiber.StatusBadRequest},
+		{"invalid from", "/api/admin/workspaces/" + ws + "/migrate-vectors?from=nope", admin, fiber.StatusBadRequest},
+		{"missing workspace", "/api/admin/workspaces/" + uuid.New().String() + "/migrate-vectors", admin, fiber.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := apiRequest(t, app, "POST", tt.path, "", tt.headers); status != tt.want {
+				t.Errorf("status = %d, want %d: %s", status, tt.want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreateWorkspaceReq struct {
+	Name          string `json:"name"`
+	QuotaSpecs    *int   `json:"quota_specs,omitempty"`
+	QuotaCodeJobs *int   `json:"quota_code_jobs,omitempty"`
+	AllowMature   bool   `json:"allow_mature,omitempty"`
+}
+
+// CreateWorkspace creates a workspace and returns its API key. The key is only shown once,
+// the database keeps its hash. This route is guarded by middleware.RequireAdmin.
+func CreateWorkspace(db *pgxpool.Pool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req CreateWorkspaceReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			return middleware.NewProblem(fiber.StatusBadRequest, "name is required")
+		}
+		if (req.QuotaSpecs != nil && *req.QuotaSpecs < 0) || (req.QuotaCodeJobs != nil && *req.QuotaCodeJobs < 0) {
+			return middleware.NewProblem(fiber.StatusBadRequest, "quotas must not be negative")
+		}
+
+		token, err := newRandomToken()
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to generate API key")
+		}
+		apiKey := "ws_" + token
+
+		id := uuid.New().String()
+		ctx, cancel := queryCtx(c.UserContext())
+		defer cancel()
+
+		var createdAt time.Time
+		err = db.QueryRow(ctx, `
+			INSERT INTO workspaces (id, name, api_key_hash, quota_specs, quota_code_jobs, allow_mature)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING created_at
+		`, id, req.Name, middleware.HashAPIKey(apiKey), req.QuotaSpecs, req.QuotaCodeJobs, req.AllowMature).Scan(&createdAt)
+		if err != nil {
+			return middleware.NewProblem(fiber.StatusInternalServerError, "Failed to create workspace")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"id":              id,
+			"name":            req.Name,
+			"api_key":         apiKey,
+			"quota_specs":     req.QuotaSpecs,
+			"quota_code_jobs": req.QuotaCodeJobs,
+			"allow_mature":    req.AllowMature,
+			"created_at":      createdAt,
+		})
+	}
+}
+
+// workspaceAllowsMature reports whether a workspace accepts specs rated M. The shared workspace
+// used by requests without an API key never does.
+func workspaceAllowsMature(parent context.Context, db *pgxpool.Pool, workspaceID *string) (bool, error) {
+	if workspaceID == nil {
+		return false, nil
+	}
+	ctx, cancel := queryCtx(parent)
+	defer cancel()
+	var allowed bool
+	if err := db.QueryRow(ctx, `SELECT allow_mature FROM workspaces WHERE id = $1`, *workspaceID).Scan(&allowed); err != nil {
+		return false, err
+	}
+	return allowed, nil
+}
+
+// vectorID namespaces a spec id for the vector store so similarity search stays within a workspace
+func vectorID(workspaceID *string, specID string) string {
+	if workspaceID == nil {
+		return specID
+	}
+	return *workspaceID + ":" + specID
+}
+
+// specIDFromVectorID strips the workspace prefix added by vectorID
+func specIDFromVectorID(id string) string {
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// vectorNamespace returns the vector store namespace of a workspace, empty for the shared workspace
+func vectorNamespace(workspaceID *string) string {
+	if workspaceID == nil {
+		return ""
+	}
+	return *workspaceID
+}
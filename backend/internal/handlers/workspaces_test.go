@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"backend/internal/dbtest"
+	"backend/internal/middleware"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWorkspaceIsolation(t *testing.T) {
+	pool := dbtest.New(t)
+	app := newTestAPI(pool)
+
+	workspaceA := newTestWorkspace(t, pool, "key-a")
+	newTestWorkspace(t, pool, "key-b")
+	specID := newTestSpec(t, pool, &workspaceA, nil)
+
+	outputPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outputPath, "index.html"), []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	newTestCodeJob(t, pool, specID, &workspaceA, outputPath)
+
+	keyA := map[string]string{middleware.APIKeyHeader: "key-a"}
+	keyB := map[string]string{middleware.APIKeyHeader: "key-b"}
+	noKey := map[string]string{}
+
+	requests := []struct {
+		name, method, path, body string
+	}{
+		{"get", "GET", "/api/specs/" + specID, ""},
+		{"json", "GET", "/api/specs/" + specID + "/json", ""},
+		{"files", "GET", "/api/specs/" + specID + "/files", ""},
+		{"file", "GET", "/api/specs/" + specID + "/files/index.html", ""},
+		{"share", "POST", "/api/specs/" + specID + "/share", ""},
+		{"bulk delete", "POST", "/api/specs/bulk-delete", `{"ids":["` + specID + `"]}`},
+		{"delete", "DELETE", "/api/specs/" + specID, ""},
+	}
+	for _, other := range []struct {
+		name    string
+		headers map[string]string
+	}{{"key B", keyB}, {"shared workspace", noKey}} {
+		for _, r := range requests {
+			t.Run(other.name+"/"+r.name, func(t *testing.T) {
+				status, body := apiRequest(t, app, r.method, r.path, r.body, other.headers)
+				if status != fiber.StatusNotFound {
+					t.Fatalf("status = %d, want 404: %s", status, body)
+				}
+			})
+		}
+	}
+
+	// The spec is untouched and still served to its own workspace
+	for _, r := range requests[:4] {
+		t.Run("key A/"+r.name, func(t *testing.T) {
+			status, body := apiRequest(t, app, r.method, r.path, r.body, keyA)
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", status, body)
+			}
+		})
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var listB []map[string]interface{}
+		status, body := apiRequest(t, app, "GET", "/api/specs", "", keyB)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		decodeJSON(t, body, &listB)
+		if len(listB) != 0 {
+			t.Errorf("workspace B lists %d specs, want 0", len(listB))
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		status, _ := apiRequest(t, app, "GET", "/api/specs/"+specID, "", map[string]string{middleware.APIKeyHeader: "key-c"})
+		if status != fiber.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401", status)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"backend/internal/config"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the LLM backend while the breaker is open
+var ErrCircuitOpen = errors.New("llm backend circuit is open")
+
+// Breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// Breaker is a circuit breaker for the LLM backend. It opens after threshold consecutive
+// failures and fails fast for cooldown; then a single probe request is let through, which
+// closes the circuit when it succeeds and opens it again when it fails.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed breaker
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+var (
+	backendOnce    sync.Once
+	backendBreaker *Breaker
+)
+
+// Backend is the breaker shared by all calls to the LLM backend, opened after
+// LLM_BREAKER_FAILURES (default 5) failures in a row for LLM_BREAKER_COOLDOWN (default 30s)
+func Backend() *Breaker {
+	backendOnce.Do(func() {
+		backendBreaker = NewBreaker(
+			config.MustGetInt("LLM_BREAKER_FAILURES", 5),
+			config.MustGetDuration("LLM_BREAKER_COOLDOWN", 30*time.Second),
+		)
+	})
+	return backendBreaker
+}
+
+// Allow reports ErrCircuitOpen when a call must not be made. A nil error while half-open
+// makes the caller the probe, which must report its outcome with Success or Failure.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state() {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a call that reached the backend and closes the circuit
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed call, opening the circuit once threshold failures happened in a row
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.probing || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// release gives up a probe without an outcome, letting the next call probe instead
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns StateClosed, StateOpen or StateHalfOpen
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *Breaker) state() string {
+	if b.failures < b.threshold {
+		return StateClosed
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return StateOpen
+	}
+	return StateHalfOpen
+}
+
+// Do sends req to the LLM backend through the Backend breaker. Transport errors, timeouts and
+// 5xx responses count as failures; a request canceled by its caller counts as neither.
+func Do(req *http.Request) (*http.Response, error) {
+	b := Backend()
+	if err := b.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		b.release()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		b.Failure()
+	default:
+		b.Success()
+	}
+	return resp, err
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"backend/internal/specschema"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NoChanges is the changelog of a regeneration that produced the same spec_json
+const NoChanges = "No changes to the spec."
+
+const changelogSystem = "You write short changelogs for game designers. Respond with a markdown bullet list only."
+
+const changelogPrompt = `A game spec was regenerated. Summarise what changed for a player or designer in a few bullets,
+grouping related fields and skipping changes that don't matter. Each line below is one field:
+"+" was added, "-" was removed, "~" changed from the old to the new value.
+
+`
+
+// GenerateChangelog asks the model to summarise the differences between two spec_json documents
+// as markdown. Identical documents return NoChanges without calling the model.
+func GenerateChangelog(ctx context.Context, llm Client, oldSpec, newSpec SpecJSON) (string, error) {
+	diff := specschema.DiffSpecJSON(oldSpec, newSpec)
+	if diff.IsEmpty() {
+		return NoChanges, nil
+	}
+	return llm.Complete(ctx, changelogSystem, changelogPrompt+FormatDiff(diff))
+}
+
+// FormatDiff renders a diff as one line per field sorted by path, in the format the changelog
+// prompt describes. It also serves as a plain changelog when the model is unavailable.
+func FormatDiff(d specschema.SpecDiff) string {
+	type line struct{ path, text string }
+	var lines []line
+	for path, v := range d.Added {
+		lines = append(lines, line{path, fmt.Sprintf("+ %s: %s", path, compact(v))})
+	}
+	for path, v := range d.Removed {
+		lines = append(lines, line{path, fmt.Sprintf("- %s: %s", path, compact(v))})
+	}
+	for path, c := range d.Changed {
+		lines = append(lines, line{path, fmt.Sprintf("~ %s: %s -> %s", path, compact(c.From), compact(c.To))})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].path < lines[j].path })
+
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// compact renders a value as single-line JSON
+func compact(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mockClient records the prompts it is sent and answers with reply or err
+type mockClient struct {
+	system, prompt string
+	calls          int
+	reply          string
+	err            error
+}
+
+func (m *mockClient) Complete(_ context.Context, system, prompt string) (string, error) {
+	m.calls++
+	m.system, m.prompt = system, prompt
+	return m.reply, m.err
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	oldSpec := SpecJSON{
+		"genre":    "platformer",
+		"lives":    3.0,
+		"controls": map[string]interface{}{"jump": "space", "move": "arrows"},
+		"music":    "chiptune",
+	}
+	newSpec := SpecJSON{
+		"genre":    "platformer",
+		"lives":    5.0,
+		"controls": map[string]interface{}{"jump": "up", "move": "arrows", "dash": "shift"},
+		"levels":   []interface{}{"forest", "cave"},
+	}
+	client := &mockClient{reply: "- More lives"}
+
+	got, err := GenerateChangelog(context.Background(), client, oldSpec, newSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "- More lives" {
+		t.Errorf("changelog = %q, want the model's reply", got)
+	}
+	if client.system != changelogSystem {
+		t.Errorf("system = %q", client.system)
+	}
+	wantDiff := `+ controls.dash: "shift"
+~ controls.jump: "space" -> "up"
++ levels: ["forest","cave"]
+~ lives: 3 -> 5
+- music: "chiptune"
+`
+	if !strings.HasPrefix(client.prompt, changelogPrompt) {
+		t.Fatalf("prompt doesn't start with the instructions:\n%s", client.prompt)
+	}
+	if diff := strings.TrimPrefix(client.prompt, changelogPrompt); diff != wantDiff {
+		t.Errorf("diff sent to the model =\n%s\nwant\n%s", diff, wantDiff)
+	}
+}
+
+func TestGenerateChangelogNoChanges(t *testing.T) {
+	client := &mockClient{}
+	spec := SpecJSON{"genre": "puzzle", "controls": map[string]interface{}{"tap": "rotate"}}
+	got, err := GenerateChangelog(context.Background(), client, spec, SpecJSON{"genre": "puzzle", "controls": map[string]interface{}{"tap": "rotate"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != NoChanges {
+		t.Errorf("changelog = %q, want %q", got, NoChanges)
+	}
+	if client.calls != 0 {
+		t.Errorf("model called %d times for identical specs", client.calls)
+	}
+}
+
+func TestGenerateChangelogError(t *testing.T) {
+	client := &mockClient{err: errors.New("backend down")}
+	if _, err := GenerateChangelog(context.Background(), client, SpecJSON{"a": 1.0}, SpecJSON{"a": 2.0}); err == nil {
+		t.Error("expected the model's error")
+	}
+}
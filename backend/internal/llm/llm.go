@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client sends a prompt to a language model and returns its reply
+type Client interface {
+	Complete(ctx context.Context, system, prompt string) (string, error)
+}
+
+// SpecJSON is the structured part of a game spec
+type SpecJSON = map[string]interface{}
+
+// HTTPClient calls the /llm/complete endpoint of the LLM backend
+type HTTPClient struct {
+	BaseURL string
+	Model   string
+}
+
+// NewHTTPClient returns a client for the LLM backend at baseURL using model
+func NewHTTPClient(baseURL, model string) *HTTPClient {
+	return &HTTPClient{BaseURL: strings.TrimSuffix(baseURL, "/"), Model: model}
+}
+
+type completeReq struct {
+	Prompt    string `json:"prompt"`
+	System    string `json:"system,omitempty"`
+	Model     string `json:"model"`
+	MaxTokens int    `json:"max_tokens"`
+}
+
+type completeResp struct {
+	Text string `json:"text"`
+}
+
+// Complete implements Client
+func (c *HTTPClient) Complete(ctx context.Context, system, prompt string) (string, error) {
+	body, _ := json.Marshal(completeReq{Prompt: prompt, System: system, Model: c.Model, MaxTokens: 1000})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/llm/complete", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := Do(req)
+	if err != nil {
+		return "", fmt.Errorf("llm complete failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("llm complete returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out completeResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	return strings.TrimSpace(out.Text), nil
+}
+
+type translateReq struct {
+	Text           string `json:"text"`
+	SourceLanguage string `json:"source_language,omitempty"`
+	TargetLanguage string `json:"target_language"`
+	Model          string `json:"model"`
+}
+
+// Translate calls /llm/translate to translate text from the given ISO 639-1 language to English
+func (c *HTTPClient) Translate(ctx context.Context, text, from string) (string, error) {
+	body, _ := json.Marshal(translateReq{Text: text, SourceLanguage: from, TargetLanguage: "en", Model: c.Model})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/llm/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := Do(req)
+	if err != nil {
+		return "", fmt.Errorf("llm translate failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("llm translate returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out completeResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	return strings.TrimSpace(out.Text), nil
+}
+
+type estimateReq struct {
+	Brief           string                 `json:"brief"`
+	Constraints     map[string]interface{} `json:"constraints,omitempty"`
+	Model           string                 `json:"model"`
+	IncludeTutorial bool                   `json:"include_tutorial,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+}
+
+// TokenEstimate is the token usage predicted for generating a spec
+type TokenEstimate struct {
+	PromptTokens         int `json:"prompt_tokens"`
+	EstimatedTotalTokens int `json:"estimated_total_tokens"`
+}
+
+// EstimateTokens asks the LLM backend how many tokens generating a spec from brief would use
+func (c *HTTPClient) EstimateTokens(ctx context.Context, brief string, constraints, params map[string]interface{}, includeTutorial bool) (TokenEstimate, error) {
+	var out TokenEstimate
+	body, _ := json.Marshal(estimateReq{Brief: brief, Constraints: constraints, Model: c.Model, IncludeTutorial: includeTutorial, Params: params})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/llm/estimate-tokens", bytes.NewReader(body))
+	if err != nil {
+		return out, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := Do(req)
+	if err != nil {
+		return out, fmt.Errorf("llm estimate failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return out, fmt.Errorf("llm estimate returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"backend/internal/config"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// CORS applies the cross-origin policy from CORS_ALLOWED_ORIGINS, a comma-separated list of
+// origins that may contain globs (https://*.example.com, or *.example.com to match any scheme).
+// "*" keeps the wildcard policy for development.
+func CORS(exposeHeaders ...string) fiber.Handler {
+	cfg := cors.Config{
+		AllowHeaders:     "*",
+		ExposeHeaders:    strings.Join(exposeHeaders, ","),
+		AllowCredentials: config.MustGetBool("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           config.MustGetInt("CORS_MAX_AGE_SECONDS", 0),
+	}
+
+	patterns := parseOriginPatterns(config.GetString("CORS_ALLOWED_ORIGINS", "*"))
+	if len(patterns) == 0 || contains(patterns, "*") {
+		cfg.AllowOrigins = "*"
+		if cfg.AllowCredentials {
+			log.Println("[WARNING] CORS_ALLOW_CREDENTIALS is ignored while CORS_ALLOWED_ORIGINS allows every origin")
+			cfg.AllowCredentials = false
+		}
+	} else {
+		cfg.AllowOriginsFunc = func(origin string) bool {
+			return originAllowed(patterns, origin)
+		}
+	}
+
+	return cors.New(cfg)
+}
+
+func parseOriginPatterns(raw string) []string {
+	var patterns []string
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(p), "/"))
+		if p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// originAllowed matches an Origin header against the allowlist. Patterns without a scheme
+// are matched against the origin's host.
+func originAllowed(patterns []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	host := origin
+	if i := strings.Index(origin, "://"); i >= 0 {
+		host = origin[i+3:]
+	}
+
+	for _, p := range patterns {
+		target := origin
+		if !strings.Contains(p, "://") {
+			target = host
+		}
+		if p == target {
+			return true
+		}
+		if ok, err := path.Match(p, target); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
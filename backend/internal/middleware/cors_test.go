@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	patterns := parseOriginPatterns(" https://app.example.com/ , https://*.preview.example.com,*.example.org ")
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://other.example.com", false},
+		{"https://pr-1.preview.example.com", true},
+		{"http://pr-1.preview.example.com", false},
+		{"https://preview.example.com", false},
+		{"https://evil.com/.preview.example.com", false},
+		{"http://shop.example.org", true},
+		{"https://shop.example.org", true},
+		{"https://example.org", false},
+		{"https://example.org.evil.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := originAllowed(patterns, tt.origin); got != tt.want {
+			t.Errorf("originAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+// preflight sends an OPTIONS request from origin through CORS and returns the response headers
+func preflight(t *testing.T, origin string) (int, string, string) {
+	t.Helper()
+	app := fiber.New()
+	app.Use(CORS("X-Next-Cursor"))
+	app.Post("/api/spec-jobs", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	req := httptest.NewRequest("OPTIONS", "/api/spec-jobs", nil)
+	req.Header.Set(fiber.HeaderOrigin, origin)
+	req.Header.Set(fiber.HeaderAccessControlRequestMethod, "POST")
+	req.Header.Set(fiber.HeaderAccessControlRequestHeaders, "Content-Type, X-API-Key")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin), resp.Header.Get(fiber.HeaderAccessControlAllowCredentials)
+}
+
+func TestCORSPreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     string
+		credentials string
+		origin      string
+		wantOrigin  string
+		wantCreds   string
+	}{
+		{name: "wildcard", allowed: "*", origin: "https://anything.test", wantOrigin: "*"},
+		{name: "wildcard drops credentials", allowed: "*", credentials: "true", origin: "https://anything.test", wantOrigin: "*"},
+		{name: "unset allows every origin", origin: "https://anything.test", wantOrigin: "*"},
+		{name: "allowed origin", allowed: "https://app.example.com", origin: "https://app.example.com", wantOrigin: "https://app.example.com"},
+		{name: "allowed origin with credentials", allowed: "https://app.example.com", credentials: "true", origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantCreds: "true"},
+		{name: "disallowed origin", allowed: "https://app.example.com", origin: "https://evil.test"},
+		{name: "glob origin", allowed: "https://*.example.com", origin: "https://pr-7.example.com", wantOrigin: "https://pr-7.example.com"},
+		{name: "glob without scheme", allowed: "*.example.com", origin: "http://pr-7.example.com", wantOrigin: "http://pr-7.example.com"},
+		{name: "glob wrong scheme", allowed: "https://*.example.com", origin: "http://pr-7.example.com"},
+		{name: "glob other domain", allowed: "https://*.example.com", origin: "https://example.com.evil.test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.allowed)
+			t.Setenv("CORS_ALLOW_CREDENTIALS", tt.credentials)
+			status, origin, creds := preflight(t, tt.origin)
+			if status != fiber.StatusNoContent {
+				t.Errorf("status = %d, want %d", status, fiber.StatusNoContent)
+			}
+			if origin != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", origin, tt.wantOrigin)
+			}
+			if creds != tt.wantCreds {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", creds, tt.wantCreds)
+			}
+		})
+	}
+}
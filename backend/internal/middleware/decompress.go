@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+)
+
+const defaultMaxRequestBytes = 4 << 20
+
+// MaxRequestBytes is the largest request body accepted, from MAX_REQUEST_BYTES (default 4 MB).
+// It bounds the body as sent (Fiber's BodyLimit) and, through Decompress, once decompressed.
+func MaxRequestBytes() int {
+	n := config.MustGetInt("MAX_REQUEST_BYTES", defaultMaxRequestBytes)
+	if n <= 0 {
+		return defaultMaxRequestBytes
+	}
+	return n
+}
+
+// Decompress inflates gzip and br encoded request bodies before they reach the handlers.
+// The decompressed size is capped at MaxRequestBytes to guard against zip bombs.
+func Decompress() fiber.Handler {
+	maxBytes := int64(MaxRequestBytes())
+
+	return func(c *fiber.Ctx) error {
+		encoding := strings.ToLower(strings.TrimSpace(c.Get(fiber.HeaderContentEncoding)))
+		if encoding == "" || encoding == "identity" {
+			return c.Next()
+		}
+
+		var reader io.Reader
+		// c.Body() would already inflate the body, without any size limit
+		body := bytes.NewReader(c.Request().Body())
+		switch encoding {
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(body)
+			if err != nil {
+				return NewProblem(fiber.StatusBadRequest, "Invalid gzip body")
+			}
+			defer gz.Close()
+			reader = gz
+		case "br":
+			reader = brotli.NewReader(body)
+		default:
+			return NewProblem(fiber.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported Content-Encoding %q", encoding))
+		}
+
+		// Read one byte past the limit to tell an exact fit from an oversized body
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+		if err != nil {
+			return NewProblem(fiber.StatusBadRequest, "Failed to decompress request body")
+		}
+		if int64(len(decoded)) > maxBytes {
+			return NewProblem(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("Decompressed body exceeds %d bytes", maxBytes))
+		}
+
+		c.Request().SetBody(decoded)
+		c.Request().Header.Del(fiber.HeaderContentEncoding)
+		c.Request().Header.SetContentLength(len(decoded))
+		return c.Next()
+	}
+}
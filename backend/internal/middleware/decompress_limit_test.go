@@ -0,0 +1,21 @@
+package middleware
+
+import "testing"
+
+func TestMaxRequestBytes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", defaultMaxRequestBytes},
+		{"2048", 2048},
+		{"0", defaultMaxRequestBytes},
+		{"-1", defaultMaxRequestBytes},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAX_REQUEST_BYTES", tt.value)
+		if got := MaxRequestBytes(); got != tt.want {
+			t.Errorf("MAX_REQUEST_BYTES=%q: got %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
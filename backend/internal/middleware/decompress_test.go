@@ -0,0 +1,165 @@
+package middleware_test
+
+import (
+	"backend/internal/handlers"
+	"backend/internal/middleware"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newDecompressApp echoes the CreateJobReq it parses from the decompressed body
+func newDecompressApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Use(middleware.Decompress())
+	app.Post("/", func(c *fiber.Ctx) error {
+		var req handlers.CreateJobReq
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.NewProblem(fiber.StatusBadRequest, err.Error())
+		}
+		return c.JSON(req)
+	})
+	return app
+}
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func brotliBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	br := brotli.NewWriter(&buf)
+	if _, err := br.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func postEncoded(t *testing.T, app *fiber.App, encoding string, body []byte) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if encoding != "" {
+		req.Header.Set(fiber.HeaderContentEncoding, encoding)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, out.Bytes()
+}
+
+func TestDecompressRoundTrip(t *testing.T) {
+	want := handlers.CreateJobReq{
+		Brief:           "A platformer about a cat collecting yarn",
+		Constraints:     map[string]interface{}{"genre": "platformer"},
+		IncludeTutorial: true,
+		Params:          map[string]interface{}{"temperature": 0.5},
+	}
+	raw, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"", raw},
+		{"identity", raw},
+		{"gzip", gzipBytes(t, raw)},
+		{"x-gzip", gzipBytes(t, raw)},
+		{"br", brotliBytes(t, raw)},
+	}
+	for _, tt := range tests {
+		t.Run("encoding "+tt.encoding, func(t *testing.T) {
+			status, body := postEncoded(t, newDecompressApp(), tt.encoding, tt.body)
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d, body %s", status, body)
+			}
+			var got handlers.CreateJobReq
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	t.Setenv("MAX_REQUEST_BYTES", "1024")
+
+	fits, err := json.Marshal(handlers.CreateJobReq{Brief: strings.Repeat("a", 1024-len(`{"brief":""}`))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, body := postEncoded(t, newDecompressApp(), "gzip", gzipBytes(t, fits)); status != fiber.StatusOK {
+		t.Errorf("body of exactly the limit: status = %d, body %s", status, body)
+	}
+
+	// A few hundred bytes on the wire that inflate far past the limit
+	bomb := gzipBytes(t, bytes.Repeat([]byte{' '}, 10<<20))
+	if len(bomb) > 64<<10 {
+		t.Fatalf("bomb is %d bytes compressed", len(bomb))
+	}
+	for _, tt := range []struct {
+		encoding string
+		body     []byte
+	}{
+		{"gzip", bomb},
+		{"br", brotliBytes(t, bytes.Repeat([]byte{' '}, 10<<20))},
+	} {
+		if status, _ := postEncoded(t, newDecompressApp(), tt.encoding, tt.body); status != fiber.StatusRequestEntityTooLarge {
+			t.Errorf("%s bomb: status = %d, want %d", tt.encoding, status, fiber.StatusRequestEntityTooLarge)
+		}
+	}
+}
+
+func TestDecompressInvalidBody(t *testing.T) {
+	valid := gzipBytes(t, []byte(`{"brief":"A puzzle game"}`))
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+	}{
+		{"not gzip", "gzip", []byte("plain text"), fiber.StatusBadRequest},
+		{"truncated gzip", "gzip", valid[:len(valid)-6], fiber.StatusBadRequest},
+		{"corrupt brotli", "br", []byte{0xff, 0xff, 0xff, 0xff}, fiber.StatusBadRequest},
+		{"unsupported encoding", "deflate", valid, fiber.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := postEncoded(t, newDecompressApp(), tt.encoding, tt.body); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", status, tt.wantStatus, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// MIMEProblemJSON is the content type of RFC 7807 error responses
+const MIMEProblemJSON = "application/problem+json"
+
+// problemTypes maps status codes to the type URI of their problem details
+var problemTypes = map[int]string{
+	fiber.StatusBadRequest:            "/problems/bad-request",
+	fiber.StatusUnauthorized:          "/problems/unauthorized",
+	fiber.StatusPaymentRequired:       "/problems/quota-exceeded",
+	fiber.StatusForbidden:             "/problems/forbidden",
+	fiber.StatusNotFound:              "/problems/not-found",
+	fiber.StatusMethodNotAllowed:      "/problems/method-not-allowed",
+	fiber.StatusConflict:              "/problems/conflict",
+	fiber.StatusRequestEntityTooLarge: "/problems/payload-too-large",
+	fiber.StatusUnsupportedMediaType:  "/problems/unsupported-media-type",
+	fiber.StatusUnprocessableEntity:   "/problems/unprocessable-entity",
+	fiber.StatusTooManyRequests:       "/problems/too-many-requests",
+	fiber.StatusInternalServerError:   "/problems/internal-error",
+	fiber.StatusBadGateway:            "/problems/upstream-error",
+	fiber.StatusServiceUnavailable:    "/problems/service-unavailable",
+	fiber.StatusGatewayTimeout:        "/problems/upstream-timeout",
+}
+
+// Machine-readable error codes shared by all handlers. Every problem response carries one
+// in its code member; handlers set more specific codes with WithCode.
+const (
+	CodeValidation         = "validation_error"
+	CodeUnauthorized       = "unauthorized"
+	CodeQuotaExceeded      = "quota_exceeded"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeUnprocessable      = "unprocessable"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal_error"
+	CodeUpstreamError      = "upstream_error"
+	CodeServiceUnavailable = "service_unavailable"
+	CodeTimeout            = "timeout"
+)
+
+// statusCodes maps status codes to the error code used when a handler doesn't set one
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:            CodeValidation,
+	fiber.StatusUnauthorized:          CodeUnauthorized,
+	fiber.StatusPaymentRequired:       CodeQuotaExceeded,
+	fiber.StatusForbidden:             CodeForbidden,
+	fiber.StatusNotFound:              CodeNotFound,
+	fiber.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	fiber.StatusConflict:              CodeConflict,
+	fiber.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	fiber.StatusUnsupportedMediaType:  CodeUnsupportedMedia,
+	fiber.StatusUnprocessableEntity:   CodeUnprocessable,
+	fiber.StatusTooManyRequests:       CodeRateLimited,
+	fiber.StatusInternalServerError:   CodeInternal,
+	fiber.StatusBadGateway:            CodeUpstreamError,
+	fiber.StatusServiceUnavailable:    CodeServiceUnavailable,
+	fiber.StatusGatewayTimeout:        CodeTimeout,
+}
+
+// Problem is an error rendered as RFC 7807 problem details. Extensions are added as extra
+// top-level members of the response.
+type Problem struct {
+	Status     int
+	Code       string
+	Detail     string
+	Extensions map[string]interface{}
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+// NewProblem returns a Problem with the given status and detail
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Status: status, Detail: detail}
+}
+
+// WithCode sets the machine-readable error code of the problem
+func (p *Problem) WithCode(code string) *Problem {
+	p.Code = code
+	return p
+}
+
+// ErrorCode returns the code a problem is rendered with
+func (p *Problem) ErrorCode() string {
+	if p.Code != "" {
+		return p.Code
+	}
+	if code, ok := statusCodes[p.Status]; ok {
+		return code
+	}
+	if p.Status >= 500 {
+		return CodeInternal
+	}
+	return CodeValidation
+}
+
+// With adds an extension member to the problem
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// ProblemDetails renders errors returned by later handlers as application/problem+json.
+// Missing rows become a 404 and expired deadlines a 504; any other error that is neither a
+// Problem nor a fiber.Error becomes a 500 without leaking its message.
+func ProblemDetails() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err == nil {
+			return nil
+		}
+		return writeProblem(c, toProblem(c, err))
+	}
+}
+
+// ErrorHandler is the fiber.Config error handler. It renders errors raised outside the
+// middleware chain, such as an oversized body, as problem details like ProblemDetails does.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	return writeProblem(c, toProblem(c, err))
+}
+
+func toProblem(c *fiber.Ctx, err error) *Problem {
+	var p *Problem
+	var fe *fiber.Error
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.As(err, &fe):
+		return NewProblem(fe.Code, fe.Message)
+	case errors.Is(err, pgx.ErrNoRows):
+		return NewProblem(fiber.StatusNotFound, "Not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("[ERROR] Deadline exceeded on %s %s: %v", c.Method(), c.Path(), err)
+		return NewProblem(fiber.StatusGatewayTimeout, "The request timed out")
+	default:
+		log.Printf("[ERROR] Unhandled error on %s %s: %v", c.Method(), c.Path(), err)
+		return NewProblem(fiber.StatusInternalServerError, "Internal server error")
+	}
+}
+
+func writeProblem(c *fiber.Ctx, p *Problem) error {
+	body := make(map[string]interface{}, len(p.Extensions)+6)
+	for k, v := range p.Extensions {
+		body[k] = v
+	}
+	problemType, ok := problemTypes[p.Status]
+	if !ok {
+		problemType = "about:blank"
+	}
+	body["type"] = problemType
+	body["title"] = http.StatusText(p.Status)
+	body["status"] = p.Status
+	body["code"] = p.ErrorCode()
+	body["detail"] = p.Detail
+	body["instance"] = c.OriginalURL()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.Status(p.Status)
+	c.Set(fiber.HeaderContentType, MIMEProblemJSON)
+	return c.Send(b)
+}
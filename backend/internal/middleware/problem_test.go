@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestProblemDetails(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(ProblemDetails())
+	app.Get("/problem", func(c *fiber.Ctx) error {
+		return NewProblem(fiber.StatusConflict, "Spec already exists").WithCode("duplicate_spec").With("existing_id", "spec-1")
+	})
+	app.Get("/fiber-error", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid JSON")
+	})
+	app.Get("/no-rows", func(c *fiber.Ctx) error {
+		return fmt.Errorf("loading spec: %w", pgx.ErrNoRows)
+	})
+	app.Get("/deadline", func(c *fiber.Ctx) error {
+		return fmt.Errorf("query: %w", context.DeadlineExceeded)
+	})
+	app.Get("/plain", func(c *fiber.Ctx) error {
+		return errors.New("password=hunter2 leaked")
+	})
+	app.Get("/teapot", func(c *fiber.Ctx) error {
+		return NewProblem(fiber.StatusTeapot, "Short and stout")
+	})
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.SendString("fine")
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantType   string
+		wantCode   string
+		wantDetail string
+		wantExtra  map[string]interface{}
+	}{
+		{
+			path: "/problem?x=1", wantStatus: fiber.StatusConflict, wantType: "/problems/conflict",
+			wantCode: "duplicate_spec", wantDetail: "Spec already exists",
+			wantExtra: map[string]interface{}{"existing_id": "spec-1"},
+		},
+		{path: "/fiber-error", wantStatus: fiber.StatusBadRequest, wantType: "/problems/bad-request", wantCode: CodeValidation, wantDetail: "Invalid JSON"},
+		{path: "/no-rows", wantStatus: fiber.StatusNotFound, wantType: "/problems/not-found", wantCode: CodeNotFound, wantDetail: "Not found"},
+		{path: "/deadline", wantStatus: fiber.StatusGatewayTimeout, wantType: "/problems/upstream-timeout", wantCode: CodeTimeout, wantDetail: "The request timed out"},
+		{path: "/plain", wantStatus: fiber.StatusInternalServerError, wantType: "/problems/internal-error", wantCode: CodeInternal, wantDetail: "Internal server error"},
+		{path: "/teapot", wantStatus: fiber.StatusTeapot, wantType: "about:blank", wantCode: CodeValidation, wantDetail: "Short and stout"},
+		{path: "/missing-route", wantStatus: fiber.StatusNotFound, wantType: "/problems/not-found", wantCode: CodeNotFound, wantDetail: "Cannot GET /missing-route"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if ct := resp.Header.Get(fiber.HeaderContentType); ct != MIMEProblemJSON {
+				t.Errorf("Content-Type = %q, want %q", ct, MIMEProblemJSON)
+			}
+
+			raw, _ := io.ReadAll(resp.Body)
+			var body map[string]interface{}
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("body is not JSON: %s", raw)
+			}
+			want := map[string]interface{}{
+				"type":     tt.wantType,
+				"title":    nil,
+				"status":   float64(tt.wantStatus),
+				"code":     tt.wantCode,
+				"detail":   tt.wantDetail,
+				"instance": tt.path,
+			}
+			for k, v := range tt.wantExtra {
+				want[k] = v
+			}
+			if len(body) != len(want) {
+				t.Errorf("members = %v, want %d members", body, len(want))
+			}
+			for k, v := range want {
+				got, ok := body[k]
+				if !ok {
+					t.Errorf("missing member %q in %s", k, raw)
+					continue
+				}
+				if k == "title" {
+					if s, _ := got.(string); s == "" {
+						t.Errorf("title = %v, want the status text", got)
+					}
+					continue
+				}
+				if got != v {
+					t.Errorf("%s = %v, want %v", k, got, v)
+				}
+			}
+		})
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ok", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderContentType) == MIMEProblemJSON {
+		t.Errorf("successful response was rewritten: %d %s", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+}
+
+func TestProblemErrorCode(t *testing.T) {
+	tests := []struct {
+		p    *Problem
+		want string
+	}{
+		{NewProblem(fiber.StatusNotFound, ""), CodeNotFound},
+		{NewProblem(fiber.StatusNotFound, "").WithCode("spec_not_found"), "spec_not_found"},
+		{NewProblem(fiber.StatusTeapot, ""), CodeValidation},
+		{NewProblem(fiber.StatusInsufficientStorage, ""), CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := tt.p.ErrorCode(); got != tt.want {
+			t.Errorf("ErrorCode() of %d/%q = %q, want %q", tt.p.Status, tt.p.Code, got, tt.want)
+		}
+	}
+}
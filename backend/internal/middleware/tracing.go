@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"backend/internal/tracing"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span per request, continuing the trace of an incoming traceparent
+// header. The span's context becomes the request's user context so handlers can add children.
+// Register it before ProblemDetails so the span sees the final status code.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Fiber canonicalizes header names while propagators look up lower-case keys
+		carrier := propagation.MapCarrier{}
+		for key, values := range c.GetReqHeaders() {
+			if len(values) > 0 {
+				carrier.Set(strings.ToLower(key), values[0])
+			}
+		}
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		ctx, span := tracing.Tracer().Start(ctx, fmt.Sprintf("%s %s", c.Method(), c.Path()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// Name the span after the matched route so ids don't explode span cardinality
+		span.SetName(fmt.Sprintf("%s %s", c.Method(), c.Route().Path))
+		status := c.Response().StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if err != nil || status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		return err
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider recording every ended span for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return rec
+}
+
+func attr(s sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(Tracing(), ProblemDetails())
+	app.Get("/specs/:id", func(c *fiber.Ctx) error {
+		_, child := otel.Tracer("test").Start(c.UserContext(), "child")
+		child.End()
+		if c.Params("id") == "missing" {
+			return NewProblem(fiber.StatusNotFound, "Spec not found")
+		}
+		if c.Params("id") == "broken" {
+			return NewProblem(fiber.StatusBadGateway, "LLM failed")
+		}
+		return c.SendString("ok")
+	})
+
+	const parentTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantStatus  int
+		wantError   bool
+	}{
+		{name: "new trace", path: "/specs/spec-1", wantStatus: fiber.StatusOK},
+		{name: "continued trace", path: "/specs/spec-2", traceparent: "00-" + parentTrace + "-00f067aa0ba902b7-01", wantStatus: fiber.StatusOK},
+		{name: "client error", path: "/specs/missing", wantStatus: fiber.StatusNotFound},
+		{name: "server error", path: "/specs/broken", wantStatus: fiber.StatusBadGateway, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := recordSpans(t)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			spans := rec.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("%d spans ended, want the handler's child and the server span", len(spans))
+			}
+			child, server := spans[0], spans[1]
+			if server.Name() != "GET /specs/:id" {
+				t.Errorf("server span name = %q, want the route", server.Name())
+			}
+			if server.SpanKind() != trace.SpanKindServer {
+				t.Errorf("server span kind = %v", server.SpanKind())
+			}
+			if got := attr(server, "http.response.status_code").AsInt64(); got != int64(tt.wantStatus) {
+				t.Errorf("http.response.status_code = %d, want %d", got, tt.wantStatus)
+			}
+			if got := attr(server, "url.path").AsString(); got != tt.path {
+				t.Errorf("url.path = %q, want %q", got, tt.path)
+			}
+			if isError := server.Status().Code == codes.Error; isError != tt.wantError {
+				t.Errorf("span status = %+v, want error %t", server.Status(), tt.wantError)
+			}
+			if child.Parent().SpanID() != server.SpanContext().SpanID() || child.SpanContext().TraceID() != server.SpanContext().TraceID() {
+				t.Error("handler span is not a child of the server span")
+			}
+
+			if tt.traceparent != "" {
+				if got := server.SpanContext().TraceID().String(); got != parentTrace {
+					t.Errorf("trace id = %s, want the incoming %s", got, parentTrace)
+				}
+				if !server.Parent().IsRemote() {
+					t.Error("server span parent is not the remote caller")
+				}
+			} else if server.Parent().IsValid() {
+				t.Error("server span without traceparent has a parent")
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"backend/internal/config"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const userLocal = "user_id"
+
+// User resolves the user making the request from the header named by USER_ID_HEADER, which an
+// authenticating proxy in front of the API must set (and strip from client requests). Without
+// USER_ID_HEADER requests have no user; with it, a request missing the header is rejected with 401
+// rather than seeing every user's specs.
+func User() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := config.GetString("USER_ID_HEADER", "")
+		if header == "" {
+			return c.Next()
+		}
+		id := strings.TrimSpace(c.Get(header))
+		if id == "" {
+			return NewProblem(fiber.StatusUnauthorized, "Missing "+header+" header")
+		}
+		c.Locals(userLocal, id)
+		return c.Next()
+	}
+}
+
+// UserID returns the user resolved for the request, or nil when there is none
+func UserID(c *fiber.Ctx) *string {
+	id, ok := c.Locals(userLocal).(string)
+	if !ok || id == "" {
+		return nil
+	}
+	return &id
+}
+
+// IsAdmin reports whether the request's user is listed in ADMIN_USER_IDS (comma-separated)
+func IsAdmin(c *fiber.Ctx) bool {
+	id := UserID(c)
+	if id == nil {
+		return false
+	}
+	for _, admin := range strings.Split(config.GetString("ADMIN_USER_IDS", ""), ",") {
+		if strings.TrimSpace(admin) == *id {
+			return true
+		}
+	}
+	return false
+}
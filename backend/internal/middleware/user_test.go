@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newUserApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(User())
+	app.Get("/", func(c *fiber.Ctx) error {
+		if id := UserID(c); id != nil {
+			return c.SendString(*id)
+		}
+		return c.SendString("")
+	})
+	return app
+}
+
+func TestUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "header not configured", wantStatus: fiber.StatusOK},
+		{name: "header present", header: "X-User-Id", value: " alice ", wantStatus: fiber.StatusOK, wantUser: "alice"},
+		{name: "header missing", header: "X-User-Id", wantStatus: fiber.StatusUnauthorized},
+		{name: "header blank", header: "X-User-Id", value: "  ", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("USER_ID_HEADER", tt.header)
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.value != "" {
+				req.Header.Set("X-User-Id", tt.value)
+			}
+			resp, err := newUserApp().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			body := make([]byte, 64)
+			n, _ := resp.Body.Read(body)
+			if got := string(body[:n]); got != tt.wantUser {
+				t.Errorf("user = %q, want %q", got, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"backend/internal/config"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// APIKeyHeader carries the workspace API key
+	APIKeyHeader = "X-API-Key"
+
+	workspaceLocal           = "workspace_id"
+	defaultWorkspaceCacheTTL = 5 * time.Minute
+)
+
+type cachedWorkspace struct {
+	id        string
+	expiresAt time.Time
+}
+
+// workspaceCache maps API key hashes to workspace ids so most requests skip the lookup query
+type workspaceCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedWorkspace
+}
+
+func (wc *workspaceCache) get(keyHash string) (string, bool) {
+	wc.mu.RLock()
+	defer wc.mu.RUnlock()
+	e, ok := wc.entries[keyHash]
+	if !ok || time.Now().After(e.expiresAt) {
+		return "", false
+	}
+	return e.id, true
+}
+
+func (wc *workspaceCache) set(keyHash, id string, ttl time.Duration) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.entries[keyHash] = cachedWorkspace{id: id, expiresAt: time.Now().Add(ttl)}
+}
+
+// HashAPIKey returns the hex sha256 of an API key, which is what the workspaces table stores
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// Workspace resolves the workspace of the request from the X-API-Key header.
+// Requests without a key use the shared workspace, unknown keys are rejected with 401.
+func Workspace(db *pgxpool.Pool) fiber.Handler {
+	cache := &workspaceCache{entries: map[string]cachedWorkspace{}}
+	ttl := config.MustGetDuration("WORKSPACE_CACHE_TTL", defaultWorkspaceCacheTTL)
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(APIKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+
+		keyHash := HashAPIKey(key)
+		id, ok := cache.get(keyHash)
+		if !ok {
+			ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+			err := db.QueryRow(ctx, `SELECT id FROM workspaces WHERE api_key_hash = $1`, keyHash).Scan(&id)
+			cancel()
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return NewProblem(fiber.StatusUnauthorized, "Invalid API key")
+				}
+				log.Printf("[ERROR] Failed to resolve workspace: %v", err)
+				return NewProblem(fiber.StatusInternalServerError, "Database error")
+			}
+			cache.set(keyHash, id, ttl)
+		}
+
+		c.Locals(workspaceLocal, id)
+		return c.Next()
+	}
+}
+
+// WorkspaceID returns the workspace resolved for the request, or nil for the shared workspace.
+// The pointer can be passed straight to queries as a nullable uuid.
+func WorkspaceID(c *fiber.Ctx) *string {
+	id, ok := c.Locals(workspaceLocal).(string)
+	if !ok || id == "" {
+		return nil
+	}
+	return &id
+}
+
+// RequireAdmin only lets requests through whose X-API-Key matches ADMIN_API_KEY.
+// Admin routes are disabled when ADMIN_API_KEY is not set.
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminKey := config.GetString("ADMIN_API_KEY", "")
+		if adminKey == "" {
+			return NewProblem(fiber.StatusForbidden, "Admin API is disabled")
+		}
+		if subtle.ConstantTimeCompare([]byte(c.Get(APIKeyHeader)), []byte(adminKey)) != 1 {
+			return NewProblem(fiber.StatusUnauthorized, "Invalid admin API key")
+		}
+		return c.Next()
+	}
+}
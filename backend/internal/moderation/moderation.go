@@ -0,0 +1,90 @@
+package moderation
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+var client = &http.Client{}
+
+type moderationReq struct {
+	Input string `json:"input"`
+	Model string `json:"model,omitempty"`
+}
+
+// moderationResp is the response of the OpenAI moderation endpoint, or of any API answering in its shape
+type moderationResp struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// CheckBrief asks the moderation API at MODERATION_API_URL whether a brief is safe to generate a
+// game from, returning the flagged categories as the reason when it isn't. Every brief is safe
+// when no URL is configured. An error means the API couldn't give an answer.
+func CheckBrief(ctx context.Context, brief string) (safe bool, reason string, err error) {
+	url := config.GetString("MODERATION_API_URL", "")
+	if url == "" {
+		return true, "", nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.MustGetDuration("MODERATION_TIMEOUT", 5*time.Second))
+	defer cancel()
+
+	body, _ := json.Marshal(moderationReq{Input: brief, Model: config.GetString("MODERATION_MODEL", "")})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := config.GetString("MODERATION_API_KEY", ""); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, "", fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, "", fmt.Errorf("moderation API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out moderationResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, "", fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	if len(out.Results) == 0 {
+		return false, "", errors.New("moderation response has no results")
+	}
+
+	var flagged []string
+	for _, result := range out.Results {
+		if !result.Flagged {
+			continue
+		}
+		for category, hit := range result.Categories {
+			if hit {
+				flagged = append(flagged, category)
+			}
+		}
+		if len(flagged) == 0 {
+			flagged = append(flagged, "flagged")
+		}
+	}
+	if len(flagged) == 0 {
+		return true, "", nil
+	}
+	sort.Strings(flagged)
+	return false, "brief flagged for " + strings.Join(flagged, ", "), nil
+}
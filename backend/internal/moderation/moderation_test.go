@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newModerationServer answers every request with status and body, recording the last request
+func newModerationServer(t *testing.T, status int, body string) (*httptest.Server, *moderationReq, *http.Header) {
+	t.Helper()
+	var got moderationReq
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got, &headers
+}
+
+func TestCheckBrief(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantSafe   bool
+		wantReason string
+		wantErr    bool
+	}{
+		{
+			name:     "safe brief",
+			status:   http.StatusOK,
+			body:     `{"results":[{"flagged":false,"categories":{"violence":false}}]}`,
+			wantSafe: true,
+		},
+		{
+			name:       "flagged brief",
+			status:     http.StatusOK,
+			body:       `{"results":[{"flagged":true,"categories":{"violence":true,"hate":true,"sexual":false}}]}`,
+			wantReason: "brief flagged for hate, violence",
+		},
+		{
+			name:       "flagged without categories",
+			status:     http.StatusOK,
+			body:       `{"results":[{"flagged":true}]}`,
+			wantReason: "brief flagged for flagged",
+		},
+		{name: "server error", status: http.StatusInternalServerError, body: "upstream down", wantErr: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: "slow down", wantErr: true},
+		{name: "malformed response", status: http.StatusOK, body: `{"results":`, wantErr: true},
+		{name: "no results", status: http.StatusOK, body: `{"results":[]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, _ := newModerationServer(t, tt.status, tt.body)
+			t.Setenv("MODERATION_API_URL", srv.URL)
+
+			safe, reason, err := CheckBrief(context.Background(), "A cat collects yarn")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if safe != tt.wantSafe || reason != tt.wantReason {
+				t.Errorf("got (%v, %q), want (%v, %q)", safe, reason, tt.wantSafe, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckBriefRequest(t *testing.T) {
+	srv, got, headers := newModerationServer(t, http.StatusOK, `{"results":[{"flagged":false}]}`)
+	t.Setenv("MODERATION_API_URL", srv.URL)
+	t.Setenv("MODERATION_API_KEY", "secret")
+	t.Setenv("MODERATION_MODEL", "omni-moderation-latest")
+
+	if _, _, err := CheckBrief(context.Background(), "A cat collects yarn"); err != nil {
+		t.Fatal(err)
+	}
+	if got.Input != "A cat collects yarn" || got.Model != "omni-moderation-latest" {
+		t.Errorf("request = %+v", *got)
+	}
+	if auth := headers.Get("Authorization"); auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestCheckBriefNotConfigured(t *testing.T) {
+	t.Setenv("MODERATION_API_URL", "")
+	safe, reason, err := CheckBrief(context.Background(), "anything")
+	if !safe || reason != "" || err != nil {
+		t.Errorf("got (%v, %q, %v), want (true, \"\", nil)", safe, reason, err)
+	}
+}
+
+func TestCheckBriefUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	t.Setenv("MODERATION_API_URL", url)
+
+	if _, _, err := CheckBrief(context.Background(), "A cat collects yarn"); err == nil {
+		t.Error("expected an error when the API is down")
+	}
+}
@@ -0,0 +1,35 @@
+package nlp
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/pemistahl/lingua-go"
+)
+
+// ErrUndetected is returned when the language of a text can't be determined
+var ErrUndetected = errors.New("language could not be detected")
+
+var (
+	detectorOnce sync.Once
+	detector     lingua.LanguageDetector
+)
+
+// DetectLanguage returns the ISO 639-1 code (e.g. "en") of the most likely language of text
+// and its confidence between 0 and 1. Language models are loaded on first use.
+func DetectLanguage(text string) (string, float64, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", 0, ErrUndetected
+	}
+	detectorOnce.Do(func() {
+		detector = lingua.NewLanguageDetectorBuilder().FromAllLanguages().Build()
+	})
+
+	values := detector.ComputeLanguageConfidenceValues(text)
+	if len(values) == 0 || values[0].Value() == 0 {
+		return "", 0, ErrUndetected
+	}
+	top := values[0]
+	return strings.ToLower(top.Language().IsoCode639_1().String()), top.Value(), nil
+}
@@ -0,0 +1,39 @@
+package nlp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"english", "A platformer where a cat collects balls of yarn while avoiding the vacuum cleaner.", "en"},
+		{"french", "Un jeu de plateforme où un chat ramasse des pelotes de laine en évitant l'aspirateur.", "fr"},
+		{"japanese", "猫が掃除機を避けながら毛糸玉を集めるプラットフォームゲーム。", "ja"},
+		{"german", "Ein Jump-and-Run-Spiel, in dem eine Katze Wollknäuel sammelt und dem Staubsauger ausweicht.", "de"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, confidence, err := DetectLanguage(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lang != tt.want {
+				t.Errorf("language = %q, want %q", lang, tt.want)
+			}
+			if confidence <= 0 || confidence > 1 {
+				t.Errorf("confidence = %v, want (0, 1]", confidence)
+			}
+		})
+	}
+}
+
+func TestDetectLanguageEmpty(t *testing.T) {
+	for _, text := range []string{"", "   \n\t"} {
+		if _, _, err := DetectLanguage(text); !errors.Is(err, ErrUndetected) {
+			t.Errorf("DetectLanguage(%q) error = %v, want ErrUndetected", text, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package specschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ComplexityScore predicts how hard a spec is to turn into a working game
+type ComplexityScore struct {
+	Score   int      `json:"score"`
+	Level   string   `json:"level"`
+	Factors []string `json:"factors"`
+}
+
+// Complexity levels
+const (
+	ComplexityLow    = "low"
+	ComplexityMedium = "medium"
+	ComplexityHigh   = "high"
+)
+
+var (
+	audioKeywords       = []string{"audio", "sound", "music", "sfx"}
+	multiplayerKeywords = []string{"multiplayer", "pvp", "online", "co-op", "coop", "network"}
+	persistenceKeywords = []string{"save", "persist", "progress", "leaderboard", "inventory", "account"}
+)
+
+// EstimateComplexity scores a spec from its mechanics, controls, audio, multiplayer and
+// persistence needs and its duration. Below 30 is low, below 60 medium, anything else high.
+func EstimateComplexity(specJSON map[string]interface{}) ComplexityScore {
+	var c ComplexityScore
+	add := func(points int, factor string) {
+		if points > 0 {
+			c.Score += points
+			c.Factors = append(c.Factors, factor)
+		}
+	}
+
+	mechanics := countItems(specJSON["mechanics"])
+	add(mechanics*6, fmt.Sprintf("%d mechanics", mechanics))
+	controls := countItems(specJSON["controls"])
+	add(controls*2, fmt.Sprintf("%d controls", controls))
+
+	text := strings.ToLower(flatten(specJSON))
+	if containsAnyWord(text, audioKeywords) {
+		add(8, "audio")
+	}
+	if containsAnyWord(text, multiplayerKeywords) {
+		add(20, "multiplayer")
+	}
+	if containsAnyWord(text, persistenceKeywords) {
+		add(12, "persistence")
+	}
+
+	// Longer sessions need more content; one point per minute, capped at 20
+	if d, ok := specJSON["duration_sec"].(float64); ok && d > 0 {
+		minutes := int(d / 60)
+		if minutes > 20 {
+			minutes = 20
+		}
+		add(minutes, fmt.Sprintf("%d min duration", int(d/60)))
+	}
+
+	switch {
+	case c.Score < 30:
+		c.Level = ComplexityLow
+	case c.Score < 60:
+		c.Level = ComplexityMedium
+	default:
+		c.Level = ComplexityHigh
+	}
+	if c.Factors == nil {
+		c.Factors = []string{}
+	}
+	return c
+}
+
+func countItems(v interface{}) int {
+	switch val := v.(type) {
+	case []interface{}:
+		return len(val)
+	case map[string]interface{}:
+		return len(val)
+	case string:
+		if strings.TrimSpace(val) != "" {
+			return 1
+		}
+	}
+	return 0
+}
+
+// flatten joins every string and key of a JSON value, with map keys sorted for stable output
+func flatten(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, flatten(item))
+		}
+		return strings.Join(parts, " ")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, 2*len(keys))
+		for _, k := range keys {
+			parts = append(parts, k, flatten(val[k]))
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+func containsAnyWord(text string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package specschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEstimateComplexity(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want ComplexityScore
+	}{
+		{
+			name: "empty spec",
+			spec: `{}`,
+			want: ComplexityScore{Score: 0, Level: ComplexityLow, Factors: []string{}},
+		},
+		{
+			name: "pong clone",
+			spec: `{"mechanics":["bounce ball"],"controls":["up","down"],"duration_sec":120}`,
+			want: ComplexityScore{Score: 6 + 4 + 2, Level: ComplexityLow, Factors: []string{"1 mechanics", "2 controls", "2 min duration"}},
+		},
+		{
+			name: "controls as an object",
+			spec: `{"mechanics":"jump","controls":{"move":"arrows","jump":"space","pause":"p"}}`,
+			want: ComplexityScore{Score: 6 + 6, Level: ComplexityLow, Factors: []string{"1 mechanics", "3 controls"}},
+		},
+		{
+			name: "platformer with music and saves",
+			spec: `{"mechanics":["jump","collect coins","enemies"],"controls":["arrows","space"],"features":["background music","save progress"],"duration_sec":600}`,
+			want: ComplexityScore{Score: 18 + 4 + 8 + 12 + 10, Level: ComplexityMedium, Factors: []string{"3 mechanics", "2 controls", "audio", "persistence", "10 min duration"}},
+		},
+		{
+			name: "online rpg",
+			spec: `{"genre":"RPG","mechanics":["quests","crafting","combat","trading","leveling"],"controls":["wasd","mouse","e","i"],"modes":["Online PvP"],"notes":"inventory and sound effects","duration_sec":3600}`,
+			want: ComplexityScore{Score: 30 + 8 + 8 + 20 + 12 + 20, Level: ComplexityHigh, Factors: []string{"5 mechanics", "4 controls", "audio", "multiplayer", "persistence", "60 min duration"}},
+		},
+		{
+			name: "level boundaries",
+			spec: `{"mechanics":["a","b","c","d","e"]}`,
+			want: ComplexityScore{Score: 30, Level: ComplexityMedium, Factors: []string{"5 mechanics"}},
+		},
+		{
+			name: "sixty is high",
+			spec: `{"mechanics":["a","b","c","d","e","f","g","h","i","j"]}`,
+			want: ComplexityScore{Score: 60, Level: ComplexityHigh, Factors: []string{"10 mechanics"}},
+		},
+		{
+			name: "invalid duration is ignored",
+			spec: `{"mechanics":["jump"],"duration_sec":"long"}`,
+			want: ComplexityScore{Score: 6, Level: ComplexityLow, Factors: []string{"1 mechanics"}},
+		},
+		{
+			name: "short duration adds nothing",
+			spec: `{"duration_sec":45}`,
+			want: ComplexityScore{Score: 0, Level: ComplexityLow, Factors: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateComplexity(doc(t, tt.spec))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EstimateComplexity = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := EstimateComplexity(nil); got.Level != ComplexityLow || got.Score != 0 {
+		t.Errorf("EstimateComplexity(nil) = %+v", got)
+	}
+}
@@ -0,0 +1,59 @@
+package specschema
+
+import "reflect"
+
+// FieldChange holds the before and after value of a changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// SpecDiff is a field-level diff between two spec_json documents.
+// Nested keys are reported with a dotted path, e.g. "controls.keyboard".
+type SpecDiff struct {
+	Added   map[string]interface{} `json:"added"`
+	Removed map[string]interface{} `json:"removed"`
+	Changed map[string]FieldChange `json:"changed"`
+}
+
+// IsEmpty reports whether the two documents were identical
+func (d SpecDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffSpecJSON compares two spec_json documents at the top level and one level deep
+func DiffSpecJSON(from, to map[string]interface{}) SpecDiff {
+	d := SpecDiff{
+		Added:   map[string]interface{}{},
+		Removed: map[string]interface{}{},
+		Changed: map[string]FieldChange{},
+	}
+	diffLevel(d, "", from, to, 1)
+	return d
+}
+
+func diffLevel(d SpecDiff, prefix string, from, to map[string]interface{}, depth int) {
+	for k, oldVal := range from {
+		key := prefix + k
+		newVal, ok := to[k]
+		if !ok {
+			d.Removed[key] = oldVal
+			continue
+		}
+		if reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		oldMap, oldIsMap := oldVal.(map[string]interface{})
+		newMap, newIsMap := newVal.(map[string]interface{})
+		if depth > 0 && oldIsMap && newIsMap {
+			diffLevel(d, key+".", oldMap, newMap, depth-1)
+			continue
+		}
+		d.Changed[key] = FieldChange{From: oldVal, To: newVal}
+	}
+	for k, newVal := range to {
+		if _, ok := from[k]; !ok {
+			d.Added[prefix+k] = newVal
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package specschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// doc parses a spec_json document the way it comes out of the database
+func doc(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDiffSpecJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    string
+		wantAdded   map[string]interface{}
+		wantRemoved map[string]interface{}
+		wantChanged map[string]FieldChange
+	}{
+		{
+			name: "identical",
+			from: `{"title":"Cat","controls":{"keyboard":"arrows"},"levels":[1,2]}`,
+			to:   `{"title":"Cat","controls":{"keyboard":"arrows"},"levels":[1,2]}`,
+		},
+		{
+			name:      "added key",
+			from:      `{"title":"Cat"}`,
+			to:        `{"title":"Cat","genre":"puzzle"}`,
+			wantAdded: map[string]interface{}{"genre": "puzzle"},
+		},
+		{
+			name:        "removed key",
+			from:        `{"title":"Cat","duration_sec":300}`,
+			to:          `{"title":"Cat"}`,
+			wantRemoved: map[string]interface{}{"duration_sec": float64(300)},
+		},
+		{
+			name:        "changed value",
+			from:        `{"title":"Cat","duration_sec":300}`,
+			to:          `{"title":"Dog","duration_sec":300}`,
+			wantChanged: map[string]FieldChange{"title": {From: "Cat", To: "Dog"}},
+		},
+		{
+			name:        "changed type",
+			from:        `{"levels":3}`,
+			to:          `{"levels":[1,2,3]}`,
+			wantChanged: map[string]FieldChange{"levels": {From: float64(3), To: []interface{}{float64(1), float64(2), float64(3)}}},
+		},
+		{
+			name:        "changed array",
+			from:        `{"levels":[1,2]}`,
+			to:          `{"levels":[1,2,3]}`,
+			wantChanged: map[string]FieldChange{"levels": {From: []interface{}{float64(1), float64(2)}, To: []interface{}{float64(1), float64(2), float64(3)}}},
+		},
+		{
+			name:        "nested keys",
+			from:        `{"controls":{"keyboard":"arrows","mouse":true}}`,
+			to:          `{"controls":{"keyboard":"wasd","touch":true}}`,
+			wantAdded:   map[string]interface{}{"controls.touch": true},
+			wantRemoved: map[string]interface{}{"controls.mouse": true},
+			wantChanged: map[string]FieldChange{"controls.keyboard": {From: "arrows", To: "wasd"}},
+		},
+		{
+			name: "nested object replaced by a scalar",
+			from: `{"controls":{"keyboard":"arrows"}}`,
+			to:   `{"controls":"none"}`,
+			wantChanged: map[string]FieldChange{"controls": {
+				From: map[string]interface{}{"keyboard": "arrows"},
+				To:   "none",
+			}},
+		},
+		{
+			name: "deeper levels are compared whole",
+			from: `{"art":{"palette":{"primary":"red"}}}`,
+			to:   `{"art":{"palette":{"primary":"blue"}}}`,
+			wantChanged: map[string]FieldChange{"art.palette": {
+				From: map[string]interface{}{"primary": "red"},
+				To:   map[string]interface{}{"primary": "blue"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DiffSpecJSON(doc(t, tt.from), doc(t, tt.to))
+			want := SpecDiff{Added: tt.wantAdded, Removed: tt.wantRemoved, Changed: tt.wantChanged}
+			if want.Added == nil {
+				want.Added = map[string]interface{}{}
+			}
+			if want.Removed == nil {
+				want.Removed = map[string]interface{}{}
+			}
+			if want.Changed == nil {
+				want.Changed = map[string]FieldChange{}
+			}
+			if !reflect.DeepEqual(d, want) {
+				t.Errorf("got %+v, want %+v", d, want)
+			}
+			if d.IsEmpty() != (tt.name == "identical") {
+				t.Errorf("IsEmpty() = %v", d.IsEmpty())
+			}
+		})
+	}
+}
+
+func TestDiffSpecJSONNil(t *testing.T) {
+	d := DiffSpecJSON(nil, map[string]interface{}{"title": "Cat"})
+	if !reflect.DeepEqual(d.Added, map[string]interface{}{"title": "Cat"}) || len(d.Removed) != 0 || len(d.Changed) != 0 {
+		t.Errorf("got %+v", d)
+	}
+	if d := DiffSpecJSON(nil, nil); !d.IsEmpty() {
+		t.Errorf("got %+v, want empty", d)
+	}
+}
@@ -0,0 +1,41 @@
+package specschema
+
+import "strings"
+
+// markdownSections are headings a thorough spec is expected to cover
+var markdownSections = []string{"overview", "mechanic", "control", "mode", "scor", "win", "ui", "technical"}
+
+// QualityScore rates a spec from 0 to 100. 60 points come from spec_json, losing 6 per
+// validation error, and 40 from the markdown: its length and how many expected sections
+// it covers.
+func QualityScore(specJSON map[string]interface{}, markdown string) int {
+	jsonScore := 60 - 6*len(Validate(specJSON))
+	if jsonScore < 0 {
+		jsonScore = 0
+	}
+
+	// Up to 15 points for length, full marks from 3000 characters
+	lengthScore := len(strings.TrimSpace(markdown)) * 15 / 3000
+	if lengthScore > 15 {
+		lengthScore = 15
+	}
+
+	var headings []string
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			headings = append(headings, strings.ToLower(line))
+		}
+	}
+	covered := 0
+	for _, section := range markdownSections {
+		for _, h := range headings {
+			if strings.Contains(h, section) {
+				covered++
+				break
+			}
+		}
+	}
+	sectionScore := covered * 25 / len(markdownSections)
+
+	return jsonScore + lengthScore + sectionScore
+}
@@ -0,0 +1,56 @@
+package specschema
+
+import (
+	"bytes"
+	"html"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+)
+
+const highlightStyle = "github"
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle(highlightStyle),
+			// Classes rather than inline styles so the sanitizer can keep them
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+)
+
+// sanitizer keeps user generated markdown output plus the classes used for highlighting
+var sanitizer = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").OnElements("pre", "code", "span")
+	return p
+}()
+
+// RenderMarkdownHTML renders spec markdown as a standalone, sanitized HTML page
+func RenderMarkdownHTML(title, md string) ([]byte, error) {
+	var body bytes.Buffer
+	if err := markdown.Convert([]byte(md), &body); err != nil {
+		return nil, err
+	}
+
+	var css bytes.Buffer
+	if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&css, styles.Get(highlightStyle)); err != nil {
+		return nil, err
+	}
+
+	var page bytes.Buffer
+	page.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>")
+	page.WriteString(html.EscapeString(title))
+	page.WriteString("</title>\n<style>\n")
+	page.Write(css.Bytes())
+	page.WriteString("</style>\n</head>\n<body>\n")
+	page.Write(sanitizer.SanitizeBytes(body.Bytes()))
+	page.WriteString("</body>\n</html>\n")
+	return page.Bytes(), nil
+}
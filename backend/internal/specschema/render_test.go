@@ -0,0 +1,109 @@
+package specschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownHTMLStripsXSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      string
+		banned  []string
+		wantHas string
+	}{
+		{
+			name:    "script tag",
+			md:      "# Cat\n\n<script>alert(1)</script>\n\nhello",
+			banned:  []string{"<script", "alert(1)"},
+			wantHas: "hello",
+		},
+		{
+			name:   "event handler",
+			md:     `<img src="x.png" onerror="alert(1)">`,
+			banned: []string{"onerror"},
+		},
+		{
+			name:    "markdown image",
+			md:      `![cat](x.png "a\" onerror=\"alert(1)")`,
+			banned:  []string{"onerror="},
+			wantHas: `<img src="x.png"`,
+		},
+		{
+			name:    "javascript link",
+			md:      "[click](javascript:alert(1))",
+			banned:  []string{"javascript:"},
+			wantHas: "click",
+		},
+		{
+			name:   "javascript link in raw html",
+			md:     `<a href="javascript:alert(1)">click</a>`,
+			banned: []string{"javascript:"},
+		},
+		{
+			name:   "iframe",
+			md:     `<iframe src="https://evil.example"></iframe>`,
+			banned: []string{"<iframe", "evil.example"},
+		},
+		{
+			name:   "inline style",
+			md:     `<p style="background:url(javascript:alert(1))">styled</p>`,
+			banned: []string{"style=", "javascript:"},
+		},
+		{
+			name:   "svg payload",
+			md:     `<svg onload="alert(1)"><circle r="1"/></svg>`,
+			banned: []string{"<svg", "onload"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := RenderMarkdownHTML("Cat", tt.md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := bodyOf(t, string(page))
+			for _, b := range tt.banned {
+				if strings.Contains(body, b) {
+					t.Errorf("output contains %q:\n%s", b, body)
+				}
+			}
+			if tt.wantHas != "" && !strings.Contains(body, tt.wantHas) {
+				t.Errorf("output lost %q:\n%s", tt.wantHas, body)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownHTML(t *testing.T) {
+	md := "# Yarn Cat\n\n| key | action |\n|---|---|\n| space | jump |\n\n```go\nfunc main() {}\n```\n"
+	page, err := RenderMarkdownHTML(`<Cat> & "Yarn"`, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(page)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>&lt;Cat&gt; &amp; &#34;Yarn&#34;</title>",
+		"<h1",
+		"Yarn Cat</h1>",
+		"<table>",
+		`<pre class="chroma">`,
+		`<span class="kd">func</span>`,
+		".chroma",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("page lacks %q:\n%s", want, s)
+		}
+	}
+}
+
+// bodyOf returns the part of page after <body>, leaving out the generated stylesheet
+func bodyOf(t *testing.T, page string) string {
+	t.Helper()
+	i := strings.Index(page, "<body>")
+	if i < 0 {
+		t.Fatalf("no <body> in %s", page)
+	}
+	return page[i:]
+}
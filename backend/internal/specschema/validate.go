@@ -0,0 +1,121 @@
+package specschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a spec_json field that doesn't match the spec format
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// requiredStrings are the top-level text fields every spec must fill
+var requiredStrings = []string{"title", "genre", "description"}
+
+// requiredLists are the top-level fields that must be non-empty lists of strings
+var requiredLists = []string{"platform", "controls", "mechanics"}
+
+// Validate checks a spec_json document against the format the spec prompt asks the LLM for
+func Validate(specJSON map[string]interface{}) []ValidationError {
+	errs := []ValidationError{}
+	if specJSON == nil {
+		return append(errs, ValidationError{Field: "spec_json", Message: "is required"})
+	}
+
+	for _, field := range requiredStrings {
+		if msg := checkString(specJSON[field]); msg != "" {
+			errs = append(errs, ValidationError{Field: field, Message: msg})
+		}
+	}
+
+	switch d := specJSON["duration_sec"].(type) {
+	case nil:
+		errs = append(errs, ValidationError{Field: "duration_sec", Message: "is required"})
+	case float64:
+		if d <= 0 {
+			errs = append(errs, ValidationError{Field: "duration_sec", Message: "must be positive"})
+		}
+	default:
+		errs = append(errs, ValidationError{Field: "duration_sec", Message: "must be a number"})
+	}
+
+	for _, field := range requiredLists {
+		errs = append(errs, checkStringList(specJSON[field], field)...)
+	}
+
+	errs = append(errs, checkGameModes(specJSON["game_modes"])...)
+
+	scoring, ok := specJSON["scoring"].(map[string]interface{})
+	switch {
+	case specJSON["scoring"] == nil:
+		errs = append(errs, ValidationError{Field: "scoring", Message: "is required"})
+	case !ok:
+		errs = append(errs, ValidationError{Field: "scoring", Message: "must be an object"})
+	default:
+		if msg := checkString(scoring["win_condition"]); msg != "" {
+			errs = append(errs, ValidationError{Field: "scoring.win_condition", Message: msg})
+		}
+	}
+	return errs
+}
+
+func checkString(v interface{}) string {
+	s, ok := v.(string)
+	switch {
+	case v == nil:
+		return "is required"
+	case !ok:
+		return "must be a string"
+	case strings.TrimSpace(s) == "":
+		return "must not be empty"
+	}
+	return ""
+}
+
+func checkStringList(v interface{}, field string) []ValidationError {
+	list, ok := v.([]interface{})
+	switch {
+	case v == nil:
+		return []ValidationError{{Field: field, Message: "is required"}}
+	case !ok:
+		return []ValidationError{{Field: field, Message: "must be a list"}}
+	case len(list) == 0:
+		return []ValidationError{{Field: field, Message: "must not be empty"}}
+	}
+
+	var errs []ValidationError
+	for i, item := range list {
+		if s, ok := item.(string); !ok || strings.TrimSpace(s) == "" {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("%s[%d]", field, i), Message: "must be a non-empty string"})
+		}
+	}
+	return errs
+}
+
+func checkGameModes(v interface{}) []ValidationError {
+	modes, ok := v.([]interface{})
+	switch {
+	case v == nil:
+		return []ValidationError{{Field: "game_modes", Message: "is required"}}
+	case !ok:
+		return []ValidationError{{Field: "game_modes", Message: "must be a list"}}
+	case len(modes) == 0:
+		return []ValidationError{{Field: "game_modes", Message: "must not be empty"}}
+	}
+
+	var errs []ValidationError
+	for i, m := range modes {
+		field := fmt.Sprintf("game_modes[%d]", i)
+		mode, ok := m.(map[string]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{Field: field, Message: "must be an object"})
+			continue
+		}
+		if msg := checkString(mode["mode"]); msg != "" {
+			errs = append(errs, ValidationError{Field: field + ".mode", Message: msg})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,122 @@
+package specschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validSpec = `{
+	"title": "Yarn Cat",
+	"genre": "platformer",
+	"description": "A cat collects yarn",
+	"duration_sec": 300,
+	"platform": ["web"],
+	"controls": ["arrow keys", "spacebar"],
+	"mechanics": ["jump", "collect yarn"],
+	"game_modes": [{"mode": "story"}],
+	"scoring": {"win_condition": "collect 10 balls of yarn"}
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []ValidationError
+	}{
+		{name: "valid", spec: validSpec, want: []ValidationError{}},
+		{
+			name: "partial",
+			spec: `{
+				"title": "Yarn Cat",
+				"genre": "",
+				"description": "A cat collects yarn",
+				"duration_sec": "5 minutes",
+				"platform": ["web"],
+				"controls": ["arrow keys", ""],
+				"mechanics": [],
+				"game_modes": [{"mode": "story"}, {"name": "endless"}, "versus"],
+				"scoring": {}
+			}`,
+			want: []ValidationError{
+				{Field: "genre", Message: "must not be empty"},
+				{Field: "duration_sec", Message: "must be a number"},
+				{Field: "controls[1]", Message: "must be a non-empty string"},
+				{Field: "mechanics", Message: "must not be empty"},
+				{Field: "game_modes[1].mode", Message: "is required"},
+				{Field: "game_modes[2]", Message: "must be an object"},
+				{Field: "scoring.win_condition", Message: "is required"},
+			},
+		},
+		{
+			name: "wrong types",
+			spec: `{
+				"title": 1, "genre": "puzzle", "description": "d", "duration_sec": -5,
+				"platform": "web", "controls": ["tap"], "mechanics": ["match"],
+				"game_modes": {"mode": "story"}, "scoring": "most points"
+			}`,
+			want: []ValidationError{
+				{Field: "title", Message: "must be a string"},
+				{Field: "duration_sec", Message: "must be positive"},
+				{Field: "platform", Message: "must be a list"},
+				{Field: "game_modes", Message: "must be a list"},
+				{Field: "scoring", Message: "must be an object"},
+			},
+		},
+		{
+			name: "empty document",
+			spec: `{}`,
+			want: []ValidationError{
+				{Field: "title", Message: "is required"},
+				{Field: "genre", Message: "is required"},
+				{Field: "description", Message: "is required"},
+				{Field: "duration_sec", Message: "is required"},
+				{Field: "platform", Message: "is required"},
+				{Field: "controls", Message: "is required"},
+				{Field: "mechanics", Message: "is required"},
+				{Field: "game_modes", Message: "is required"},
+				{Field: "scoring", Message: "is required"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Validate(doc(t, tt.spec))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Validate(nil); !reflect.DeepEqual(got, []ValidationError{{Field: "spec_json", Message: "is required"}}) {
+		t.Errorf("Validate(nil) = %+v", got)
+	}
+}
+
+func TestQualityScore(t *testing.T) {
+	sections := "# Overview\n## Mechanics\n## Controls\n## Game modes\n## Scoring\n## Win condition\n## UI\n## Technical notes\n"
+	full := sections + strings.Repeat("x", 3000)
+
+	tests := []struct {
+		name     string
+		spec     map[string]interface{}
+		markdown string
+		want     int
+	}{
+		{name: "complete", spec: doc(t, validSpec), markdown: full, want: 100},
+		{name: "valid json without markdown", spec: doc(t, validSpec), want: 60},
+		{name: "half the sections", spec: doc(t, validSpec), markdown: "# Overview\n# Mechanics\n# Controls\n# Modes", want: 60 + 0 + 12},
+		{name: "length is capped", spec: doc(t, validSpec), markdown: strings.Repeat("x", 9000), want: 75},
+		{name: "each validation error costs 6", spec: doc(t, `{"title":"Yarn Cat","genre":"puzzle","description":"d","duration_sec":60,"platform":["web"],"controls":["tap"],"mechanics":["match"]}`), want: 48},
+		{name: "missing spec_json", spec: nil, want: 54},
+		{name: "json score floors at zero", spec: doc(t, `{"controls":["","","","","","",""]}`), markdown: full, want: 40},
+		{name: "headings only count as sections", spec: doc(t, validSpec), markdown: "overview mechanics controls", want: 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QualityScore(tt.spec, tt.markdown); got != tt.want {
+				t.Errorf("QualityScore = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider recording every ended span for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return rec
+}
+
+func TestStartEnd(t *testing.T) {
+	rec := recordSpans(t)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, ok := Start(ctx, "ok")
+	End(ok, nil)
+	_, failed := Start(ctx, "failed")
+	End(failed, errors.New("boom"))
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("%d spans ended, want 3", len(spans))
+	}
+	for _, s := range spans[:2] {
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of parent", s.Name())
+		}
+	}
+	if st := spans[0].Status(); st.Code != codes.Unset {
+		t.Errorf("ok span status = %+v", st)
+	}
+	if st := spans[1].Status(); st.Code != codes.Error || st.Description != "boom" {
+		t.Errorf("failed span status = %+v", st)
+	}
+	if events := spans[1].Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("failed span events = %+v, want the recorded error", events)
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	rec := recordSpans(t)
+
+	ctx, span := Start(context.Background(), "request")
+	traceparent := Inject(ctx)
+	span.End()
+	if traceparent == "" {
+		t.Fatal("Inject returned no traceparent")
+	}
+
+	// A consumer on another goroutine continues the trace from the carried traceparent
+	resumed := Extract(context.Background(), traceparent)
+	_, child := Start(resumed, "consumer")
+	child.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+	if spans[1].SpanContext().TraceID() != span.SpanContext().TraceID() || spans[1].Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("consumer span does not continue the request trace")
+	}
+
+	if ctx := Extract(context.Background(), ""); trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("Extract of an empty traceparent returned a span context")
+	}
+	if got := Inject(context.Background()); got != "" {
+		t.Errorf("Inject without a span = %q, want empty", got)
+	}
+}
+
+func TestInitWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	prev := otel.GetTracerProvider()
+	shutdown, err := Init(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if otel.GetTracerProvider() != prev {
+		t.Error("Init replaced the tracer provider without an endpoint")
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"backend/internal/config"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Commit actions
+const (
+	CommitActionGenerated = "generated"
+	CommitActionRemoved   = "removed"
+)
+
+const defaultCommitMessageTemplate = `{{if eq .Action "removed"}}Removed game folder for deleted spec{{else}}Generated game{{end}}: {{.Title}} (ID: {{.GameID}})`
+
+var defaultCommitTemplate = template.Must(template.New("commit message").Parse(defaultCommitMessageTemplate))
+
+// CommitInfo is what GIT_COMMIT_MESSAGE_TEMPLATE is rendered with. JobID and Genre are empty
+// when the commit isn't made by a code job or the spec has no genre.
+type CommitInfo struct {
+	Title  string
+	GameID string
+	JobID  string
+	Genre  string
+	// Date is the commit day as YYYY-MM-DD
+	Date string
+	// Action is CommitActionGenerated or CommitActionRemoved
+	Action string
+}
+
+// commitMessageTemplate parses GIT_COMMIT_MESSAGE_TEMPLATE, or the default when it is unset. A
+// legacy fmt template such as "Generated game: %s (ID: %s)" gets the title and id in order.
+func commitMessageTemplate() (*template.Template, error) {
+	text := config.GetString("GIT_COMMIT_MESSAGE_TEMPLATE", "")
+	if text == "" {
+		text = defaultCommitMessageTemplate
+	} else if !strings.Contains(text, "{{") {
+		text = strings.Replace(text, "%s", "{{.Title}}", 1)
+		text = strings.Replace(text, "%s", "{{.GameID}}", 1)
+	}
+	tmpl, err := template.New("commit message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Unknown fields only fail on execution, so render sample data once
+	if err := tmpl.Execute(new(strings.Builder), CommitInfo{Action: CommitActionGenerated}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// ValidateCommitMessageTemplate checks that GIT_COMMIT_MESSAGE_TEMPLATE renders, called once at startup
+func ValidateCommitMessageTemplate() error {
+	_, err := commitMessageTemplate()
+	return err
+}
+
+// commitMessage renders the commit message of a change, falling back to the default template
+// when the configured one can't be used
+func commitMessage(info CommitInfo) string {
+	if info.Date == "" {
+		info.Date = time.Now().Format("2006-01-02")
+	}
+	var out strings.Builder
+	tmpl, err := commitMessageTemplate()
+	if err == nil {
+		err = tmpl.Execute(&out, info)
+	}
+	if err != nil {
+		log.Printf("[WARNING] Invalid GIT_COMMIT_MESSAGE_TEMPLATE, using the default: %v", err)
+		out.Reset()
+		_ = defaultCommitTemplate.Execute(&out, info)
+	}
+	return out.String()
+}
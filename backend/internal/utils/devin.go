@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"backend/internal/config"
+	"backend/internal/devin"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DevinStatusWorking is recorded when a session is created, before Devin reports anything
+const DevinStatusWorking = "working"
+
+// DevinSession is a session created by the Devin API
+type DevinSession struct {
+	ID  string
+	URL string
+}
+
+// DevinSessionURL returns the stored URL of a session. Sessions stored before the URL was
+// recorded fall back to the app URL built from the id.
+func DevinSessionURL(sessionID string, storedURL *string) string {
+	if storedURL != nil && *storedURL != "" {
+		return *storedURL
+	}
+	return fmt.Sprintf("https://app.devin.ai/sessions/%s", sessionID)
+}
+
+// ValidateDevinConfig checks DEVIN_EXTRA_HEADERS and the Devin task template so a malformed value fails at startup
+// instead of on the first Devin call
+func ValidateDevinConfig() error {
+	if _, err := devinExtraHeaders(); err != nil {
+		return err
+	}
+	_, err := devin.ConfiguredTemplate()
+	return err
+}
+
+// devinExtraHeaders parses DEVIN_EXTRA_HEADERS, a comma-separated list of Key:Value pairs
+// sent with every Devin API request (e.g. an organization id for enterprise tenants)
+func devinExtraHeaders() (http.Header, error) {
+	headers := http.Header{}
+	for _, pair := range strings.Split(config.GetString("DEVIN_EXTRA_HEADERS", ""), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid DEVIN_EXTRA_HEADERS entry %q, expected Key:Value", pair)
+		}
+		headers.Add(key, value)
+	}
+	return headers, nil
+}
+
+// setDevinHeaders sets authentication and the configured extra headers on a Devin API request
+func setDevinHeaders(req *http.Request, apiKey string) error {
+	extra, err := devinExtraHeaders()
+	if err != nil {
+		return err
+	}
+	for key, values := range extra {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	return nil
+}
+
+// IsTerminalDevinStatus reports whether a Devin session with this status will not do more work
+func IsTerminalDevinStatus(status string) bool {
+	switch strings.ToLower(status) {
+	case "finished", "expired", "stopped", "failed", "cancelled":
+		return true
+	}
+	return false
+}
+
+// GetDevinSessionStatus fetches the current status of a Devin session
+func GetDevinSessionStatus(sessionID string) (string, error) {
+	apiKey := config.GetString("DEVIN_API_KEY", "")
+	if apiKey == "" {
+		return "", fmt.Errorf("DEVIN_API_KEY environment variable is required")
+	}
+	baseURL := strings.TrimSuffix(config.GetString("DEVIN_SESSION_API_URL", "https://api.devin.ai/v1/session"), "/")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/devin-%s", baseURL, sessionID), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := setDevinHeaders(req, apiKey); err != nil {
+		return "", err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Devin API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var session struct {
+		Status     string `json:"status"`
+		StatusEnum string `json:"status_enum"`
+	}
+	if err := json.Unmarshal(body, &session); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if session.StatusEnum != "" {
+		return session.StatusEnum, nil
+	}
+	return session.Status, nil
+}
+
+// DevinAPIError is returned when the Devin API answers with an unsuccessful status
+type DevinAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *DevinAPIError) Error() string {
+	return fmt.Sprintf("Devin API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+const maxDevinRetryDelay = 30 * time.Second
+
+// postDevinSession creates a Devin session, retrying up to DEVIN_MAX_ATTEMPTS times (default 4).
+// 429 responses wait for Retry-After; 5xx and network errors back off exponentially from
+// DEVIN_RETRY_BASE_DELAY (default 1s). Other 4xx responses fail immediately.
+func postDevinSession(apiURL, apiKey string, payload []byte) ([]byte, error) {
+	attempts := config.MustGetInt("DEVIN_MAX_ATTEMPTS", 4)
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := config.MustGetDuration("DEVIN_RETRY_BASE_DELAY", time.Second)
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay = min(delay*2, maxDevinRetryDelay)
+		}
+
+		req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if err := setDevinHeaders(req, apiKey); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to make request: %w", err)
+			log.Printf("[WARNING] Devin API attempt %d/%d failed: %v", attempt, attempts, err)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		log.Printf("Devin API Response Status: %d", resp.StatusCode)
+		log.Printf("Devin API Response Body: %s", string(body))
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			return body, nil
+		}
+		lastErr = &DevinAPIError{StatusCode: resp.StatusCode, Body: string(body)}
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(wait, maxDevinRetryDelay)
+			}
+		case resp.StatusCode >= 500:
+		default:
+			return nil, lastErr
+		}
+		log.Printf("[WARNING] Devin API attempt %d/%d returned status %d", attempt, attempts, resp.StatusCode)
+	}
+	return nil, lastErr
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
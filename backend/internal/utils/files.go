@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"backend/internal/config"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GeneratedFile is a file produced by the generation pipeline, relative to the game folder
+type GeneratedFile struct {
+	Path     string
+	Content  []byte
+	FileType string
+}
+
+// GeneratedCodeSubdir returns GENERATED_CODE_SUBDIR as a clean relative path (e.g. "src"), or ""
+// when generated code is written flat into the game folder next to its README.md
+func GeneratedCodeSubdir() string {
+	dir := filepath.ToSlash(filepath.Clean("/" + config.GetString("GENERATED_CODE_SUBDIR", "")))
+	return strings.Trim(dir, "/")
+}
+
+// GeneratedCodeDir returns the folder of gamePath that generated code is written to
+func GeneratedCodeDir(gamePath string) string {
+	return filepath.Join(gamePath, filepath.FromSlash(GeneratedCodeSubdir()))
+}
+
+// allowedFileTypes are the file types the pipeline may write and the mode each is written with.
+// A web game needs nothing executable, so no type gets an exec bit.
+var allowedFileTypes = map[string]os.FileMode{
+	"html": 0644, "css": 0644, "js": 0644, "json": 0644, "md": 0644, "txt": 0644,
+	"svg": 0644, "png": 0644, "jpg": 0644, "jpeg": 0644, "gif": 0644, "webp": 0644, "ico": 0644,
+	"mp3": 0644, "ogg": 0644, "wav": 0644, "woff": 0644, "woff2": 0644,
+}
+
+// SkippedFile is a generated file that was not written, and why
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// checkFileType returns why f may not be written, or "" when its type is allowed and matches its extension
+func checkFileType(f GeneratedFile) string {
+	fileType := strings.ToLower(f.FileType)
+	if _, ok := allowedFileTypes[fileType]; !ok {
+		return fmt.Sprintf("file type %q is not allowed", f.FileType)
+	}
+	if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(f.Path), ".")); ext != fileType {
+		return fmt.Sprintf("extension %q does not match file type %q", ext, f.FileType)
+	}
+	return ""
+}
+
+// FilterGeneratedFiles splits files into those WriteGeneratedFiles accepts and those it would reject
+func FilterGeneratedFiles(files []GeneratedFile) ([]GeneratedFile, []SkippedFile) {
+	var kept []GeneratedFile
+	var skipped []SkippedFile
+	for _, f := range files {
+		if reason := checkFileType(f); reason != "" {
+			skipped = append(skipped, SkippedFile{Path: f.Path, Reason: reason})
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, skipped
+}
+
+// WriteGeneratedFiles writes files under dir, creating subfolders as needed. Files of a type
+// outside the allowlist are refused; callers drop them first with FilterGeneratedFiles.
+func WriteGeneratedFiles(dir string, files []GeneratedFile) error {
+	for _, f := range files {
+		if reason := checkFileType(f); reason != "" {
+			return fmt.Errorf("refusing to write %s: %s", f.Path, reason)
+		}
+		target := filepath.Join(dir, filepath.Clean("/"+f.Path))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("refusing to write %s outside of %s", f.Path, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create folder for %s: %v", f.Path, err)
+		}
+		if err := os.WriteFile(target, f.Content, allowedFileTypes[strings.ToLower(f.FileType)]); err != nil {
+			return fmt.Errorf("failed to write %s: %v", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// FileInfo describes a file of a generated game, relative to the game folder
+type FileInfo struct {
+	Path      string    `json:"path"`
+	SizeBytes int64     `json:"size_bytes"`
+	FileType  string    `json:"file_type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListFiles walks dir recursively, skipping .git, and returns its files sorted by path
+func ListFiles(dir string) ([]FileInfo, error) {
+	files := []FileInfo{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, FileInfo{
+			Path:      filepath.ToSlash(rel),
+			SizeBytes: info.Size(),
+			FileType:  strings.TrimPrefix(filepath.Ext(rel), "."),
+			CreatedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
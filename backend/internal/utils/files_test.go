@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates files under dir, keyed by slash-separated path
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"index.html":          "<html></html>",
+		"js/game.js":          "start()",
+		"assets/img/cat.png":  "png",
+		"README.md":           "# Cat",
+		"LICENSE":             "MIT",
+		".git/HEAD":           "ref: refs/heads/main",
+		".git/objects/ab/cd":  "blob",
+		"assets/.gitkeep":     "",
+		"js/vendor/.git/HEAD": "nested repo",
+	})
+
+	files, err := ListFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		Path      string
+		SizeBytes int64
+		FileType  string
+	}
+	var got []entry
+	for _, f := range files {
+		got = append(got, entry{f.Path, f.SizeBytes, f.FileType})
+		if f.CreatedAt.IsZero() {
+			t.Errorf("%s: created_at is zero", f.Path)
+		}
+	}
+	want := []entry{
+		{"LICENSE", 3, ""},
+		{"README.md", 5, "md"},
+		{"assets/.gitkeep", 0, "gitkeep"},
+		{"assets/img/cat.png", 3, "png"},
+		{"index.html", 13, "html"},
+		{"js/game.js", 7, "js"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListFiles =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestListFilesEmpty(t *testing.T) {
+	files, err := ListFiles(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files == nil || len(files) != 0 {
+		t.Errorf("ListFiles of an empty folder = %#v, want an empty slice", files)
+	}
+}
+
+func TestListFilesMissingDir(t *testing.T) {
+	if _, err := ListFiles(filepath.Join(t.TempDir(), "gone")); err == nil {
+		t.Error("ListFiles of a missing folder returned no error")
+	}
+}
+
+func TestGeneratedCodeSubdir(t *testing.T) {
+	for _, tt := range []struct{ env, want string }{
+		{"", ""},
+		{"src", "src"},
+		{"/src/", "src"},
+		{"code/game", "code/game"},
+		{"../../etc", "etc"},
+		{".", ""},
+	} {
+		t.Setenv("GENERATED_CODE_SUBDIR", tt.env)
+		if got := GeneratedCodeSubdir(); got != tt.want {
+			t.Errorf("GENERATED_CODE_SUBDIR=%q: GeneratedCodeSubdir() = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratedReadmeKeepsSpecReadme(t *testing.T) {
+	generated := []GeneratedFile{
+		{Path: "README.md", Content: []byte("# Written by the LLM"), FileType: "md"},
+		{Path: "index.html", Content: []byte("<html></html>"), FileType: "html"},
+	}
+	for _, tt := range []struct {
+		subdir, wantReadme string
+	}{
+		{"src", "spec"},
+		// Unset keeps the flat layout, where the generated README wins
+		{"", "llm"},
+	} {
+		t.Run("subdir="+tt.subdir, func(t *testing.T) {
+			t.Setenv("GENERATED_CODE_SUBDIR", tt.subdir)
+			gamePath := filepath.Join(t.TempDir(), "game-1")
+			if _, err := writeGameFolder(gamePath, "game-1", "Yarn Cat", map[string]interface{}{"genre": "platformer"}); err != nil {
+				t.Fatal(err)
+			}
+			specReadme, err := os.ReadFile(filepath.Join(gamePath, "README.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := WriteGeneratedFiles(GeneratedCodeDir(gamePath), generated); err != nil {
+				t.Fatal(err)
+			}
+			readme, err := os.ReadFile(filepath.Join(gamePath, "README.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := specReadme
+			if tt.wantReadme == "llm" {
+				want = generated[0].Content
+			}
+			if string(readme) != string(want) {
+				t.Errorf("README.md = %q, want %q", readme, want)
+			}
+			if _, err := os.Stat(filepath.Join(GeneratedCodeDir(gamePath), "index.html")); err != nil {
+				t.Errorf("index.html not written under %q: %v", tt.subdir, err)
+			}
+		})
+	}
+	t.Run("nested README", func(t *testing.T) {
+		t.Setenv("GENERATED_CODE_SUBDIR", "src")
+		gamePath := t.TempDir()
+		if err := WriteGeneratedFiles(GeneratedCodeDir(gamePath), generated); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(gamePath, "src", "README.md"))
+		if err != nil || string(b) != "# Written by the LLM" {
+			t.Errorf("src/README.md = %q, %v", b, err)
+		}
+	})
+}
@@ -1,38 +1,97 @@
 package utils
 
 import (
-	"bytes"
+	"backend/internal/config"
+	"backend/internal/devin"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
+	"sync"
 )
 
+// repoMu serializes git operations on the shared repository so a commit never picks up
+// another game's half-written changes
+var repoMu sync.Mutex
+
 type GitRepo struct {
 	RepoPath string
 	RepoURL  string
 	Username string
 	Token    string
 	AutoPush bool
+	// PerGame gives every game its own repository under Org instead of a folder in RepoURL.
+	// RepoPath then holds the local clones of those repositories.
+	PerGame bool
+	Org     string
+	// Identity of generated commits; see commitArgs for the defaults
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
 }
 
 func NewGitRepo() *GitRepo {
 	return &GitRepo{
-		RepoPath: os.Getenv("GIT_REPO_PATH"),
-		RepoURL:  os.Getenv("GIT_REPO_URL"),
-		Username: os.Getenv("GIT_USERNAME"),
-		Token:    os.Getenv("GIT_TOKEN"),
+		RepoPath: config.GetString("GIT_REPO_PATH", ""),
+		RepoURL:  config.GetString("GIT_REPO_URL", ""),
+		Username: config.GetString("GIT_USERNAME", ""),
+		Token:    config.GetString("GIT_TOKEN", ""),
+		PerGame:  config.MustGetBool("GIT_REPO_PER_GAME", false),
+		Org:      config.GetString("GITHUB_ORG", ""),
+
+		AuthorName:     config.GetString("GIT_AUTHOR_NAME", ""),
+		AuthorEmail:    config.GetString("GIT_AUTHOR_EMAIL", ""),
+		CommitterName:  config.GetString("GIT_COMMITTER_NAME", ""),
+		CommitterEmail: config.GetString("GIT_COMMITTER_EMAIL", ""),
+	}
+}
+
+// commitArgs returns the git arguments committing paths (everything staged when none are given)
+// with message. The author defaults to Username with its GitHub noreply address and the committer
+// to the author. Both are passed with the commit so no git config is written.
+func (g *GitRepo) commitArgs(message string, paths ...string) []string {
+	authorName := g.AuthorName
+	if authorName == "" {
+		authorName = g.Username
+	}
+	if authorName == "" {
+		authorName = "game-generator"
+	}
+	authorEmail := g.AuthorEmail
+	if authorEmail == "" {
+		login := g.Username
+		if login == "" {
+			login = "game-generator"
+		}
+		authorEmail = fmt.Sprintf("%s@users.noreply.github.com", login)
+	}
+	committerName, committerEmail := g.CommitterName, g.CommitterEmail
+	if committerName == "" {
+		committerName = authorName
+	}
+	if committerEmail == "" {
+		committerEmail = authorEmail
+	}
+
+	args := []string{
+		"-c", "user.name=" + committerName, "-c", "user.email=" + committerEmail,
+		"commit", "-m", message, "--author", fmt.Sprintf("%s <%s>", authorName, authorEmail),
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
 	}
+	return args
 }
 
 func (g *GitRepo) IsConfigured() bool {
+	if g.PerGame {
+		return g.RepoPath != "" && g.Org != "" && g.Token != ""
+	}
 	return g.RepoPath != "" && g.RepoURL != "" && g.Token != ""
 }
 
@@ -104,6 +163,11 @@ func (g *GitRepo) InitializeRepo() error {
 		}
 	}
 
+	// In per-game mode RepoPath only holds the clones, each game repo is set up by CreateGameFolder
+	if g.PerGame {
+		return nil
+	}
+
 	// Check if it's already a git repository
 	gitDir := filepath.Join(g.RepoPath, ".git")
 	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
@@ -146,25 +210,20 @@ func (g *GitRepo) InitializeRepo() error {
 		}
 	}
 
-	// Configure git user if not already set
-	if g.Username != "" {
-		cmd := exec.Command("git", "config", "user.name", g.Username)
-		cmd.Dir = g.RepoPath
-		cmd.Run() // Ignore error
-
-		cmd = exec.Command("git", "config", "user.email", fmt.Sprintf("%s@users.noreply.github.com", g.Username))
-		cmd.Dir = g.RepoPath
-		cmd.Run() // Ignore error
-	}
-
 	return nil
 }
 
-// CreateGameFolder creates a folder using gameID as the folder name with detailed game spec content
+// CreateGameFolder creates the game folder (named by GameFolderName) with detailed game spec content
 func (g *GitRepo) CreateGameFolder(gameID, gameTitle string, gameSpec map[string]interface{}) (string, error) {
-	// Use gameID directly as folder name for better control
-	gamePath := filepath.Join(g.RepoPath, gameID)
+	if g.PerGame {
+		return g.createGameRepo(gameID, gameTitle, gameSpec)
+	}
+
+	return writeGameFolder(filepath.Join(g.RepoPath, GameFolderName(gameID, gameTitle)), gameID, gameTitle, gameSpec)
+}
 
+// writeGameFolder creates gamePath with a README.md describing the game spec
+func writeGameFolder(gamePath, gameID, gameTitle string, gameSpec map[string]interface{}) (string, error) {
 	err := os.MkdirAll(gamePath, 0755)
 	if err != nil {
 		return "", fmt.Errorf("failed to create game folder: %v", err)
@@ -172,37 +231,11 @@ func (g *GitRepo) CreateGameFolder(gameID, gameTitle string, gameSpec map[string
 
 	// Create a comprehensive README.md file with game spec content
 	readmePath := filepath.Join(gamePath, "README.md")
-
-	// Build README content with game spec details
-	var readmeContent strings.Builder
-	readmeContent.WriteString(fmt.Sprintf("# %s\n\n", gameTitle))
-	readmeContent.WriteString(fmt.Sprintf("**Game ID:** %s\n", gameID))
-	readmeContent.WriteString(fmt.Sprintf("**Generated:** %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
-
-	// Add spec_markdown content if available
-	if specMarkdown, ok := gameSpec["spec_markdown"].(string); ok && specMarkdown != "" {
-		readmeContent.WriteString("## Game Specification\n\n")
-		readmeContent.WriteString(specMarkdown)
-		readmeContent.WriteString("\n\n")
-	}
-
-	// Add spec_json content if available
-	if specJSON := gameSpec["spec_json"]; specJSON != nil {
-		readmeContent.WriteString("## Game Configuration (JSON)\n\n")
-		readmeContent.WriteString("```json\n")
-
-		// Convert spec_json to formatted JSON string
-		if jsonBytes, err := json.MarshalIndent(specJSON, "", "  "); err == nil {
-			readmeContent.WriteString(string(jsonBytes))
-		} else {
-			// Fallback to basic string representation
-			readmeContent.WriteString(fmt.Sprintf("%+v", specJSON))
-		}
-
-		readmeContent.WriteString("\n```\n\n")
-	}
-
-	if err := os.WriteFile(readmePath, []byte(readmeContent.String()), 0644); err != nil {
+	readmeContent, err := renderReadme(gameID, gameTitle, gameSpec)
+	if err != nil {
+		// Don't fail if README creation fails, just log it
+		fmt.Printf("Warning: failed to render README.md: %v\n", err)
+	} else if err := os.WriteFile(readmePath, []byte(readmeContent), 0644); err != nil {
 		// Don't fail if README creation fails, just log it
 		fmt.Printf("Warning: failed to create README.md: %v\n", err)
 	}
@@ -210,31 +243,47 @@ func (g *GitRepo) CreateGameFolder(gameID, gameTitle string, gameSpec map[string
 	return gamePath, nil
 }
 
-func (g *GitRepo) CommitAndPush(gamePath, gameTitle, gameID string) error {
+// PreviewGameFolder returns the files writeGameFolder would create, without touching the disk
+func PreviewGameFolder(gameID, gameTitle string, gameSpec map[string]interface{}) ([]GeneratedFile, error) {
+	readmeContent, err := renderReadme(gameID, gameTitle, gameSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render README.md: %v", err)
+	}
+	return []GeneratedFile{{Path: "README.md", Content: []byte(readmeContent), FileType: "md"}}, nil
+}
+
+func (g *GitRepo) CommitAndPush(gamePath string, info CommitInfo) error {
+	info.Action = CommitActionGenerated
+	if g.PerGame {
+		return g.commitAndPushGameRepo(gamePath, info)
+	}
+
+	repoMu.Lock()
+	defer repoMu.Unlock()
+
 	// Pull latest changes before making new commits
 	if err := g.pullFromRemote(); err != nil {
 		return fmt.Errorf("failed to pull latest changes: %v", err)
 	}
 
-	// Add all files in the game folder (using gameID as folder name)
-	cmd := exec.Command("git", "add", gameID)
+	// Add all files in the game folder
+	folderName := filepath.Base(gamePath)
+	cmd := exec.Command("git", "add", "--", folderName)
 	cmd.Dir = g.RepoPath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to add files to git: %v", err)
 	}
 
-	// Create commit message
-	commitTemplate := os.Getenv("GIT_COMMIT_MESSAGE_TEMPLATE")
-	if commitTemplate == "" {
-		commitTemplate = "Generated game: %s (ID: %s)"
-	}
-	commitMessage := fmt.Sprintf(commitTemplate, gameTitle, gameID)
-
-	// Commit changes
-	cmd = exec.Command("git", "commit", "-m", commitMessage)
+	// Commit only the game folder, leaving anything else staged untouched. There is nothing to
+	// commit when an earlier attempt committed the folder but failed to push.
+	cmd = exec.Command("git", "diff", "--cached", "--quiet", "--", folderName)
 	cmd.Dir = g.RepoPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to commit changes: %v", err)
+	if cmd.Run() != nil {
+		cmd = exec.Command("git", g.commitArgs(commitMessage(info), folderName)...)
+		cmd.Dir = g.RepoPath
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to commit changes: %v", err)
+		}
 	}
 
 	// Try to push to main branch first
@@ -257,6 +306,23 @@ func (g *GitRepo) CommitAndPush(gamePath, gameTitle, gameID string) error {
 	return nil
 }
 
+// FindGameFolder returns the path of a game's existing folder, which in per-game mode is the
+// local clone of its repository
+func (g *GitRepo) FindGameFolder(gameID, gameTitle string) (string, bool) {
+	if g.PerGame {
+		clonePath := filepath.Join(g.RepoPath, g.gameRepoName(gameID, gameTitle))
+		if info, err := os.Stat(clonePath); err == nil && info.IsDir() {
+			return clonePath, true
+		}
+		return "", false
+	}
+	folderName, found := findGameFolder(g.RepoPath, gameID, gameTitle)
+	if !found {
+		return "", false
+	}
+	return filepath.Join(g.RepoPath, folderName), true
+}
+
 // RemoveGameFolders removes the folder with the exact gameID
 func (g *GitRepo) RemoveGameFolders(gameID, gameTitle string) error {
 	if !g.IsConfigured() {
@@ -265,6 +331,21 @@ func (g *GitRepo) RemoveGameFolders(gameID, gameTitle string) error {
 
 	log.Printf("[INFO] Starting git folder removal for gameID: %s, title: %s", gameID, gameTitle)
 
+	if !g.PerGame {
+		repoMu.Lock()
+		defer repoMu.Unlock()
+	}
+
+	// Never delete a game's remote repository automatically, only its local clone
+	if g.PerGame {
+		clonePath := filepath.Join(g.RepoPath, g.gameRepoName(gameID, gameTitle))
+		if err := os.RemoveAll(clonePath); err != nil {
+			return fmt.Errorf("failed to remove local clone %s: %v", clonePath, err)
+		}
+		log.Printf("[INFO] Removed local clone %s, remote repository %s is kept", clonePath, g.GameURL(gameID, gameTitle))
+		return nil
+	}
+
 	// Pull latest changes before making deletions
 	if err := g.pullFromRemote(); err != nil {
 		log.Printf("[WARNING] Failed to pull latest changes before deletion: %v", err)
@@ -272,26 +353,25 @@ func (g *GitRepo) RemoveGameFolders(gameID, gameTitle string) error {
 	}
 
 	// Check if the folder exists
-	folderPath := filepath.Join(g.RepoPath, gameID)
-	log.Printf("[INFO] Checking for folder at path: %s", folderPath)
-
-	if _, err := os.Stat(folderPath); os.IsNotExist(err) {
+	folderName, found := findGameFolder(g.RepoPath, gameID, gameTitle)
+	if !found {
 		// Folder doesn't exist, nothing to remove
-		log.Printf("[INFO] Folder %s does not exist, nothing to remove", gameID)
+		log.Printf("[INFO] Folder for %s does not exist, nothing to remove", gameID)
 		return nil
 	}
+	folderPath := filepath.Join(g.RepoPath, folderName)
 
-	log.Printf("[INFO] Found folder %s, proceeding with removal", gameID)
+	log.Printf("[INFO] Found folder %s, proceeding with removal", folderName)
 
 	// Remove the folder
 	if err := os.RemoveAll(folderPath); err != nil {
-		return fmt.Errorf("failed to remove folder %s: %v", gameID, err)
+		return fmt.Errorf("failed to remove folder %s: %v", folderName, err)
 	}
 
-	log.Printf("[INFO] Successfully removed folder from filesystem: %s", gameID)
+	log.Printf("[INFO] Successfully removed folder from filesystem: %s", folderName)
 
-	// Stage the deletion
-	cmd := exec.Command("git", "add", "-A")
+	// Stage the deletion of this folder only, never unrelated changes in the repository
+	cmd := exec.Command("git", "add", "-A", "--", folderName)
 	cmd.Dir = g.RepoPath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to stage deletion: %v", err)
@@ -300,7 +380,7 @@ func (g *GitRepo) RemoveGameFolders(gameID, gameTitle string) error {
 	log.Printf("[INFO] Staged deletion for git commit")
 
 	// Check if there are any changes to commit
-	cmd = exec.Command("git", "diff", "--cached", "--quiet")
+	cmd = exec.Command("git", "diff", "--cached", "--quiet", "--", folderName)
 	cmd.Dir = g.RepoPath
 	if err := cmd.Run(); err == nil {
 		// No changes to commit
@@ -309,15 +389,10 @@ func (g *GitRepo) RemoveGameFolders(gameID, gameTitle string) error {
 	}
 
 	// Commit the deletion
-	commitTemplate := os.Getenv("GIT_COMMIT_MESSAGE_TEMPLATE")
-	if commitTemplate == "" {
-		commitTemplate = "Removed game folder for deleted spec: %s (ID: %s)"
-	}
-	commitMessage := fmt.Sprintf(commitTemplate, gameTitle, gameID)
-
-	log.Printf("[INFO] Committing deletion with message: %s", commitMessage)
+	message := commitMessage(CommitInfo{Title: gameTitle, GameID: gameID, Action: CommitActionRemoved})
+	log.Printf("[INFO] Committing deletion with message: %s", message)
 
-	cmd = exec.Command("git", "commit", "-m", commitMessage)
+	cmd = exec.Command("git", g.commitArgs(message, folderName)...)
 	cmd.Dir = g.RepoPath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to commit folder deletion: %v", err)
@@ -347,116 +422,79 @@ func (g *GitRepo) RemoveGameFolders(gameID, gameTitle string) error {
 	return nil
 }
 
-// CreateDevinTask creates a Devin task for further game development and returns the session ID
-func (g *GitRepo) CreateDevinTask(gameSpecID, gameTitle string) (string, error) {
-	repoURL := strings.TrimSuffix(os.Getenv("GIT_REPO_URL"), ".git")
-	if repoURL == "" {
-		return "", fmt.Errorf("GIT_REPO_URL environment variable not set")
+// CreateDevinTask creates a Devin task for further game development and returns the created session.
+// targetFramework is optional; when empty Devin picks the tech stack.
+func (g *GitRepo) CreateDevinTask(gameSpecID, gameTitle, targetFramework string) (DevinSession, error) {
+	repoURL := strings.TrimSuffix(config.GetString("GIT_REPO_URL", ""), ".git")
+	folder := GameFolderName(gameSpecID, gameTitle)
+	if g.PerGame {
+		repoURL = g.GameURL(gameSpecID, gameTitle)
+		folder = "/ (repository root)"
+	} else if repoURL == "" {
+		return DevinSession{}, fmt.Errorf("GIT_REPO_URL environment variable not set")
 	}
 
-	taskDescription := fmt.Sprintf(`Please work on the game project in folder %s.
-
-This folder contains a README.md file that describes the complete game specification and requirements.
-
-Your tasks:
-1. Navigate to the %s folder in the repository
-2. Read the README.md file to understand the game specification
-3. Implement the complete game based on the specification in the README
-4. Create all necessary HTML, CSS, and JavaScript files for the game
-5. Ensure the game is fully functional and meets all requirements specified in the README
-6. Test the game thoroughly to ensure it works correctly
-7. Create a new branch for your implementation (e.g., implement/game-%s or develop/game-%s)
-8. Commit your implementation to the new branch with descriptive commit messages
-9. Create a pull request to merge your implementation into the main branch
-10. Include screenshots or a demo video in the PR description
-
-Repository: %s
-Game Title: %s
-Game Spec ID: %s
-
-IMPORTANT: Do NOT commit directly to the main branch. Always create a feature branch and submit a pull request for review. The README.md contains the complete specification - implement the game from scratch based on these requirements.`, gameSpecID, gameSpecID, gameSpecID, gameSpecID, repoURL, gameTitle, gameSpecID)
+	tmpl, err := devin.ConfiguredTemplate()
+	if err != nil {
+		return DevinSession{}, err
+	}
+	taskDescription, err := tmpl.Render(devin.TaskData{
+		Folder:          folder,
+		RepoURL:         repoURL,
+		GameTitle:       gameTitle,
+		GameSpecID:      gameSpecID,
+		CodeSubdir:      GeneratedCodeSubdir(),
+		TargetFramework: targetFramework,
+	})
+	if err != nil {
+		return DevinSession{}, err
+	}
 
 	// Create payload for Devin API sessions endpoint
 	payload := map[string]interface{}{
 		"prompt":     taskDescription,
-		"idempotent": true,
+		"idempotent": config.MustGetBool("DEVIN_IDEMPOTENT", true),
 	}
 
 	// Marshal payload
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload: %w", err)
+		return DevinSession{}, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
 	// Get Devin API URL from environment or use default
-	apiURL := os.Getenv("DEVIN_API_URL")
-	if apiURL == "" {
-		apiURL = "https://api.devin.ai/v1/sessions"
-	}
+	apiURL := config.GetString("DEVIN_API_URL", "https://api.devin.ai/v1/sessions")
 
 	// Get API key
-	apiKey := os.Getenv("DEVIN_API_KEY")
+	apiKey := config.GetString("DEVIN_API_KEY", "")
 	if apiKey == "" {
-		return "", fmt.Errorf("DEVIN_API_KEY environment variable is required")
-	}
-
-	// Create HTTP request
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
-
-	// Make request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return DevinSession{}, fmt.Errorf("DEVIN_API_KEY environment variable is required")
 	}
-	defer resp.Body.Close()
 
-	// Read response body for better error reporting
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := postDevinSession(apiURL, apiKey, payloadBytes)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Log the response for debugging
-	log.Printf("Devin API Response Status: %d", resp.StatusCode)
-	log.Printf("Devin API Response Body: %s", string(respBody))
-
-	// Check response status
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return "", fmt.Errorf("Devin API returned status %d: %s", resp.StatusCode, string(respBody))
+		return DevinSession{}, err
 	}
 
 	// Parse response to get session info
 	var sessionResponse map[string]interface{}
 	if err := json.Unmarshal(respBody, &sessionResponse); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	// Extract session ID from response
-	_, ok := sessionResponse["session_id"]
-	if !ok {
-		return "", fmt.Errorf("session_id not found in response")
+		return DevinSession{}, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	sessionIDStr, ok := sessionResponse["session_id"].(string)
-	if !ok {
-		return "", fmt.Errorf("session_id is not a string")
+	if !ok || sessionIDStr == "" {
+		return DevinSession{}, fmt.Errorf("session_id not found in response")
 	}
-	sessionIDStr = strings.TrimPrefix(sessionIDStr, "devin-")
-
-	// Log session creation success
-	log.Printf("Successfully created Devin session: %s", sessionIDStr)
-	if sessionURL, ok := sessionResponse["url"]; ok {
-		log.Printf("Session URL: %s", sessionURL)
+	session := DevinSession{ID: strings.TrimPrefix(sessionIDStr, "devin-")}
+	if sessionURL, ok := sessionResponse["url"].(string); ok && sessionURL != "" {
+		session.URL = sessionURL
+	} else {
+		session.URL = DevinSessionURL(session.ID, nil)
 	}
-	log.Printf("Game will be created in folder: %s", gameSpecID)
 
-	return sessionIDStr, nil
+	log.Printf("Successfully created Devin session: %s (%s)", session.ID, session.URL)
+	log.Printf("Game will be created in folder: %s", folder)
+
+	return session, nil
 }
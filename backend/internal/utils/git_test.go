@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// git runs a git command in dir and returns its output without the trailing newline
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// newTestRepo initializes a local repository without a remote, holding one committed file per game
+func newTestRepo(t *testing.T, gameIDs ...string) *GitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_FOLDER_NAMING", "uuid")
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	for _, id := range gameIDs {
+		writeTree(t, dir, map[string]string{id + "/index.html": "<html>" + id + "</html>"})
+	}
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	return &GitRepo{RepoPath: dir, RepoURL: "https://github.com/example/games.git", Token: "token"}
+}
+
+func TestRemoveGameFoldersLeavesUnrelatedChanges(t *testing.T) {
+	g := newTestRepo(t, "game-a", "game-b")
+	dir := g.RepoPath
+
+	// Another game is mid-commit: one file staged, one modified, one untracked
+	writeTree(t, dir, map[string]string{
+		"game-b/staged.js":   "staged",
+		"game-b/index.html":  "<html>edited</html>",
+		"game-c/index.html":  "<html>new game</html>",
+		"notes-untracked.md": "scratch",
+	})
+	git(t, dir, "add", "game-b/staged.js")
+
+	if err := g.RemoveGameFolders("game-a", "Game A"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "game-a")); !os.IsNotExist(err) {
+		t.Errorf("game-a still exists: %v", err)
+	}
+	if got := git(t, dir, "show", "--name-status", "--format=", "HEAD"); got != "D\tgame-a/index.html" {
+		t.Errorf("removal commit touches:\n%s\nwant only game-a/index.html", got)
+	}
+	if got := git(t, dir, "rev-list", "--count", "HEAD"); got != "2" {
+		t.Errorf("commit count = %s, want 2", got)
+	}
+
+	status := git(t, dir, "status", "--porcelain", "--untracked-files=all")
+	for _, want := range []string{
+		"A  game-b/staged.js",
+		" M game-b/index.html",
+		"?? game-c/index.html",
+		"?? notes-untracked.md",
+	} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status lost %q:\n%s", want, status)
+		}
+	}
+}
+
+func TestRemoveGameFoldersMissingFolder(t *testing.T) {
+	g := newTestRepo(t, "game-a")
+	git(t, g.RepoPath, "rm", "-q", "--cached", "game-a/index.html")
+
+	if err := g.RemoveGameFolders("game-z", "Game Z"); err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, g.RepoPath, "rev-list", "--count", "HEAD"); got != "1" {
+		t.Errorf("commit count = %s, want 1", got)
+	}
+	if status := git(t, g.RepoPath, "status", "--porcelain"); !strings.Contains(status, "D  game-a/index.html") {
+		t.Errorf("staged deletion of game-a was lost:\n%s", status)
+	}
+}
+
+func TestPreviewGameFolder(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GIT_REPO_PATH", dir)
+	files, err := PreviewGameFolder("game-a", "Yarn Cat", map[string]interface{}{
+		"title":         "Yarn Cat",
+		"spec_markdown": "# Yarn Cat",
+		"spec_json":     map[string]interface{}{"genre": "puzzle"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "README.md" || files[0].FileType != "md" || len(files[0].Content) == 0 {
+		t.Errorf("files = %+v, want only README.md", files)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("PreviewGameFolder wrote %d entries", len(entries))
+	}
+}
+
+func TestCommitMetadata(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		repo GitRepo
+		want string
+	}{
+		{
+			"defaults to the GitHub noreply address of the username",
+			GitRepo{Username: "octocat"},
+			"octocat <octocat@users.noreply.github.com> / octocat <octocat@users.noreply.github.com>",
+		},
+		{
+			"no username",
+			GitRepo{},
+			"game-generator <game-generator@users.noreply.github.com> / game-generator <game-generator@users.noreply.github.com>",
+		},
+		{
+			"author doubles as committer",
+			GitRepo{Username: "octocat", AuthorName: "Ada", AuthorEmail: "ada@example.com"},
+			"Ada <ada@example.com> / Ada <ada@example.com>",
+		},
+		{
+			"bot commits on behalf of a user",
+			GitRepo{AuthorName: "Ada", AuthorEmail: "ada@example.com", CommitterName: "games-bot", CommitterEmail: "bot@example.com"},
+			"Ada <ada@example.com> / games-bot <bot@example.com>",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestRepo(t, "game-a", "game-b")
+			tt.repo.RepoPath = g.RepoPath
+			writeTree(t, g.RepoPath, map[string]string{"game-a/index.html": "changed", "game-b/index.html": "changed"})
+			git(t, g.RepoPath, "add", "-A")
+
+			cmd := exec.Command("git", tt.repo.commitArgs("Update game-a", "game-a")...)
+			cmd.Dir = g.RepoPath
+			// Keep the identity of whoever runs the tests out of the commit
+			for _, kv := range os.Environ() {
+				if !strings.HasPrefix(kv, "GIT_AUTHOR_") && !strings.HasPrefix(kv, "GIT_COMMITTER_") {
+					cmd.Env = append(cmd.Env, kv)
+				}
+			}
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("commit: %v\n%s", err, out)
+			}
+
+			if got := git(t, g.RepoPath, "log", "-1", "--format=%an <%ae> / %cn <%ce>"); got != tt.want {
+				t.Errorf("author / committer = %q, want %q", got, tt.want)
+			}
+			if got := git(t, g.RepoPath, "log", "-1", "--format=%s"); got != "Update game-a" {
+				t.Errorf("message = %q", got)
+			}
+			if got := git(t, g.RepoPath, "show", "--name-only", "--format=", "HEAD"); got != "game-a/index.html" {
+				t.Errorf("committed %q, want only game-a", got)
+			}
+			// The identity is passed per commit, not written to the repository config
+			if out, err := exec.Command("git", "-C", g.RepoPath, "config", "--local", "--get", "user.email").Output(); err == nil {
+				t.Errorf("user.email written to the repository config: %s", out)
+			}
+		})
+	}
+}
+
+func TestNewGitRepoIdentity(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Ada")
+	t.Setenv("GIT_AUTHOR_EMAIL", "ada@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "games-bot")
+	t.Setenv("GIT_COMMITTER_EMAIL", "bot@example.com")
+	g := NewGitRepo()
+	if g.AuthorName != "Ada" || g.AuthorEmail != "ada@example.com" || g.CommitterName != "games-bot" || g.CommitterEmail != "bot@example.com" {
+		t.Errorf("identity = %q <%q> / %q <%q>", g.AuthorName, g.AuthorEmail, g.CommitterName, g.CommitterEmail)
+	}
+}
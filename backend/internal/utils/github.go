@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gameRepoName returns the name of the dedicated repository of a game in per-game mode
+func (g *GitRepo) gameRepoName(gameID, gameTitle string) string {
+	return config.GetString("GIT_REPO_PREFIX", "game-") + GameFolderName(gameID, gameTitle)
+}
+
+// gameRepoRemote returns the clone URL of a game's dedicated repository
+func (g *GitRepo) gameRepoRemote(gameID, gameTitle string) string {
+	return fmt.Sprintf("https://github.com/%s/%s.git", g.Org, g.gameRepoName(gameID, gameTitle))
+}
+
+// GameURL returns the web URL where the generated game can be browsed
+func (g *GitRepo) GameURL(gameID, gameTitle string) string {
+	if g.PerGame {
+		return strings.TrimSuffix(g.gameRepoRemote(gameID, gameTitle), ".git")
+	}
+	return fmt.Sprintf("%s/tree/main/%s", strings.TrimSuffix(g.RepoURL, ".git"), GameFolderName(gameID, gameTitle))
+}
+
+// createGitHubRepo creates a repository under the configured organization, reusing it if it already exists
+func (g *GitRepo) createGitHubRepo(name, description string) error {
+	apiURL := strings.TrimSuffix(config.GetString("GITHUB_API_URL", "https://api.github.com"), "/")
+	payload, _ := json.Marshal(map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"private":     config.MustGetBool("GIT_REPO_PRIVATE", true),
+	})
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/orgs/%s/repos", apiURL, url.PathEscape(g.Org)), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusCreated:
+		log.Printf("[INFO] Created GitHub repository %s/%s", g.Org, name)
+		return nil
+	case resp.StatusCode == http.StatusUnprocessableEntity && strings.Contains(string(body), "already exists"):
+		log.Printf("[INFO] GitHub repository %s/%s already exists, reusing it", g.Org, name)
+		return nil
+	default:
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// createGameRepo creates the dedicated repository of a game and a local clone with its README.md
+func (g *GitRepo) createGameRepo(gameID, gameTitle string, gameSpec map[string]interface{}) (string, error) {
+	name := g.gameRepoName(gameID, gameTitle)
+	if err := g.createGitHubRepo(name, fmt.Sprintf("Generated game: %s", gameTitle)); err != nil {
+		return "", err
+	}
+
+	gamePath := filepath.Join(g.RepoPath, name)
+	if _, err := writeGameFolder(gamePath, gameID, gameTitle, gameSpec); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(gamePath, ".git")); os.IsNotExist(err) {
+		cmd := exec.Command("git", "init")
+		cmd.Dir = gamePath
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("failed to initialize git repo: %v", err)
+		}
+
+		cmd = exec.Command("git", "branch", "-M", "main")
+		cmd.Dir = gamePath
+		cmd.Run() // Ignore error as this might fail on older git versions
+	}
+
+	// Point origin at the game repository with authentication
+	remote := &GitRepo{RepoURL: g.gameRepoRemote(gameID, gameTitle), Username: g.Username, Token: g.Token}
+	authURL, err := remote.getAuthenticatedURL()
+	if err != nil {
+		return "", fmt.Errorf("failed to create authenticated URL: %v", err)
+	}
+	cmd := exec.Command("git", "remote", "add", "origin", authURL)
+	cmd.Dir = gamePath
+	if err := cmd.Run(); err != nil {
+		cmd = exec.Command("git", "remote", "set-url", "origin", authURL)
+		cmd.Dir = gamePath
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("failed to set remote origin: %v", err)
+		}
+	}
+
+	return gamePath, nil
+}
+
+// commitAndPushGameRepo commits everything in a game's own repository and pushes main
+func (g *GitRepo) commitAndPushGameRepo(gamePath string, info CommitInfo) error {
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = gamePath
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to add files to git: %v", err)
+	}
+
+	// Nothing to commit when an earlier attempt committed but failed to push
+	cmd = exec.Command("git", "diff", "--cached", "--quiet")
+	cmd.Dir = gamePath
+	if cmd.Run() != nil {
+		cmd = exec.Command("git", g.commitArgs(commitMessage(info))...)
+		cmd.Dir = gamePath
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to commit changes: %v", err)
+		}
+	}
+
+	cmd = exec.Command("git", "push", "-u", "origin", "main")
+	cmd.Dir = gamePath
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to push to remote: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"backend/internal/config"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localFolderPrefix marks folders we own so cleanup never touches anything else under the output root
+const localFolderPrefix = "game_"
+
+// LocalOutputRoot returns the folder games are written to when git isn't configured
+func LocalOutputRoot() string {
+	return config.GetString("LOCAL_OUTPUT_DIR", "/tmp")
+}
+
+// CreateLocalGameFolder creates the folder of a game under the local output root, named by its id
+func CreateLocalGameFolder(gameID, gameTitle string, gameSpec map[string]interface{}) (string, error) {
+	return writeGameFolder(filepath.Join(LocalOutputRoot(), localFolderPrefix+gameID), gameID, gameTitle, gameSpec)
+}
+
+// RemoveLocalGameFolder removes a game folder written by CreateLocalGameFolder and reports whether
+// it did. Paths outside the local output root, such as the git repository, are left alone.
+func RemoveLocalGameFolder(path string) (bool, error) {
+	path = filepath.Clean(path)
+	if filepath.Dir(path) != filepath.Clean(LocalOutputRoot()) || !strings.HasPrefix(filepath.Base(path), localFolderPrefix) {
+		return false, nil
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CleanupLocalOutput removes game folders under the local output root that are older than ttl
+func CleanupLocalOutput(ttl time.Duration) (int, error) {
+	root := LocalOutputRoot()
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-ttl)
+	removed := 0
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), localFolderPrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, e.Name())); err != nil {
+			log.Printf("[ERROR] Failed to remove expired game folder %s: %v", e.Name(), err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RunLocalOutputCleanup periodically removes game folders older than LOCAL_OUTPUT_TTL.
+// Cleanup is disabled when LOCAL_OUTPUT_TTL is unset or zero.
+func RunLocalOutputCleanup(ctx context.Context) {
+	ttl := config.MustGetDuration("LOCAL_OUTPUT_TTL", 0)
+	if ttl <= 0 {
+		return
+	}
+
+	// Check a few times per TTL, but at most every minute
+	interval := ttl / 4
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if removed, err := CleanupLocalOutput(ttl); err != nil {
+			log.Printf("[ERROR] Local output cleanup failed: %v", err)
+		} else if removed > 0 {
+			log.Printf("[INFO] Removed %d expired game folders from %s", removed, LocalOutputRoot())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
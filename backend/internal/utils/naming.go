@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"backend/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxSlugLength = 50
+
+// GameFolderName returns the folder name of a game. With GIT_FOLDER_NAMING=slug it is the
+// slugified title plus a short id suffix (e.g. space-invaders-clone-3f2a9c1d), otherwise the game id.
+func GameFolderName(gameID, gameTitle string) string {
+	if strings.ToLower(config.GetString("GIT_FOLDER_NAMING", "uuid")) != "slug" {
+		return gameID
+	}
+	return slugify(gameTitle) + "-" + shortID(gameID)
+}
+
+func shortID(gameID string) string {
+	id := strings.ReplaceAll(gameID, "-", "")
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return id
+}
+
+// slugify lowercases s and replaces every run of non-alphanumeric characters with a single dash
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		return "game"
+	}
+	return slug
+}
+
+// findGameFolder locates the folder of a game under root. It tries the current naming scheme first,
+// then the other scheme and any slug folder carrying the game's short id, so folders stay
+// reachable after the title or GIT_FOLDER_NAMING changes.
+func findGameFolder(root, gameID, gameTitle string) (string, bool) {
+	candidates := []string{GameFolderName(gameID, gameTitle), gameID}
+	if matches, err := filepath.Glob(filepath.Join(root, "*-"+shortID(gameID))); err == nil {
+		for _, m := range matches {
+			candidates = append(candidates, filepath.Base(m))
+		}
+	}
+
+	for _, name := range candidates {
+		p := filepath.Join(root, name)
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const pagesBranch = "gh-pages"
+
+// pagesMu serializes deploys so two games never race to push gh-pages
+var pagesMu sync.Mutex
+
+// pagesExtensions are the files of a game that are published to GitHub Pages
+var pagesExtensions = map[string]bool{".html": true, ".css": true, ".js": true}
+
+// ReadPagesFiles returns the HTML, CSS and JS files under gamePath, skipping .git
+func ReadPagesFiles(gamePath string) ([]GeneratedFile, error) {
+	infos, err := ListFiles(gamePath)
+	if err != nil {
+		return nil, err
+	}
+	var files []GeneratedFile
+	for _, info := range infos {
+		if !pagesExtensions[strings.ToLower(filepath.Ext(info.Path))] {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(gamePath, filepath.FromSlash(info.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", info.Path, err)
+		}
+		files = append(files, GeneratedFile{Path: info.Path, Content: content, FileType: info.FileType})
+	}
+	return files, nil
+}
+
+// DeployToGitHubPages publishes files under <specID>/ on the gh-pages branch of the shared
+// repository, creating the branch if needed and replacing the game's previous deploy. It works
+// in a throwaway clone so the main checkout is never touched, and returns the Pages URL.
+func (g *GitRepo) DeployToGitHubPages(specID string, files []GeneratedFile) (string, error) {
+	if g.PerGame {
+		return "", fmt.Errorf("GitHub Pages deploys need the shared repository, not GIT_REPO_PER_GAME")
+	}
+	owner, repo, err := githubOwnerRepo(g.RepoURL)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := g.getAuthenticatedURL()
+	if err != nil {
+		return "", fmt.Errorf("failed to create authenticated URL: %v", err)
+	}
+
+	pagesMu.Lock()
+	defer pagesMu.Unlock()
+
+	dir, err := os.MkdirTemp("", "gh-pages-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create deploy folder: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			// Keep the token out of logged errors
+			msg := strings.ReplaceAll(strings.TrimSpace(string(out)), authURL, g.RepoURL)
+			return fmt.Errorf("git %s: %v: %s", args[0], err, msg)
+		}
+		return nil
+	}
+
+	if err := git("init"); err != nil {
+		return "", err
+	}
+	if err := git("remote", "add", "origin", authURL); err != nil {
+		return "", err
+	}
+	// Continue from the existing branch so other games' deploys are kept
+	if git("fetch", "--depth", "1", "origin", pagesBranch) == nil {
+		err = git("checkout", "-B", pagesBranch, "FETCH_HEAD")
+	} else {
+		err = git("checkout", "--orphan", pagesBranch)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	gameDir := filepath.Join(dir, specID)
+	if err := os.RemoveAll(gameDir); err != nil {
+		return "", fmt.Errorf("failed to clear previous deploy: %v", err)
+	}
+	if err := WriteGeneratedFiles(gameDir, files); err != nil {
+		return "", err
+	}
+	// Serve the files as they are instead of through Jekyll
+	if err := os.WriteFile(filepath.Join(dir, ".nojekyll"), nil, 0644); err != nil {
+		return "", fmt.Errorf("failed to write .nojekyll: %v", err)
+	}
+
+	if err := git("add", "-A"); err != nil {
+		return "", err
+	}
+
+	pagesURL := fmt.Sprintf("https://%s.github.io/%s/%s/", strings.ToLower(owner), repo, specID)
+	cmd := exec.Command("git", "diff", "--cached", "--quiet")
+	cmd.Dir = dir
+	if cmd.Run() == nil {
+		// Same files as the current deploy
+		return pagesURL, nil
+	}
+
+	if err := git(g.commitArgs(fmt.Sprintf("Deploy game %s to GitHub Pages", specID))...); err != nil {
+		return "", err
+	}
+	if err := git("push", "origin", pagesBranch); err != nil {
+		return "", err
+	}
+	return pagesURL, nil
+}
+
+// githubOwnerRepo extracts the owner and repository name from a GitHub repository URL
+func githubOwnerRepo(repoURL string) (string, string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse repository URL: %v", err)
+	}
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(u.Path, ".git"), "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", fmt.Errorf("repository URL %q does not end in /<owner>/<repo>", repoURL)
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newPagesRemote returns a GitRepo whose remote is a local bare repository laid out like
+// github.com/acme/games
+func newPagesRemote(t *testing.T) (*GitRepo, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	bare := filepath.Join(t.TempDir(), "acme", "games.git")
+	git(t, filepath.Dir(filepath.Dir(bare)), "init", "-q", "--bare", bare)
+	return &GitRepo{RepoPath: t.TempDir(), RepoURL: "file://" + bare}, bare
+}
+
+func pagesFiles(names ...string) []GeneratedFile {
+	files := make([]GeneratedFile, 0, len(names))
+	for _, n := range names {
+		files = append(files, GeneratedFile{Path: n, Content: []byte("content of " + n), FileType: strings.TrimPrefix(filepath.Ext(n), ".")})
+	}
+	return files
+}
+
+func TestDeployToGitHubPages(t *testing.T) {
+	g, bare := newPagesRemote(t)
+	tree := func() string {
+		t.Helper()
+		return strings.ReplaceAll(git(t, bare, "ls-tree", "-r", "--name-only", pagesBranch), "\n", ",")
+	}
+	commits := func() string {
+		t.Helper()
+		return git(t, bare, "rev-list", "--count", pagesBranch)
+	}
+
+	// The first deploy creates the branch
+	url, err := g.DeployToGitHubPages("spec-1", pagesFiles("index.html", "js/game.js", "style.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://acme.github.io/games/spec-1/" {
+		t.Errorf("url = %q", url)
+	}
+	if got := tree(); got != ".nojekyll,spec-1/index.html,spec-1/js/game.js,spec-1/style.css" {
+		t.Errorf("gh-pages after first deploy = %s", got)
+	}
+	if got := git(t, bare, "show", pagesBranch+":spec-1/index.html"); got != "content of index.html" {
+		t.Errorf("index.html = %q", got)
+	}
+
+	// Another game is deployed next to it
+	if _, err := g.DeployToGitHubPages("spec-2", pagesFiles("index.html")); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree(); got != ".nojekyll,spec-1/index.html,spec-1/js/game.js,spec-1/style.css,spec-2/index.html" {
+		t.Errorf("gh-pages after second game = %s", got)
+	}
+
+	// A redeploy replaces the game's previous files
+	if _, err := g.DeployToGitHubPages("spec-1", pagesFiles("index.html")); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree(); got != ".nojekyll,spec-1/index.html,spec-2/index.html" {
+		t.Errorf("gh-pages after redeploy = %s", got)
+	}
+
+	// Deploying the same files again commits nothing
+	before := commits()
+	if _, err := g.DeployToGitHubPages("spec-1", pagesFiles("index.html")); err != nil {
+		t.Fatal(err)
+	}
+	if after := commits(); after != before || before != "3" {
+		t.Errorf("commits = %s then %s, want 3 both times", before, after)
+	}
+	if msg := git(t, bare, "log", "-1", "--format=%s", pagesBranch); msg != "Deploy game spec-1 to GitHub Pages" {
+		t.Errorf("commit message = %q", msg)
+	}
+}
+
+func TestDeployToGitHubPagesErrors(t *testing.T) {
+	g, _ := newPagesRemote(t)
+
+	perGame := *g
+	perGame.PerGame = true
+	if _, err := perGame.DeployToGitHubPages("spec-1", pagesFiles("index.html")); err == nil {
+		t.Error("deploy with GIT_REPO_PER_GAME succeeded")
+	}
+
+	noOwner := *g
+	noOwner.RepoURL = "https://github.com/games"
+	if _, err := noOwner.DeployToGitHubPages("spec-1", pagesFiles("index.html")); err == nil {
+		t.Error("deploy to a URL without an owner succeeded")
+	}
+
+	unreachable := *g
+	unreachable.RepoURL = "file://" + filepath.Join(t.TempDir(), "acme", "missing.git")
+	if _, err := unreachable.DeployToGitHubPages("spec-1", pagesFiles("index.html")); err == nil {
+		t.Error("deploy to a missing remote succeeded")
+	}
+
+	if _, err := g.DeployToGitHubPages("spec-1", pagesFiles("run.sh")); err == nil {
+		t.Error("deploy of a disallowed file type succeeded")
+	}
+}
+
+func TestReadPagesFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"README.md":         "# Yarn Cat",
+		"index.html":        "<html></html>",
+		"css/Style.CSS":     "body {}",
+		"js/game.js":        "start()",
+		"assets/cat.png":    "png",
+		".git/hooks/x.js":   "hook",
+		"docs/notes.txt":    "notes",
+		"js/vendor/lib.min": "lib",
+	})
+
+	files, err := ReadPagesFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, ","); got != "css/Style.CSS,index.html,js/game.js" {
+		t.Errorf("paths = %s", got)
+	}
+	if len(files) > 1 && string(files[1].Content) != "<html></html>" {
+		t.Errorf("index.html content = %q", files[1].Content)
+	}
+
+	if _, err := ReadPagesFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ReadPagesFiles of a missing folder returned no error")
+	}
+}
+
+func TestGithubOwnerRepo(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		{url: "https://github.com/acme/games.git", wantOwner: "acme", wantRepo: "games"},
+		{url: "https://github.com/Acme/games/", wantOwner: "Acme", wantRepo: "games"},
+		{url: "https://github.com/games", wantErr: true},
+		{url: "https://github.com/", wantErr: true},
+		{url: "://bad", wantErr: true},
+	}
+	for _, tt := range tests {
+		owner, repo, err := githubOwnerRepo(tt.url)
+		if (err != nil) != tt.wantErr || owner != tt.wantOwner || repo != tt.wantRepo {
+			t.Errorf("githubOwnerRepo(%q) = %q, %q, %v", tt.url, owner, repo, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"backend/internal/config"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/README.md.tmpl
+var defaultReadmeTemplate string
+
+// ReadmeData is what the README template of a game folder is rendered with
+type ReadmeData struct {
+	Title        string
+	GameID       string
+	GeneratedAt  string
+	SpecMarkdown string
+	// SpecJSON is the indented spec_json, empty when the spec has none
+	SpecJSON string
+}
+
+// readmeTemplate parses README_TEMPLATE, a template file path, or the embedded default
+func readmeTemplate() (*template.Template, error) {
+	text := defaultReadmeTemplate
+	if path := config.GetString("README_TEMPLATE", ""); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read README_TEMPLATE: %w", err)
+		}
+		text = string(b)
+	}
+	return template.New("README.md").Option("missingkey=error").Parse(text)
+}
+
+// ValidateReadmeTemplate checks that the README template parses, called once at startup
+func ValidateReadmeTemplate() error {
+	_, err := readmeTemplate()
+	return err
+}
+
+func renderReadme(gameID, gameTitle string, gameSpec map[string]interface{}) (string, error) {
+	tmpl, err := readmeTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	data := ReadmeData{
+		Title:       gameTitle,
+		GameID:      gameID,
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if specMarkdown, ok := gameSpec["spec_markdown"].(string); ok {
+		data.SpecMarkdown = specMarkdown
+	}
+	if specJSON := gameSpec["spec_json"]; specJSON != nil {
+		if jsonBytes, err := json.MarshalIndent(specJSON, "", "  "); err == nil {
+			data.SpecJSON = string(jsonBytes)
+		} else {
+			data.SpecJSON = fmt.Sprintf("%+v", specJSON)
+		}
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render README template: %w", err)
+	}
+	return out.String(), nil
+}
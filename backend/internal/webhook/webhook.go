@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"backend/internal/config"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed with STATE_WEBHOOK_SECRET
+const SignatureHeader = "X-Signature-256"
+
+// StateTransition is the payload sent for each spec state transition
+type StateTransition struct {
+	SpecID      string    `json:"spec_id"`
+	StateBefore string    `json:"state_before"`
+	StateAfter  string    `json:"state_after"`
+	Detail      string    `json:"detail"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// NotifyStateTransition posts t to STATE_WEBHOOK_URL in the background. It returns immediately
+// and does nothing when no URL is configured. Failed deliveries are retried up to
+// STATE_WEBHOOK_MAX_ATTEMPTS times (default 3) with a doubling delay from one second.
+func NotifyStateTransition(t StateTransition) {
+	url := config.GetString("STATE_WEBHOOK_URL", "")
+	if url == "" {
+		return
+	}
+	body, err := json.Marshal(t)
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode state webhook for spec %s: %v", t.SpecID, err)
+		return
+	}
+	attempts := config.MustGetInt("STATE_WEBHOOK_MAX_ATTEMPTS", 3)
+	secret := config.GetString("STATE_WEBHOOK_SECRET", "")
+
+	go func() {
+		delay := time.Second
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if attempt > 1 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+			err := deliver(url, secret, body)
+			if err == nil {
+				return
+			}
+			log.Printf("[WARNING] State webhook attempt %d/%d for spec %s failed: %v", attempt, attempts, t.SpecID, err)
+		}
+		log.Printf("[ERROR] Giving up on state webhook for spec %s (%s → %s)", t.SpecID, t.StateBefore, t.StateAfter)
+	}()
+}
+
+func deliver(url, secret string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
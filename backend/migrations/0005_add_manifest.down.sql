@@ -0,0 +1 @@
+ALTER TABLE game_specs DROP COLUMN IF EXISTS manifest;
@@ -0,0 +1 @@
+ALTER TABLE game_specs ADD COLUMN IF NOT EXISTS manifest JSONB NULL;
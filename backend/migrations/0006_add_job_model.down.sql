@@ -0,0 +1 @@
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS model;
@@ -0,0 +1 @@
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS model TEXT NULL;
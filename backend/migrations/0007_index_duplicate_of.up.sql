@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_gen_spec_jobs_duplicate_of ON gen_spec_jobs USING GIN (duplicate_of);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS spec_shares (
+    token TEXT PRIMARY KEY,
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_spec_shares_spec_id ON spec_shares(spec_id);
@@ -0,0 +1 @@
+ALTER TABLE code_jobs ADD COLUMN IF NOT EXISTS target_framework TEXT NULL;
@@ -0,0 +1,12 @@
+ALTER TABLE game_specs DROP CONSTRAINT IF EXISTS game_specs_workspace_spec_hash_key;
+ALTER TABLE game_specs ADD CONSTRAINT game_specs_spec_hash_key UNIQUE (spec_hash);
+
+DROP INDEX IF EXISTS idx_code_jobs_workspace_id;
+DROP INDEX IF EXISTS idx_gen_spec_jobs_workspace_id;
+DROP INDEX IF EXISTS idx_game_specs_workspace_id;
+
+ALTER TABLE code_jobs DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE game_specs DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    api_key_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Rows without a workspace belong to the shared workspace used by requests without an API key
+ALTER TABLE game_specs ADD COLUMN workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE gen_spec_jobs ADD COLUMN workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE code_jobs ADD COLUMN workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_game_specs_workspace_id ON game_specs(workspace_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_gen_spec_jobs_workspace_id ON gen_spec_jobs(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_code_jobs_workspace_id ON code_jobs(workspace_id);
+
+-- The same spec may exist once per workspace
+ALTER TABLE game_specs DROP CONSTRAINT IF EXISTS game_specs_spec_hash_key;
+ALTER TABLE game_specs ADD CONSTRAINT game_specs_workspace_spec_hash_key UNIQUE NULLS NOT DISTINCT (workspace_id, spec_hash);
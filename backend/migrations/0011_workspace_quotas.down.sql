@@ -0,0 +1,5 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS quota_reset_at;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS used_code_jobs;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS used_specs;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS quota_code_jobs;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS quota_specs;
@@ -0,0 +1,6 @@
+-- NULL quotas mean unlimited
+ALTER TABLE workspaces ADD COLUMN quota_specs INT;
+ALTER TABLE workspaces ADD COLUMN quota_code_jobs INT;
+ALTER TABLE workspaces ADD COLUMN used_specs INT NOT NULL DEFAULT 0;
+ALTER TABLE workspaces ADD COLUMN used_code_jobs INT NOT NULL DEFAULT 0;
+ALTER TABLE workspaces ADD COLUMN quota_reset_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
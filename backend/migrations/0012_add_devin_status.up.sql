@@ -0,0 +1,2 @@
+-- Last known status of the Devin session in devin_session_id
+ALTER TABLE game_specs ADD COLUMN devin_status TEXT NULL;
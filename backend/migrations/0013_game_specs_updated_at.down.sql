@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_game_specs_updated_at ON game_specs;
+DROP FUNCTION IF EXISTS set_game_specs_updated_at();
+ALTER TABLE game_specs DROP COLUMN IF EXISTS updated_at;
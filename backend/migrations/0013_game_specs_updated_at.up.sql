@@ -0,0 +1,14 @@
+ALTER TABLE game_specs ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE game_specs SET updated_at = created_at;
+
+-- Keep updated_at current on every write without touching each UPDATE statement
+CREATE OR REPLACE FUNCTION set_game_specs_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_game_specs_updated_at
+    BEFORE UPDATE ON game_specs
+    FOR EACH ROW EXECUTE FUNCTION set_game_specs_updated_at();
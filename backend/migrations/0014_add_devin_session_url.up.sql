@@ -0,0 +1,2 @@
+-- Canonical session URL returned by the Devin API
+ALTER TABLE game_specs ADD COLUMN devin_session_url TEXT NULL;
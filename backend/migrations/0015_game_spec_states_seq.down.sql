@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_game_spec_states_replay;
+ALTER TABLE game_spec_states DROP COLUMN IF EXISTS seq;
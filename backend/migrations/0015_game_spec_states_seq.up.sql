@@ -0,0 +1,3 @@
+-- Append order of state events, breaking created_at ties when replaying
+ALTER TABLE game_spec_states ADD COLUMN seq BIGSERIAL;
+CREATE INDEX IF NOT EXISTS idx_game_spec_states_replay ON game_spec_states(game_spec_id, created_at, seq);
@@ -0,0 +1,4 @@
+UPDATE gen_spec_jobs SET status = 'DUPLICATE' WHERE status = 'HASH_DUPLICATE';
+ALTER TABLE gen_spec_jobs DROP CONSTRAINT IF EXISTS gen_spec_jobs_status_check;
+ALTER TABLE gen_spec_jobs ADD CONSTRAINT gen_spec_jobs_status_check
+    CHECK (status IN ('QUEUED','RUNNING','DUPLICATE','COMPLETED','FAILED'));
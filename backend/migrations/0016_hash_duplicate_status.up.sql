@@ -0,0 +1,4 @@
+-- Jobs whose spec_json matches an existing spec exactly
+ALTER TABLE gen_spec_jobs DROP CONSTRAINT IF EXISTS gen_spec_jobs_status_check;
+ALTER TABLE gen_spec_jobs ADD CONSTRAINT gen_spec_jobs_status_check
+    CHECK (status IN ('QUEUED','RUNNING','DUPLICATE','HASH_DUPLICATE','COMPLETED','FAILED'));
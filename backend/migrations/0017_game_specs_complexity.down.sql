@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_game_specs_complexity_level;
+ALTER TABLE game_specs DROP COLUMN IF EXISTS complexity_level;
+ALTER TABLE game_specs DROP COLUMN IF EXISTS complexity_score;
@@ -0,0 +1,4 @@
+-- Predicted code generation difficulty, see specschema.EstimateComplexity
+ALTER TABLE game_specs ADD COLUMN complexity_score INT NULL;
+ALTER TABLE game_specs ADD COLUMN complexity_level TEXT NULL;
+CREATE INDEX IF NOT EXISTS idx_game_specs_complexity_level ON game_specs(complexity_level);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS code_jobs_active_spec_key;
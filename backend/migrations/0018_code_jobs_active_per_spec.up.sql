@@ -0,0 +1,11 @@
+-- Fail all but the newest active job of each spec so the index can be built
+UPDATE code_jobs SET status = 'failed', error = 'superseded by a newer job', updated_at = now()
+WHERE status IN ('queued', 'processing') AND id NOT IN (
+    SELECT DISTINCT ON (game_spec_id) id FROM code_jobs
+    WHERE status IN ('queued', 'processing') AND game_spec_id IS NOT NULL
+    ORDER BY game_spec_id, created_at DESC
+) AND game_spec_id IS NOT NULL;
+
+-- At most one queued or processing code job per spec
+CREATE UNIQUE INDEX IF NOT EXISTS code_jobs_active_spec_key ON code_jobs(game_spec_id)
+    WHERE status IN ('queued', 'processing');
@@ -0,0 +1,3 @@
+ALTER TABLE workspaces DROP COLUMN IF EXISTS allow_mature;
+DROP INDEX IF EXISTS idx_game_specs_age_rating;
+ALTER TABLE game_specs DROP COLUMN IF EXISTS age_rating;
@@ -0,0 +1,6 @@
+-- Audience rating of a spec (E, T or M), see content.ClassifyAgeRating
+ALTER TABLE game_specs ADD COLUMN age_rating TEXT NULL;
+CREATE INDEX IF NOT EXISTS idx_game_specs_age_rating ON game_specs(age_rating);
+
+-- Specs rated M are rejected unless their workspace opts in
+ALTER TABLE workspaces ADD COLUMN allow_mature BOOLEAN NOT NULL DEFAULT false;
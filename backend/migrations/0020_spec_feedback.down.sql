@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS spec_feedback_stats ON spec_feedback;
+DROP FUNCTION IF EXISTS refresh_spec_feedback_stats();
+ALTER TABLE game_specs DROP COLUMN IF EXISTS feedback_count;
+ALTER TABLE game_specs DROP COLUMN IF EXISTS average_rating;
+DROP TABLE IF EXISTS spec_feedback;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS spec_feedback (
+    id UUID PRIMARY KEY,
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_spec_feedback_spec_id ON spec_feedback(spec_id, created_at DESC);
+
+-- Aggregates kept up to date by the trigger below
+ALTER TABLE game_specs ADD COLUMN average_rating DOUBLE PRECISION NULL;
+ALTER TABLE game_specs ADD COLUMN feedback_count INT NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION refresh_spec_feedback_stats() RETURNS trigger AS $$
+DECLARE
+    target UUID := COALESCE(NEW.spec_id, OLD.spec_id);
+BEGIN
+    UPDATE game_specs
+    SET average_rating = (SELECT AVG(rating) FROM spec_feedback WHERE spec_id = target),
+        feedback_count = (SELECT COUNT(*) FROM spec_feedback WHERE spec_id = target)
+    WHERE id = target;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER spec_feedback_stats
+AFTER INSERT OR UPDATE OR DELETE ON spec_feedback
+FOR EACH ROW EXECUTE FUNCTION refresh_spec_feedback_stats();
@@ -0,0 +1,2 @@
+-- GitHub Pages URL of the deployed game, see GIT_DEPLOY_GITHUB_PAGES
+ALTER TABLE game_specs ADD COLUMN deploy_url TEXT NULL;
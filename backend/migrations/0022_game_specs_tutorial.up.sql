@@ -0,0 +1,2 @@
+-- Beginner tutorial generated alongside the spec when include_tutorial is set
+ALTER TABLE game_specs ADD COLUMN tutorial_markdown TEXT NULL;
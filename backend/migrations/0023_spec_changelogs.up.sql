@@ -0,0 +1,11 @@
+-- What changed when a spec was regenerated. Regeneration replaces a spec in place, so
+-- parent_spec_id is the spec the old content came from and equals spec_id for those.
+CREATE TABLE IF NOT EXISTS spec_changelogs (
+    id UUID PRIMARY KEY,
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    parent_spec_id UUID NULL REFERENCES game_specs(id) ON DELETE SET NULL,
+    changes_markdown TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_spec_changelogs_spec_id ON spec_changelogs(spec_id, created_at DESC);
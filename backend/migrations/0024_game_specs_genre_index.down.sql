@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_game_specs_spec_json_genre;
@@ -0,0 +1,2 @@
+-- Serves the GROUP BY of GET /api/genres/popular
+CREATE INDEX IF NOT EXISTS idx_game_specs_spec_json_genre ON game_specs ((spec_json->>'genre'));
@@ -0,0 +1,9 @@
+-- One row per GetSpec call, used to recommend specs a workspace hasn't seen
+CREATE TABLE IF NOT EXISTS spec_views (
+    id BIGSERIAL PRIMARY KEY,
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    workspace_id UUID NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_spec_views_workspace_spec ON spec_views(workspace_id, spec_id);
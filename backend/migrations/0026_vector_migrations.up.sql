@@ -0,0 +1,10 @@
+-- Specs whose vector was moved into their workspace's namespace by
+-- POST /api/admin/workspaces/:id/migrate-vectors. from_vector_prefix is the workspace prefix the
+-- old vector id had, empty for unprefixed ids.
+CREATE TABLE IF NOT EXISTS vector_migrations (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    from_vector_prefix TEXT NOT NULL DEFAULT '',
+    migrated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, spec_id, from_vector_prefix)
+);
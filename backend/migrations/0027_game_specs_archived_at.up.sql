@@ -0,0 +1,2 @@
+-- Set when a spec's content was moved to S3 and replaced by a stub (see internal/archiver)
+ALTER TABLE game_specs ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ NULL;
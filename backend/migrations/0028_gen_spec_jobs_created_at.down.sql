@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_gen_spec_jobs_workspace_created_at;
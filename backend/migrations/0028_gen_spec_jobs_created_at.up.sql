@@ -0,0 +1,2 @@
+-- Serves the keyset pagination of GET /api/spec-jobs
+CREATE INDEX IF NOT EXISTS idx_gen_spec_jobs_workspace_created_at ON gen_spec_jobs(workspace_id, created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS gen_spec_jobs_parent_job_key;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS retry_count;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS parent_job_id;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS include_tutorial;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS constraints;
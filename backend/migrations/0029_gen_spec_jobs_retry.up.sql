@@ -0,0 +1,8 @@
+-- The input of a spec job is kept so it can be retried (see POST /api/spec-jobs/:id/retry)
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS constraints JSONB NULL;
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS include_tutorial BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS parent_job_id UUID NULL REFERENCES gen_spec_jobs(id) ON DELETE SET NULL;
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS retry_count INT NOT NULL DEFAULT 0;
+
+-- A job is retried at most once; later retries go through the newest job
+CREATE UNIQUE INDEX IF NOT EXISTS gen_spec_jobs_parent_job_key ON gen_spec_jobs(parent_job_id);
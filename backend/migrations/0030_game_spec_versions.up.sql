@@ -0,0 +1,13 @@
+-- The content a spec had before each regeneration or restore, numbered per spec from 1
+CREATE TABLE IF NOT EXISTS game_spec_versions (
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    title TEXT NOT NULL,
+    spec_markdown TEXT NOT NULL,
+    spec_json JSONB NOT NULL,
+    spec_hash TEXT NOT NULL,
+    tutorial_markdown TEXT NULL,
+    age_rating TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (spec_id, version)
+);
@@ -0,0 +1 @@
+ALTER TABLE code_jobs DROP COLUMN IF EXISTS checkpoint;
@@ -0,0 +1,2 @@
+-- Last pipeline stage a code job completed, so a retry resumes after it (see internal/codegen)
+ALTER TABLE code_jobs ADD COLUMN IF NOT EXISTS checkpoint JSONB NULL;
@@ -0,0 +1,2 @@
+-- Set when the vector store was unreachable and the job's spec skipped similarity detection
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS dedup_skipped BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- Extra LLM generation parameters of the job (temperature, max_tokens, ...)
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS params JSONB NULL;
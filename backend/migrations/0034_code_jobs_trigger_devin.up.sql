@@ -0,0 +1,2 @@
+-- Whether the job starts a Devin session after pushing; NULL follows DEVIN_AUTO_TRIGGER
+ALTER TABLE code_jobs ADD COLUMN IF NOT EXISTS trigger_devin BOOLEAN NULL;
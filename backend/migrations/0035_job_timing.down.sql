@@ -0,0 +1,2 @@
+ALTER TABLE code_jobs DROP COLUMN IF EXISTS timing;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS timing;
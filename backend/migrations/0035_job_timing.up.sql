@@ -0,0 +1,3 @@
+-- Duration in milliseconds of each pipeline stage a job ran, keyed by stage
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS timing JSONB NULL;
+ALTER TABLE code_jobs ADD COLUMN IF NOT EXISTS timing JSONB NULL;
@@ -0,0 +1,2 @@
+-- The brief as submitted when BRIEF_OVERFLOW_POLICY=truncate shortened it; brief has the truncated text
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS brief_original TEXT NULL;
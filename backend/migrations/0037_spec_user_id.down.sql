@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_game_specs_workspace_user;
+ALTER TABLE code_jobs DROP COLUMN IF EXISTS user_id;
+ALTER TABLE gen_spec_jobs DROP COLUMN IF EXISTS user_id;
+ALTER TABLE game_specs DROP COLUMN IF EXISTS user_id;
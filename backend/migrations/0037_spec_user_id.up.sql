@@ -0,0 +1,6 @@
+-- The user who created a spec or job, from USER_ID_HEADER; NULL for anonymous requests
+ALTER TABLE game_specs ADD COLUMN IF NOT EXISTS user_id TEXT NULL;
+ALTER TABLE gen_spec_jobs ADD COLUMN IF NOT EXISTS user_id TEXT NULL;
+ALTER TABLE code_jobs ADD COLUMN IF NOT EXISTS user_id TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_game_specs_workspace_user ON game_specs (workspace_id, user_id, created_at DESC);
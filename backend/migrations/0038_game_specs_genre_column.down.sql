@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_game_specs_genre;
+CREATE INDEX IF NOT EXISTS idx_game_specs_spec_json_genre ON game_specs ((spec_json->>'genre'));
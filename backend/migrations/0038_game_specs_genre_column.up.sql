@@ -0,0 +1,7 @@
+-- Genre counts and recommendations read the genre column, which archiving leaves in place,
+-- instead of spec_json, which it replaces with a stub
+UPDATE game_specs SET genre = spec_json->>'genre'
+WHERE genre IS NULL AND archived_at IS NULL AND spec_json->>'genre' IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_game_specs_spec_json_genre;
+CREATE INDEX IF NOT EXISTS idx_game_specs_genre ON game_specs (genre);
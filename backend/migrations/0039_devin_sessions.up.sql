@@ -0,0 +1,19 @@
+-- Every Devin session started for a spec with its last known status. game_specs keeps the
+-- latest session in devin_session_id, devin_session_url and devin_status.
+CREATE TABLE IF NOT EXISTS devin_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    spec_id UUID NOT NULL REFERENCES game_specs(id) ON DELETE CASCADE,
+    session_id TEXT NOT NULL UNIQUE,
+    session_url TEXT NULL,
+    status TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_devin_sessions_spec_id ON devin_sessions(spec_id, created_at DESC);
+
+INSERT INTO devin_sessions (spec_id, session_id, session_url, status)
+SELECT id, devin_session_id, devin_session_url, devin_status
+FROM game_specs
+WHERE devin_session_id IS NOT NULL AND devin_session_id <> ''
+ON CONFLICT (session_id) DO NOTHING;
@@ -0,0 +1,3 @@
+-- When a request started creating a Devin session for the spec; NULL once the session is stored
+-- or the Devin call failed. Claims older than the Devin call can take are ignored.
+ALTER TABLE game_specs ADD COLUMN IF NOT EXISTS devin_claimed_at TIMESTAMPTZ NULL;
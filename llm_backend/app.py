@@ -4,10 +4,11 @@ from typing import List, Optional, Dict, Any
 from fastapi import FastAPI, HTTPException
 from pydantic import BaseModel
 from qdrant_client import QdrantClient
-from qdrant_client.http.models import VectorParams, Distance, PointStruct
+from qdrant_client.http.models import VectorParams, Distance, PointStruct, Filter, FieldCondition, MatchValue, IsEmptyCondition, PayloadField
 from sentence_transformers import SentenceTransformer
 from openai import OpenAI
 import json
+import uuid
 from dotenv import load_dotenv
 
 # Load environment variables from .env file
@@ -48,21 +49,30 @@ def ensure_collection():
 ensure_collection()
 
 
+DEFAULT_SPEC_MODEL = os.getenv("DEFAULT_SPEC_MODEL", "gpt-4")
+
+
 class GenSpecReq(BaseModel):
     brief: str
     constraints: Optional[Dict[str, Any]] = None
+    model: Optional[str] = "default"
+    include_tutorial: bool = False
+    # Extra generation parameters; the Go backend only forwards allowlisted keys
+    params: Optional[Dict[str, Any]] = None
 
 
 class GenSpecResp(BaseModel):
     title: str
     spec_markdown: str
     spec_json: Dict[str, Any]
+    tutorial_markdown: Optional[str] = None
 
 
 class SearchReq(BaseModel):
     text: str
     top_k: int = 5
     threshold: float = 0.86
+    namespace: Optional[str] = None
 
 
 class SimilarItem(BaseModel):
@@ -79,6 +89,7 @@ class UpsertReq(BaseModel):
     spec_id: str
     text: str
     payload: Dict[str, Any] = {}
+    namespace: Optional[str] = None
 
 
 def load_spec_prompt_template() -> str:
@@ -110,32 +121,67 @@ Respond with a detailed markdown specification."""
         return "Generate a detailed game specification based on the brief: {BRIEF}"
 
 
-def generate_spec_from_brief(brief: str, constraints: Optional[Dict[str, Any]] = None) -> GenSpecResp:
-    if not openai_client:
-        raise HTTPException(
-            status_code=500, detail="OpenAI API key not configured")
-
+def point_id(spec_id: str) -> str:
+    """Qdrant only accepts UUID ids, so workspace-prefixed spec ids are mapped to a stable uuid5"""
     try:
-        # Load the detailed prompt template
-        prompt_template = load_spec_prompt_template()
+        return str(uuid.UUID(spec_id))
+    except ValueError:
+        return str(uuid.uuid5(uuid.NAMESPACE_URL, spec_id))
+
+
+def namespace_filter(namespace: Optional[str]) -> Filter:
+    """Restrict a search to one workspace; points without a namespace belong to the shared workspace"""
+    if namespace:
+        return Filter(must=[FieldCondition(key="namespace", match=MatchValue(value=namespace))])
+    return Filter(must=[IsEmptyCondition(is_empty=PayloadField(key="namespace"))])
+
+
+def resolve_model(requested: Optional[str]) -> str:
+    """Map the model requested by the Go backend to an OpenAI model; "default" lets us choose"""
+    if not requested or requested == "default":
+        return DEFAULT_SPEC_MODEL
+    return requested
+
 
-        # Extract game name from brief or use a default
-        game_name = brief.split('.')[0].strip() if '.' in brief else brief[:50]
+def spec_game_name(brief: str) -> str:
+    """Extract game name from brief or use a default"""
+    return brief.split('.')[0].strip() if '.' in brief else brief[:50]
 
-        # Replace placeholders in the template
-        prompt = prompt_template.replace("{GAME_NAME}", game_name)
-        prompt = prompt.replace("{BRIEF}", brief)
 
-        # Add blockchain chain placeholder (you can customize this)
-        prompt = prompt.replace("{BLOCKCHAIN_CHAIN}", "Ethereum")
+def build_spec_prompt(brief: str, constraints: Optional[Dict[str, Any]] = None, params: Optional[Dict[str, Any]] = None) -> str:
+    # Load the detailed prompt template
+    prompt_template = load_spec_prompt_template()
 
-        # Add constraints if provided
-        if constraints:
-            constraints_text = f"\n\nAdditional Constraints: {json.dumps(constraints, indent=2)}"
-            prompt += constraints_text
+    # Replace placeholders in the template
+    prompt = prompt_template.replace("{GAME_NAME}", spec_game_name(brief))
+    prompt = prompt.replace("{BRIEF}", brief)
+
+    # Add blockchain chain placeholder (you can customize this)
+    prompt = prompt.replace("{BLOCKCHAIN_CHAIN}", "Ethereum")
+
+    # Add constraints if provided
+    if constraints:
+        constraints_text = f"\n\nAdditional Constraints: {json.dumps(constraints, indent=2)}"
+        prompt += constraints_text
+
+    params = params or {}
+    if params.get("complexity_level"):
+        prompt += f"\n\nTarget complexity level: {params['complexity_level']}"
+    return prompt
+
+
+def generate_spec_from_brief(brief: str, constraints: Optional[Dict[str, Any]] = None, model_name: str = DEFAULT_SPEC_MODEL, params: Optional[Dict[str, Any]] = None) -> GenSpecResp:
+    if not openai_client:
+        raise HTTPException(
+            status_code=500, detail="OpenAI API key not configured")
+
+    try:
+        game_name = spec_game_name(brief)
+        prompt = build_spec_prompt(brief, constraints, params)
+        params = params or {}
 
         response = openai_client.chat.completions.create(
-            model="gpt-4",
+            model=model_name,
             messages=[
                 {
                     "role": "system",
@@ -146,8 +192,8 @@ def generate_spec_from_brief(brief: str, constraints: Optional[Dict[str, Any]] =
                     "content": prompt
                 }
             ],
-            max_tokens=3000,
-            temperature=0.7
+            max_tokens=int(params.get("max_tokens", 3000)),
+            temperature=float(params.get("temperature", 0.7))
         )
 
         # Parse the response
@@ -204,11 +250,140 @@ def generate_spec_from_brief(brief: str, constraints: Optional[Dict[str, Any]] =
         return GenSpecResp(title=title, spec_markdown=spec_md, spec_json=fallback_json)
 
 
+def generate_tutorial(title: str, spec_markdown: str, model_name: str) -> Optional[str]:
+    """Write a beginner tutorial for the game described by the spec; None when it fails"""
+    if not openai_client:
+        return None
+    try:
+        response = openai_client.chat.completions.create(
+            model=model_name,
+            messages=[
+                {
+                    "role": "system",
+                    "content": "You write friendly, step-by-step tutorials for players new to a game. Respond with markdown only."
+                },
+                {
+                    "role": "user",
+                    "content": f"Write a beginner tutorial for the game \"{title}\". Cover the goal, the controls, a first play-through and tips.\n\nGame specification:\n{spec_markdown}"
+                }
+            ],
+            max_tokens=1500,
+            temperature=0.7
+        )
+        return response.choices[0].message.content.strip()
+    except Exception as e:
+        print(f"Error in generate_tutorial: {e}")
+        return None
+
+
 @app.post("/llm/generate-spec", response_model=GenSpecResp)
 def generate_spec(req: GenSpecReq):
     if not req.brief:
         raise HTTPException(status_code=400, detail="brief is required")
-    return generate_spec_from_brief(req.brief, req.constraints)
+    model_name = resolve_model(req.model)
+    spec = generate_spec_from_brief(req.brief, req.constraints, model_name, req.params)
+    if req.include_tutorial:
+        spec.tutorial_markdown = generate_tutorial(spec.title, spec.spec_markdown, model_name)
+    return spec
+
+
+class EstimateTokensResp(BaseModel):
+    prompt_tokens: int
+    estimated_total_tokens: int
+
+
+def count_tokens(text: str, model_name: str) -> int:
+    """Counts tokens with tiktoken when it's installed, otherwise assumes ~4 characters per token"""
+    try:
+        import tiktoken
+        try:
+            encoding = tiktoken.encoding_for_model(model_name)
+        except KeyError:
+            encoding = tiktoken.get_encoding("cl100k_base")
+        return len(encoding.encode(text))
+    except ImportError:
+        return max(1, len(text) // 4)
+
+
+@app.post("/llm/estimate-tokens", response_model=EstimateTokensResp)
+def estimate_tokens(req: GenSpecReq):
+    """Predicts the tokens /llm/generate-spec would use for a request without calling the model"""
+    if not req.brief:
+        raise HTTPException(status_code=400, detail="brief is required")
+    model_name = resolve_model(req.model)
+    prompt_tokens = count_tokens(build_spec_prompt(req.brief, req.constraints, req.params), model_name)
+    # Completions are assumed to use their whole max_tokens budget
+    total = prompt_tokens + int((req.params or {}).get("max_tokens", 3000))
+    if req.include_tutorial:
+        total += 1500
+    return EstimateTokensResp(prompt_tokens=prompt_tokens, estimated_total_tokens=total)
+
+
+class CompleteReq(BaseModel):
+    prompt: str
+    system: Optional[str] = None
+    model: Optional[str] = "default"
+    max_tokens: int = 1000
+
+
+class CompleteResp(BaseModel):
+    text: str
+
+
+@app.post("/llm/complete", response_model=CompleteResp)
+def complete(req: CompleteReq):
+    """Plain text completion for auxiliary tasks such as changelog summaries"""
+    if not openai_client:
+        raise HTTPException(
+            status_code=500, detail="OpenAI API key not configured")
+    if not req.prompt:
+        raise HTTPException(status_code=400, detail="prompt is required")
+
+    messages = []
+    if req.system:
+        messages.append({"role": "system", "content": req.system})
+    messages.append({"role": "user", "content": req.prompt})
+    try:
+        response = openai_client.chat.completions.create(
+            model=resolve_model(req.model),
+            messages=messages,
+            max_tokens=req.max_tokens,
+            temperature=0.3
+        )
+    except Exception as e:
+        raise HTTPException(status_code=502, detail=f"completion failed: {e}")
+    return CompleteResp(text=response.choices[0].message.content.strip())
+
+
+class TranslateReq(BaseModel):
+    text: str
+    source_language: Optional[str] = None
+    target_language: str = "en"
+    model: Optional[str] = "default"
+
+
+@app.post("/llm/translate", response_model=CompleteResp)
+def translate(req: TranslateReq):
+    """Translates a brief before spec generation (BRIEF_LANGUAGE_POLICY=translate)"""
+    if not openai_client:
+        raise HTTPException(
+            status_code=500, detail="OpenAI API key not configured")
+    if not req.text:
+        raise HTTPException(status_code=400, detail="text is required")
+
+    source = f" from the language with ISO 639-1 code '{req.source_language}'" if req.source_language else ""
+    system = (f"Translate the user's text{source} to the language with ISO 639-1 code '{req.target_language}'. "
+              "Keep its meaning, names and formatting, and reply with the translation only.")
+    try:
+        response = openai_client.chat.completions.create(
+            model=resolve_model(req.model),
+            messages=[{"role": "system", "content": system},
+                      {"role": "user", "content": req.text}],
+            temperature=0
+        )
+    except Exception as e:
+        raise HTTPException(status_code=502, detail=f"translation failed: {e}")
+    return CompleteResp(text=response.choices[0].message.content.strip())
 
 
 @app.post("/vector/search", response_model=SearchResp)
@@ -220,11 +395,12 @@ def search_similar(req: SearchReq):
         query_vector=emb.tolist(),
         limit=req.top_k,
         with_payload=True,
-        score_threshold=req.threshold
+        score_threshold=req.threshold,
+        query_filter=namespace_filter(req.namespace)
     )
     items = []
     for r in result:
-        pid = r.id if isinstance(r.id, str) else str(r.id)
+        pid = r.payload.get("spec_id") or (r.id if isinstance(r.id, str) else str(r.id))
         title = r.payload.get("title", "")
         items.append(SimilarItem(
             spec_id=pid, title=title, score=float(r.score)))
@@ -235,10 +411,14 @@ def search_similar(req: SearchReq):
 def upsert_point(req: UpsertReq):
     ensure_collection()
     emb = model.encode([req.text])[0]
+    payload = dict(req.payload)
+    payload["spec_id"] = req.spec_id
+    if req.namespace:
+        payload["namespace"] = req.namespace
     client.upsert(
         collection_name=COLLECTION_NAME,
         points=[PointStruct(
-            id=req.spec_id, vector=emb.tolist(), payload=req.payload)]
+            id=point_id(req.spec_id), vector=emb.tolist(), payload=payload)]
     )
     return {"ok": True, "id": req.spec_id}
 
@@ -274,6 +454,21 @@ def recreate_collection():
             status_code=500, detail=f"Failed to recreate collection: {str(e)}")
 
 
+@app.get("/vector/spec/{spec_id}/embedding")
+def get_spec_embedding(spec_id: str):
+    """Return the stored embedding of a spec, 404 when it has none"""
+    ensure_collection()
+    points = client.retrieve(
+        collection_name=COLLECTION_NAME,
+        ids=[point_id(spec_id)],
+        with_vectors=True
+    )
+    if not points or points[0].vector is None:
+        raise HTTPException(
+            status_code=404, detail=f"No embedding stored for spec '{spec_id}'")
+    return {"spec_id": spec_id, "embedding": list(points[0].vector)}
+
+
 @app.delete("/vector/spec/{spec_id}")
 def delete_spec_from_vector(spec_id: str):
     """Delete a specific spec from the vector database"""
@@ -281,7 +476,7 @@ def delete_spec_from_vector(spec_id: str):
         # Delete the point with the given spec_id
         client.delete(
             collection_name=COLLECTION_NAME,
-            points_selector=[spec_id]
+            points_selector=[point_id(spec_id)]
         )
         return {"ok": True, "message": f"Spec '{spec_id}' deleted from vector database successfully"}
     except Exception as e: